@@ -0,0 +1,188 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package assemblyscript implements the env module imported by modules
+// compiled by the AssemblyScript compiler, so that they can run with exec
+// without the JavaScript loader of AssemblyScript.
+//
+//	env := assemblyscript.New(assemblyscript.Config{Stdout: os.Stdout})
+//	m, err := wasm.ReadModule(r, env.Resolver(nil))
+//	...
+//	vm.RecoverPanic = true
+//	_, err = vm.ExecCode(main)
+//	if err, ok := err.(*assemblyscript.AbortError); ok {
+//		log.Printf("%s at %s:%d:%d", err.Message, err.File, err.Line, err.Column)
+//	}
+//
+// ReadString decodes the strings of AssemblyScript, for host functions
+// receiving them from the guest.
+package assemblyscript
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// ModuleName is the name of the module the functions of the runtime are
+// imported from.
+const ModuleName = "env"
+
+// Config is the environment of a guest.
+type Config struct {
+	// Stdout receives the output of trace. It defaults to ioutil.Discard.
+	Stdout io.Writer
+
+	// Now returns the current time, from which seed returns its seed. It
+	// defaults to time.Now.
+	Now func() time.Time
+}
+
+// AbortError is the error returned by (*exec.VM).ExecCode when the guest
+// aborts, by calling abort or failing an assertion. The VM must recover
+// panics for it to be returned, see exec.VM.RecoverPanic.
+type AbortError struct {
+	Message string
+	File    string // Name of the source file, empty if unknown
+	Line    uint32
+	Column  uint32
+}
+
+func (e *AbortError) Error() string {
+	msg := "assemblyscript: abort: " + e.Message
+	if e.File != "" {
+		msg += fmt.Sprintf(" at %s:%d:%d", e.File, e.Line, e.Column)
+	}
+	return msg
+}
+
+// Env holds the state of the env module imported by a guest.
+type Env struct {
+	cfg Config
+}
+
+// New returns an env module with the environment cfg.
+func New(cfg Config) *Env {
+	if cfg.Stdout == nil {
+		cfg.Stdout = ioutil.Discard
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Env{cfg: cfg}
+}
+
+// Module returns the host module exporting the functions of e.
+func (e *Env) Module() (*wasm.Module, error) {
+	return exec.NewHostModule(map[string]interface{}{
+		"abort": e.abort,
+		"trace": e.trace,
+		"seed":  e.seed,
+	})
+}
+
+// Resolver returns a wasm.ResolveFunc resolving ModuleName to the host
+// module of e, and the other modules with next, if not nil.
+func (e *Env) Resolver(next wasm.ResolveFunc) wasm.ResolveFunc {
+	return func(name string) (*wasm.Module, error) {
+		if name == ModuleName {
+			return e.Module()
+		}
+		if next == nil {
+			return nil, fmt.Errorf("assemblyscript: unknown module %q", name)
+		}
+		return next(name)
+	}
+}
+
+// Imports returns whether the module m imports the abort function of
+// AssemblyScript, which takes the message, file name, line and column of
+// the failure.
+func Imports(m *wasm.Module) bool {
+	if m.Import == nil || m.Types == nil {
+		return false
+	}
+	for _, entry := range m.Import.Entries {
+		imp, ok := entry.Type.(wasm.FuncImport)
+		if !ok || entry.ModuleName != ModuleName || entry.FieldName != "abort" {
+			continue
+		}
+		if int(imp.Type) >= len(m.Types.Entries) {
+			return false
+		}
+		sig := m.Types.Entries[imp.Type]
+		return len(sig.ReturnTypes) == 0 && len(sig.ParamTypes) == 4 &&
+			sig.ParamTypes[0] == wasm.ValueTypeI32 && sig.ParamTypes[1] == wasm.ValueTypeI32 &&
+			sig.ParamTypes[2] == wasm.ValueTypeI32 && sig.ParamTypes[3] == wasm.ValueTypeI32
+	}
+	return false
+}
+
+// readString returns the string at ptr, or "" if ptr is null.
+func readString(proc *exec.Process, ptr uint32) string {
+	if ptr == 0 {
+		return ""
+	}
+	s, err := ReadString(proc, ptr)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (e *Env) abort(proc *exec.Process, msg, file, line, col uint32) {
+	panic(&AbortError{
+		Message: readString(proc, msg),
+		File:    readString(proc, file),
+		Line:    line,
+		Column:  col,
+	})
+}
+
+func (e *Env) trace(proc *exec.Process, msg, n uint32, a0, a1, a2, a3, a4 float64) {
+	var b strings.Builder
+	b.WriteString("trace: ")
+	b.WriteString(readString(proc, msg))
+	if n > 5 {
+		n = 5
+	}
+	for i, a := range []float64{a0, a1, a2, a3, a4}[:n] {
+		if i == 0 {
+			b.WriteString(" ")
+		} else {
+			b.WriteString(", ")
+		}
+		b.WriteString(formatNumber(a))
+	}
+	b.WriteString("\n")
+	io.WriteString(e.cfg.Stdout, b.String())
+}
+
+// seed returns the seed of the random number generator of the guest, the
+// current time in milliseconds as with the JavaScript loader.
+func (e *Env) seed(proc *exec.Process) float64 {
+	return float64(e.cfg.Now().UnixNano() / 1e6)
+}
+
+// formatNumber formats f the way JavaScript does, for the common cases.
+func formatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == math.Trunc(f) && math.Abs(f) < 1e21:
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package assemblyscript
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+// managedString returns the size header and UTF-16 code units of s.
+func managedString(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := make([]byte, rtSizeOffset+2*len(units))
+	binary.LittleEndian.PutUint32(b, uint32(2*len(units)))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(b[rtSizeOffset+2*i:], u)
+	}
+	return b
+}
+
+// guestModule returns the encoding of a module whose fail function aborts
+// with the message "boom" at main.ts:10:5.
+func guestModule(t *testing.T) []byte {
+	i32 := wasm.ValueTypeI32
+	m := wasm.NewModule()
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{
+		{Form: wasm.TypeFunc, ParamTypes: []wasm.ValueType{i32, i32, i32, i32}},
+		{Form: wasm.TypeFunc},
+	}}
+	m.Import = &wasm.SectionImports{Entries: []wasm.ImportEntry{
+		{ModuleName: ModuleName, FieldName: "abort", Type: wasm.FuncImport{Type: 0}},
+	}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{1}}
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.Export = &wasm.SectionExports{
+		Entries: map[string]wasm.ExportEntry{
+			"fail": {FieldStr: "fail", Kind: wasm.ExternalFunction, Index: 1},
+		},
+		Names: []string{"fail"},
+	}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{
+		// (call $abort (i32.const 4) (i32.const 16) (i32.const 10) (i32.const 5))
+		{Code: []byte{ops.I32Const, 4, ops.I32Const, 16, ops.I32Const, 10, ops.I32Const, 5, ops.Call, 0}},
+	}}
+	data := append(managedString("boom"), managedString("main.ts")...)
+	m.Data = &wasm.SectionData{Entries: []wasm.DataSegment{{
+		Offset: []byte{ops.I32Const, 0, ops.End},
+		Data:   data,
+	}}}
+	m.Sections = []wasm.Section{m.Types, m.Import, m.Function, m.Memory, m.Export, m.Code, m.Data}
+
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, m); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestAbort(t *testing.T) {
+	env := New(Config{})
+	m, err := wasm.ReadModule(bytes.NewReader(guestModule(t)), env.Resolver(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Imports(m) {
+		t.Errorf("the module does not import the runtime")
+	}
+	vm, err := exec.NewVM(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.RecoverPanic = true
+
+	fail, _ := vm.GetExportEntry("fail")
+	_, err = vm.ExecCode(int64(fail.Index))
+	want := &AbortError{Message: "boom", File: "main.ts", Line: 10, Column: 5}
+	if e, ok := err.(*AbortError); !ok || *e != *want {
+		t.Fatalf("got error %v, want %v", err, want)
+	}
+	if got, want := err.Error(), "assemblyscript: abort: boom at main.ts:10:5"; got != want {
+		t.Errorf("got message %q, want %q", got, want)
+	}
+}
+
+// newProcess returns a process with one page of memory.
+func newProcess(t *testing.T) *exec.Process {
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.LinearMemoryIndexSpace = [][]byte{make([]byte, 65536)}
+	vm, err := exec.NewVM(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exec.NewProcess(vm)
+}
+
+func TestReadString(t *testing.T) {
+	proc := newProcess(t)
+	proc.WriteBytes(0, managedString("héllo, 世界 😀"))
+	proc.WriteBytes(100, []byte{4, 0, 0, 0, 0x3d, 0xd8, 'a', 0}) // lone high surrogate
+	proc.WriteUint32Le(200, 0xffff)
+
+	for _, tc := range []struct {
+		ptr  uint32
+		want string
+		err  error
+	}{
+		{4, "héllo, 世界 😀", nil},
+		{104, "�a", nil},
+		{0, "", exec.ErrOutOfBoundsMemoryAccess},
+		{204, "", exec.ErrOutOfBoundsMemoryAccess},
+	} {
+		got, err := ReadString(proc, tc.ptr)
+		if got != tc.want || err != tc.err {
+			t.Errorf("ReadString(%d) = %q, %v, want %q, %v", tc.ptr, got, err, tc.want, tc.err)
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	proc := newProcess(t)
+	proc.WriteBytes(0, managedString("x"))
+	out := new(bytes.Buffer)
+	env := New(Config{Stdout: out})
+
+	env.trace(proc, 4, 0, 0, 0, 0, 0, 0)
+	env.trace(proc, 4, 3, 1, -0.5, math.NaN(), 7, 7)
+	env.trace(proc, 0, 1, 1e21, 0, 0, 0, 0)
+	want := "trace: x\ntrace: x 1, -0.5, NaN\ntrace:  1e+21\n"
+	if got := out.String(); got != want {
+		t.Errorf("got output %q, want %q", got, want)
+	}
+}
+
+func TestSeed(t *testing.T) {
+	now := time.Unix(2, 5e8)
+	env := New(Config{Now: func() time.Time { return now }})
+	if got := env.seed(nil); got != 2500 {
+		t.Errorf("seed returned %v, want 2500", got)
+	}
+}
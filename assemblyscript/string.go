@@ -0,0 +1,46 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package assemblyscript
+
+import (
+	"encoding/binary"
+	"unicode/utf16"
+
+	"github.com/go-interpreter/wagon/exec"
+)
+
+// The strings of AssemblyScript are managed objects: a pointer to a string
+// points to its UTF-16LE code units, and the header of the object before
+// them ends with their size in bytes.
+const rtSizeOffset = 4 // Offset of the size, before the pointer
+
+// ReadString returns the string at ptr in the memory of proc, decoding its
+// UTF-16 code units. Invalid surrogates are replaced with U+FFFD.
+// exec.ErrOutOfBoundsMemoryAccess is returned if the string is not within
+// the memory.
+func ReadString(proc *exec.Process, ptr uint32) (string, error) {
+	if ptr < rtSizeOffset {
+		return "", exec.ErrOutOfBoundsMemoryAccess
+	}
+	size, err := proc.ReadUint32Le(ptr - rtSizeOffset)
+	if err != nil {
+		return "", err
+	}
+	b, err := proc.MemoryView(ptr, size)
+	if err != nil {
+		return "", err
+	}
+	return decodeUTF16(b), nil
+}
+
+// decodeUTF16 decodes the UTF-16LE code units of b. A trailing odd byte is
+// ignored.
+func decodeUTF16(b []byte) string {
+	units := make([]uint16, len(b)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(units))
+}
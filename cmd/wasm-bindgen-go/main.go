@@ -0,0 +1,622 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// wasm-bindgen-go generates a Go package wrapping a wasm module.
+//
+// The generated package contains a struct with one typed method per
+// function exported by the module, and an Imports interface listing the host
+// functions the embedder must implement to instantiate it, along with the
+// initial values of the imported globals. Imported memories and tables are
+// created by the bindings.
+// Parameter names are taken from the name section of the module, when present.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `wasm-bindgen-go generates Go bindings for a wasm module.
+
+Usage: wasm-bindgen-go [options] file.wasm
+
+Options:
+`)
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	log.SetPrefix("wasm-bindgen-go: ")
+	log.SetFlags(0)
+
+	flagPkg := flag.String("pkg", "", "name of the generated package (default: name of the module file)")
+	flagType := flag.String("type", "Module", "name of the generated wrapper type")
+	flagOut := flag.String("o", "", "output file (default: stdout)")
+
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	fname := flag.Arg(0)
+	f, err := os.Open(fname)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	m, err := wasm.DecodeModule(f)
+	if err != nil {
+		log.Fatalf("could not read module: %v", err)
+	}
+
+	pkg := *flagPkg
+	if pkg == "" {
+		pkg = strings.ToLower(goName(strings.TrimSuffix(filepath.Base(fname), filepath.Ext(fname)), false))
+	}
+	if pkg == "" || !token.IsIdentifier(pkg) {
+		log.Fatalf("invalid package name %q", pkg)
+	}
+
+	src, err := generate(m, config{
+		Package: pkg,
+		Type:    *flagType,
+		Source:  filepath.Base(fname),
+	})
+	if err != nil {
+		log.Fatalf("could not generate bindings: %v", err)
+	}
+
+	if *flagOut == "" {
+		_, err = os.Stdout.Write(src)
+	} else {
+		err = ioutil.WriteFile(*flagOut, src, 0644)
+	}
+	if err != nil {
+		log.Fatalf("could not write bindings: %v", err)
+	}
+}
+
+// config holds the parameters of the generated package.
+type config struct {
+	Package string // Name of the package
+	Type    string // Name of the wrapper type
+	Source  string // Name of the module file, for the generated header
+}
+
+// function is a function of the module, either imported or exported, or
+// an imported memory, table or global.
+type function struct {
+	index  uint32
+	kind   wasm.External
+	module string // Module of an import
+	name   string // Import field or export name
+	method string // Go method name
+	sig    wasm.FunctionSig
+	params []string       // Go parameter names
+	global wasm.GlobalVar // Type of an imported global
+	host   bool           // Whether an exported function is imported
+}
+
+// generate returns the gofmt'ed source of the Go bindings for m.
+func generate(m *wasm.Module, cfg config) ([]byte, error) {
+	imports, exports, err := functions(m)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "// Code generated by wasm-bindgen-go from %s. DO NOT EDIT.\n\n", cfg.Source)
+	fmt.Fprintf(buf, "package %s\n\n", cfg.Package)
+	fmt.Fprintf(buf, "import (\n")
+	if len(imports) != 0 {
+		fmt.Fprintf(buf, "\t%q\n", "fmt")
+	}
+	fmt.Fprintf(buf, "\t%q\n", "io")
+	if usesFloats(exports) {
+		fmt.Fprintf(buf, "\t%q\n", "math")
+	}
+	if hasKind(imports, wasm.ExternalFunction) {
+		fmt.Fprintf(buf, "\t%q\n", "reflect")
+	}
+	fmt.Fprintf(buf, "\n\t%q\n", "github.com/go-interpreter/wagon/exec")
+	fmt.Fprintf(buf, "\t%q\n", "github.com/go-interpreter/wagon/wasm")
+	fmt.Fprintf(buf, ")\n\n")
+
+	if len(imports) != 0 {
+		writeImports(buf, imports)
+	}
+	writeConstructor(buf, cfg.Type, imports)
+	for _, fn := range exports {
+		writeExport(buf, cfg.Type, fn)
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+// functions returns the imported and exported functions of m, sorted by
+// method name.
+func functions(m *wasm.Module) (imports, exports []function, err error) {
+	var sigs []wasm.FunctionSig
+	if m.Types != nil {
+		sigs = m.Types.Entries
+	}
+	sigOf := func(typ uint32) (wasm.FunctionSig, error) {
+		if int(typ) >= len(sigs) {
+			return wasm.FunctionSig{}, wasm.InvalidFunctionIndexError(typ)
+		}
+		sig := sigs[typ]
+		for _, t := range append(append([]wasm.ValueType(nil), sig.ParamTypes...), sig.ReturnTypes...) {
+			if _, ok := goTypes[t]; !ok {
+				return wasm.FunctionSig{}, fmt.Errorf("unsupported value type %v", t)
+			}
+		}
+		return sig, nil
+	}
+
+	var types []uint32 // Type index of each function of the index space
+	if m.Import != nil {
+		for _, entry := range m.Import.Entries {
+			fn := function{
+				kind:   entry.Type.Kind(),
+				module: entry.ModuleName,
+				name:   entry.FieldName,
+				method: goName(entry.ModuleName, true) + goName(entry.FieldName, true),
+			}
+			switch imp := entry.Type.(type) {
+			case wasm.FuncImport:
+				sig, err := sigOf(imp.Type)
+				if err != nil {
+					return nil, nil, err
+				}
+				fn.index, fn.sig = uint32(len(types)), sig
+				types = append(types, imp.Type)
+			case wasm.GlobalVarImport:
+				if _, ok := goTypes[imp.Type.Type]; !ok {
+					return nil, nil, fmt.Errorf("import %s.%s: unsupported value type %v", entry.ModuleName, entry.FieldName, imp.Type.Type)
+				}
+				fn.global = imp.Type
+			case wasm.MemoryImport, wasm.TableImport:
+			default:
+				return nil, nil, fmt.Errorf("import %s.%s: unsupported import kind %v", entry.ModuleName, entry.FieldName, entry.Type.Kind())
+			}
+			imports = append(imports, fn)
+		}
+	}
+	hostFuncs := uint32(len(types))
+	if m.Function != nil {
+		types = append(types, m.Function.Types...)
+	}
+
+	if m.Export != nil {
+		for name, entry := range m.Export.Entries {
+			if entry.Kind != wasm.ExternalFunction {
+				continue
+			}
+			if int(entry.Index) >= len(types) {
+				return nil, nil, wasm.InvalidFunctionIndexError(entry.Index)
+			}
+			sig, err := sigOf(types[entry.Index])
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(sig.ReturnTypes) > 1 {
+				return nil, nil, fmt.Errorf("export %s: functions with more than one return value are not supported", name)
+			}
+			exports = append(exports, function{
+				index:  entry.Index,
+				name:   name,
+				method: goName(name, true),
+				sig:    sig,
+				host:   entry.Index < hostFuncs,
+			})
+		}
+	}
+
+	locals, err := localNames(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, fns := range [][]function{imports, exports} {
+		for i := range fns {
+			if fns[i].kind == wasm.ExternalFunction {
+				fns[i].params = paramNames(fns[i].sig, locals[fns[i].index])
+			}
+		}
+	}
+
+	// Imported memories and tables have no method, but are sorted along
+	// with the other imports for simplicity.
+	sortFunctions(imports, nil)
+	// The VM field of the wrapper type shares the namespace of its methods.
+	sortFunctions(exports, map[string]bool{"VM": true})
+	return imports, exports, nil
+}
+
+// sortFunctions sorts fns by method name, renaming methods clashing with
+// each other or with reserved names.
+func sortFunctions(fns []function, reserved map[string]bool) {
+	sort.Slice(fns, func(i, j int) bool {
+		if fns[i].method != fns[j].method {
+			return fns[i].method < fns[j].method
+		}
+		return fns[i].module+"."+fns[i].name < fns[j].module+"."+fns[j].name
+	})
+	used := make(map[string]bool)
+	for name := range reserved {
+		used[name] = true
+	}
+	for i := range fns {
+		for used[fns[i].method] {
+			fns[i].method += "_"
+		}
+		used[fns[i].method] = true
+	}
+}
+
+// localNames returns the names of the locals of each function, from the name
+// section of m.
+func localNames(m *wasm.Module) (map[uint32]wasm.NameMap, error) {
+	s := m.Custom(wasm.CustomSectionName)
+	if s == nil {
+		return nil, nil
+	}
+	var names wasm.NameSection
+	err := names.UnmarshalWASM(bytes.NewReader(s.Data))
+	if err != nil {
+		return nil, fmt.Errorf("could not read name section: %v", err)
+	}
+	if len(names.Types[wasm.NameLocal]) == 0 {
+		return nil, nil
+	}
+	sub, err := names.Decode(wasm.NameLocal)
+	if err != nil {
+		return nil, fmt.Errorf("could not read local names: %v", err)
+	}
+	return sub.(*wasm.LocalNames).Funcs, nil
+}
+
+// paramNames returns the Go names of the parameters of a function, using
+// the local names of the function when they are valid and unambiguous.
+func paramNames(sig wasm.FunctionSig, locals wasm.NameMap) []string {
+	// Names used by the generated code.
+	used := map[string]bool{"m": true, "proc": true, "res": true, "err": true}
+	names := make([]string, len(sig.ParamTypes))
+	for i := range names {
+		name := goName(locals[uint32(i)], false)
+		if name == "" || name == "_" || used[name] || token.IsKeyword(name) || isPredeclared(name) {
+			name = fmt.Sprintf("p%d", i)
+		}
+		for used[name] {
+			name += "_"
+		}
+		used[name] = true
+		names[i] = name
+	}
+	return names
+}
+
+func isPredeclared(name string) bool {
+	switch name {
+	case "int32", "int64", "float32", "float64", "uint64", "uint32", "math", "exec", "wasm", "fmt", "io", "reflect":
+		return true
+	}
+	return false
+}
+
+// goName converts a wasm name into a Go identifier, in CamelCase if exported
+// is true and in camelCase otherwise.
+func goName(name string, exported bool) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		if i == 0 && !exported {
+			r := []rune(word)
+			r[0] = unicode.ToLower(r[0])
+			words[i] = string(r)
+			continue
+		}
+		r := []rune(word)
+		r[0] = unicode.ToUpper(r[0])
+		words[i] = string(r)
+	}
+	id := strings.Join(words, "")
+	if id == "" {
+		if exported {
+			return "X"
+		}
+		return ""
+	}
+	if unicode.IsDigit([]rune(id)[0]) {
+		if exported {
+			return "X" + id
+		}
+		return "x" + id
+	}
+	return id
+}
+
+var goTypes = map[wasm.ValueType]string{
+	wasm.ValueTypeI32: "int32",
+	wasm.ValueTypeI64: "int64",
+	wasm.ValueTypeF32: "float32",
+	wasm.ValueTypeF64: "float64",
+}
+
+var wasmTypes = map[wasm.ValueType]string{
+	wasm.ValueTypeI32: "wasm.ValueTypeI32",
+	wasm.ValueTypeI64: "wasm.ValueTypeI64",
+	wasm.ValueTypeF32: "wasm.ValueTypeF32",
+	wasm.ValueTypeF64: "wasm.ValueTypeF64",
+}
+
+// hasKind returns whether one of fns is of the given kind.
+func hasKind(fns []function, kind wasm.External) bool {
+	for _, fn := range fns {
+		if fn.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+func usesFloats(fns []function) bool {
+	for _, fn := range fns {
+		for _, typ := range append(append([]wasm.ValueType(nil), fn.sig.ParamTypes...), fn.sig.ReturnTypes...) {
+			if typ == wasm.ValueTypeF32 || typ == wasm.ValueTypeF64 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// params returns the Go parameter list of fn.
+func params(fn function) string {
+	var list []string
+	for i, typ := range fn.sig.ParamTypes {
+		list = append(list, fn.params[i]+" "+goTypes[typ])
+	}
+	return strings.Join(list, ", ")
+}
+
+// signature returns the Go expression of the signature of fn.
+func signature(fn function) string {
+	typeList := func(types []wasm.ValueType) string {
+		list := make([]string, len(types))
+		for i, typ := range types {
+			list[i] = wasmTypes[typ]
+		}
+		return strings.Join(list, ", ")
+	}
+	sig := "wasm.FunctionSig{"
+	if len(fn.sig.ParamTypes) != 0 {
+		sig += "ParamTypes: []wasm.ValueType{" + typeList(fn.sig.ParamTypes) + "}"
+		if len(fn.sig.ReturnTypes) != 0 {
+			sig += ", "
+		}
+	}
+	if len(fn.sig.ReturnTypes) != 0 {
+		sig += "ReturnTypes: []wasm.ValueType{" + typeList(fn.sig.ReturnTypes) + "}"
+	}
+	return sig + "}"
+}
+
+func writeImports(w io.Writer, imports []function) {
+	if hasKind(imports, wasm.ExternalGlobal) {
+		fmt.Fprintf(w, "// Imports is the set of host functions imported by the module, and of\n")
+		fmt.Fprintf(w, "// the initial values of its imported globals.\n")
+	} else {
+		fmt.Fprintf(w, "// Imports is the set of host functions imported by the module.\n")
+	}
+	fmt.Fprintf(w, "type Imports interface {\n")
+	for _, fn := range imports {
+		switch fn.kind {
+		case wasm.ExternalGlobal:
+			fmt.Fprintf(w, "\t// %s returns the initial value of the global imported as %q\n", fn.method, fn.name)
+			fmt.Fprintf(w, "\t// from module %q.\n", fn.module)
+			fmt.Fprintf(w, "\t%s() %s\n", fn.method, goTypes[fn.global.Type])
+			continue
+		case wasm.ExternalFunction:
+		default:
+			continue
+		}
+		fmt.Fprintf(w, "\t// %s is imported as %q from module %q.\n", fn.method, fn.name, fn.module)
+		fmt.Fprintf(w, "\t%s(proc *exec.Process", fn.method)
+		if len(fn.sig.ParamTypes) != 0 {
+			fmt.Fprintf(w, ", %s", params(fn))
+		}
+		fmt.Fprintf(w, ")")
+		switch len(fn.sig.ReturnTypes) {
+		case 0:
+		case 1:
+			fmt.Fprintf(w, " %s", goTypes[fn.sig.ReturnTypes[0]])
+		default:
+			list := make([]string, len(fn.sig.ReturnTypes))
+			for i, typ := range fn.sig.ReturnTypes {
+				list[i] = goTypes[typ]
+			}
+			fmt.Fprintf(w, " (%s)", strings.Join(list, ", "))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+	fmt.Fprintf(w, "}\n\n")
+
+	modules := make(map[string][]function)
+	var names []string
+	for _, fn := range imports {
+		if _, ok := modules[fn.module]; !ok {
+			names = append(names, fn.module)
+		}
+		modules[fn.module] = append(modules[fn.module], fn)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "// resolve returns a host module exporting the functions of imp imported\n")
+	fmt.Fprintf(w, "// from the module name.")
+	if hasKind(imports, wasm.ExternalGlobal) || hasKind(imports, wasm.ExternalMemory) || hasKind(imports, wasm.ExternalTable) {
+		fmt.Fprintf(w, " Imported globals are initialized with the values of\n")
+		fmt.Fprintf(w, "// imp, and imported memories and tables are created with the size the\n")
+		fmt.Fprintf(w, "// module expects.")
+	}
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "func resolve(imp Imports, name string) (*wasm.Module, error) {\n")
+	fmt.Fprintf(w, "\tm := wasm.NewModule()\n")
+	fmt.Fprintf(w, "\tm.Export = &wasm.SectionExports{Entries: make(map[string]wasm.ExportEntry)}\n")
+	if hasKind(imports, wasm.ExternalFunction) {
+		fmt.Fprintf(w, "\tadd := func(field string, sig wasm.FunctionSig, fn interface{}) {\n")
+		fmt.Fprintf(w, "\t\tm.Export.Entries[field] = wasm.ExportEntry{FieldStr: field, Kind: wasm.ExternalFunction, Index: uint32(len(m.FunctionIndexSpace))}\n")
+		fmt.Fprintf(w, "\t\tm.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{Sig: &sig, Host: reflect.ValueOf(fn), Body: &wasm.FunctionBody{}})\n")
+		fmt.Fprintf(w, "\t}\n")
+	}
+	if hasKind(imports, wasm.ExternalGlobal) {
+		fmt.Fprintf(w, "\taddGlobal := func(field string, typ wasm.GlobalVar, v interface{}) error {\n")
+		fmt.Fprintf(w, "\t\tcell, err := wasm.NewGlobal(typ, v)\n")
+		fmt.Fprintf(w, "\t\tif err != nil {\n")
+		fmt.Fprintf(w, "\t\t\treturn err\n")
+		fmt.Fprintf(w, "\t\t}\n")
+		fmt.Fprintf(w, "\t\tm.Export.Entries[field] = wasm.ExportEntry{FieldStr: field, Kind: wasm.ExternalGlobal, Index: uint32(len(m.GlobalIndexSpace))}\n")
+		fmt.Fprintf(w, "\t\tm.GlobalIndexSpace = append(m.GlobalIndexSpace, wasm.GlobalEntry{Type: typ, Cell: cell})\n")
+		fmt.Fprintf(w, "\t\treturn nil\n")
+		fmt.Fprintf(w, "\t}\n")
+	}
+	if hasKind(imports, wasm.ExternalMemory) {
+		fmt.Fprintf(w, "\taddMemory := func(field string) {\n")
+		fmt.Fprintf(w, "\t\tm.Export.Entries[field] = wasm.ExportEntry{FieldStr: field, Kind: wasm.ExternalMemory, Index: uint32(len(m.LinearMemoryIndexSpace))}\n")
+		fmt.Fprintf(w, "\t\tm.LinearMemoryIndexSpace = append(m.LinearMemoryIndexSpace, nil)\n")
+		fmt.Fprintf(w, "\t}\n")
+	}
+	if hasKind(imports, wasm.ExternalTable) {
+		fmt.Fprintf(w, "\taddTable := func(field string) {\n")
+		fmt.Fprintf(w, "\t\tm.Export.Entries[field] = wasm.ExportEntry{FieldStr: field, Kind: wasm.ExternalTable, Index: uint32(len(m.TableIndexSpace))}\n")
+		fmt.Fprintf(w, "\t\tm.TableIndexSpace = append(m.TableIndexSpace, nil)\n")
+		fmt.Fprintf(w, "\t}\n")
+	}
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "\tswitch name {\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "\tcase %q:\n", name)
+		for _, fn := range modules[name] {
+			switch fn.kind {
+			case wasm.ExternalFunction:
+				fmt.Fprintf(w, "\t\tadd(%q, %s, imp.%s)\n", fn.name, signature(fn), fn.method)
+			case wasm.ExternalGlobal:
+				fmt.Fprintf(w, "\t\tif err := addGlobal(%q, wasm.GlobalVar{Type: %s, Mutable: %v}, imp.%s()); err != nil {\n", fn.name, wasmTypes[fn.global.Type], fn.global.Mutable, fn.method)
+				fmt.Fprintf(w, "\t\t\treturn nil, err\n")
+				fmt.Fprintf(w, "\t\t}\n")
+			case wasm.ExternalMemory:
+				fmt.Fprintf(w, "\t\taddMemory(%q)\n", fn.name)
+			case wasm.ExternalTable:
+				fmt.Fprintf(w, "\t\taddTable(%q)\n", fn.name)
+			}
+		}
+	}
+	fmt.Fprintf(w, "\tdefault:\n")
+	fmt.Fprintf(w, "\t\treturn nil, fmt.Errorf(\"unknown import module %%q\", name)\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\treturn m, nil\n")
+	fmt.Fprintf(w, "}\n\n")
+}
+
+func writeConstructor(w io.Writer, typ string, imports []function) {
+	fmt.Fprintf(w, "// %s is an instance of the module.\n", typ)
+	fmt.Fprintf(w, "type %s struct {\n", typ)
+	fmt.Fprintf(w, "\tVM *exec.VM\n")
+	fmt.Fprintf(w, "}\n\n")
+
+	if len(imports) == 0 {
+		fmt.Fprintf(w, "// New reads the module from r and instantiates it.\n")
+		fmt.Fprintf(w, "func New(r io.Reader, opts ...exec.VMOption) (*%s, error) {\n", typ)
+		fmt.Fprintf(w, "\tm, err := wasm.ReadModule(r, nil)\n")
+	} else {
+		fmt.Fprintf(w, "// New reads the module from r and instantiates it, with its imports\n")
+		fmt.Fprintf(w, "// implemented by imp.\n")
+		fmt.Fprintf(w, "func New(r io.Reader, imp Imports, opts ...exec.VMOption) (*%s, error) {\n", typ)
+		fmt.Fprintf(w, "\tm, err := wasm.ReadModule(r, func(name string) (*wasm.Module, error) {\n")
+		fmt.Fprintf(w, "\t\treturn resolve(imp, name)\n")
+		fmt.Fprintf(w, "\t})\n")
+	}
+	fmt.Fprintf(w, "\tif err != nil {\n")
+	fmt.Fprintf(w, "\t\treturn nil, err\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\tvm, err := exec.NewVM(m, opts...)\n")
+	fmt.Fprintf(w, "\tif err != nil {\n")
+	fmt.Fprintf(w, "\t\treturn nil, err\n")
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\treturn &%s{VM: vm}, nil\n", typ)
+	fmt.Fprintf(w, "}\n\n")
+}
+
+func writeExport(w io.Writer, typ string, fn function) {
+	args := make([]string, len(fn.sig.ParamTypes))
+	for i, t := range fn.sig.ParamTypes {
+		switch t {
+		case wasm.ValueTypeI32:
+			args[i] = fmt.Sprintf("uint64(uint32(%s))", fn.params[i])
+		case wasm.ValueTypeI64:
+			args[i] = fmt.Sprintf("uint64(%s)", fn.params[i])
+		case wasm.ValueTypeF32:
+			args[i] = fmt.Sprintf("uint64(math.Float32bits(%s))", fn.params[i])
+		case wasm.ValueTypeF64:
+			args[i] = fmt.Sprintf("math.Float64bits(%s)", fn.params[i])
+		}
+	}
+	call := fmt.Sprintf("m.VM.ExecCode(%d", fn.index)
+	if fn.host {
+		// ExecCode cannot call host functions.
+		call = fmt.Sprintf("exec.NewProcess(m.VM).Call(%d", fn.index)
+	}
+	if len(args) != 0 {
+		call += ", " + strings.Join(args, ", ")
+	}
+	call += ")"
+
+	fmt.Fprintf(w, "// %s calls the exported function %q.\n", fn.method, fn.name)
+	if fn.host {
+		fmt.Fprintf(w, "// The function is imported by the module: it is implemented by Imports,\n")
+		fmt.Fprintf(w, "// and calling it through the module fails with exec.ErrHostFunction.\n")
+	}
+	if len(fn.sig.ReturnTypes) == 0 {
+		fmt.Fprintf(w, "func (m *%s) %s(%s) error {\n", typ, fn.method, params(fn))
+		fmt.Fprintf(w, "\t_, err := %s\n", call)
+		fmt.Fprintf(w, "\treturn err\n")
+		fmt.Fprintf(w, "}\n\n")
+		return
+	}
+
+	ret := fn.sig.ReturnTypes[0]
+	fmt.Fprintf(w, "func (m *%s) %s(%s) (%s, error) {\n", typ, fn.method, params(fn), goTypes[ret])
+	fmt.Fprintf(w, "\tres, err := %s\n", call)
+	fmt.Fprintf(w, "\tif err != nil {\n")
+	fmt.Fprintf(w, "\t\treturn 0, err\n")
+	fmt.Fprintf(w, "\t}\n")
+	switch ret {
+	case wasm.ValueTypeI32:
+		fmt.Fprintf(w, "\treturn int32(res.(uint32)), nil\n")
+	case wasm.ValueTypeI64:
+		fmt.Fprintf(w, "\treturn int64(res.(uint64)), nil\n")
+	default:
+		fmt.Fprintf(w, "\treturn res.(%s), nil\n", goTypes[ret])
+	}
+	fmt.Fprintf(w, "}\n\n")
+}
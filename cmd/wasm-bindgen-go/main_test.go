@@ -0,0 +1,155 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// bindgenModule returns a module importing env.log and env.now, and
+// exporting add, scale and run, with the names of the parameters of add in
+// its name section.
+func bindgenModule(t *testing.T) *wasm.Module {
+	m := wasm.NewModule()
+	m.Types = &wasm.SectionTypes{
+		Entries: []wasm.FunctionSig{
+			{ParamTypes: []wasm.ValueType{wasm.ValueTypeI32}},
+			{ReturnTypes: []wasm.ValueType{wasm.ValueTypeI64}},
+			{
+				ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32},
+				ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+			},
+			{
+				ParamTypes:  []wasm.ValueType{wasm.ValueTypeF64, wasm.ValueTypeF32},
+				ReturnTypes: []wasm.ValueType{wasm.ValueTypeF64},
+			},
+			{},
+		},
+	}
+	m.Import = &wasm.SectionImports{
+		Entries: []wasm.ImportEntry{
+			{ModuleName: "env", FieldName: "log", Type: wasm.FuncImport{Type: 0}},
+			{ModuleName: "env", FieldName: "now", Type: wasm.FuncImport{Type: 1}},
+		},
+	}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{2, 3, 4}}
+	m.Export = &wasm.SectionExports{
+		Entries: map[string]wasm.ExportEntry{
+			"add":      {FieldStr: "add", Kind: wasm.ExternalFunction, Index: 2},
+			"scale_by": {FieldStr: "scale_by", Kind: wasm.ExternalFunction, Index: 3},
+			"_start":   {FieldStr: "_start", Kind: wasm.ExternalFunction, Index: 4},
+		},
+	}
+
+	locals := &wasm.LocalNames{
+		Funcs: map[uint32]wasm.NameMap{
+			0: {0: "msg"},
+			2: {0: "x", 1: "y", 2: "tmp"},
+			3: {0: "type", 1: "factor"},
+		},
+	}
+	buf := new(bytes.Buffer)
+	if err := locals.MarshalWASM(buf); err != nil {
+		t.Fatal(err)
+	}
+	names := &wasm.NameSection{Types: map[wasm.NameType][]byte{wasm.NameLocal: buf.Bytes()}}
+	buf = new(bytes.Buffer)
+	if err := names.MarshalWASM(buf); err != nil {
+		t.Fatal(err)
+	}
+	m.Customs = []*wasm.SectionCustom{{Name: wasm.CustomSectionName, Data: buf.Bytes()}}
+	return m
+}
+
+func TestGenerate(t *testing.T) {
+	got, err := generate(bindgenModule(t), config{Package: "example", Type: "Example", Source: "example.wasm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("testdata/example.go.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("invalid output.\ngot:\n%s\nwant:\n%s\n", got, want)
+	}
+}
+
+// importsModule returns a module importing a function, a global, a memory
+// and a table, and re-exporting the function.
+func importsModule() *wasm.Module {
+	m := wasm.NewModule()
+	m.Types = &wasm.SectionTypes{
+		Entries: []wasm.FunctionSig{
+			{ParamTypes: []wasm.ValueType{wasm.ValueTypeI32}, ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32}},
+		},
+	}
+	m.Import = &wasm.SectionImports{
+		Entries: []wasm.ImportEntry{
+			{ModuleName: "env", FieldName: "double", Type: wasm.FuncImport{Type: 0}},
+			{ModuleName: "env", FieldName: "counter", Type: wasm.GlobalVarImport{
+				Type: wasm.GlobalVar{Type: wasm.ValueTypeI64, Mutable: true},
+			}},
+			{ModuleName: "env", FieldName: "memory", Type: wasm.MemoryImport{
+				Type: wasm.Memory{Limits: wasm.ResizableLimits{Initial: 1}},
+			}},
+			{ModuleName: "env", FieldName: "table", Type: wasm.TableImport{
+				Type: wasm.Table{ElementType: wasm.ElemTypeAnyFunc, Limits: wasm.ResizableLimits{Initial: 1}},
+			}},
+		},
+	}
+	m.Export = &wasm.SectionExports{
+		Entries: map[string]wasm.ExportEntry{
+			"double": {FieldStr: "double", Kind: wasm.ExternalFunction, Index: 0},
+		},
+	}
+	return m
+}
+
+func TestGenerateImports(t *testing.T) {
+	got, err := generate(importsModule(), config{Package: "imports", Type: "Module", Source: "imports.wasm"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("testdata/imports.go.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("invalid output.\ngot:\n%s\nwant:\n%s\n", got, want)
+	}
+}
+
+func TestGenerateUnsupported(t *testing.T) {
+	m := bindgenModule(t)
+	m.Import.Entries = append(m.Import.Entries, wasm.ImportEntry{
+		ModuleName: "env",
+		FieldName:  "error",
+		Type:       wasm.TagImport{},
+	})
+	if _, err := generate(m, config{Package: "example", Type: "Example"}); err == nil {
+		t.Fatal("expected an error for a tag import")
+	}
+
+	m = bindgenModule(t)
+	m.Import.Entries = append(m.Import.Entries, wasm.ImportEntry{
+		ModuleName: "env",
+		FieldName:  "ref",
+		Type:       wasm.GlobalVarImport{Type: wasm.GlobalVar{Type: wasm.ValueType(0x6f)}},
+	})
+	if _, err := generate(m, config{Package: "example", Type: "Example"}); err == nil {
+		t.Fatal("expected an error for a global of an unsupported type")
+	}
+
+	m = bindgenModule(t)
+	m.Types.Entries[4].ReturnTypes = []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32}
+	if _, err := generate(m, config{Package: "example", Type: "Example"}); err == nil {
+		t.Fatal("expected an error for an export with several return values")
+	}
+}
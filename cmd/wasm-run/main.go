@@ -5,23 +5,73 @@
 package main
 
 import (
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
+	"math"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/go-interpreter/wagon/assemblyscript"
+	"github.com/go-interpreter/wagon/emscripten"
 	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/gojs"
 	"github.com/go-interpreter/wagon/validate"
+	"github.com/go-interpreter/wagon/vfs"
+	"github.com/go-interpreter/wagon/wasi"
 	"github.com/go-interpreter/wagon/wasm"
 )
 
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `wasm-run runs the exported functions of a wasm module.
+
+Usage: wasm-run [options] file.wasm [args...]
+
+Modules importing WASI are run through their _start function, with args
+as command line arguments. Otherwise, without -invoke, all the exported
+functions taking no argument are run.
+With -invoke, the given function is run with args, parsed according to its
+signature: integers may be written in decimal or hexadecimal, and floats
+either as decimal or hexadecimal floats (1.5, 0x1.8p0, inf, nan) or as the
+hexadecimal bit pattern of the float (0x3fc00000).
+Modules built with Emscripten or AssemblyScript are provided their runtime
+as their env module, unless an env.wasm file is found; an abort of an
+AssemblyScript module is reported with its message and location.
+Go programs built with GOOS=js GOARCH=wasm are run with args as command
+line arguments; the directory preopened as / with -dir is their root
+directory.
+With -repl, commands to call functions and inspect the instance are read
+from the standard input; type help for a list.
+
+Options:
+`)
+		flag.PrintDefaults()
+	}
+}
+
 func main() {
 	log.SetPrefix("wasm-run: ")
 	log.SetFlags(0)
 
 	verbose := flag.Bool("v", false, "enable/disable verbose mode")
 	verify := flag.Bool("verify-module", false, "run module verification")
+	invoke := flag.String("invoke", "", "name of the exported function to run")
+	aot := flag.Bool("aot", false, "enable ahead-of-time compilation to native code")
+	fuel := flag.Uint64("fuel", 0, "maximum number of instructions to execute (0 for no limit)")
+	timeout := flag.Duration("timeout", 0, "maximum duration of each function call (0 for no limit)")
+	repl := flag.Bool("repl", false, "run an interactive session with the module")
+	var dirs, env listFlag
+	flag.Var(&dirs, "dir", "preopen a directory for WASI or Go programs, as `dir` or guest=host (repeatable)")
+	flag.Var(&env, "env", "set an environment variable of WASI or Go programs, as `key=value` (repeatable)")
 
 	flag.Parse()
 
@@ -32,46 +82,122 @@ func main() {
 
 	wasm.SetDebugMode(*verbose)
 
-	run(os.Stdout, flag.Arg(0), *verify)
+	err := run(os.Stdout, flag.Arg(0), options{
+		verify:  *verify,
+		invoke:  *invoke,
+		args:    flag.Args()[1:],
+		aot:     *aot,
+		fuel:    *fuel,
+		timeout: *timeout,
+		repl:    *repl,
+		dirs:    dirs,
+		env:     env,
+	})
+	if code, ok := err.(wasi.ExitError); ok {
+		os.Exit(int(code))
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
-func run(w io.Writer, fname string, verify bool) {
-	f, err := os.Open(fname)
+// listFlag is a flag that may be repeated.
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// options controls how run executes a module.
+type options struct {
+	verify  bool     // Run module verification
+	invoke  string   // Name of the export to run, all if empty
+	args    []string // Arguments of the export to run
+	aot     bool     // Enable ahead-of-time compilation
+	fuel    uint64   // Maximum number of instructions to execute, if not 0
+	timeout time.Duration
+	repl    bool     // Run an interactive session
+	dirs    []string // Directories preopened for WASI and Go programs
+	env     []string // Environment variables of WASI and Go programs
+}
+
+func run(out io.Writer, fname string, opts options) error {
+	raw, err := ioutil.ReadFile(fname)
 	if err != nil {
-		log.Fatal(err)
+		return err
+	}
+	// The env module of AssemblyScript and Emscripten differ, so the
+	// imports are decoded first to pick the runtime.
+	decoded, err := wasm.DecodeModule(bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("could not read module: %v", err)
 	}
-	defer f.Close()
 
-	m, err := wasm.ReadModule(f, importer)
+	preopens, err := preopenDirs(opts.dirs)
 	if err != nil {
-		log.Fatalf("could not read module: %v", err)
+		return err
+	}
+	w := newWASI(out, fname, preopens, opts)
+	g := newGo(out, fname, preopens, opts)
+	m, err := wasm.ReadModule(bytes.NewReader(raw), resolver(out, w, g, assemblyscript.Imports(decoded)))
+	if err != nil {
+		return fmt.Errorf("could not read module: %v", err)
 	}
 
-	if verify {
+	if opts.verify {
 		err = validate.VerifyModule(m)
 		if err != nil {
-			log.Fatalf("could not verify module: %v", err)
+			return fmt.Errorf("could not verify module: %v", err)
 		}
 	}
 
 	if m.Export == nil {
-		log.Fatalf("module has no export section")
+		return errors.New("module has no export section")
 	}
 
-	vm, err := exec.NewVM(m)
+	vm, err := newVM(m, opts)
 	if err != nil {
-		log.Fatalf("could not create VM: %v", err)
+		return err
+	}
+
+	if opts.repl {
+		r := &repl{w: out, m: m, vm: vm, opts: opts}
+		defer func() { r.vm.Close() }()
+		return r.run(os.Stdin)
 	}
+	defer vm.Close()
 
+	if opts.invoke != "" {
+		return invoke(out, vm, m, opts.invoke, opts.args, opts.timeout)
+	}
+	if wasi.Imports(m) {
+		return invoke(out, vm, m, "_start", nil, opts.timeout)
+	}
+	if gojs.Imports(m) {
+		return g.Run(vm)
+	}
+
+	names := make([]string, 0, len(m.Export.Entries))
 	for name, e := range m.Export.Entries {
-		i := int64(e.Index)
-		fidx := m.Function.Types[int(i)]
-		ftype := m.Types.Entries[int(fidx)]
+		if e.Kind == wasm.ExternalFunction {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		i := int64(m.Export.Entries[name].Index)
+		ftype := m.GetFunction(int(i)).Sig
 		switch len(ftype.ReturnTypes) {
 		case 1:
-			fmt.Fprintf(w, "%s() %s => ", name, ftype.ReturnTypes[0])
+			fmt.Fprintf(out, "%s() %s => ", name, ftype.ReturnTypes[0])
 		case 0:
-			fmt.Fprintf(w, "%s() => ", name)
+			fmt.Fprintf(out, "%s() => ", name)
 		default:
 			log.Printf("running exported functions with more than one return value is not supported")
 			continue
@@ -80,18 +206,222 @@ func run(w io.Writer, fname string, verify bool) {
 			log.Printf("running exported functions with input parameters is not supported")
 			continue
 		}
-		o, err := vm.ExecCode(i)
+		o, err := call(vm, i, opts.timeout)
 		if err != nil {
-			fmt.Fprintf(w, "\n")
+			fmt.Fprintf(out, "\n")
 			log.Printf("err=%v", err)
 			continue
 		}
 		if len(ftype.ReturnTypes) == 0 {
-			fmt.Fprintf(w, "\n")
+			fmt.Fprintf(out, "\n")
 			continue
 		}
-		fmt.Fprintf(w, "%[1]v (%[1]T)\n", o)
+		fmt.Fprintf(out, "%[1]v (%[1]T)\n", o)
+	}
+	return nil
+}
+
+// preopenDirs returns the file systems of the directories of -dir, by
+// their name in the guest.
+func preopenDirs(dirs []string) (map[string]vfs.FS, error) {
+	preopens := make(map[string]vfs.FS)
+	for _, dir := range dirs {
+		guest, host := dir, dir
+		if i := strings.Index(dir, "="); i >= 0 {
+			guest, host = dir[:i], dir[i+1:]
+		}
+		fi, err := os.Stat(host)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", host)
+		}
+		preopens[guest] = vfs.OS(host)
+	}
+	return preopens, nil
+}
+
+// commandLine returns the command line arguments of the module fname:
+// opts.args, unless they are those of -invoke.
+func commandLine(fname string, opts options) []string {
+	args := []string{filepath.Base(fname)}
+	if opts.invoke == "" {
+		args = append(args, opts.args...)
+	}
+	return args
+}
+
+// newWASI returns the WASI environment of the module fname, whose standard
+// output is out.
+func newWASI(out io.Writer, fname string, preopens map[string]vfs.FS, opts options) *wasi.WASI {
+	return wasi.New(wasi.Config{
+		Args:     commandLine(fname, opts),
+		Env:      opts.env,
+		Stdin:    os.Stdin,
+		Stdout:   out,
+		Stderr:   os.Stderr,
+		Preopens: preopens,
+	})
+}
+
+// newGo returns the runtime of the module fname if it is a Go program. Its
+// standard output is out, and its root directory the one preopened as /.
+func newGo(out io.Writer, fname string, preopens map[string]vfs.FS, opts options) *gojs.Runtime {
+	return gojs.New(gojs.Config{
+		Args:   commandLine(fname, opts),
+		Env:    opts.env,
+		Stdin:  os.Stdin,
+		Stdout: out,
+		Stderr: os.Stderr,
+		FS:     preopens["/"],
+	})
+}
+
+// newVM instantiates m with the options of the command line.
+func newVM(m *wasm.Module, opts options) (*exec.VM, error) {
+	vm, err := exec.NewVM(m, exec.EnableAOT(opts.aot), exec.EnableInterrupt(opts.timeout > 0))
+	if err != nil {
+		return nil, fmt.Errorf("could not create VM: %v", err)
+	}
+	vm.RecoverPanic = true
+	vm.SetFuel(opts.fuel)
+	return vm, nil
+}
+
+// invoke runs the exported function name with the arguments args, and
+// prints its result.
+func invoke(w io.Writer, vm *exec.VM, m *wasm.Module, name string, args []string, timeout time.Duration) error {
+	e, ok := m.Export.Entries[name]
+	if !ok || e.Kind != wasm.ExternalFunction {
+		return fmt.Errorf("module has no exported function %q", name)
+	}
+	sig := m.GetFunction(int(e.Index)).Sig
+	if len(args) != len(sig.ParamTypes) {
+		return fmt.Errorf("%s expects %d arguments, got %d", name, len(sig.ParamTypes), len(args))
+	}
+	raw := make([]uint64, len(args))
+	for i, arg := range args {
+		v, err := parseArg(arg, sig.ParamTypes[i])
+		if err != nil {
+			return fmt.Errorf("argument %d of %s: %v", i, name, err)
+		}
+		raw[i] = v
+	}
+
+	o, err := call(vm, int64(e.Index), timeout, raw...)
+	if code, ok := err.(wasi.ExitError); ok {
+		if code == 0 {
+			return nil
+		}
+		return code
+	}
+	if err != nil {
+		return fmt.Errorf("trap: %v", err)
+	}
+	if o != nil {
+		fmt.Fprintln(w, formatResult(o))
+	}
+	return nil
+}
+
+// call runs the function at index, interrupting it after timeout if it is
+// not 0.
+func call(vm *exec.VM, index int64, timeout time.Duration, args ...uint64) (interface{}, error) {
+	if timeout > 0 {
+		fired := make(chan struct{})
+		timer := time.AfterFunc(timeout, func() {
+			vm.Interrupt()
+			close(fired)
+		})
+		defer func() {
+			// The timer may fire after the function returns, in which case
+			// the interruption must not stop the next call.
+			if !timer.Stop() {
+				<-fired
+				vm.ClearInterrupt()
+			}
+		}()
+	}
+	return vm.ExecCode(index, args...)
+}
+
+// parseArg parses the argument s of type typ, and returns its raw value.
+func parseArg(s string, typ wasm.ValueType) (uint64, error) {
+	switch typ {
+	case wasm.ValueTypeI32:
+		v, err := parseInt(s, 32)
+		return uint64(uint32(v)), err
+	case wasm.ValueTypeI64:
+		return parseInt(s, 64)
+	case wasm.ValueTypeF32:
+		if isBitPattern(s) {
+			return parseInt(s, 32)
+		}
+		v, err := strconv.ParseFloat(s, 32)
+		return uint64(math.Float32bits(float32(v))), err
+	case wasm.ValueTypeF64:
+		if isBitPattern(s) {
+			return parseInt(s, 64)
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		return math.Float64bits(v), err
+	}
+	return 0, fmt.Errorf("unsupported type %v", typ)
+}
+
+// parseInt parses an integer of the given bit size, either signed or
+// unsigned.
+func parseInt(s string, bitSize int) (uint64, error) {
+	if v, err := strconv.ParseInt(s, 0, bitSize); err == nil {
+		return uint64(v), nil
+	}
+	return strconv.ParseUint(s, 0, bitSize)
+}
+
+// isBitPattern returns whether s is a hexadecimal integer, rather than a
+// hexadecimal float with an exponent.
+func isBitPattern(s string) bool {
+	s = strings.ToLower(s)
+	return strings.HasPrefix(s, "0x") && !strings.ContainsAny(s, ".p")
+}
+
+// formatResult formats a value returned by (*exec.VM).ExecCode.
+func formatResult(v interface{}) string {
+	switch v := v.(type) {
+	case uint32:
+		return strconv.FormatInt(int64(int32(v)), 10)
+	case uint64:
+		return strconv.FormatInt(int64(v), 10)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// resolver returns the resolver of the imports of a module: w resolves
+// WASI, g the imports of Go programs, the AssemblyScript runtime if as is
+// set or else the Emscripten runtime resolves env unless there is an
+// env.wasm file, and the other modules are read from the files named after
+// them.
+func resolver(out io.Writer, w *wasi.WASI, g *gojs.Runtime, as bool) wasm.ResolveFunc {
+	next := importer
+	if _, err := os.Stat(emscripten.ModuleName + ".wasm"); os.IsNotExist(err) {
+		if as {
+			env := assemblyscript.New(assemblyscript.Config{Stdout: out})
+			next = env.Resolver(importer)
+		} else {
+			env := emscripten.New(emscripten.Config{
+				Stdin:  os.Stdin,
+				Stdout: out,
+				Stderr: os.Stderr,
+			})
+			next = env.Resolver(importer)
+		}
 	}
+	return w.Resolver(g.Resolver(next))
 }
 
 func importer(name string) (*wasm.Module, error) {
@@ -7,7 +7,17 @@ package main
 import (
 	"bytes"
 	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
 	"testing"
+
+	"github.com/go-interpreter/wagon/assemblyscript"
+	"github.com/go-interpreter/wagon/emscripten"
+	"github.com/go-interpreter/wagon/wasi"
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
 )
 
 func TestRun(t *testing.T) {
@@ -28,7 +38,10 @@ func TestRun(t *testing.T) {
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			out := new(bytes.Buffer)
-			run(out, tc.name, tc.verify)
+			err := run(out, tc.name, options{verify: tc.verify})
+			if err != nil {
+				t.Fatal(err)
+			}
 
 			want, err := ioutil.ReadFile(tc.want)
 			if err != nil {
@@ -41,3 +54,295 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestInvoke(t *testing.T) {
+	for _, tc := range []struct {
+		invoke string
+		args   []string
+		want   string
+		err    bool
+	}{
+		{invoke: "iadd", args: []string{"2", "40"}, want: "42\n"},
+		{invoke: "iadd", args: []string{"-1", "-2"}, want: "-3\n"},
+		{invoke: "iadd", args: []string{"0xffffffff", "0x2"}, want: "1\n"},
+		{invoke: "iadd", args: []string{"1"}, err: true},
+		{invoke: "iadd", args: []string{"1", "one"}, err: true},
+		{invoke: "isub", args: []string{"1", "2"}, err: true},
+	} {
+		out := new(bytes.Buffer)
+		err := run(out, "../../exec/testdata/add-ex.wasm", options{
+			verify: true,
+			invoke: tc.invoke,
+			args:   tc.args,
+			aot:    true,
+			fuel:   100,
+		})
+		if (err != nil) != tc.err {
+			t.Errorf("%s%v: got error %v", tc.invoke, tc.args, err)
+			continue
+		}
+		if got := out.String(); got != tc.want {
+			t.Errorf("%s%v: got %q, want %q", tc.invoke, tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestParseArg(t *testing.T) {
+	for _, tc := range []struct {
+		arg  string
+		typ  wasm.ValueType
+		want uint64
+	}{
+		{"42", wasm.ValueTypeI32, 42},
+		{"-1", wasm.ValueTypeI32, 0xffffffff},
+		{"0x80000000", wasm.ValueTypeI32, 0x80000000},
+		{"-1", wasm.ValueTypeI64, math.MaxUint64},
+		{"0xffffffffffffffff", wasm.ValueTypeI64, math.MaxUint64},
+		{"1.5", wasm.ValueTypeF32, uint64(math.Float32bits(1.5))},
+		{"0x1.8p0", wasm.ValueTypeF32, uint64(math.Float32bits(1.5))},
+		{"0x7fc00001", wasm.ValueTypeF32, 0x7fc00001},
+		{"-inf", wasm.ValueTypeF64, math.Float64bits(math.Inf(-1))},
+		{"0x7ff0000000000001", wasm.ValueTypeF64, 0x7ff0000000000001},
+	} {
+		got, err := parseArg(tc.arg, tc.typ)
+		if err != nil {
+			t.Errorf("%s (%v): %v", tc.arg, tc.typ, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%s (%v): got %#x, want %#x", tc.arg, tc.typ, got, tc.want)
+		}
+	}
+
+	for _, arg := range []string{"0x100000000", "1.5"} {
+		if _, err := parseArg(arg, wasm.ValueTypeI32); err == nil {
+			t.Errorf("%s (i32): expected an error", arg)
+		}
+	}
+}
+
+// wasiModule returns the encoding of a module writing its first argument to
+// its standard output with fd_write, and exiting with status 3.
+func wasiModule(t *testing.T) []byte {
+	i32 := wasm.ValueTypeI32
+	m := wasm.NewModule()
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{
+		{Form: wasm.TypeFunc, ParamTypes: []wasm.ValueType{i32, i32}, ReturnTypes: []wasm.ValueType{i32}},
+		{Form: wasm.TypeFunc, ParamTypes: []wasm.ValueType{i32, i32, i32, i32}, ReturnTypes: []wasm.ValueType{i32}},
+		{Form: wasm.TypeFunc, ParamTypes: []wasm.ValueType{i32}},
+		{Form: wasm.TypeFunc},
+	}}
+	m.Import = &wasm.SectionImports{Entries: []wasm.ImportEntry{
+		{ModuleName: wasi.ModuleName, FieldName: "args_get", Type: wasm.FuncImport{Type: 0}},
+		{ModuleName: wasi.ModuleName, FieldName: "fd_write", Type: wasm.FuncImport{Type: 1}},
+		{ModuleName: wasi.ModuleName, FieldName: "proc_exit", Type: wasm.FuncImport{Type: 2}},
+	}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{3}}
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.Export = &wasm.SectionExports{
+		Entries: map[string]wasm.ExportEntry{
+			"_start": {FieldStr: "_start", Kind: wasm.ExternalFunction, Index: 3},
+		},
+		Names: []string{"_start"},
+	}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{{Code: []byte{
+		// Store argv at 0 and the arguments at 32, then write an iovec
+		// {argv[1], 5} at 16.
+		ops.I32Const, 0, ops.I32Const, 32, ops.Call, 0, ops.Drop,
+		ops.I32Const, 16, ops.I32Const, 4, ops.I32Load, 2, 0, ops.I32Store, 2, 0,
+		ops.I32Const, 20, ops.I32Const, 5, ops.I32Store, 2, 0,
+		ops.I32Const, 1, ops.I32Const, 16, ops.I32Const, 1, ops.I32Const, 24, ops.Call, 1, ops.Drop,
+		ops.I32Const, 3, ops.Call, 2,
+	}}}}
+	m.Sections = []wasm.Section{m.Types, m.Import, m.Function, m.Memory, m.Export, m.Code}
+
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, m); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRunWASI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wasm-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "hello.wasm")
+	if err := ioutil.WriteFile(fname, wasiModule(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := new(bytes.Buffer)
+	err = run(out, fname, options{args: []string{"hello"}, dirs: []string{"/tmp=" + dir}})
+	if err != wasi.ExitError(3) {
+		t.Errorf("got error %v, want %v", err, wasi.ExitError(3))
+	}
+	if got := out.String(); got != "hello" {
+		t.Errorf("got output %q, want %q", got, "hello")
+	}
+
+	err = run(out, fname, options{dirs: []string{filepath.Join(dir, "missing")}})
+	if err == nil {
+		t.Errorf("expected an error for a missing directory")
+	}
+}
+
+// emscriptenModule returns the encoding of a module whose main function
+// prints "hi" with emscripten_console_log.
+func emscriptenModule(t *testing.T) []byte {
+	m := wasm.NewModule()
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{
+		{Form: wasm.TypeFunc, ParamTypes: []wasm.ValueType{wasm.ValueTypeI32}},
+		{Form: wasm.TypeFunc},
+	}}
+	m.Import = &wasm.SectionImports{Entries: []wasm.ImportEntry{
+		{ModuleName: emscripten.ModuleName, FieldName: "emscripten_console_log", Type: wasm.FuncImport{Type: 0}},
+	}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{1}}
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.Export = &wasm.SectionExports{
+		Entries: map[string]wasm.ExportEntry{
+			"main": {FieldStr: "main", Kind: wasm.ExternalFunction, Index: 1},
+		},
+		Names: []string{"main"},
+	}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{{Code: []byte{
+		ops.I32Const, 0, ops.Call, 0,
+	}}}}
+	m.Data = &wasm.SectionData{Entries: []wasm.DataSegment{{
+		Offset: []byte{ops.I32Const, 0, ops.End},
+		Data:   []byte("hi\x00"),
+	}}}
+	m.Sections = []wasm.Section{m.Types, m.Import, m.Function, m.Memory, m.Export, m.Code, m.Data}
+
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, m); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRunEmscripten(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wasm-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "hello.wasm")
+	if err := ioutil.WriteFile(fname, emscriptenModule(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := new(bytes.Buffer)
+	if err := run(out, fname, options{invoke: "main"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "hi\n" {
+		t.Errorf("got output %q, want %q", got, "hi\n")
+	}
+}
+
+// assemblyScriptModule returns the encoding of a module whose main function
+// aborts with the message "boom" at main.ts:3:1.
+func assemblyScriptModule(t *testing.T) []byte {
+	i32 := wasm.ValueTypeI32
+	m := wasm.NewModule()
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{
+		{Form: wasm.TypeFunc, ParamTypes: []wasm.ValueType{i32, i32, i32, i32}},
+		{Form: wasm.TypeFunc},
+	}}
+	m.Import = &wasm.SectionImports{Entries: []wasm.ImportEntry{
+		{ModuleName: assemblyscript.ModuleName, FieldName: "abort", Type: wasm.FuncImport{Type: 0}},
+	}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{1}}
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.Export = &wasm.SectionExports{
+		Entries: map[string]wasm.ExportEntry{
+			"main": {FieldStr: "main", Kind: wasm.ExternalFunction, Index: 1},
+		},
+		Names: []string{"main"},
+	}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{{Code: []byte{
+		ops.I32Const, 4, ops.I32Const, 16, ops.I32Const, 3, ops.I32Const, 1, ops.Call, 0,
+	}}}}
+	// The UTF-16 strings "boom" and "main.ts", each after its size.
+	data := []byte("\x08\x00\x00\x00b\x00o\x00o\x00m\x00\x0e\x00\x00\x00m\x00a\x00i\x00n\x00.\x00t\x00s\x00")
+	m.Data = &wasm.SectionData{Entries: []wasm.DataSegment{{
+		Offset: []byte{ops.I32Const, 0, ops.End},
+		Data:   data,
+	}}}
+	m.Sections = []wasm.Section{m.Types, m.Import, m.Function, m.Memory, m.Export, m.Code, m.Data}
+
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, m); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRunAssemblyScript(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wasm-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "abort.wasm")
+	if err := ioutil.WriteFile(fname, assemblyScriptModule(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err = run(new(bytes.Buffer), fname, options{invoke: "main"})
+	want := "trap: assemblyscript: abort: boom at main.ts:3:1"
+	if err == nil || err.Error() != want {
+		t.Errorf("got error %v, want %q", err, want)
+	}
+
+	// Without -invoke, the traps of the exported functions are only logged.
+	if err := run(new(bytes.Buffer), fname, options{}); err != nil {
+		t.Errorf("got error %v, want none", err)
+	}
+}
+
+func TestRunGo(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping the build of a Go program in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go tool is not available")
+	}
+	dir, err := ioutil.TempDir("", "wasm-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fname := filepath.Join(dir, "hello.wasm")
+	cmd := exec.Command(goTool, "build", "-o", fname, "hello.go")
+	cmd.Dir = "../../gojs/testdata"
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("could not build a GOOS=js program: %v\n%s", err, output)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	out := new(bytes.Buffer)
+	err = run(out, fname, options{
+		args: []string{"a"},
+		env:  []string{"GREETING=x"},
+		dirs: []string{"/=" + dir},
+	})
+	if err != wasi.ExitError(3) {
+		t.Errorf("got error %v, want %v", err, wasi.ExitError(3))
+	}
+	want := "hello [a] x\ndata <nil>\nout.txt 4 false\n"
+	if got := out.String(); got != want {
+		t.Errorf("got output %q, want %q", got, want)
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "dir", "out.txt")); string(data) != "data" {
+		t.Errorf("got file content %q (%v), want %q", data, err, "data")
+	}
+}
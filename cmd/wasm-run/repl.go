@@ -0,0 +1,393 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+const replHelp = `commands:
+  exports                            list the exports of the module
+  call <name> [args...]              call an exported function
+  memory                             list the linear memories
+  dump <addr> [len] [mem]            hexdump len bytes of memory (default 64)
+  load <type> <addr> [mem]           read a value of type i32, i64, f32 or f64
+  store <type> <addr> <value> [mem]  write a value to memory
+  globals                            list the globals and their values
+  get <global>                       print a global, by index or export name
+  set <global> <value>               set a mutable global
+  reset                              instantiate the module again
+  help                               print this help
+  quit                               leave the session
+`
+
+var errQuit = errors.New("quit")
+
+// repl is an interactive session with an instance of a module.
+type repl struct {
+	w    io.Writer
+	m    *wasm.Module
+	vm   *exec.VM
+	opts options
+}
+
+// run reads commands from in until it is exhausted or quit is entered.
+func (r *repl) run(in io.Reader) error {
+	s := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(r.w, "> ")
+		if !s.Scan() {
+			fmt.Fprintln(r.w)
+			return s.Err()
+		}
+		args := strings.Fields(s.Text())
+		if len(args) == 0 {
+			continue
+		}
+		err := r.exec(args[0], args[1:])
+		if err == errQuit {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(r.w, "error: %v\n", err)
+		}
+	}
+}
+
+// exec runs a command of the session.
+func (r *repl) exec(cmd string, args []string) error {
+	nargs := func(min, max int) error {
+		if len(args) < min || len(args) > max {
+			return fmt.Errorf("invalid number of arguments to %s, see help", cmd)
+		}
+		return nil
+	}
+
+	switch cmd {
+	case "help":
+		fmt.Fprint(r.w, replHelp)
+	case "quit", "exit":
+		return errQuit
+	case "exports":
+		r.exports()
+	case "call":
+		if err := nargs(1, math.MaxInt32); err != nil {
+			return err
+		}
+		return invoke(r.w, r.vm, r.m, args[0], args[1:], r.opts.timeout)
+	case "memory":
+		return r.memories()
+	case "dump":
+		if err := nargs(1, 3); err != nil {
+			return err
+		}
+		return r.dump(args)
+	case "load":
+		if err := nargs(2, 3); err != nil {
+			return err
+		}
+		return r.load(args)
+	case "store":
+		if err := nargs(3, 4); err != nil {
+			return err
+		}
+		return r.store(args)
+	case "globals":
+		return r.globals()
+	case "get":
+		if err := nargs(1, 1); err != nil {
+			return err
+		}
+		index, err := r.global(args[0])
+		if err != nil {
+			return err
+		}
+		v, err := r.vm.GlobalValue(index)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(r.w, v)
+	case "set":
+		if err := nargs(2, 2); err != nil {
+			return err
+		}
+		return r.set(args[0], args[1])
+	case "reset":
+		vm, err := newVM(r.m, r.opts)
+		if err != nil {
+			return err
+		}
+		r.vm.Close()
+		r.vm = vm
+	default:
+		return fmt.Errorf("unknown command %q, see help", cmd)
+	}
+	return nil
+}
+
+// exportNames returns the names of the exports, in module order.
+func (r *repl) exportNames() []string {
+	if len(r.m.Export.Names) == len(r.m.Export.Entries) {
+		return r.m.Export.Names
+	}
+	names := make([]string, 0, len(r.m.Export.Entries))
+	for name := range r.m.Export.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *repl) exports() {
+	for _, name := range r.exportNames() {
+		e := r.m.Export.Entries[name]
+		switch e.Kind {
+		case wasm.ExternalFunction:
+			fmt.Fprintf(r.w, "func %s %s\n", name, signature(r.m.GetFunction(int(e.Index)).Sig))
+		case wasm.ExternalGlobal:
+			fmt.Fprintf(r.w, "global %s %s\n", name, globalType(r.m.GetGlobal(int(e.Index)).Type))
+		default:
+			fmt.Fprintf(r.w, "%s %s\n", e.Kind, name)
+		}
+	}
+}
+
+// signature formats sig as "(i32, i32) -> i32".
+func signature(sig *wasm.FunctionSig) string {
+	types := func(list []wasm.ValueType) string {
+		s := make([]string, len(list))
+		for i, t := range list {
+			s[i] = t.String()
+		}
+		return strings.Join(s, ", ")
+	}
+	str := "(" + types(sig.ParamTypes) + ")"
+	switch len(sig.ReturnTypes) {
+	case 0:
+	case 1:
+		str += " -> " + types(sig.ReturnTypes)
+	default:
+		str += " -> (" + types(sig.ReturnTypes) + ")"
+	}
+	return str
+}
+
+func globalType(t wasm.GlobalVar) string {
+	if t.Mutable {
+		return "mut " + t.Type.String()
+	}
+	return t.Type.String()
+}
+
+// memory returns the linear memory whose index is the optional argument
+// args[i].
+func (r *repl) memory(args []string, i int) (*exec.Memory, error) {
+	var index uint64
+	if len(args) > i {
+		var err error
+		index, err = strconv.ParseUint(args[i], 0, 32)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return exec.NewProcess(r.vm).Memory(uint32(index))
+}
+
+func (r *repl) memories() error {
+	for i := range r.m.LinearMemoryIndexSpace {
+		mem, err := exec.NewProcess(r.vm).Memory(uint32(i))
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(r.w, "memory %d: %d pages (%d bytes)\n", i, mem.Size()/65536, mem.Size())
+	}
+	return nil
+}
+
+func (r *repl) dump(args []string) error {
+	addr, err := strconv.ParseUint(args[0], 0, 32)
+	if err != nil {
+		return err
+	}
+	n := uint64(64)
+	if len(args) > 1 {
+		n, err = strconv.ParseUint(args[1], 0, 32)
+		if err != nil {
+			return err
+		}
+	}
+	mem, err := r.memory(args, 2)
+	if err != nil {
+		return err
+	}
+	p, err := mem.MemoryView(uint32(addr), uint32(n))
+	if err != nil {
+		return err
+	}
+	hexDump(r.w, p, addr)
+	return nil
+}
+
+// hexDump writes p in the format of hexdump -C, with addresses starting at
+// addr.
+func hexDump(w io.Writer, p []byte, addr uint64) {
+	for len(p) > 0 {
+		line := p
+		if len(line) > 16 {
+			line = line[:16]
+		}
+		p = p[len(line):]
+
+		var hex, chars strings.Builder
+		for i := 0; i < 16; i++ {
+			if i == 8 {
+				hex.WriteByte(' ')
+			}
+			if i >= len(line) {
+				hex.WriteString("   ")
+				continue
+			}
+			fmt.Fprintf(&hex, "%02x ", line[i])
+			if c := line[i]; c >= 32 && c <= 126 {
+				chars.WriteByte(c)
+			} else {
+				chars.WriteByte('.')
+			}
+		}
+		fmt.Fprintf(w, "%08x  %s |%s|\n", addr, hex.String(), chars.String())
+		addr += uint64(len(line))
+	}
+}
+
+// valueType parses the name of a value type.
+func valueType(s string) (wasm.ValueType, error) {
+	for _, t := range []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI64, wasm.ValueTypeF32, wasm.ValueTypeF64} {
+		if t.String() == s {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid value type %q", s)
+}
+
+func (r *repl) load(args []string) error {
+	typ, err := valueType(args[0])
+	if err != nil {
+		return err
+	}
+	addr, err := strconv.ParseUint(args[1], 0, 32)
+	if err != nil {
+		return err
+	}
+	mem, err := r.memory(args, 2)
+	if err != nil {
+		return err
+	}
+	var v interface{}
+	switch typ {
+	case wasm.ValueTypeI32:
+		v, err = mem.ReadUint32Le(uint32(addr))
+	case wasm.ValueTypeI64:
+		v, err = mem.ReadUint64Le(uint32(addr))
+	case wasm.ValueTypeF32:
+		v, err = mem.ReadFloat32(uint32(addr))
+	case wasm.ValueTypeF64:
+		v, err = mem.ReadFloat64(uint32(addr))
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(r.w, formatResult(v))
+	return nil
+}
+
+func (r *repl) store(args []string) error {
+	typ, err := valueType(args[0])
+	if err != nil {
+		return err
+	}
+	addr, err := strconv.ParseUint(args[1], 0, 32)
+	if err != nil {
+		return err
+	}
+	v, err := parseArg(args[2], typ)
+	if err != nil {
+		return err
+	}
+	mem, err := r.memory(args, 3)
+	if err != nil {
+		return err
+	}
+	switch typ {
+	case wasm.ValueTypeI32, wasm.ValueTypeF32:
+		return mem.WriteUint32Le(uint32(addr), uint32(v))
+	default:
+		return mem.WriteUint64Le(uint32(addr), v)
+	}
+}
+
+func (r *repl) globals() error {
+	for _, g := range r.vm.Globals() {
+		v, err := r.vm.GlobalValue(g.Index)
+		if err != nil {
+			return err
+		}
+		name := g.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(r.w, "global %d %s %s = %v\n", g.Index, name, globalType(g.Type), v)
+	}
+	return nil
+}
+
+// global returns the index of the global s, either an index or the name
+// it is exported as.
+func (r *repl) global(s string) (uint32, error) {
+	if index, err := strconv.ParseUint(s, 0, 32); err == nil {
+		return uint32(index), nil
+	}
+	e, ok := r.m.Export.Entries[s]
+	if !ok || e.Kind != wasm.ExternalGlobal {
+		return 0, fmt.Errorf("module has no exported global %q", s)
+	}
+	return e.Index, nil
+}
+
+func (r *repl) set(name, value string) error {
+	index, err := r.global(name)
+	if err != nil {
+		return err
+	}
+	g := r.m.GetGlobal(int(index))
+	if g == nil {
+		return wasm.InvalidGlobalIndexError(index)
+	}
+	raw, err := parseArg(value, g.Type.Type)
+	if err != nil {
+		return err
+	}
+	var v interface{}
+	switch g.Type.Type {
+	case wasm.ValueTypeI32:
+		v = int32(uint32(raw))
+	case wasm.ValueTypeI64:
+		v = int64(raw)
+	case wasm.ValueTypeF32:
+		v = math.Float32frombits(uint32(raw))
+	case wasm.ValueTypeF64:
+		v = math.Float64frombits(raw)
+	}
+	return r.vm.SetGlobalValue(index, v)
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 The go-interpreter Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+// replModule returns a module with a memory holding "hello" at address 16,
+// a mutable global exported as counter, and a function inc adding its
+// argument to the counter.
+func replModule() *wasm.Module {
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{{
+		Form:        0x60,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0}}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{{
+		Module: m,
+		Code: []byte{
+			ops.GetGlobal, 0, ops.GetLocal, 0, ops.I32Add, ops.SetGlobal, 0,
+			ops.GetGlobal, 0,
+		},
+	}}}
+	m.FunctionIndexSpace = []wasm.Function{{Sig: &m.Types.Entries[0], Body: &m.Code.Bodies[0]}}
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.LinearMemoryIndexSpace = [][]byte{append(make([]byte, 16), "hello"...)}
+	m.GlobalIndexSpace = []wasm.GlobalEntry{{
+		Type: wasm.GlobalVar{Type: wasm.ValueTypeI32, Mutable: true},
+		Init: []byte{ops.I32Const, 7, ops.End},
+	}}
+	m.Export = &wasm.SectionExports{
+		Entries: map[string]wasm.ExportEntry{
+			"inc":     {FieldStr: "inc", Kind: wasm.ExternalFunction, Index: 0},
+			"memory":  {FieldStr: "memory", Kind: wasm.ExternalMemory, Index: 0},
+			"counter": {FieldStr: "counter", Kind: wasm.ExternalGlobal, Index: 0},
+		},
+		Names: []string{"inc", "memory", "counter"},
+	}
+	return m
+}
+
+func TestREPL(t *testing.T) {
+	m := replModule()
+	vm, err := newVM(m, options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &repl{w: new(bytes.Buffer), m: m, vm: vm}
+
+	script := `exports
+call inc 5
+call inc
+get counter
+set counter 0x10
+globals
+store i32 0 0xdeadbeef
+load i32 0
+load f32 0 0
+load i64 65535
+dump 0 21
+memory
+reset
+get 0
+bogus
+quit
+call inc 1
+`
+	err = r.run(strings.NewReader(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `> func inc (i32) -> i32
+memory memory
+global counter mut i32
+> 12
+> error: inc expects 1 arguments, got 0
+> 12
+> > global 0 counter mut i32 = 16
+> > -559038737
+> -6.2598534e+18
+> error: exec: out of bounds memory access
+> 00000000  ef be ad de 00 00 00 00  00 00 00 00 00 00 00 00  |................|
+00000010  68 65 6c 6c 6f                                    |hello|
+> memory 0: 1 pages (65536 bytes)
+> > 7
+> error: unknown command "bogus", see help
+> `
+	if got := r.w.(*bytes.Buffer).String(); got != want {
+		t.Fatalf("invalid output.\ngot:\n%s\nwant:\n%s\n", got, want)
+	}
+}
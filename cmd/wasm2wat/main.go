@@ -0,0 +1,86 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/go-interpreter/wagon/wast"
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `wasm2wat converts a module from the binary format to the text format.
+
+Usage: wasm2wat [options] file.wasm
+
+The module is written to the standard output, unless -o is given.
+
+Options:
+`)
+		flag.PrintDefaults()
+	}
+}
+
+// options controls how convert writes a module.
+type options struct {
+	names       bool // Use the names of the name section
+	fold        bool // Write the instructions in the folded form
+	annotations bool // Write the custom sections as annotations
+}
+
+func main() {
+	log.SetPrefix("wasm2wat: ")
+	log.SetFlags(0)
+
+	out := flag.String("o", "", "write the module to `file` instead of the standard output")
+	names := flag.Bool("names", true, "use the name section, and label blocks; -names=false ignores it")
+	fold := flag.Bool("fold", false, "write the instructions as folded expressions")
+	annotations := flag.Bool("annotations", false, "write the custom sections as @custom annotations")
+
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	buf := new(bytes.Buffer)
+	err := convert(buf, flag.Arg(0), options{names: *names, fold: *fold, annotations: *annotations})
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *out == "" || *out == "-" {
+		_, err = buf.WriteTo(os.Stdout)
+	} else {
+		err = ioutil.WriteFile(*out, buf.Bytes(), 0644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// convert writes the text format of the module of the binary file fname.
+func convert(w io.Writer, fname string, opts options) error {
+	raw, err := ioutil.ReadFile(fname)
+	if err != nil {
+		return err
+	}
+	m, err := wasm.DecodeModule(bytes.NewReader(raw))
+	if err != nil {
+		return fmt.Errorf("could not read module: %v", err)
+	}
+	if !opts.names {
+		m.StripNames()
+	}
+	return wast.WriteTo(w, m, wast.FoldExprs(opts.fold), wast.Names(opts.names), wast.Annotations(opts.annotations))
+}
@@ -0,0 +1,56 @@
+// Copyright 2026 The go-interpreter Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts options
+		want string
+	}{
+		{
+			name: "../../wasm/testdata/f64.wasm",
+			opts: options{names: true},
+			want: "../../wasm/testdata/f64.wat",
+		},
+		{
+			name: "testdata/names.wasm",
+			opts: options{names: true},
+			want: "testdata/names.wat",
+		},
+		{
+			name: "testdata/names.wasm",
+			opts: options{fold: true},
+			want: "testdata/names.fold.wat",
+		},
+		{
+			name: "testdata/names.wasm",
+			opts: options{names: true, annotations: true},
+			want: "testdata/names.annotations.wat",
+		},
+	} {
+		t.Run(tc.want, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			if err := convert(out, tc.name, tc.opts); err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := ioutil.ReadFile(tc.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := out.Bytes(), want; !bytes.Equal(got, want) {
+				t.Fatalf("invalid output.\ngot:\n%s\nwant:\n%s\n", string(got), string(want))
+			}
+		})
+	}
+}
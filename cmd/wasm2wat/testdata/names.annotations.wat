@@ -0,0 +1,25 @@
+(module
+  (type (;0;) (func (param i32)))
+  (type (;1;) (func (param i32) (result i32)))
+  (type (;2;) (func))
+  (import "env" "print" (func $print (type 0)))
+  (func $fac (type 1) (param $n i32) (result i32)
+    get_local $n
+    i32.eqz
+    if $I1 (result i32)
+      i32.const 1
+    else
+      get_local $n
+      get_local $n
+      i32.const 1
+      i32.sub
+      call $fac
+      i32.mul
+    end)
+  (func $main (type 2)
+    i32.const 5
+    call $fac
+    call $print)
+  (export "fac" (func $fac))
+  (export "main" (func $main))
+  (@custom "name" (after code) "\00\06\05names\01\13\03\00\05print\01\03fac\02\04main\02\06\01\01\01\00\01n"))
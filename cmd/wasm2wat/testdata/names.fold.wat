@@ -0,0 +1,24 @@
+(module
+  (type (;0;) (func (param i32)))
+  (type (;1;) (func (param i32) (result i32)))
+  (type (;2;) (func))
+  (import "env" "print" (func $env.print (type 0)))
+  (func (;1;) (type 1) (param i32) (result i32)
+    (if (result i32)  ;; label = @1
+      (i32.eqz
+        (get_local 0))
+      (then
+        (i32.const 1))
+      (else
+        (i32.mul
+          (get_local 0)
+          (call 1
+            (i32.sub
+              (get_local 0)
+              (i32.const 1)))))))
+  (func (;2;) (type 2)
+    (call $env.print
+      (call 1
+        (i32.const 5))))
+  (export "fac" (func 1))
+  (export "main" (func 2)))
@@ -0,0 +1,169 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-interpreter/wagon/validate"
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+	"github.com/go-interpreter/wagon/wast"
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, `wat2wasm converts a module from the text format to the binary format.
+
+Usage: wat2wasm [options] file.wat
+
+The instructions of the module may be written in the flat or the folded
+form. The module is validated, its imports being assumed to have the types
+it expects, and written to file.wasm, unless -o is given.
+With -fold, the module is instead written back in the text format, with its
+instructions folded, to the standard output unless -o is given.
+
+Options:
+`)
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	log.SetPrefix("wat2wasm: ")
+	log.SetFlags(0)
+
+	out := flag.String("o", "", "write the module to `file`, - for the standard output")
+	names := flag.Bool("names", true, "keep the name section; -names=false strips it")
+	fold := flag.Bool("fold", false, "write the module in the text format, with folded instructions")
+
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	fname := flag.Arg(0)
+	switch {
+	case *out != "":
+	case *fold:
+		*out = "-"
+	default:
+		*out = strings.TrimSuffix(fname, filepath.Ext(fname)) + ".wasm"
+	}
+	buf := new(bytes.Buffer)
+	if err := convert(buf, fname, options{names: *names, fold: *fold}); err != nil {
+		log.Fatal(err)
+	}
+	var err error
+	if *out == "-" {
+		_, err = buf.WriteTo(os.Stdout)
+	} else {
+		err = ioutil.WriteFile(*out, buf.Bytes(), 0644)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// options controls how convert writes a module.
+type options struct {
+	names bool // Keep the name section
+	fold  bool // Write the text format, with folded instructions
+}
+
+// convert writes the binary encoding of the module of the text file fname,
+// once validated, or its folded text if opts.fold is set.
+func convert(w io.Writer, fname string, opts options) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	m, err := wast.ParseModule(f)
+	if err != nil {
+		return err
+	}
+	if !opts.names {
+		m.StripNames()
+	}
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, m); err != nil {
+		return err
+	}
+	if err := verify(buf.Bytes(), m); err != nil {
+		return err
+	}
+	if opts.fold {
+		return wast.WriteTo(w, m, wast.FoldExprs(true))
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// verify validates the module m, whose binary encoding is raw.
+func verify(raw []byte, m *wasm.Module) error {
+	checked, err := wasm.ReadModule(bytes.NewReader(raw), stubResolver(m))
+	if err != nil {
+		return err
+	}
+	return validate.VerifyModule(checked)
+}
+
+// stubResolver returns a resolver of the imports of m to modules exporting
+// entities of the types m expects, so that m may be validated on its own.
+func stubResolver(m *wasm.Module) wasm.ResolveFunc {
+	return func(name string) (*wasm.Module, error) {
+		stub := wasm.NewModule()
+		stub.Types = m.Types
+		stub.Export.Entries = make(map[string]wasm.ExportEntry)
+		for _, e := range m.Import.Entries {
+			if e.ModuleName != name {
+				continue
+			}
+			export := wasm.ExportEntry{FieldStr: e.FieldName, Kind: e.Type.Kind()}
+			switch t := e.Type.(type) {
+			case wasm.FuncImport:
+				if int(t.Type) >= len(m.Types.Entries) {
+					return nil, wasm.InvalidImportError{ModuleName: e.ModuleName, FieldName: e.FieldName, TypeIndex: t.Type}
+				}
+				export.Index = uint32(len(stub.FunctionIndexSpace))
+				// The bodies of imported functions are validated along with
+				// the module, and unreachable is valid for any signature.
+				stub.FunctionIndexSpace = append(stub.FunctionIndexSpace, wasm.Function{
+					Sig:  &m.Types.Entries[t.Type],
+					Body: &wasm.FunctionBody{Code: []byte{ops.Unreachable}},
+				})
+			case wasm.GlobalVarImport:
+				export.Index = uint32(len(stub.GlobalIndexSpace))
+				stub.GlobalIndexSpace = append(stub.GlobalIndexSpace, wasm.GlobalEntry{
+					Type: t.Type,
+					Cell: &wasm.Global{Type: t.Type},
+				})
+			case wasm.TableImport:
+				export.Index = uint32(len(stub.TableIndexSpace))
+				stub.TableIndexSpace = append(stub.TableIndexSpace, nil)
+			case wasm.MemoryImport:
+				export.Index = uint32(len(stub.LinearMemoryIndexSpace))
+				stub.LinearMemoryIndexSpace = append(stub.LinearMemoryIndexSpace, nil)
+			case wasm.TagImport:
+				export.Index = uint32(len(stub.TagIndexSpace))
+				stub.TagIndexSpace = append(stub.TagIndexSpace, t.Type)
+			}
+			stub.Export.Entries[e.FieldName] = export
+		}
+		return stub, nil
+	}
+}
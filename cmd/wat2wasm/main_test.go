@@ -0,0 +1,113 @@
+// Copyright 2026 The go-interpreter Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-interpreter/wagon/validate"
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+func TestConvert(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		names bool
+		fold  bool
+		want  string
+	}{
+		{
+			name: "../../wasm/testdata/f64.wat",
+			want: "../../wasm/testdata/f64.wasm",
+		},
+		{
+			name:  "testdata/names.wat",
+			names: true,
+			want:  "../wasm2wat/testdata/names.wasm",
+		},
+		{
+			name:  "testdata/names.wat",
+			names: true,
+			fold:  true,
+			want:  "testdata/names.fold.wat",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			if err := convert(out, tc.name, options{names: tc.names, fold: tc.fold}); err != nil {
+				t.Fatal(err)
+			}
+
+			want, err := ioutil.ReadFile(tc.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, want := out.Bytes(), want; !bytes.Equal(got, want) {
+				t.Fatalf("invalid output.\ngot:  %x\nwant: %x\n", got, want)
+			}
+		})
+	}
+}
+
+func TestConvertNames(t *testing.T) {
+	for _, names := range []bool{false, true} {
+		out := new(bytes.Buffer)
+		if err := convert(out, "testdata/names.wat", options{names: names}); err != nil {
+			t.Fatal(err)
+		}
+		m, err := wasm.DecodeModule(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := m.Custom(wasm.CustomSectionName) != nil; got != names {
+			t.Errorf("names=%v: got a name section: %v", names, got)
+		}
+	}
+}
+
+func TestConvertVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "wat2wasm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct {
+		text  string
+		valid bool
+	}{
+		{text: `(module (func (br 3)))`},
+		{text: `(module (func (result i32) (i64.const 1)))`},
+		{
+			text: `(module
+				(import "env" "f" (func $f (param i64) (result i64)))
+				(import "env" "g" (global $g i64))
+				(import "env" "mem" (memory 1))
+				(import "env" "table" (table 1 funcref))
+				(func (result i64) (call $f (global.get $g))))`,
+			valid: true,
+		},
+	} {
+		fname := filepath.Join(dir, "module.wat")
+		if err := ioutil.WriteFile(fname, []byte(tc.text), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := convert(new(bytes.Buffer), fname, options{names: true})
+		if tc.valid {
+			if err != nil {
+				t.Errorf("%s: got error %v", tc.text, err)
+			}
+			continue
+		}
+		if _, ok := err.(validate.Error); !ok {
+			t.Errorf("%s: got error %v, want a validation error", tc.text, err)
+		}
+	}
+}
@@ -18,11 +18,16 @@ import (
 func Assemble(instr []Instr) ([]byte, error) {
 	body := new(bytes.Buffer)
 	for _, ins := range instr {
-		body.WriteByte(ins.Op.Code)
+		if ins.Op.Prefix != 0 {
+			body.WriteByte(ins.Op.Prefix)
+			leb128.WriteVarUint32(body, ins.Op.PrefixedCode)
+		} else {
+			body.WriteByte(ins.Op.Code)
+		}
 		switch op := ins.Op.Code; op {
-		case ops.Block, ops.Loop, ops.If:
+		case ops.Block, ops.Loop, ops.If, ops.Try:
 			body.WriteByte(byte(ins.Immediates[0].(wasm.BlockType)))
-		case ops.Br, ops.BrIf:
+		case ops.Br, ops.BrIf, ops.Rethrow, ops.Delegate, ops.Catch, ops.Throw:
 			leb128.WriteVarUint32(body, ins.Immediates[0].(uint32))
 		case ops.BrTable:
 			cnt := ins.Immediates[0].(uint32)
@@ -31,9 +36,9 @@ func Assemble(instr []Instr) ([]byte, error) {
 				leb128.WriteVarUint32(body, ins.Immediates[i+1].(uint32))
 			}
 			leb128.WriteVarUint32(body, ins.Immediates[1+cnt].(uint32))
-		case ops.Call, ops.CallIndirect:
+		case ops.Call, ops.CallIndirect, ops.ReturnCall, ops.ReturnCallIndirect:
 			leb128.WriteVarUint32(body, ins.Immediates[0].(uint32))
-			if op == ops.CallIndirect {
+			if op == ops.CallIndirect || op == ops.ReturnCallIndirect {
 				leb128.WriteVarUint32(body, ins.Immediates[1].(uint32))
 			}
 		case ops.GetLocal, ops.SetLocal, ops.TeeLocal, ops.GetGlobal, ops.SetGlobal:
@@ -53,10 +58,24 @@ func Assemble(instr []Instr) ([]byte, error) {
 			binary.LittleEndian.PutUint64(b[:], math.Float64bits(f))
 			body.Write(b[:])
 		case ops.I32Load, ops.I64Load, ops.F32Load, ops.F64Load, ops.I32Load8s, ops.I32Load8u, ops.I32Load16s, ops.I32Load16u, ops.I64Load8s, ops.I64Load8u, ops.I64Load16s, ops.I64Load16u, ops.I64Load32s, ops.I64Load32u, ops.I32Store, ops.I64Store, ops.F32Store, ops.F64Store, ops.I32Store8, ops.I32Store16, ops.I64Store8, ops.I64Store16, ops.I64Store32:
+			align := ins.Immediates[0].(uint32)
+			if len(ins.Immediates) > 2 {
+				leb128.WriteVarUint32(body, align|memIndexFlag)
+				leb128.WriteVarUint32(body, ins.Immediates[2].(uint32))
+			} else {
+				leb128.WriteVarUint32(body, align)
+			}
+			switch offset := ins.Immediates[1].(type) {
+			case uint32:
+				leb128.WriteVarUint32(body, offset)
+			case uint64:
+				leb128.WriteVarUint64(body, offset)
+			}
+		case ops.CurrentMemory, ops.GrowMemory, ops.MemoryFill:
+			leb128.WriteVarUint32(body, ins.Immediates[0].(uint32))
+		case ops.MemoryCopy:
 			leb128.WriteVarUint32(body, ins.Immediates[0].(uint32))
 			leb128.WriteVarUint32(body, ins.Immediates[1].(uint32))
-		case ops.CurrentMemory, ops.GrowMemory:
-			leb128.WriteVarUint32(body, uint32(ins.Immediates[0].(uint8)))
 		}
 	}
 	return body.Bytes(), nil
@@ -8,10 +8,12 @@ import (
 	"bytes"
 	"io/ioutil"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/go-interpreter/wagon/disasm"
 	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
 )
 
 var testPaths = []string{
@@ -59,3 +61,36 @@ func TestAssemble(t *testing.T) {
 		}
 	}
 }
+
+func TestAssemblePrefixed(t *testing.T) {
+	code := []byte{
+		ops.F32Const, 0, 0, 0, 0,
+		ops.PrefixMisc, 4, // i64.trunc_s:sat/f32
+		ops.I64Extend32S,
+		ops.Drop,
+		ops.I32Const, 0, ops.I32Const, 1, ops.I32Const, 2,
+		ops.PrefixMisc, 10, 0, 1, // memory.copy 0 1
+		ops.I32Const, 0, ops.I32Const, 1, ops.I32Const, 2,
+		ops.PrefixMisc, 11, 0, // memory.fill 0
+	}
+	d, err := disasm.Disassemble(code)
+	if err != nil {
+		t.Fatalf("disassemble failed: %v", err)
+	}
+	var names []string
+	for _, instr := range d {
+		if instr.Op.Prefix != 0 {
+			names = append(names, instr.Op.Name)
+		}
+	}
+	if got, want := strings.Join(names, " "), "i64.trunc_s:sat/f32 memory.copy memory.fill"; got != want {
+		t.Errorf("got prefixed operators %q, want %q", got, want)
+	}
+	got, err := disasm.Assemble(d)
+	if err != nil {
+		t.Fatalf("assemble failed: %v", err)
+	}
+	if !bytes.Equal(got, code) {
+		t.Fatalf("got code %x, want %x", got, code)
+	}
+}
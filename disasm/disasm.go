@@ -33,7 +33,8 @@ type Instr struct {
 	Unreachable bool       // whether the operator can be reached during execution
 	// IsReturn is true if executing this instruction will result in the
 	// function returning. This is true for branches (br, br_if) to
-	// the depth <max_relative_depth> + 1, or the return operator itself.
+	// the depth <max_relative_depth> + 1, the return operator itself, and
+	// tail calls (return_call, return_call_indirect).
 	// If true, NewStack for this instruction is nil.
 	IsReturn bool
 	// If the operator is br_table (ops.BrTable), this is a list of StackInfo
@@ -86,6 +87,22 @@ func isInstrReachable(indexStack [][]int) bool {
 
 var ErrStackUnderflow = errors.New("disasm: stack underflow")
 
+// ErrInvalidMemoryOffset is returned when the offset of a memory access
+// doesn't fit in 32 bits for a 32-bit linear memory.
+var ErrInvalidMemoryOffset = errors.New("disasm: memory offset out of range")
+
+// memIndexFlag is set in the alignment of a memory_immediate when it is
+// followed by a memory index. Otherwise, the first memory is accessed.
+const memIndexFlag = 0x40
+
+func isMemoryAccess(op byte) bool {
+	switch op {
+	case ops.I32Load, ops.I64Load, ops.F32Load, ops.F64Load, ops.I32Load8s, ops.I32Load8u, ops.I32Load16s, ops.I32Load16u, ops.I64Load8s, ops.I64Load8u, ops.I64Load16s, ops.I64Load16u, ops.I64Load32s, ops.I64Load32u, ops.I32Store, ops.I64Store, ops.F32Store, ops.F64Store, ops.I32Store8, ops.I32Store16, ops.I64Store8, ops.I64Store16, ops.I64Store32:
+		return true
+	}
+	return false
+}
+
 // NewDisassembly disassembles the given function. It also takes the function's
 // parent module as an argument for locating any other functions referenced by
 // fn.
@@ -108,11 +125,48 @@ func NewDisassembly(fn wasm.Function, module *wasm.Module) (*Disassembly, error)
 	blockIndices := &stack.Stack{} // a stack of indices to operators which start new blocks
 	curIndex := 0
 
+	multiMemory := len(module.LinearMemoryIndexSpace) > 1
 	for _, instr := range instrs {
+		if isMemoryAccess(instr.Op.Code) {
+			var memIndex uint32
+			if len(instr.Immediates) > 2 {
+				memIndex = instr.Immediates[2].(uint32)
+			}
+			mem := module.GetMemory(int(memIndex))
+			if mem == nil && memIndex != 0 {
+				return nil, wasm.InvalidLinearMemoryIndexError(memIndex)
+			}
+			memory64 := mem != nil && mem.Limits.Is64()
+
+			// Offsets of accesses to 64-bit memories are always uint64
+			// values, and must fit in a uint32 otherwise.
+			switch offset := instr.Immediates[1].(type) {
+			case uint32:
+				if memory64 {
+					instr.Immediates[1] = uint64(offset)
+				}
+			case uint64:
+				if !memory64 {
+					return nil, ErrInvalidMemoryOffset
+				}
+			}
+
+			// The memory index is only kept for modules with more than
+			// one memory, where it is always present.
+			instr.Immediates = instr.Immediates[:2]
+			if multiMemory {
+				instr.Immediates = append(instr.Immediates, memIndex)
+			}
+		}
+
 		logger.Printf("stack top is %d", stackDepths.Top())
 		opStr := instr.Op
 		op := opStr.Code
-		if op == ops.End || op == ops.Else {
+		// catch and catch_all start a new sequence in a try block the same
+		// way else does in an if block, while delegate ends a try block.
+		isBlockEnd := op == ops.End || op == ops.Delegate
+		isElse := op == ops.Else || op == ops.Catch || op == ops.CatchAll
+		if isBlockEnd || isElse {
 			// There are two possible cases here:
 			// 1. The corresponding block/if/loop instruction
 			// *is* reachable, and an instruction somewhere in this
@@ -130,12 +184,12 @@ func NewDisassembly(fn wasm.Function, module *wasm.Module) (*Disassembly, error)
 
 		var blockStartIndex uint64
 		switch op {
-		case ops.End, ops.Else:
+		case ops.End, ops.Else, ops.Catch, ops.CatchAll, ops.Delegate:
 			blockStartIndex = blockIndices.Pop()
-			if op == ops.Else {
+			if isElse {
 				blockIndices.Push(uint64(curIndex))
 			}
-		case ops.Block, ops.Loop, ops.If:
+		case ops.Block, ops.Loop, ops.If, ops.Try:
 			blockIndices.Push(uint64(curIndex))
 		}
 
@@ -159,7 +213,14 @@ func NewDisassembly(fn wasm.Function, module *wasm.Module) (*Disassembly, error)
 		}
 
 		switch op {
-		case ops.Unreachable:
+		case ops.Unreachable, ops.Rethrow:
+			pushPolymorphicOp(blockPolymorphicOps, curIndex)
+		case ops.Throw:
+			sig, err := module.GetTagSig(instr.Immediates[0].(uint32))
+			if err != nil {
+				return nil, err
+			}
+			stackDepths.SetTop(stackDepths.Top() - uint64(len(sig.ParamTypes)))
 			pushPolymorphicOp(blockPolymorphicOps, curIndex)
 		case ops.Drop:
 			stackDepths.SetTop(stackDepths.Top() - 1)
@@ -168,16 +229,16 @@ func NewDisassembly(fn wasm.Function, module *wasm.Module) (*Disassembly, error)
 		case ops.Return:
 			stackDepths.SetTop(stackDepths.Top() - uint64(len(fn.Sig.ReturnTypes)))
 			pushPolymorphicOp(blockPolymorphicOps, curIndex)
-		case ops.End, ops.Else:
+		case ops.End, ops.Else, ops.Catch, ops.CatchAll, ops.Delegate:
 			blockSig := disas.Code[blockStartIndex].Block.Signature
 			instr.Block = &BlockInfo{
 				Start:     false,
 				Signature: blockSig,
 			}
-			if op == ops.End {
+			if isBlockEnd {
 				instr.Block.BlockStartIndex = int(blockStartIndex)
 				disas.Code[blockStartIndex].Block.EndIndex = curIndex
-			} else { // ops.Else
+			} else { // ops.Else, ops.Catch, ops.CatchAll
 				instr.Block.ElseIfIndex = int(blockStartIndex)
 				disas.Code[blockStartIndex].Block.IfElseIndex = int(curIndex)
 			}
@@ -191,7 +252,7 @@ func NewDisassembly(fn wasm.Function, module *wasm.Module) (*Disassembly, error)
 			prevDepthIndex := stackDepths.Len() - 2
 			prevDepth := stackDepths.Get(prevDepthIndex)
 
-			if op != ops.Else && blockSig != wasm.BlockTypeEmpty {
+			if isBlockEnd && blockSig != wasm.BlockTypeEmpty {
 				stackDepths.Set(prevDepthIndex, prevDepth+1)
 				disas.checkMaxDepth(int(stackDepths.Get(prevDepthIndex)))
 			}
@@ -200,11 +261,22 @@ func NewDisassembly(fn wasm.Function, module *wasm.Module) (*Disassembly, error)
 			blockPolymorphicOps = blockPolymorphicOps[:len(blockPolymorphicOps)-1]
 
 			stackDepths.Pop()
-			if op == ops.Else {
+			if isElse {
 				stackDepths.Push(stackDepths.Top())
 				blockPolymorphicOps = append(blockPolymorphicOps, []int{})
 			}
-		case ops.Block, ops.Loop, ops.If:
+			if op == ops.Catch {
+				// the values carried by the exception are pushed
+				// on entering the catch block.
+				sig, err := module.GetTagSig(instr.Immediates[0].(uint32))
+				if err != nil {
+					return nil, err
+				}
+				top := int(stackDepths.Top()) + len(sig.ParamTypes)
+				stackDepths.SetTop(uint64(top))
+				disas.checkMaxDepth(top)
+			}
+		case ops.Block, ops.Loop, ops.If, ops.Try:
 			sig := instr.Immediates[0].(wasm.BlockType)
 			logger.Printf("if, depth is %d", stackDepths.Top())
 			stackDepths.Push(stackDepths.Top())
@@ -312,6 +384,32 @@ func NewDisassembly(fn wasm.Function, module *wasm.Module) (*Disassembly, error)
 			top += len(sig.ReturnTypes)
 			stackDepths.SetTop(uint64(top))
 			disas.checkMaxDepth(top)
+		case ops.ReturnCall, ops.ReturnCallIndirect:
+			index := instr.Immediates[0].(uint32)
+			var sig *wasm.FunctionSig
+			top := int(stackDepths.Top())
+
+			switch op {
+			case ops.ReturnCallIndirect:
+				if module.Types == nil {
+					return nil, errors.New("missing types section")
+				}
+				sig = &module.Types.Entries[index]
+				top--
+			default:
+				sig, err = module.GetFunctionSig(index)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			// A tail call replaces the current frame, so like return,
+			// it leaves the callee's results (which match ours) as the
+			// only values the caller sees.
+			top -= len(sig.ParamTypes)
+			stackDepths.SetTop(uint64(top))
+			instr.IsReturn = true
+			pushPolymorphicOp(blockPolymorphicOps, curIndex)
 		case ops.GetLocal, ops.SetLocal, ops.TeeLocal, ops.GetGlobal, ops.SetGlobal:
 			top := stackDepths.Top()
 			switch op {
@@ -352,8 +450,18 @@ func Disassemble(code []byte) ([]Instr, error) {
 			return nil, err
 		}
 
-		opStr, err := ops.New(op)
-		if err != nil {
+		var opStr ops.Op
+		if ops.IsPrefix(op) {
+			code, err := leb128.ReadVarUint32(reader)
+			if err != nil {
+				return nil, err
+			}
+			opStr, err = ops.NewPrefixed(op, code)
+			if err != nil {
+				return nil, err
+			}
+			op = opStr.Code
+		} else if opStr, err = ops.New(op); err != nil {
 			return nil, err
 		}
 		instr := Instr{
@@ -361,18 +469,24 @@ func Disassemble(code []byte) ([]Instr, error) {
 		}
 
 		switch op {
-		case ops.Block, ops.Loop, ops.If:
+		case ops.Block, ops.Loop, ops.If, ops.Try:
 			sig, err := wasm.ReadByte(reader)
 			if err != nil {
 				return nil, err
 			}
 			instr.Immediates = append(instr.Immediates, wasm.BlockType(sig))
-		case ops.Br, ops.BrIf:
+		case ops.Br, ops.BrIf, ops.Rethrow, ops.Delegate:
 			depth, err := leb128.ReadVarUint32(reader)
 			if err != nil {
 				return nil, err
 			}
 			instr.Immediates = append(instr.Immediates, depth)
+		case ops.Catch, ops.Throw:
+			index, err := leb128.ReadVarUint32(reader)
+			if err != nil {
+				return nil, err
+			}
+			instr.Immediates = append(instr.Immediates, index)
 		case ops.BrTable:
 			targetCount, err := leb128.ReadVarUint32(reader)
 			if err != nil {
@@ -392,13 +506,13 @@ func Disassemble(code []byte) ([]Instr, error) {
 				return nil, err
 			}
 			instr.Immediates = append(instr.Immediates, defaultTarget)
-		case ops.Call, ops.CallIndirect:
+		case ops.Call, ops.CallIndirect, ops.ReturnCall, ops.ReturnCallIndirect:
 			index, err := leb128.ReadVarUint32(reader)
 			if err != nil {
 				return nil, err
 			}
 			instr.Immediates = append(instr.Immediates, index)
-			if op == ops.CallIndirect {
+			if op == ops.CallIndirect || op == ops.ReturnCallIndirect {
 				idx, err := wasm.ReadByte(reader)
 				if err != nil {
 					return nil, err
@@ -446,22 +560,47 @@ func Disassemble(code []byte) ([]Instr, error) {
 			if err != nil {
 				return nil, err
 			}
-			instr.Immediates = append(instr.Immediates, align)
+			// the alignment has the memIndexFlag bit set when the
+			// index of the memory follows it.
+			hasMemIndex := align&memIndexFlag != 0
+			instr.Immediates = append(instr.Immediates, align&^memIndexFlag)
+
+			var memIndex uint32
+			if hasMemIndex {
+				if memIndex, err = leb128.ReadVarUint32(reader); err != nil {
+					return nil, err
+				}
+			}
 
-			offset, err := leb128.ReadVarUint32(reader)
+			// offsets are 64-bit values for 64-bit memories, and are
+			// kept as uint32 values whenever possible.
+			offset, err := leb128.ReadVarUint64(reader)
 			if err != nil {
 				return nil, err
 			}
-			instr.Immediates = append(instr.Immediates, offset)
-		case ops.CurrentMemory, ops.GrowMemory:
-			idx, err := wasm.ReadByte(reader)
+			if offset <= math.MaxUint32 {
+				instr.Immediates = append(instr.Immediates, uint32(offset))
+			} else {
+				instr.Immediates = append(instr.Immediates, offset)
+			}
+			if hasMemIndex {
+				instr.Immediates = append(instr.Immediates, memIndex)
+			}
+		case ops.CurrentMemory, ops.GrowMemory, ops.MemoryFill:
+			idx, err := leb128.ReadVarUint32(reader)
 			if err != nil {
 				return nil, err
 			}
-			if idx != 0x00 {
-				return nil, errors.New("disasm: memory index must be 0")
+			instr.Immediates = append(instr.Immediates, idx)
+		case ops.MemoryCopy:
+			// the indices of the destination and source memories.
+			for i := 0; i < 2; i++ {
+				idx, err := leb128.ReadVarUint32(reader)
+				if err != nil {
+					return nil, err
+				}
+				instr.Immediates = append(instr.Immediates, idx)
 			}
-			instr.Immediates = append(instr.Immediates, uint8(idx))
 		}
 		out = append(out, instr)
 	}
@@ -0,0 +1,389 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package dwarf maps the code of a module to the source it was compiled
+// from, with the DWARF debugging information that compilers such as Clang
+// and Rust embed in the .debug_* custom sections of the module.
+//
+// The addresses of the DWARF information of a module are byte offsets in
+// the payload of its code section, which CodeOffset computes for the
+// instructions of a function body:
+//
+//	d, err := dwarf.New(m)
+//	...
+//	off, err := dwarf.CodeOffset(m, body, pc)
+//	...
+//	for _, f := range d.Frames(off) {
+//		fmt.Printf("%s\n\t%s:%d\n", f.Function, f.File, f.Line)
+//	}
+package dwarf
+
+import (
+	"bytes"
+	"debug/dwarf"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/go-interpreter/wagon/wasm/leb128"
+)
+
+// ErrNoDebugInfo is returned by New for modules without DWARF debugging
+// information.
+var ErrNoDebugInfo = errors.New("dwarf: no DWARF debugging information")
+
+// Frame is a source location of code.
+type Frame struct {
+	Function string // The name of the function the code belongs to
+	File     string
+	Line     int // The line in File, 0 if unknown
+	Column   int // The column in the line, 0 if unknown
+}
+
+// Data is the debugging information of a module.
+type Data struct {
+	DWARF *dwarf.Data // The data of the .debug_* custom sections
+
+	lines []lineRange // Sorted by address
+	funcs []funcRange // Sorted by address
+}
+
+// lineRange is a range of code of a row of a line table.
+type lineRange struct {
+	low, high    uint64
+	file         string
+	line, column int
+}
+
+// funcRange is a range of code of a function.
+type funcRange struct {
+	low, high uint64
+	fn        *scope
+}
+
+// scope is a function, or the code of a function inlined in it.
+type scope struct {
+	name   string
+	ranges [][2]uint64
+
+	// The call site of an inlined function.
+	callFile             string
+	callLine, callColumn int
+
+	inlined []*scope
+}
+
+// New returns the debugging information of the module m. It returns
+// ErrNoDebugInfo if m has no .debug_info section.
+func New(m *wasm.Module) (*Data, error) {
+	section := func(name string) []byte {
+		if s := m.Custom(".debug_" + name); s != nil {
+			return s.Data
+		}
+		return nil
+	}
+	info := section("info")
+	if info == nil {
+		return nil, ErrNoDebugInfo
+	}
+	dd, err := dwarf.New(section("abbrev"), section("aranges"), section("frame"), info,
+		section("line"), section("pubnames"), section("ranges"), section("str"))
+	if err != nil {
+		return nil, err
+	}
+	// The sections of DWARF 5 are only supported from Go 1.14 on.
+	if ds, ok := interface{}(dd).(interface {
+		AddSection(name string, contents []byte) error
+	}); ok {
+		for _, name := range []string{"addr", "line_str", "str_offsets", "rnglists", "loclists"} {
+			if p := section(name); p != nil {
+				if err := ds.AddSection(".debug_"+name, p); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	d := &Data{DWARF: dd}
+	r := dd.Reader()
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			break
+		}
+		if e.Tag != dwarf.TagCompileUnit {
+			r.SkipChildren()
+			continue
+		}
+		files, err := d.readLines(e)
+		if err != nil {
+			return nil, err
+		}
+		if e.Children {
+			if err := d.readScopes(r, nil, files); err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Slice(d.lines, func(i, j int) bool { return d.lines[i].low < d.lines[j].low })
+	sort.Slice(d.funcs, func(i, j int) bool { return d.funcs[i].low < d.funcs[j].low })
+	return d, nil
+}
+
+// readLines reads the line table of the compilation unit cu, and returns
+// the files it refers to.
+func (d *Data) readLines(cu *dwarf.Entry) ([]*dwarf.LineFile, error) {
+	lr, err := d.DWARF.LineReader(cu)
+	if err != nil || lr == nil {
+		return nil, err
+	}
+	var (
+		prev  dwarf.LineEntry
+		entry dwarf.LineEntry
+		seq   = false // Whether prev is a row of a sequence to keep
+	)
+	for {
+		err := lr.Next(&entry)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if seq && entry.Address > prev.Address {
+			r := lineRange{low: prev.Address, high: entry.Address, line: prev.Line, column: prev.Column}
+			if prev.File != nil {
+				r.file = prev.File.Name
+			}
+			d.lines = append(d.lines, r)
+		}
+		switch {
+		case entry.EndSequence:
+			seq = false
+		case !seq:
+			// The code section starts with the number of function
+			// bodies: the sequences at 0 are of functions removed by the
+			// linker.
+			seq = entry.Address != 0
+		}
+		prev = entry
+	}
+	// The file table is only available from Go 1.14 on.
+	if fl, ok := interface{}(lr).(interface{ Files() []*dwarf.LineFile }); ok {
+		return fl.Files(), nil
+	}
+	return nil, nil
+}
+
+// readScopes reads the entries up to the end of the children of the current
+// entry of r, adding the functions to d and the inlined functions to parent.
+func (d *Data) readScopes(r *dwarf.Reader, parent *scope, files []*dwarf.LineFile) error {
+	for {
+		e, err := r.Next()
+		if err != nil {
+			return err
+		}
+		if e == nil || e.Tag == 0 {
+			return nil
+		}
+		var s *scope
+		switch e.Tag {
+		case dwarf.TagSubprogram:
+			if s, err = d.newScope(e); err != nil {
+				return err
+			}
+			if s != nil {
+				for _, rg := range s.ranges {
+					d.funcs = append(d.funcs, funcRange{low: rg[0], high: rg[1], fn: s})
+				}
+			}
+		case dwarf.TagInlinedSubroutine:
+			if parent == nil {
+				break
+			}
+			if s, err = d.newScope(e); err != nil {
+				return err
+			}
+			if s != nil {
+				if i, ok := e.Val(dwarf.AttrCallFile).(int64); ok && i >= 0 && int(i) < len(files) && files[i] != nil {
+					s.callFile = files[i].Name
+				}
+				if line, ok := e.Val(dwarf.AttrCallLine).(int64); ok {
+					s.callLine = int(line)
+				}
+				if col, ok := e.Val(dwarf.AttrCallColumn).(int64); ok {
+					s.callColumn = int(col)
+				}
+				parent.inlined = append(parent.inlined, s)
+			}
+		}
+		if e.Children {
+			next := parent
+			if s != nil {
+				next = s
+			}
+			if err := d.readScopes(r, next, files); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// newScope returns the scope of the function or inlined function e, or nil
+// if it has no code.
+func (d *Data) newScope(e *dwarf.Entry) (*scope, error) {
+	ranges, err := d.DWARF.Ranges(e)
+	if err != nil {
+		return nil, err
+	}
+	s := &scope{}
+	for _, rg := range ranges {
+		// Like the sequences of the line table, the ranges at 0 are of
+		// removed functions.
+		if rg[0] != 0 && rg[0] < rg[1] {
+			s.ranges = append(s.ranges, rg)
+		}
+	}
+	if len(s.ranges) == 0 {
+		return nil, nil
+	}
+	s.name, err = d.name(e)
+	return s, err
+}
+
+// name returns the name of the function e, which may be given by the entry
+// it is an instance or the definition of.
+func (d *Data) name(e *dwarf.Entry) (string, error) {
+	r := d.DWARF.Reader()
+	for i := 0; i < 8; i++ {
+		if name, ok := e.Val(dwarf.AttrName).(string); ok {
+			return name, nil
+		}
+		off, ok := e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+		if !ok {
+			off, ok = e.Val(dwarf.AttrSpecification).(dwarf.Offset)
+		}
+		if !ok {
+			return "", nil
+		}
+		r.Seek(off)
+		var err error
+		if e, err = r.Next(); err != nil || e == nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+// contains returns whether the code of s contains the offset.
+func (s *scope) contains(offset uint64) bool {
+	for _, rg := range s.ranges {
+		if rg[0] <= offset && offset < rg[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// Frames returns the source locations of the code at the given offset in
+// the code section, or nil if there is none. The first frame is the
+// location of the code. If the code belongs to an inlined function, the
+// following ones are the locations of the calls of the inlined functions,
+// up to the function the code was inlined in.
+func (d *Data) Frames(offset uint64) []Frame {
+	loc := Frame{}
+	known := false
+	if i := sort.Search(len(d.lines), func(i int) bool { return d.lines[i].high > offset }); i < len(d.lines) {
+		if l := d.lines[i]; l.low <= offset {
+			loc = Frame{File: l.file, Line: l.line, Column: l.column}
+			known = true
+		}
+	}
+
+	var scopes []*scope
+	if i := sort.Search(len(d.funcs), func(i int) bool { return d.funcs[i].high > offset }); i < len(d.funcs) {
+		if f := d.funcs[i]; f.low <= offset {
+			for s := f.fn; s != nil; {
+				scopes = append(scopes, s)
+				var next *scope
+				for _, in := range s.inlined {
+					if in.contains(offset) {
+						next = in
+						break
+					}
+				}
+				s = next
+			}
+		}
+	}
+	if len(scopes) == 0 {
+		if !known {
+			return nil
+		}
+		return []Frame{loc}
+	}
+
+	frames := make([]Frame, 0, len(scopes))
+	for i := len(scopes) - 1; i >= 0; i-- {
+		s := scopes[i]
+		loc.Function = s.name
+		frames = append(frames, loc)
+		loc = Frame{File: s.callFile, Line: s.callLine, Column: s.callColumn}
+	}
+	return frames
+}
+
+// CodeOffset returns the offset in the code section of m of the instruction
+// at the offset pc in the code of its function body i, that is in
+// m.Code.Bodies[i].Code. The offset len(Code) is the one of the end
+// instruction terminating the body.
+func CodeOffset(m *wasm.Module, i, pc int) (uint64, error) {
+	if m.Code == nil || len(m.Code.Bytes) == 0 {
+		return 0, errors.New("dwarf: the module has no code section")
+	}
+	code := m.Code.Bytes
+	r := bytes.NewReader(code)
+	n, err := leb128.ReadVarUint32(r)
+	if err != nil {
+		return 0, err
+	}
+	if i < 0 || i >= int(n) {
+		return 0, fmt.Errorf("dwarf: no function body %d", i)
+	}
+	for j := 0; ; j++ {
+		size, err := leb128.ReadVarUint32(r)
+		if err != nil {
+			return 0, err
+		}
+		end := len(code) - r.Len() + int(size)
+		if j < i {
+			if _, err := r.Seek(int64(size), io.SeekCurrent); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		locals, err := leb128.ReadVarUint32(r)
+		if err != nil {
+			return 0, err
+		}
+		for k := 0; k < int(locals); k++ {
+			if _, err := leb128.ReadVarUint32(r); err != nil {
+				return 0, err
+			}
+			if _, err := r.ReadByte(); err != nil {
+				return 0, err
+			}
+		}
+		off := len(code) - r.Len() + pc
+		if pc < 0 || off >= end {
+			return 0, fmt.Errorf("dwarf: offset %d out of the code of function body %d", pc, i)
+		}
+		return uint64(off), nil
+	}
+}
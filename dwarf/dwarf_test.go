@@ -0,0 +1,97 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dwarf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+func readModule(t *testing.T, fname string) *wasm.Module {
+	raw, err := ioutil.ReadFile(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := wasm.DecodeModule(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+// testdata/sq.wasm is compiled from testdata/sq.ll, the code of testdata/sq.c
+// with the calls of square inlined:
+//	llc -mtriple=wasm32-unknown-unknown -filetype=obj sq.ll -o sq.wasm
+
+func TestFrames(t *testing.T) {
+	m := readModule(t, "testdata/sq.wasm")
+	d, err := New(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		pc   int
+		want []Frame
+	}{
+		{0, []Frame{
+			{Function: "square", File: "/src/sq.c", Line: 2, Column: 11},
+			{Function: "sum_squares", File: "/src/sq.c", Line: 6, Column: 9},
+		}},
+		{7, []Frame{
+			{Function: "square", File: "/src/sq.c", Line: 2, Column: 11},
+			{Function: "sum_squares", File: "/src/sq.c", Line: 6, Column: 21},
+		}},
+		{18, []Frame{{Function: "sum_squares", File: "/src/sq.c", Line: 6, Column: 19}}},
+		{23, []Frame{{Function: "sum_squares", File: "/src/sq.c", Line: 6, Column: 2}}},
+	} {
+		off, err := CodeOffset(m, 0, tc.pc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := d.Frames(off); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("pc %d: got frames %+v, want %+v", tc.pc, got, tc.want)
+		}
+	}
+	if got := d.Frames(0); got != nil {
+		t.Errorf("got frames %+v at offset 0, want none", got)
+	}
+}
+
+func TestCodeOffset(t *testing.T) {
+	m := readModule(t, "testdata/sq.wasm")
+	for _, tc := range []struct {
+		i, pc int
+		want  uint64
+		err   bool
+	}{
+		{i: 0, pc: 0, want: 5},
+		{i: 0, pc: 23, want: 28},
+		{i: 0, pc: 24, want: 29},
+		{i: 0, pc: 25, err: true},
+		{i: 0, pc: -1, err: true},
+		{i: 1, pc: 0, err: true},
+	} {
+		got, err := CodeOffset(m, tc.i, tc.pc)
+		switch {
+		case tc.err && err == nil:
+			t.Errorf("body %d, pc %d: got offset %d, want an error", tc.i, tc.pc, got)
+		case !tc.err && err != nil:
+			t.Errorf("body %d, pc %d: %v", tc.i, tc.pc, err)
+		case got != tc.want:
+			t.Errorf("body %d, pc %d: got offset %d, want %d", tc.i, tc.pc, got, tc.want)
+		}
+	}
+}
+
+func TestNoDebugInfo(t *testing.T) {
+	m := readModule(t, "../wasm/testdata/nofuncs.wasm")
+	if _, err := New(m); err != ErrNoDebugInfo {
+		t.Errorf("got error %v, want %v", err, ErrNoDebugInfo)
+	}
+}
@@ -0,0 +1,7 @@
+static inline int square(int x) {
+	return x * x;
+}
+
+int sum_squares(int x, int y) {
+	return square(x) + square(y);
+}
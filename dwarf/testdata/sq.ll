@@ -0,0 +1,31 @@
+target datalayout = "e-m:e-p:32:32-p10:8:8-p20:8:8-i64:64-n32:64-S128-ni:1:10:20"
+target triple = "wasm32-unknown-unknown"
+
+define hidden i32 @sum_squares(i32 %x, i32 %y) !dbg !10 {
+entry:
+  %0 = mul nsw i32 %x, %x, !dbg !20
+  %1 = mul nsw i32 %y, %y, !dbg !22
+  %add = add nsw i32 %0, %1, !dbg !24
+  ret i32 %add, !dbg !25
+}
+
+!llvm.dbg.cu = !{!0}
+!llvm.module.flags = !{!2, !3}
+
+!0 = distinct !DICompileUnit(language: DW_LANG_C11, file: !1, producer: "clang", isOptimized: true, runtimeVersion: 0, emissionKind: FullDebug)
+!1 = !DIFile(filename: "sq.c", directory: "/src")
+!2 = !{i32 7, !"Dwarf Version", i32 4}
+!3 = !{i32 2, !"Debug Info Version", i32 3}
+!4 = !DISubroutineType(types: !5)
+!5 = !{!6, !6}
+!6 = !DIBasicType(name: "int", size: 32, encoding: DW_ATE_signed)
+!7 = !DISubroutineType(types: !8)
+!8 = !{!6, !6, !6}
+!9 = distinct !DISubprogram(name: "square", scope: !1, file: !1, line: 1, type: !4, scopeLine: 1, flags: DIFlagPrototyped, spFlags: DISPFlagLocalToUnit | DISPFlagDefinition | DISPFlagOptimized, unit: !0)
+!10 = distinct !DISubprogram(name: "sum_squares", scope: !1, file: !1, line: 5, type: !7, scopeLine: 5, flags: DIFlagPrototyped, spFlags: DISPFlagDefinition | DISPFlagOptimized, unit: !0)
+!20 = !DILocation(line: 2, column: 11, scope: !9, inlinedAt: !21)
+!21 = distinct !DILocation(line: 6, column: 9, scope: !10)
+!22 = !DILocation(line: 2, column: 11, scope: !9, inlinedAt: !23)
+!23 = distinct !DILocation(line: 6, column: 21, scope: !10)
+!24 = !DILocation(line: 6, column: 19, scope: !10)
+!25 = !DILocation(line: 6, column: 2, scope: !10)
@@ -0,0 +1,242 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package emscripten implements the common subset of the env module
+// imported by modules built with Emscripten without STANDALONE_WASM, so
+// that C programs and libraries compiled with emcc can run with exec.
+//
+// Such modules usually also import WASI for their standard streams, which
+// package wasi provides:
+//
+//	env := emscripten.New(emscripten.Config{Stdout: os.Stdout})
+//	w := wasi.New(wasi.Config{Stdout: os.Stdout})
+//	m, err := wasm.ReadModule(r, w.Resolver(env.Resolver(nil)))
+//
+// Both the system calls of Emscripten 2 and later (__syscall_openat...)
+// and the numbered system calls of older versions (__syscall146...) are
+// provided, for the standard streams only: there is no file system.
+// Memory and tables imported from env, as fastcomp modules do, are not
+// supported.
+package emscripten
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/wasi"
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// ModuleName is the name of the module the functions of Emscripten are
+// imported from.
+const ModuleName = "env"
+
+// Config is the environment of a guest.
+type Config struct {
+	// Standard streams of the guest, for the numbered system calls and the
+	// emscripten_console functions. They default to an empty input and to
+	// ioutil.Discard.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Now returns the current time, it defaults to time.Now.
+	Now func() time.Time
+}
+
+// AbortError is the error returned by (*exec.VM).ExecCode when the guest
+// aborts, either by calling abort or by failing an assertion. The VM must
+// recover panics for it to be returned, see exec.VM.RecoverPanic.
+type AbortError struct {
+	Message string
+}
+
+func (e *AbortError) Error() string {
+	return "emscripten: " + e.Message
+}
+
+// Env holds the state of the env module imported by a guest.
+type Env struct {
+	cfg      Config
+	start    time.Time // Origin of emscripten_get_now
+	tempRet0 uint32    // High bits of the i64 results of legalized functions
+}
+
+// New returns an env module with the environment cfg.
+func New(cfg Config) *Env {
+	if cfg.Stdin == nil {
+		cfg.Stdin = eofReader{}
+	}
+	if cfg.Stdout == nil {
+		cfg.Stdout = ioutil.Discard
+	}
+	if cfg.Stderr == nil {
+		cfg.Stderr = ioutil.Discard
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	return &Env{cfg: cfg, start: cfg.Now()}
+}
+
+// Module returns the host module exporting the functions of e.
+func (e *Env) Module() (*wasm.Module, error) {
+	return exec.NewHostModule(map[string]interface{}{
+		"abort":                            e.abort,
+		"__assert_fail":                    e.assertFail,
+		"exit":                             e.exit,
+		"setTempRet0":                      e.setTempRet0,
+		"getTempRet0":                      e.getTempRet0,
+		"emscripten_memcpy_big":            e.memcpyBig,
+		"emscripten_resize_heap":           e.resizeHeap,
+		"emscripten_get_heap_size":         e.getHeapSize,
+		"emscripten_get_heap_max":          e.getHeapMax,
+		"emscripten_notify_memory_growth":  e.notifyMemoryGrowth,
+		"emscripten_get_now":               e.getNow,
+		"emscripten_date_now":              e.dateNow,
+		"_emscripten_get_now_is_monotonic": e.getNowIsMonotonic,
+		"emscripten_console_log":           e.consoleLog,
+		"emscripten_console_warn":          e.consoleWarn,
+		"emscripten_console_error":         e.consoleError,
+
+		"__syscall_openat":  e.syscallOpenat,
+		"__syscall_ioctl":   e.syscallStdio,
+		"__syscall_fcntl64": e.syscallStdio,
+
+		"__syscall6":   legacySyscall(e.sysStdio), // close
+		"__syscall54":  legacySyscall(e.sysStdio), // ioctl
+		"__syscall140": legacySyscall(e.sysLlseek),
+		"__syscall145": legacySyscall(e.sysReadv),
+		"__syscall146": legacySyscall(e.sysWritev),
+		"__syscall221": legacySyscall(e.sysStdio), // fcntl64
+	})
+}
+
+// Resolver returns a wasm.ResolveFunc resolving ModuleName to the host
+// module of e, and the other modules with next, if not nil.
+func (e *Env) Resolver(next wasm.ResolveFunc) wasm.ResolveFunc {
+	return func(name string) (*wasm.Module, error) {
+		if name == ModuleName {
+			return e.Module()
+		}
+		if next == nil {
+			return nil, fmt.Errorf("emscripten: unknown module %q", name)
+		}
+		return next(name)
+	}
+}
+
+func (e *Env) abort(proc *exec.Process) {
+	panic(&AbortError{Message: "native code called abort()"})
+}
+
+func (e *Env) assertFail(proc *exec.Process, cond, file, line, fn uint32) {
+	c, _ := proc.ReadCString(cond)
+	f, _ := proc.ReadCString(file)
+	name, _ := proc.ReadCString(fn)
+	panic(&AbortError{Message: fmt.Sprintf("Assertion failed: %s, at: %s,%d,%s", c, f, line, name)})
+}
+
+// exit terminates the guest with wasi.ExitError, as proc_exit does.
+func (e *Env) exit(proc *exec.Process, code uint32) {
+	panic(wasi.ExitError(code))
+}
+
+func (e *Env) setTempRet0(proc *exec.Process, v uint32) {
+	e.tempRet0 = v
+}
+
+func (e *Env) getTempRet0(proc *exec.Process) uint32 {
+	return e.tempRet0
+}
+
+// memcpyBig copies num bytes of memory from src to dest. The areas may
+// overlap.
+func (e *Env) memcpyBig(proc *exec.Process, dest, src, num uint32) {
+	from, err := proc.MemoryView(src, num)
+	if err != nil {
+		panic(err)
+	}
+	to, err := proc.MemoryView(dest, num)
+	if err != nil {
+		panic(err)
+	}
+	copy(to, from)
+}
+
+// heapMax is the maximum size of the heap reported to the guest, the
+// default maximum memory size of Emscripten.
+const heapMax = 2 << 30
+
+const pageSize = 65536
+
+// resizeHeap grows the memory to at least size bytes, and returns 1 on
+// success and 0 otherwise.
+func (e *Env) resizeHeap(proc *exec.Process, size uint32) uint32 {
+	mem, err := proc.Memory(0)
+	if err != nil {
+		return 0
+	}
+	cur := uint64(mem.Size())
+	if uint64(size) <= cur {
+		return 1
+	}
+	pages := (uint64(size) - cur + pageSize - 1) / pageSize
+	if _, err := mem.Grow(pages); err != nil {
+		return 0
+	}
+	return 1
+}
+
+func (e *Env) getHeapSize(proc *exec.Process) uint32 {
+	return uint32(proc.MemSize())
+}
+
+func (e *Env) getHeapMax(proc *exec.Process) uint32 {
+	return heapMax
+}
+
+func (e *Env) notifyMemoryGrowth(proc *exec.Process, index uint32) {}
+
+// getNow returns the number of milliseconds elapsed since e was created.
+func (e *Env) getNow(proc *exec.Process) float64 {
+	return float64(e.cfg.Now().Sub(e.start)) / float64(time.Millisecond)
+}
+
+// dateNow returns the number of milliseconds elapsed since the Unix epoch.
+func (e *Env) dateNow(proc *exec.Process) float64 {
+	return float64(e.cfg.Now().UnixNano()) / float64(time.Millisecond)
+}
+
+func (e *Env) getNowIsMonotonic(proc *exec.Process) uint32 {
+	return 1
+}
+
+func (e *Env) consoleLog(proc *exec.Process, str uint32) {
+	e.console(proc, e.cfg.Stdout, str)
+}
+
+func (e *Env) consoleWarn(proc *exec.Process, str uint32) {
+	e.console(proc, e.cfg.Stderr, str)
+}
+
+func (e *Env) consoleError(proc *exec.Process, str uint32) {
+	e.console(proc, e.cfg.Stderr, str)
+}
+
+// console writes the NUL-terminated string at str to w, as a line.
+func (e *Env) console(proc *exec.Process, w io.Writer, str uint32) {
+	s, err := proc.ReadCString(str)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Fprintln(w, s)
+}
+
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) { return 0, io.EOF }
@@ -0,0 +1,190 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package emscripten
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+// guestModule returns the encoding of a module whose main function writes
+// "hi\n" to its standard output with the numbered writev system call,
+// returning its result, and whose fail function calls abort.
+func guestModule(t *testing.T) []byte {
+	i32 := wasm.ValueTypeI32
+	m := wasm.NewModule()
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{
+		{Form: wasm.TypeFunc, ParamTypes: []wasm.ValueType{i32, i32}, ReturnTypes: []wasm.ValueType{i32}},
+		{Form: wasm.TypeFunc},
+		{Form: wasm.TypeFunc, ReturnTypes: []wasm.ValueType{i32}},
+	}}
+	m.Import = &wasm.SectionImports{Entries: []wasm.ImportEntry{
+		{ModuleName: ModuleName, FieldName: "__syscall146", Type: wasm.FuncImport{Type: 0}},
+		{ModuleName: ModuleName, FieldName: "abort", Type: wasm.FuncImport{Type: 1}},
+	}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{2, 1}}
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.Export = &wasm.SectionExports{
+		Entries: map[string]wasm.ExportEntry{
+			"main": {FieldStr: "main", Kind: wasm.ExternalFunction, Index: 2},
+			"fail": {FieldStr: "fail", Kind: wasm.ExternalFunction, Index: 3},
+		},
+		Names: []string{"main", "fail"},
+	}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{
+		// (call $__syscall146 (i32.const 146) (i32.const 0))
+		{Code: []byte{ops.I32Const, 0x80 | 146&0x7f, 146 >> 7, ops.I32Const, 0, ops.Call, 0}},
+		// (call $abort)
+		{Code: []byte{ops.Call, 1}},
+	}}
+	m.Data = &wasm.SectionData{Entries: []wasm.DataSegment{{
+		Offset: []byte{ops.I32Const, 0, ops.End},
+		// The arguments of writev: fd 1, and one iovec at address 12
+		// pointing to "hi\n" at address 20.
+		Data: []byte{1, 0, 0, 0, 12, 0, 0, 0, 1, 0, 0, 0, 20, 0, 0, 0, 3, 0, 0, 0, 'h', 'i', '\n'},
+	}}}
+	m.Sections = []wasm.Section{m.Types, m.Import, m.Function, m.Memory, m.Export, m.Code, m.Data}
+
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, m); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRunGuest(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	env := New(Config{Stdout: stdout})
+	m, err := wasm.ReadModule(bytes.NewReader(guestModule(t)), env.Resolver(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm, err := exec.NewVM(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.RecoverPanic = true
+
+	main, _ := vm.GetExportEntry("main")
+	n, err := vm.ExecCode(int64(main.Index))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != uint32(3) {
+		t.Errorf("writev returned %v, want 3", n)
+	}
+	if got := stdout.String(); got != "hi\n" {
+		t.Errorf("got output %q, want %q", got, "hi\n")
+	}
+
+	fail, _ := vm.GetExportEntry("fail")
+	_, err = vm.ExecCode(int64(fail.Index))
+	if _, ok := err.(*AbortError); !ok {
+		t.Errorf("got error %v, want an *AbortError", err)
+	}
+}
+
+// newProcess returns a process with one page of memory.
+func newProcess(t *testing.T) *exec.Process {
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1, Maximum: 2, Flags: 1}}}}
+	m.LinearMemoryIndexSpace = [][]byte{make([]byte, pageSize)}
+	vm, err := exec.NewVM(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exec.NewProcess(vm)
+}
+
+func TestMemory(t *testing.T) {
+	proc := newProcess(t)
+	env := New(Config{})
+
+	proc.WriteBytes(0, []byte("abcdef"))
+	env.memcpyBig(proc, 2, 0, 4)
+	if got, _ := proc.ReadString(0, 6); got != "ababcd" {
+		t.Errorf("got %q after emscripten_memcpy_big, want %q", got, "ababcd")
+	}
+
+	for _, tc := range []struct {
+		size uint32
+		ok   uint32
+		want int
+	}{
+		{100, 1, pageSize},
+		{pageSize + 1, 1, 2 * pageSize},
+		{3 * pageSize, 0, 2 * pageSize},
+	} {
+		if ok := env.resizeHeap(proc, tc.size); ok != tc.ok {
+			t.Errorf("emscripten_resize_heap(%d) = %d, want %d", tc.size, ok, tc.ok)
+		}
+		if got := int(env.getHeapSize(proc)); got != tc.want {
+			t.Errorf("got a heap of %d bytes after resizing to %d, want %d", got, tc.size, tc.want)
+		}
+	}
+}
+
+func TestAssertFail(t *testing.T) {
+	proc := newProcess(t)
+	env := New(Config{})
+	proc.WriteBytes(0, []byte("x > 0\x00main.c\x00f\x00"))
+
+	defer func() {
+		err, ok := recover().(*AbortError)
+		if !ok {
+			t.Fatalf("got %v, want an *AbortError", err)
+		}
+		if want := "Assertion failed: x > 0, at: main.c,12,f"; err.Message != want {
+			t.Errorf("got message %q, want %q", err.Message, want)
+		}
+	}()
+	env.assertFail(proc, 0, 6, 12, 13)
+}
+
+func TestSyscalls(t *testing.T) {
+	proc := newProcess(t)
+	stdin := bytes.NewBufferString("input")
+	now := time.Unix(1, 0)
+	env := New(Config{Stdin: stdin, Now: func() time.Time { return now }})
+
+	// readv(0, iov, 1) with an iovec of 3 bytes at address 100.
+	proc.WriteUint32Le(0, 0)
+	proc.WriteUint32Le(4, 16)
+	proc.WriteUint32Le(8, 1)
+	proc.WriteUint32Le(16, 100)
+	proc.WriteUint32Le(20, 3)
+	readv := legacySyscall(env.sysReadv)
+	if n := readv(proc, 145, 0); n != 3 {
+		t.Errorf("readv returned %d, want 3", n)
+	}
+	if got, _ := proc.ReadString(100, 3); got != "inp" {
+		t.Errorf("got %q, want %q", got, "inp")
+	}
+
+	for _, tc := range []struct {
+		what string
+		got  int32
+		want int32
+	}{
+		{"close(1)", legacySyscall(env.sysStdio)(proc, 6, 8), 0},
+		{"ioctl(5)", env.syscallStdio(proc, 5, 0, 0), errBadf},
+		{"llseek(1)", legacySyscall(env.sysLlseek)(proc, 140, 8), errSpipe},
+		{"openat", env.syscallOpenat(proc, 3, 0, 0, 0), errNosys},
+	} {
+		if tc.got != tc.want {
+			t.Errorf("%s returned %d, want %d", tc.what, tc.got, tc.want)
+		}
+	}
+
+	if got := env.dateNow(proc); got != 1000 {
+		t.Errorf("emscripten_date_now returned %v, want 1000", got)
+	}
+}
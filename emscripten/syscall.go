@@ -0,0 +1,142 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package emscripten
+
+import (
+	"io"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/wasi"
+)
+
+// System calls return a negated error code on failure. The musl of
+// Emscripten uses the error codes of WASI.
+var (
+	errBadf  = -int32(wasi.ErrnoBadf)
+	errFault = -int32(wasi.ErrnoFault)
+	errIO    = -int32(wasi.ErrnoIO)
+	errNosys = -int32(wasi.ErrnoNosys)
+	errSpipe = -int32(wasi.ErrnoSpipe)
+)
+
+// isStdio returns whether fd is one of the standard streams.
+func isStdio(fd uint32) bool {
+	return fd <= 2
+}
+
+// legacySyscall returns the host function of a numbered system call, which
+// is passed its number, and a pointer to its arguments. The arguments are
+// read from the memory when fn calls its args function.
+func legacySyscall(fn func(proc *exec.Process, args func(i uint32) uint32) int32) func(*exec.Process, uint32, uint32) int32 {
+	return func(proc *exec.Process, which, varargs uint32) int32 {
+		args := func(i uint32) uint32 {
+			v, err := proc.ReadUint32Le(varargs + 4*i)
+			if err != nil {
+				panic(err)
+			}
+			return v
+		}
+		return fn(proc, args)
+	}
+}
+
+// sysStdio implements close, ioctl and fcntl64, which succeed for the
+// standard streams: they are seen as terminals by the guest.
+func (e *Env) sysStdio(proc *exec.Process, args func(uint32) uint32) int32 {
+	if !isStdio(args(0)) {
+		return errBadf
+	}
+	return 0
+}
+
+func (e *Env) sysLlseek(proc *exec.Process, args func(uint32) uint32) int32 {
+	if !isStdio(args(0)) {
+		return errBadf
+	}
+	return errSpipe
+}
+
+func (e *Env) sysReadv(proc *exec.Process, args func(uint32) uint32) int32 {
+	if args(0) != 0 {
+		return errBadf
+	}
+	bufs, errno := iovecs(proc, args(1), args(2))
+	if errno != 0 {
+		return errno
+	}
+	total := 0
+	for _, buf := range bufs {
+		n, err := e.cfg.Stdin.Read(buf)
+		total += n
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errIO
+		}
+		if n < len(buf) {
+			break
+		}
+	}
+	return int32(total)
+}
+
+func (e *Env) sysWritev(proc *exec.Process, args func(uint32) uint32) int32 {
+	var w io.Writer
+	switch args(0) {
+	case 1:
+		w = e.cfg.Stdout
+	case 2:
+		w = e.cfg.Stderr
+	default:
+		return errBadf
+	}
+	bufs, errno := iovecs(proc, args(1), args(2))
+	if errno != 0 {
+		return errno
+	}
+	total := 0
+	for _, buf := range bufs {
+		n, err := w.Write(buf)
+		total += n
+		if err != nil {
+			return errIO
+		}
+	}
+	return int32(total)
+}
+
+// syscallOpenat fails, as there is no file system.
+func (e *Env) syscallOpenat(proc *exec.Process, dirfd, path, flags, varargs uint32) int32 {
+	return errNosys
+}
+
+// syscallStdio implements ioctl and fcntl64, like sysStdio.
+func (e *Env) syscallStdio(proc *exec.Process, fd, op, varargs uint32) int32 {
+	if !isStdio(fd) {
+		return errBadf
+	}
+	return 0
+}
+
+// iovecs returns the buffers of the n iovec structures at iovs.
+func iovecs(proc *exec.Process, iovs, n uint32) ([][]byte, int32) {
+	bufs := make([][]byte, n)
+	for i := range bufs {
+		ptr, err := proc.ReadUint32Le(iovs + uint32(i)*8)
+		if err != nil {
+			return nil, errFault
+		}
+		size, err := proc.ReadUint32Le(iovs + uint32(i)*8 + 4)
+		if err != nil {
+			return nil, errFault
+		}
+		bufs[i], err = proc.MemoryView(ptr, size)
+		if err != nil {
+			return nil, errFault
+		}
+	}
+	return bufs, 0
+}
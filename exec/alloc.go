@@ -0,0 +1,201 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"errors"
+	"math"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// ErrNoAllocator is returned by (*VM).CallWithBytes and (*VM).CallWithString
+// when the module exports none of the allocators of the VM.
+var ErrNoAllocator = errors.New("exec: module exports no allocator")
+
+// ErrResultType is returned by (*VM).CallWithBytes and (*VM).CallWithString
+// when the function returns a value other than an i64.
+var ErrResultType = errors.New("exec: function does not return an i64 pointer and length")
+
+// ErrAllocFailed is returned by (*VM).CallWithBytes and (*VM).CallWithString
+// when the allocator of the module returns a null pointer.
+var ErrAllocFailed = errors.New("exec: module failed to allocate memory")
+
+// ErrTooLarge is returned by (*VM).CallWithBytes and (*VM).CallWithString
+// when the bytes to copy don't fit in 32-bit memory.
+var ErrTooLarge = errors.New("exec: bytes do not fit in 32-bit memory")
+
+// Allocator names the functions a module exports to allocate and free
+// its memory, used to pass byte slices and strings to its functions.
+//
+// Alloc must have the signature (func (param i32) (result i32)), taking a
+// size in bytes and returning a pointer to the allocated memory.
+// Free must take the pointer, optionally followed by the size of the
+// allocation, and return nothing. Free may be empty, in which case memory is
+// never freed.
+type Allocator struct {
+	Alloc string
+	Free  string
+
+	// Whether the memory returned by functions is owned by the caller,
+	// and should be freed once copied.
+	FreeResults bool
+}
+
+// DefaultAllocators are the allocators a VM looks for by default.
+var DefaultAllocators = []Allocator{
+	{Alloc: "malloc", Free: "free"},
+	{Alloc: "allocate", Free: "deallocate"},
+}
+
+// GuestAllocators sets the allocators the module may export, in order of
+// preference. It defaults to DefaultAllocators.
+func GuestAllocators(allocators ...Allocator) VMOption {
+	return func(c *config) {
+		c.Allocators = allocators
+	}
+}
+
+// guestAllocator is an allocator exported by the module.
+type guestAllocator struct {
+	Allocator
+	alloc, free int64 // the indices of the functions, free is -1 if there is none
+	freeLen     bool  // whether free takes the size of the allocation
+}
+
+// allocator returns the first allocator of the VM exported by the module.
+func (vm *VM) allocator() (*guestAllocator, error) {
+	for _, a := range vm.allocators {
+		alloc, err := vm.funcIndex(a.Alloc)
+		if err != nil {
+			continue
+		}
+		sig := vm.module.GetFunction(int(alloc)).Sig
+		if !sameSig(sig, &wasm.FunctionSig{
+			ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+			ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+		}) {
+			return nil, ErrSignatureMismatch
+		}
+
+		ga := &guestAllocator{Allocator: a, alloc: alloc, free: -1}
+		if a.Free == "" {
+			return ga, nil
+		}
+		if ga.free, err = vm.funcIndex(a.Free); err != nil {
+			return nil, err
+		}
+		sig = vm.module.GetFunction(int(ga.free)).Sig
+		switch {
+		case sameSig(sig, &wasm.FunctionSig{ParamTypes: []wasm.ValueType{wasm.ValueTypeI32}}):
+		case sameSig(sig, &wasm.FunctionSig{ParamTypes: []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32}}):
+			ga.freeLen = true
+		default:
+			return nil, ErrSignatureMismatch
+		}
+		return ga, nil
+	}
+	return nil, ErrNoAllocator
+}
+
+func (vm *VM) guestAlloc(a *guestAllocator, size uint32) (uint32, error) {
+	res, err := vm.reenter(a.alloc, uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	return res.(uint32), nil
+}
+
+func (vm *VM) guestFree(a *guestAllocator, ptr, size uint32) error {
+	if a.free < 0 {
+		return nil
+	}
+	args := []uint64{uint64(ptr)}
+	if a.freeLen {
+		args = append(args, uint64(size))
+	}
+	_, err := vm.reenter(a.free, args...)
+	return err
+}
+
+// CallWithBytes copies p into memory allocated by the module, and calls
+// the function fn, exported by the module or at the given index, with the
+// pointer to and the length of the copy, followed by args. The copy is freed
+// once fn returns, or if the call fails. ErrAllocFailed is returned if the
+// allocator returns a null pointer.
+//
+// fn must return either nothing, in which case CallWithBytes returns nil, or
+// an i64 value encoding a pointer and a length as ptr<<32|len: the pointer in
+// the 32 most significant bits, and the length in the 32 least significant
+// bits. The bytes of memory it refers to are copied and returned, and freed
+// if the allocator has FreeResults set. Any other result type fails with
+// ErrResultType.
+func (vm *VM) CallWithBytes(fn interface{}, p []byte, args ...uint64) ([]byte, error) {
+	index, err := vm.funcIndex(fn)
+	if err != nil {
+		return nil, err
+	}
+	switch ret := vm.module.GetFunction(int(index)).Sig.ReturnTypes; {
+	case len(ret) == 0:
+	case len(ret) == 1 && ret[0] == wasm.ValueTypeI64:
+	default:
+		return nil, ErrResultType
+	}
+	a, err := vm.allocator()
+	if err != nil {
+		return nil, err
+	}
+
+	if uint64(len(p)) > math.MaxUint32 {
+		return nil, ErrTooLarge
+	}
+	mem := &Memory{vm: vm}
+	size := uint32(len(p))
+	ptr, err := vm.guestAlloc(a, size)
+	if err != nil {
+		return nil, err
+	}
+	if ptr == 0 {
+		return nil, ErrAllocFailed
+	}
+	res, err := vm.callWithCopy(mem, index, ptr, p, args)
+	if ferr := vm.guestFree(a, ptr, size); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	v, ok := res.(uint64)
+	if !ok {
+		return nil, nil
+	}
+	resPtr, resSize := uint32(v>>32), uint32(v)
+	out, err := mem.ReadBytes(resPtr, resSize)
+	if err != nil {
+		return nil, err
+	}
+	if a.FreeResults {
+		if err := vm.guestFree(a, resPtr, resSize); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// callWithCopy writes p at ptr, and calls the function at index with ptr and
+// the length of p, followed by args.
+func (vm *VM) callWithCopy(mem *Memory, index int64, ptr uint32, p []byte, args []uint64) (interface{}, error) {
+	if err := mem.WriteBytes(ptr, p); err != nil {
+		return nil, err
+	}
+	return vm.reenter(index, append([]uint64{uint64(ptr), uint64(len(p))}, args...)...)
+}
+
+// CallWithString is the same as CallWithBytes, for strings.
+func (vm *VM) CallWithString(fn interface{}, s string, args ...uint64) (string, error) {
+	out, err := vm.CallWithBytes(fn, []byte(s), args...)
+	return string(out), err
+}
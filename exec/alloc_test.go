@@ -0,0 +1,169 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+func allocModule() *wasm.Module {
+	mallocSig := wasm.FunctionSig{
+		Form:        0x60,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+	freeSig := wasm.FunctionSig{
+		Form:       0x60,
+		ParamTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+	tailSig := wasm.FunctionSig{
+		Form:        0x60,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI64},
+	}
+
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{mallocSig, freeSig, tailSig}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0, 1, 2, 2, 0, 0}}
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.LinearMemoryIndexSpace = [][]byte{nil}
+	i32 := wasm.GlobalVar{Type: wasm.ValueTypeI32, Mutable: true}
+	m.GlobalIndexSpace = []wasm.GlobalEntry{
+		{Type: i32, Init: []byte{ops.I32Const, 16, ops.End}}, // the next free address
+		{Type: i32, Init: []byte{ops.I32Const, 0, ops.End}},  // the last freed address
+	}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{
+		// (func $malloc (param i32) (result i32)
+		//   (get_global 0)
+		//   (set_global 0 (i32.add (get_global 0) (get_local 0))))
+		{Module: m, Code: []byte{
+			ops.GetGlobal, 0,
+			ops.GetGlobal, 0, ops.GetLocal, 0, ops.I32Add, ops.SetGlobal, 0,
+		}},
+		// (func $free (param i32) (set_global 1 (get_local 0)))
+		{Module: m, Code: []byte{ops.GetLocal, 0, ops.SetGlobal, 1}},
+		// (func $tail (param $ptr i32) (param $len i32) (result i64)
+		//   (i64.or
+		//     (i64.shl (i64.extend_u/i32 (i32.add (get_local $ptr) (i32.const 1))) (i64.const 32))
+		//     (i64.extend_u/i32 (i32.sub (get_local $len) (i32.const 1)))))
+		{Module: m, Code: []byte{
+			ops.GetLocal, 0, ops.I32Const, 1, ops.I32Add, ops.I64ExtendUI32,
+			ops.I64Const, 32, ops.I64Shl,
+			ops.GetLocal, 1, ops.I32Const, 1, ops.I32Sub, ops.I64ExtendUI32,
+			ops.I64Or,
+		}},
+		// (func $trap (param i32) (param i32) (result i64) (unreachable))
+		{Module: m, Code: []byte{ops.Unreachable}},
+		// (func $len (param i32) (result i32) (get_local 0))
+		{Module: m, Code: []byte{ops.GetLocal, 0}},
+		// (func $null (param i32) (result i32) (i32.const 0))
+		{Module: m, Code: []byte{ops.I32Const, 0}},
+	}}
+	for i, typ := range m.Function.Types {
+		m.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{
+			Sig:  &m.Types.Entries[typ],
+			Body: &m.Code.Bodies[i],
+		})
+	}
+	m.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{
+		Sig:  &m.Types.Entries[2],
+		Host: reflect.ValueOf(func(*Process, int32, int32) int64 { return 0 }),
+		Body: &wasm.FunctionBody{},
+	})
+	m.Export = &wasm.SectionExports{Entries: map[string]wasm.ExportEntry{
+		"malloc": {FieldStr: "malloc", Kind: wasm.ExternalFunction, Index: 0},
+		"free":   {FieldStr: "free", Kind: wasm.ExternalFunction, Index: 1},
+		"tail":   {FieldStr: "tail", Kind: wasm.ExternalFunction, Index: 2},
+		"trap":   {FieldStr: "trap", Kind: wasm.ExternalFunction, Index: 3},
+		"len":    {FieldStr: "len", Kind: wasm.ExternalFunction, Index: 4},
+		"null":   {FieldStr: "null", Kind: wasm.ExternalFunction, Index: 5},
+		"host":   {FieldStr: "host", Kind: wasm.ExternalFunction, Index: 6},
+	}}
+	return m
+}
+
+func TestCallWithString(t *testing.T) {
+	vm, err := NewVM(allocModule())
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+
+	for _, tc := range []struct {
+		in, want string
+		freed    int32
+	}{
+		{"hello", "ello", 16},
+		{"world", "orld", 21},
+	} {
+		got, err := vm.CallWithString("tail", tc.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+		if freed, _ := vm.GlobalValue(1); freed != tc.freed {
+			t.Errorf("freed address: got %v, want %d", freed, tc.freed)
+		}
+	}
+
+	vm, err = NewVM(allocModule(), GuestAllocators(Allocator{Alloc: "alloc"}))
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	if _, err := vm.CallWithBytes("tail", []byte{1}); err != ErrNoAllocator {
+		t.Errorf("got error %v, want %v", err, ErrNoAllocator)
+	}
+
+	vm, err = NewVM(allocModule(), GuestAllocators(Allocator{Alloc: "malloc", Free: "tail"}))
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	if _, err := vm.CallWithBytes("tail", []byte{1}); err != ErrSignatureMismatch {
+		t.Errorf("got error %v, want %v", err, ErrSignatureMismatch)
+	}
+}
+
+func TestCallWithBytesErrors(t *testing.T) {
+	vm, err := NewVM(allocModule())
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	vm.RecoverPanic = true
+
+	if _, err := vm.CallWithBytes("len", []byte{1}); err != ErrResultType {
+		t.Errorf("len: got error %v, want %v", err, ErrResultType)
+	}
+	if next, _ := vm.GlobalValue(0); next != int32(16) {
+		t.Errorf("len allocated memory: next free address is %v, want 16", next)
+	}
+
+	// The copy is freed when the call fails.
+	if _, err := vm.CallWithBytes("trap", []byte{1, 2}); err == nil {
+		t.Error("trap: got no error")
+	}
+	if freed, _ := vm.GlobalValue(1); freed != int32(16) {
+		t.Errorf("trap: freed address: got %v, want 16", freed)
+	}
+	if _, err := vm.CallWithBytes("host", []byte{1}); err != ErrHostFunction {
+		t.Errorf("host: got error %v, want %v", err, ErrHostFunction)
+	}
+	if freed, _ := vm.GlobalValue(1); freed != int32(18) {
+		t.Errorf("host: freed address: got %v, want 18", freed)
+	}
+
+	vm, err = NewVM(allocModule(), GuestAllocators(Allocator{Alloc: "null", Free: "free"}))
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	if _, err := vm.CallWithBytes("tail", []byte{1, 2}); err != ErrAllocFailed {
+		t.Errorf("null allocator: got error %v, want %v", err, ErrAllocFailed)
+	}
+}
@@ -28,38 +28,67 @@ func (vm *VM) call() {
 }
 
 func (vm *VM) callIndirect() {
+	elemIndex := vm.fetchIndirectTarget()
+	vm.funcs[elemIndex].call(vm, int64(elemIndex))
+}
+
+// fetchIndirectTarget reads the immediates of a call_indirect or
+// return_call_indirect operator, pops the table index off the stack, and
+// returns the index of the function it refers to, after checking that its
+// signature matches the expected one.
+func (vm *VM) fetchIndirectTarget() uint32 {
 	index := vm.fetchUint32()
 	fnExpect := vm.module.Types.Entries[index]
 	_ = vm.fetchUint32() // reserved (https://github.com/WebAssembly/design/blob/27ac254c854994103c24834a994be16f74f54186/BinaryEncoding.md#call-operators-described-here)
 	tableIndex := vm.popUint32()
-	if int(tableIndex) >= len(vm.module.TableIndexSpace[0]) {
+	if int(tableIndex) >= len(vm.tables[0]) {
 		panic(ErrUndefinedElementIndex)
 	}
-	tableEntry := vm.module.TableIndexSpace[0][tableIndex]
+	tableEntry := vm.tables[0][tableIndex]
 	if !tableEntry.Initialized {
 		panic(wasm.UninitializedTableEntryError(tableIndex))
 	}
 	elemIndex := tableEntry.Index
 	fnActual := vm.module.FunctionIndexSpace[elemIndex]
 
-	if len(fnExpect.ParamTypes) != len(fnActual.Sig.ParamTypes) {
+	if !sameSig(&fnExpect, fnActual.Sig) {
 		panic(ErrSignatureMismatch)
 	}
-	if len(fnExpect.ReturnTypes) != len(fnActual.Sig.ReturnTypes) {
-		panic(ErrSignatureMismatch)
+
+	return elemIndex
+}
+
+// tailCall implements return_call and return_call_indirect. If the callee
+// is a compiled function, the current execution context is replaced by the
+// callee's and the function is returned, so that execCode can keep running
+// it in the same Go stack frame. Host functions are called directly, in which
+// case tailCall returns false and the caller should return their result.
+func (vm *VM) tailCall(index int64) (compiledFunction, bool) {
+	compiled, ok := vm.funcs[index].(compiledFunction)
+	if !ok {
+		vm.funcs[index].call(vm, index)
+		return compiledFunction{}, false
 	}
 
-	for i := range fnExpect.ParamTypes {
-		if fnExpect.ParamTypes[i] != fnActual.Sig.ParamTypes[i] {
-			panic(ErrSignatureMismatch)
-		}
+	locals := make([]uint64, compiled.totalLocalVars)
+	for i := compiled.args - 1; i >= 0; i-- {
+		locals[i] = vm.popUint64()
 	}
 
-	for i := range fnExpect.ReturnTypes {
-		if fnExpect.ReturnTypes[i] != fnActual.Sig.ReturnTypes[i] {
-			panic(ErrSignatureMismatch)
-		}
+	// The caller's operand stack is dead once the arguments have been
+	// popped, so its backing array can be reused by the callee.
+	stack := vm.ctx.stack[:0]
+	if cap(stack) < compiled.maxDepth+1 {
+		stack = make([]uint64, 0, compiled.maxDepth+1)
 	}
 
-	vm.funcs[elemIndex].call(vm, int64(elemIndex))
+	vm.ctx = context{
+		stack:   stack,
+		locals:  locals,
+		code:    compiled.code,
+		asm:     compiled.asm,
+		pc:      0,
+		curFunc: index,
+	}
+	return compiled, true
 }
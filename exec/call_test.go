@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
 )
 
 func TestHostCall(t *testing.T) {
@@ -237,3 +238,284 @@ func TestHostTerminate(t *testing.T) {
 		t.Fatalf("Terminate did not abort execution: abort=%v, pc=%#x", vm.abort, vm.ctx.pc)
 	}
 }
+
+func TestTailCall(t *testing.T) {
+	sumSig := wasm.FunctionSig{
+		Form:        0x60,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+	unarySig := wasm.FunctionSig{
+		Form:        0x60,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{sumSig, unarySig}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0, 0, 1, 1}}
+
+	// (func $count (param $n i32) (param $acc i32) (result i32)
+	//   (if (result i32) (i32.eqz (get_local $n))
+	//     (then (get_local $acc))
+	//     (else (return_call $count
+	//       (i32.sub (get_local $n) (i32.const 1))
+	//       (i32.add (get_local $acc) (i32.const 1))))))
+	count := wasm.FunctionBody{Module: m, Code: []byte{
+		ops.GetLocal, 0, ops.I32Eqz, ops.If, byte(wasm.ValueTypeI32),
+		ops.GetLocal, 1,
+		ops.Else,
+		ops.GetLocal, 0, ops.I32Const, 1, ops.I32Sub,
+		ops.GetLocal, 1, ops.I32Const, 1, ops.I32Add,
+		ops.ReturnCall, 0,
+		ops.End,
+	}}
+	// Same as $count, but calls itself through table entry 0.
+	countIndirect := wasm.FunctionBody{Module: m, Code: []byte{
+		ops.GetLocal, 0, ops.I32Eqz, ops.If, byte(wasm.ValueTypeI32),
+		ops.GetLocal, 1,
+		ops.Else,
+		ops.GetLocal, 0, ops.I32Const, 1, ops.I32Sub,
+		ops.GetLocal, 1, ops.I32Const, 1, ops.I32Add,
+		ops.I32Const, 0,
+		ops.ReturnCallIndirect, 0, 0,
+		ops.End,
+	}}
+	// (func (param i32) (result i32) (return_call $add3 (get_local 0)))
+	callHost := wasm.FunctionBody{Module: m, Code: []byte{
+		ops.GetLocal, 0,
+		ops.ReturnCall, 2,
+	}}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{count, countIndirect, callHost}}
+	m.FunctionIndexSpace = []wasm.Function{
+		{Sig: &sumSig, Body: &m.Code.Bodies[0]},
+		{Sig: &sumSig, Body: &m.Code.Bodies[1]},
+		{Sig: &unarySig, Host: reflect.ValueOf(add3), Body: &wasm.FunctionBody{}},
+		{Sig: &unarySig, Body: &m.Code.Bodies[2]},
+	}
+	m.TableIndexSpace = [][]wasm.TableEntry{{{Index: 1, Initialized: true}}}
+
+	vm, err := NewVM(m)
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+
+	// Deep enough to exhaust the Go stack if every call recursed
+	// through execCode.
+	const depth = 1000000
+	for _, fn := range []int64{0, 1} {
+		res, err := vm.ExecCode(fn, depth, 7)
+		if err != nil {
+			t.Fatalf("function %d: %v", fn, err)
+		}
+		if got, want := res.(uint32), uint32(depth+7); got != want {
+			t.Errorf("function %d: got %d, want %d", fn, got, want)
+		}
+	}
+
+	res, err := vm.ExecCode(3, 39)
+	if err != nil {
+		t.Fatalf("tail call to host function: %v", err)
+	}
+	if got := res.(uint32); got != 42 {
+		t.Errorf("tail call to host function: got %d, want 42", got)
+	}
+
+	want := wasm.DisabledFeatureError(wasm.FeatureTailCall)
+	if _, err := NewVM(m, EnableFeatures(wasm.FeaturesMVP)); err != want {
+		t.Errorf("tail calls disabled: got error %v, want %v", err, want)
+	}
+}
+
+func TestMaxCallDepth(t *testing.T) {
+	sig := wasm.FunctionSig{
+		Form:        0x60,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{sig}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0}}
+	// (func $depth (param $n i32) (result i32)
+	//   (if (result i32) (i32.eqz (get_local $n))
+	//     (then (i32.const 0))
+	//     (else (call $depth (i32.sub (get_local $n) (i32.const 1))))))
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{{Module: m, Code: []byte{
+		ops.GetLocal, 0, ops.I32Eqz, ops.If, byte(wasm.ValueTypeI32),
+		ops.I32Const, 0,
+		ops.Else,
+		ops.GetLocal, 0, ops.I32Const, 1, ops.I32Sub,
+		ops.Call, 0,
+		ops.End,
+	}}}}
+	m.FunctionIndexSpace = []wasm.Function{{Sig: &sig, Body: &m.Code.Bodies[0]}}
+
+	vm, err := NewVM(m, MaxCallDepth(100))
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	vm.RecoverPanic = true
+
+	for _, tc := range []struct {
+		calls uint64
+		err   error
+	}{
+		{100, nil},
+		{101, ErrCallStackExhausted},
+		{100, nil}, // The depth is restored after a trap
+	} {
+		if _, err := vm.ExecCode(0, tc.calls); err != tc.err {
+			t.Errorf("%d nested calls: got error %v, want %v", tc.calls, err, tc.err)
+		}
+	}
+}
+
+func hostMalloc(proc *Process, n int32) int32 {
+	res, err := proc.Call("malloc", uint64(n))
+	if err != nil {
+		panic(err)
+	}
+	ptr := res.(uint32)
+	if err := proc.WriteBytes(ptr, []byte("hi")); err != nil {
+		panic(err)
+	}
+	return int32(ptr)
+}
+
+func TestProcessCall(t *testing.T) {
+	unarySig := wasm.FunctionSig{
+		Form:        0x60,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{unarySig}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0, 0}}
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.LinearMemoryIndexSpace = [][]byte{nil}
+	m.GlobalIndexSpace = []wasm.GlobalEntry{{
+		Type: wasm.GlobalVar{Type: wasm.ValueTypeI32, Mutable: true},
+		Init: []byte{ops.I32Const, 16, ops.End},
+	}}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{
+		// (func $malloc (param i32) (result i32)
+		//   (get_global 0)
+		//   (set_global 0 (i32.add (get_global 0) (get_local 0))))
+		{Module: m, Code: []byte{
+			ops.GetGlobal, 0,
+			ops.GetGlobal, 0, ops.GetLocal, 0, ops.I32Add, ops.SetGlobal, 0,
+		}},
+		// (func (param i32) (result i32)
+		//   (i32.add (i32.const 10) (call $hostMalloc (get_local 0))))
+		{Module: m, Code: []byte{
+			ops.I32Const, 10, ops.GetLocal, 0, ops.Call, 1, ops.I32Add,
+		}},
+	}}
+	m.FunctionIndexSpace = []wasm.Function{
+		{Sig: &unarySig, Body: &m.Code.Bodies[0]},
+		{Sig: &unarySig, Host: reflect.ValueOf(hostMalloc), Body: &wasm.FunctionBody{}},
+		{Sig: &unarySig, Body: &m.Code.Bodies[1]},
+	}
+	m.Export = &wasm.SectionExports{Entries: map[string]wasm.ExportEntry{
+		"malloc": {FieldStr: "malloc", Kind: wasm.ExternalFunction, Index: 0},
+	}}
+
+	vm, err := NewVM(m)
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+
+	for _, want := range []uint32{26, 28} {
+		res, err := vm.ExecCode(2, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := res.(uint32); got != want {
+			t.Errorf("got %d, want %d", got, want)
+		}
+	}
+	if got := string(vm.Memory()[16:20]); got != "hihi" {
+		t.Errorf("got memory %q, want %q", got, "hihi")
+	}
+
+	proc := NewProcess(vm)
+	res, err := proc.Call(int64(0), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(uint32); got != 20 {
+		t.Errorf("call by index: got %d, want 20", got)
+	}
+
+	for _, tc := range []struct {
+		fn  interface{}
+		err error
+	}{
+		{"free", UnknownExportError("free")},
+		{99, InvalidFunctionIndexError(99)},
+	} {
+		if _, err := proc.Call(tc.fn, 0); err != tc.err {
+			t.Errorf("call %v: got error %v, want %v", tc.fn, err, tc.err)
+		}
+	}
+	if _, err := proc.Call(1, 0); err != ErrHostFunction {
+		t.Errorf("call to a host function: got error %v, want %v", err, ErrHostFunction)
+	}
+}
+
+func hostTerminate(proc *Process, n int32) int32 {
+	proc.Terminate()
+	return n
+}
+
+func hostCallTerminating(proc *Process, n int32) int32 {
+	if _, err := proc.Call(0, uint64(n)); err != nil {
+		panic(err)
+	}
+	return n + 1
+}
+
+func TestProcessCallTerminate(t *testing.T) {
+	unarySig := wasm.FunctionSig{
+		Form:        0x60,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{unarySig}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0, 0, 0, 0}}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{
+		// (func (param i32) (result i32) (call $hostTerminate (get_local 0)))
+		{Module: m, Code: []byte{ops.GetLocal, 0, ops.Call, 1}},
+		// (func (param i32) (result i32)
+		//   (i32.add (call $hostCallTerminating (get_local 0)) (i32.const 1)))
+		{Module: m, Code: []byte{ops.GetLocal, 0, ops.Call, 2, ops.I32Const, 1, ops.I32Add}},
+	}}
+	m.FunctionIndexSpace = []wasm.Function{
+		{Sig: &unarySig, Body: &m.Code.Bodies[0]},
+		{Sig: &unarySig, Host: reflect.ValueOf(hostTerminate), Body: &wasm.FunctionBody{}},
+		{Sig: &unarySig, Host: reflect.ValueOf(hostCallTerminating), Body: &wasm.FunctionBody{}},
+		{Sig: &unarySig, Body: &m.Code.Bodies[1]},
+	}
+
+	vm, err := NewVM(m)
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+
+	// The termination of the nested call does not terminate the calling
+	// function.
+	res, err := vm.ExecCode(3, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := res.(uint32); !ok || got != 3 {
+		t.Errorf("got %v, want 3", res)
+	}
+}
@@ -91,3 +91,102 @@ func (vm *VM) f64ConvertUI64() {
 func (vm *VM) f64PromoteF32() {
 	vm.pushFloat64(float64(vm.popFloat32()))
 }
+
+func (vm *VM) i32Extend8S() {
+	vm.pushInt32(int32(int8(vm.popInt32())))
+}
+
+func (vm *VM) i32Extend16S() {
+	vm.pushInt32(int32(int16(vm.popInt32())))
+}
+
+func (vm *VM) i64Extend8S() {
+	vm.pushInt64(int64(int8(vm.popInt64())))
+}
+
+func (vm *VM) i64Extend16S() {
+	vm.pushInt64(int64(int16(vm.popInt64())))
+}
+
+func (vm *VM) i64Extend32S() {
+	vm.pushInt64(int64(int32(vm.popInt64())))
+}
+
+// The saturating conversions convert NaN to 0, and the values out of the
+// range of their result to its bounds.
+
+func truncSatI32(f float64) int32 {
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f >= -math.MinInt32:
+		return math.MaxInt32
+	case f <= math.MinInt32:
+		return math.MinInt32
+	}
+	return int32(f)
+}
+
+func truncSatU32(f float64) uint32 {
+	switch {
+	case math.IsNaN(f) || f <= 0:
+		return 0
+	case f >= math.MaxUint32:
+		return math.MaxUint32
+	}
+	return uint32(f)
+}
+
+func truncSatI64(f float64) int64 {
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f >= -math.MinInt64:
+		return math.MaxInt64
+	case f <= math.MinInt64:
+		return math.MinInt64
+	}
+	return int64(f)
+}
+
+func truncSatU64(f float64) uint64 {
+	switch {
+	case math.IsNaN(f) || f <= 0:
+		return 0
+	case f >= 1<<64:
+		return math.MaxUint64
+	}
+	return uint64(f)
+}
+
+func (vm *VM) i32TruncSSatF32() {
+	vm.pushInt32(truncSatI32(float64(vm.popFloat32())))
+}
+
+func (vm *VM) i32TruncUSatF32() {
+	vm.pushUint32(truncSatU32(float64(vm.popFloat32())))
+}
+
+func (vm *VM) i32TruncSSatF64() {
+	vm.pushInt32(truncSatI32(vm.popFloat64()))
+}
+
+func (vm *VM) i32TruncUSatF64() {
+	vm.pushUint32(truncSatU32(vm.popFloat64()))
+}
+
+func (vm *VM) i64TruncSSatF32() {
+	vm.pushInt64(truncSatI64(float64(vm.popFloat32())))
+}
+
+func (vm *VM) i64TruncUSatF32() {
+	vm.pushUint64(truncSatU64(float64(vm.popFloat32())))
+}
+
+func (vm *VM) i64TruncSSatF64() {
+	vm.pushInt64(truncSatI64(vm.popFloat64()))
+}
+
+func (vm *VM) i64TruncUSatF64() {
+	vm.pushUint64(truncSatU64(vm.popFloat64()))
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"fmt"
+
+	"github.com/go-interpreter/wagon/exec/internal/compile"
+)
+
+// Exception is a WebAssembly exception, as thrown by the throw operator
+// or by a host function through (*Process).Throw.
+// It is returned by (*VM).ExecCode when it is not caught by the module.
+type Exception struct {
+	Tag    uint32   // The index of the exception tag in the tag index space
+	Values []uint64 // The values carried by the exception
+}
+
+func (e *Exception) Error() string {
+	return fmt.Sprintf("exec: uncaught exception with tag %d", e.Tag)
+}
+
+// tryState is the state of a try block in an executing function.
+type tryState struct {
+	height int        // the stack height on entering the block
+	caught *Exception // the exception being handled, for rethrow
+}
+
+func (vm *VM) try(compiled compiledFunction) {
+	index := vm.fetchInt64()
+	if vm.ctx.tries == nil {
+		vm.ctx.tries = make([]tryState, len(compiled.codeMeta.TryTables))
+	}
+	vm.ctx.tries[index].height = len(vm.ctx.stack)
+}
+
+func (vm *VM) throw() {
+	tag := vm.fetchUint32()
+	sig, err := vm.module.GetTagSig(tag)
+	if err != nil {
+		panic(err)
+	}
+	values := make([]uint64, len(sig.ParamTypes))
+	for i := len(values) - 1; i >= 0; i-- {
+		values[i] = vm.popUint64()
+	}
+	vm.exception = &Exception{Tag: tag, Values: values}
+}
+
+func (vm *VM) rethrow() {
+	index := vm.fetchInt64()
+	vm.exception = vm.ctx.tries[index].caught
+}
+
+// catchException looks for a handler of the pending exception in the
+// function being executed. If one is found, the stack is unwound to the
+// height of its try block, the exception values are pushed, and execution
+// resumes at the handler. Otherwise, catchException returns false and the
+// exception should be passed to the caller.
+func (vm *VM) catchException(compiled compiledFunction) bool {
+	tables := compiled.codeMeta.TryTables
+	pc := vm.ctx.pc
+	for i := len(tables) - 1; i >= 0; i-- {
+		table := tables[i]
+		if pc <= table.Start || pc > table.End {
+			continue
+		}
+		if table.Delegate {
+			if table.DelegateTarget < 0 {
+				return false
+			}
+			// Tables in between the delegating block and its target
+			// are not enclosing the target, and are skipped.
+			i = table.DelegateTarget + 1
+			continue
+		}
+
+		addr, catchAll, ok := findHandler(table, vm.exception)
+		if !ok {
+			continue
+		}
+		state := &vm.ctx.tries[i]
+		vm.ctx.stack = vm.ctx.stack[:state.height]
+		if !catchAll {
+			for _, v := range vm.exception.Values {
+				vm.pushUint64(v)
+			}
+		}
+		state.caught = vm.exception
+		vm.exception = nil
+		vm.ctx.pc = addr
+		return true
+	}
+	return false
+}
+
+// findHandler returns the address of the handler of table for exception e,
+// and whether it is a catch_all handler.
+func findHandler(table *compile.TryTable, e *Exception) (addr int64, catchAll, ok bool) {
+	for _, c := range table.Catches {
+		if c.Tag == e.Tag {
+			return c.Addr, false, true
+		}
+	}
+	if table.HasCatchAll {
+		return table.CatchAllAddr, true, true
+	}
+	return 0, false, false
+}
+
+// Throw throws an exception with the given tag and values from a host
+// function. The exception is raised once the host function returns, and
+// any value it returns is discarded.
+func (proc *Process) Throw(tag uint32, values ...uint64) error {
+	sig, err := proc.vm.module.GetTagSig(tag)
+	if err != nil {
+		return err
+	}
+	if len(values) != len(sig.ParamTypes) {
+		return ErrInvalidArgumentCount
+	}
+	proc.vm.exception = &Exception{
+		Tag:    tag,
+		Values: append([]uint64(nil), values...),
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+func hostThrow(proc *Process, v int32) int32 {
+	if err := proc.Throw(0, uint64(v)); err != nil {
+		panic(err)
+	}
+	return 0
+}
+
+func TestExceptions(t *testing.T) {
+	unarySig := wasm.FunctionSig{
+		Form:        0x60,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+	tagSig := wasm.FunctionSig{
+		Form:       0x60,
+		ParamTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{unarySig, tagSig}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0, 0, 0, 0, 0, 0, 0}}
+	m.Tags = &wasm.SectionTags{Entries: []wasm.Tag{{Type: 1}}}
+	m.TagIndexSpace = m.Tags.Entries
+
+	i32 := byte(wasm.ValueTypeI32)
+	bodies := [][]byte{
+		// $throw: (throw 0 (get_local 0))
+		{ops.GetLocal, 0, ops.Throw, 0},
+		// $catch: the stack of the try block is unwound before the
+		// handler runs.
+		{
+			ops.Try, i32,
+			ops.I32Const, 5, ops.GetLocal, 0, ops.Call, 0, ops.I32Add,
+			ops.Catch, 0,
+			ops.I32Const, 1, ops.I32Add,
+			ops.End,
+		},
+		// $delegate: the inner try passes the exception on to the
+		// outer one.
+		{
+			ops.Try, i32,
+			ops.Try, i32,
+			ops.GetLocal, 0, ops.Call, 0,
+			ops.Delegate, 0,
+			ops.CatchAll,
+			ops.I32Const, 0x7f,
+			ops.End,
+		},
+		// $rethrow
+		{
+			ops.Try, i32,
+			ops.Try, i32,
+			ops.GetLocal, 0, ops.Call, 0,
+			ops.CatchAll,
+			ops.Rethrow, 0,
+			ops.End,
+			ops.Catch, 0,
+			ops.I32Const, 2, ops.I32Add,
+			ops.End,
+		},
+		// $uncaught
+		{ops.GetLocal, 0, ops.Call, 0},
+		// $host: catches an exception thrown by a host function.
+		{
+			ops.Try, i32,
+			ops.GetLocal, 0, ops.Call, 6,
+			ops.Catch, 0,
+			ops.End,
+		},
+	}
+
+	m.Code = &wasm.SectionCode{}
+	for _, code := range bodies {
+		m.Code.Bodies = append(m.Code.Bodies, wasm.FunctionBody{Module: m, Code: code})
+	}
+	for i := range m.Code.Bodies {
+		m.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{
+			Sig:  &unarySig,
+			Body: &m.Code.Bodies[i],
+		})
+	}
+	m.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{
+		Sig:  &unarySig,
+		Host: reflect.ValueOf(hostThrow),
+		Body: &wasm.FunctionBody{},
+	})
+
+	vm, err := NewVM(m)
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		fn   int64
+		want uint32
+	}{
+		{"catch", 1, 42},
+		{"delegate", 2, 0xffffffff},
+		{"rethrow", 3, 43},
+		{"host", 5, 41},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := vm.ExecCode(tc.fn, 41)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := res.(uint32); got != tc.want {
+				t.Errorf("got %d, want %d", got, tc.want)
+			}
+		})
+	}
+
+	_, err = vm.ExecCode(4, 41)
+	want := &Exception{Tag: 0, Values: []uint64{41}}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("uncaught exception: got %v, want %v", err, want)
+	}
+
+	// The VM should be usable after an uncaught exception.
+	res, err := vm.ExecCode(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(uint32); got != 2 {
+		t.Errorf("got %d, want 2", got)
+	}
+}
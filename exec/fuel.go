@@ -0,0 +1,67 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+var (
+	// ErrOutOfFuel is the trap raised when the VM runs out of fuel, see
+	// (*VM).SetFuel.
+	ErrOutOfFuel = errors.New("exec: out of fuel")
+	// ErrInterrupted is the trap raised when the execution of the VM is
+	// interrupted with (*VM).Interrupt.
+	ErrInterrupted = errors.New("exec: execution interrupted")
+)
+
+// SetFuel limits the number of instructions the VM may execute to n, after
+// which it traps with ErrOutOfFuel. Each dispatched instruction consumes one
+// unit of fuel; a run of natively compiled instructions counts as one.
+// Metering is disabled by SetFuel(0), which is the default.
+func (vm *VM) SetFuel(n uint64) {
+	vm.fuel = n
+	vm.metered = n != 0
+	vm.limited = vm.metered || vm.interrupt
+}
+
+// Fuel returns the fuel left to the VM, if metering is enabled.
+func (vm *VM) Fuel() uint64 {
+	return vm.fuel
+}
+
+// Interrupt stops the code running in the VM, which traps with
+// ErrInterrupted before executing its next instruction. Interrupt may be
+// called concurrently with the execution, for instance from a timer. It has
+// no effect unless the VM was created with EnableInterrupt(true).
+//
+// An interruption requested while no code is running stops the next call,
+// unless it is cancelled with ClearInterrupt.
+func (vm *VM) Interrupt() {
+	atomic.StoreUint32(&vm.interrupted, 1)
+}
+
+// ClearInterrupt cancels an interruption requested with Interrupt that
+// did not stop the execution yet.
+func (vm *VM) ClearInterrupt() {
+	atomic.StoreUint32(&vm.interrupted, 0)
+}
+
+// consumeFuel is called before the execution of each instruction when the
+// VM is metered or interruptible, and traps if the VM was interrupted or ran
+// out of fuel.
+func (vm *VM) consumeFuel() {
+	if vm.interrupt && atomic.LoadUint32(&vm.interrupted) != 0 {
+		atomic.StoreUint32(&vm.interrupted, 0)
+		panic(ErrInterrupted)
+	}
+	if vm.metered {
+		if vm.fuel == 0 {
+			panic(ErrOutOfFuel)
+		}
+		vm.fuel--
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+// loopModule returns a module whose function 0 loops forever, and function 1
+// returns 42.
+func loopModule() *wasm.Module {
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{
+		{Form: 0x60},
+		{Form: 0x60, ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32}},
+	}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0, 1}}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{
+		// (func (loop (br 0)))
+		{Module: m, Code: []byte{ops.Loop, 0x40, ops.Br, 0, ops.End}},
+		// (func (result i32) (i32.const 42))
+		{Module: m, Code: []byte{ops.I32Const, 42}},
+	}}
+	for i, typ := range m.Function.Types {
+		m.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{
+			Sig:  &m.Types.Entries[typ],
+			Body: &m.Code.Bodies[i],
+		})
+	}
+	return m
+}
+
+func TestFuel(t *testing.T) {
+	vm, err := NewVM(loopModule())
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	vm.RecoverPanic = true
+
+	vm.SetFuel(1000)
+	if _, err := vm.ExecCode(0); err != ErrOutOfFuel {
+		t.Fatalf("got error %v, want %v", err, ErrOutOfFuel)
+	}
+	if vm.Fuel() != 0 {
+		t.Errorf("got %d fuel left, want 0", vm.Fuel())
+	}
+
+	vm.SetFuel(1000)
+	res, err := vm.ExecCode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res != uint32(42) {
+		t.Errorf("got %v, want 42", res)
+	}
+	if vm.Fuel() >= 1000 {
+		t.Errorf("no fuel was consumed")
+	}
+}
+
+func TestInterrupt(t *testing.T) {
+	vm, err := NewVM(loopModule(), EnableInterrupt(true))
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	vm.RecoverPanic = true
+
+	timer := time.AfterFunc(10*time.Millisecond, vm.Interrupt)
+	defer timer.Stop()
+	if _, err := vm.ExecCode(0); err != ErrInterrupted {
+		t.Fatalf("got error %v, want %v", err, ErrInterrupted)
+	}
+
+	// The interruption only stops the running call.
+	if _, err := vm.ExecCode(1); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClearInterrupt(t *testing.T) {
+	vm, err := NewVM(loopModule(), EnableInterrupt(true))
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	vm.RecoverPanic = true
+
+	// An interruption requested between calls stops the next one...
+	vm.Interrupt()
+	if _, err := vm.ExecCode(1); err != ErrInterrupted {
+		t.Fatalf("got error %v, want %v", err, ErrInterrupted)
+	}
+	// ...unless it is cleared.
+	vm.Interrupt()
+	vm.ClearInterrupt()
+	if _, err := vm.ExecCode(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupt has no effect unless enabled.
+	vm, err = NewVM(loopModule())
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	vm.Interrupt()
+	if _, err := vm.ExecCode(1); err != nil {
+		t.Fatal(err)
+	}
+}
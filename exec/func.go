@@ -74,6 +74,10 @@ func (fn goFunction) call(vm *VM, index int64) {
 	}
 
 	rtrns := fn.val.Call(args)
+	if vm.exception != nil {
+		// the host function threw an exception, its results are discarded.
+		return
+	}
 	for i, out := range rtrns {
 		kind := out.Kind()
 		switch kind {
@@ -90,6 +94,14 @@ func (fn goFunction) call(vm *VM, index int64) {
 }
 
 func (compiled compiledFunction) call(vm *VM, index int64) {
+	if vm.maxCallDepth > 0 {
+		if vm.callDepth >= vm.maxCallDepth {
+			panic(ErrCallStackExhausted)
+		}
+		vm.callDepth++
+		defer func() { vm.callDepth-- }()
+	}
+
 	// Make space on the stack for all intermediate values and
 	// a possible return value.
 	newStack := make([]uint64, 0, compiled.maxDepth+1)
@@ -116,7 +128,7 @@ func (compiled compiledFunction) call(vm *VM, index int64) {
 	//restore execution context
 	vm.ctx = prevCtxt
 
-	if compiled.returns {
+	if compiled.returns && vm.exception == nil {
 		vm.pushUint64(rtrn)
 	}
 }
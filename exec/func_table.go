@@ -143,6 +143,21 @@ func (vm *VM) newFuncTable() {
 	vm.funcTable[ops.F64ConvertUI64] = vm.f64ConvertUI64
 	vm.funcTable[ops.F64PromoteF32] = vm.f64PromoteF32
 
+	vm.funcTable[ops.I32Extend8S] = vm.i32Extend8S
+	vm.funcTable[ops.I32Extend16S] = vm.i32Extend16S
+	vm.funcTable[ops.I64Extend8S] = vm.i64Extend8S
+	vm.funcTable[ops.I64Extend16S] = vm.i64Extend16S
+	vm.funcTable[ops.I64Extend32S] = vm.i64Extend32S
+
+	vm.funcTable[ops.I32TruncSSatF32] = vm.i32TruncSSatF32
+	vm.funcTable[ops.I32TruncUSatF32] = vm.i32TruncUSatF32
+	vm.funcTable[ops.I32TruncSSatF64] = vm.i32TruncSSatF64
+	vm.funcTable[ops.I32TruncUSatF64] = vm.i32TruncUSatF64
+	vm.funcTable[ops.I64TruncSSatF32] = vm.i64TruncSSatF32
+	vm.funcTable[ops.I64TruncUSatF32] = vm.i64TruncUSatF32
+	vm.funcTable[ops.I64TruncSSatF64] = vm.i64TruncSSatF64
+	vm.funcTable[ops.I64TruncUSatF64] = vm.i64TruncUSatF64
+
 	vm.funcTable[ops.I32Load] = vm.i32Load
 	vm.funcTable[ops.I64Load] = vm.i64Load
 	vm.funcTable[ops.F32Load] = vm.f32Load
@@ -168,6 +183,8 @@ func (vm *VM) newFuncTable() {
 	vm.funcTable[ops.I64Store32] = vm.i64Store32
 	vm.funcTable[ops.CurrentMemory] = vm.currentMemory
 	vm.funcTable[ops.GrowMemory] = vm.growMemory
+	vm.funcTable[ops.MemoryCopy] = vm.memoryCopy
+	vm.funcTable[ops.MemoryFill] = vm.memoryFill
 
 	vm.funcTable[ops.Drop] = vm.drop
 	vm.funcTable[ops.Select] = vm.selectOp
@@ -0,0 +1,70 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// GlobalInfo describes a global variable of the VM's module.
+type GlobalInfo struct {
+	Index uint32         // The index of the global in the global index space
+	Name  string         // The name the global is exported as, if any
+	Type  wasm.GlobalVar // The value type and mutability of the global
+}
+
+// Globals returns the globals of the VM's module, in index order.
+func (vm *VM) Globals() []GlobalInfo {
+	names := make(map[uint32]string)
+	if vm.module.Export != nil {
+		for _, name := range vm.module.Export.Names {
+			entry := vm.module.Export.Entries[name]
+			if _, ok := names[entry.Index]; !ok && entry.Kind == wasm.ExternalGlobal {
+				names[entry.Index] = name
+			}
+		}
+	}
+
+	globals := make([]GlobalInfo, len(vm.module.GlobalIndexSpace))
+	for i, global := range vm.module.GlobalIndexSpace {
+		globals[i] = GlobalInfo{
+			Index: uint32(i),
+			Name:  names[uint32(i)],
+			Type:  global.Type,
+		}
+	}
+	return globals
+}
+
+// GlobalValue returns the value of the global at the given index as an
+// int32, int64, float32 or float64, depending on its value type.
+func (vm *VM) GlobalValue(index uint32) (interface{}, error) {
+	global := vm.module.GetGlobal(int(index))
+	if global == nil {
+		return nil, wasm.InvalidGlobalIndexError(index)
+	}
+	g := wasm.Global{Type: global.Type}
+	g.SetBits(vm.getGlobalBits(index))
+	return g.Get(), nil
+}
+
+// SetGlobalValue sets the value of the mutable global at the given index.
+// The type of v must match the value type of the global, as returned by
+// GlobalValue.
+func (vm *VM) SetGlobalValue(index uint32, v interface{}) error {
+	global := vm.module.GetGlobal(int(index))
+	if global == nil {
+		return wasm.InvalidGlobalIndexError(index)
+	}
+	if !global.Type.Mutable {
+		return wasm.ErrImmutableGlobal
+	}
+	g, err := wasm.NewGlobal(global.Type, v)
+	if err != nil {
+		return err
+	}
+	vm.setGlobalBits(index, g.Bits())
+	return nil
+}
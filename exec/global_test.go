@@ -0,0 +1,75 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+func TestGlobalsHostAPI(t *testing.T) {
+	m, err := wasm.ReadModule(bytes.NewReader(moduleExportGlobal), nil)
+	if err != nil {
+		t.Fatalf("Could not read module: %v", err)
+	}
+	// (global i64 (i64.const 5))
+	m.GlobalIndexSpace = append(m.GlobalIndexSpace, wasm.GlobalEntry{
+		Type: wasm.GlobalVar{Type: wasm.ValueTypeI64},
+		Init: []byte{0x42, 0x05, 0x0b},
+	})
+	vm, err := NewVM(m)
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+
+	want := []GlobalInfo{
+		{Index: 0, Name: "g", Type: wasm.GlobalVar{Type: wasm.ValueTypeI32, Mutable: true}},
+		{Index: 1, Type: wasm.GlobalVar{Type: wasm.ValueTypeI64}},
+	}
+	if got := vm.Globals(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Globals: got %+v, want %+v", got, want)
+	}
+
+	for i, want := range []interface{}{int32(42), int64(5)} {
+		got, err := vm.GlobalValue(uint32(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("global %d: got %v (%T), want %v (%T)", i, got, got, want, want)
+		}
+	}
+
+	if err := vm.SetGlobalValue(0, int32(-3)); err != nil {
+		t.Fatal(err)
+	}
+	res, err := vm.ExecCode(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := int32(res.(uint32)); got != -3 {
+		t.Errorf("get after SetGlobalValue: got %d, want -3", got)
+	}
+
+	for _, tc := range []struct {
+		index uint32
+		v     interface{}
+		err   error
+	}{
+		{0, int64(1), wasm.InvalidGlobalValueError{Type: wasm.ValueTypeI32, Value: int64(1)}},
+		{1, int64(1), wasm.ErrImmutableGlobal},
+		{2, int32(1), wasm.InvalidGlobalIndexError(2)},
+	} {
+		if err := vm.SetGlobalValue(tc.index, tc.v); err != tc.err {
+			t.Errorf("SetGlobalValue(%d, %v): got error %v, want %v", tc.index, tc.v, err, tc.err)
+		}
+	}
+	if _, err := vm.GlobalValue(2); err != wasm.InvalidGlobalIndexError(2) {
+		t.Errorf("GlobalValue(2): got error %v", err)
+	}
+}
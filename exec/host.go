@@ -0,0 +1,92 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+var procType = reflect.TypeOf((*Process)(nil))
+
+// NewHostModule returns a module exporting the Go functions of funcs under
+// their key, for instance to be returned by a wasm.ResolveFunc.
+// The first parameter of each function must be a *Process, the others and
+// its results must be of type int32, uint32, int64, uint64, float32 or
+// float64, and determine the signature of the exported function.
+func NewHostModule(funcs map[string]interface{}) (*wasm.Module, error) {
+	names := make([]string, 0, len(funcs))
+	for name := range funcs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	m := wasm.NewModule()
+	m.Types = &wasm.SectionTypes{}
+	m.Export = &wasm.SectionExports{Entries: make(map[string]wasm.ExportEntry)}
+	for _, name := range names {
+		fn := reflect.ValueOf(funcs[name])
+		sig, err := hostSignature(fn.Type())
+		if err != nil {
+			return nil, fmt.Errorf("exec: host function %s: %v", name, err)
+		}
+		m.Export.Entries[name] = wasm.ExportEntry{
+			FieldStr: name,
+			Kind:     wasm.ExternalFunction,
+			Index:    uint32(len(m.FunctionIndexSpace)),
+		}
+		m.Export.Names = append(m.Export.Names, name)
+		m.Types.Entries = append(m.Types.Entries, sig)
+		m.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{
+			Sig:  &sig,
+			Host: fn,
+			Body: &wasm.FunctionBody{},
+		})
+	}
+	return m, nil
+}
+
+// hostSignature returns the signature of the host function of type typ.
+func hostSignature(typ reflect.Type) (wasm.FunctionSig, error) {
+	sig := wasm.FunctionSig{Form: wasm.TypeFunc}
+	if typ.Kind() != reflect.Func {
+		return sig, fmt.Errorf("%v is not a function", typ)
+	}
+	if typ.NumIn() == 0 || typ.In(0) != procType {
+		return sig, fmt.Errorf("the first parameter of %v is not a %v", typ, procType)
+	}
+	for i := 1; i < typ.NumIn(); i++ {
+		t, err := hostValueType(typ.In(i))
+		if err != nil {
+			return sig, err
+		}
+		sig.ParamTypes = append(sig.ParamTypes, t)
+	}
+	for i := 0; i < typ.NumOut(); i++ {
+		t, err := hostValueType(typ.Out(i))
+		if err != nil {
+			return sig, err
+		}
+		sig.ReturnTypes = append(sig.ReturnTypes, t)
+	}
+	return sig, nil
+}
+
+func hostValueType(typ reflect.Type) (wasm.ValueType, error) {
+	switch typ.Kind() {
+	case reflect.Int32, reflect.Uint32:
+		return wasm.ValueTypeI32, nil
+	case reflect.Int64, reflect.Uint64:
+		return wasm.ValueTypeI64, nil
+	case reflect.Float32:
+		return wasm.ValueTypeF32, nil
+	case reflect.Float64:
+		return wasm.ValueTypeF64, nil
+	}
+	return 0, fmt.Errorf("unsupported type %v", typ)
+}
@@ -0,0 +1,45 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+func TestNewHostModule(t *testing.T) {
+	m, err := NewHostModule(map[string]interface{}{
+		"add": func(proc *Process, a int32, b uint64) float64 { return float64(a) + float64(b) },
+		"nop": func(proc *Process) {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := m.Export.Entries["add"]
+	if !ok || e.Kind != wasm.ExternalFunction {
+		t.Fatalf("add is not exported")
+	}
+	sig := m.GetFunction(int(e.Index)).Sig
+	want := wasm.FunctionSig{
+		Form:        wasm.TypeFunc,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI64},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeF64},
+	}
+	if !reflect.DeepEqual(*sig, want) {
+		t.Errorf("got signature %v, want %v", *sig, want)
+	}
+
+	for _, fn := range []interface{}{
+		func(a int32) int32 { return a },
+		func(proc *Process, s string) {},
+		42,
+	} {
+		if _, err := NewHostModule(map[string]interface{}{"f": fn}); err == nil {
+			t.Errorf("%T: expected an error", fn)
+		}
+	}
+}
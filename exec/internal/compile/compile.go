@@ -78,6 +78,9 @@ var (
 	// OpDiscardPreserveTop discards a given number of elements from the
 	// execution stack, while preserving the value on the top of the stack.
 	OpDiscardPreserveTop byte = 0x05
+	// OpTry marks the entry of a try block. Its immediate is the index
+	// of the block in BytecodeMetadata.TryTables.
+	OpTry byte = 0x06
 )
 
 const (
@@ -115,6 +118,32 @@ type BranchTable struct {
 	blocksLen     int      // The length of the blocks map in Compile when this table was initialized
 }
 
+// Catch is a handler of a try block that is run when an exception
+// with the given tag is thrown from within the block.
+type Catch struct {
+	Tag  uint32 // The index of the tag handled by this catch
+	Addr int64  // The absolute address of the handler
+}
+
+// TryTable describes a try block of the compiled bytecode.
+// An exception thrown while the program counter is in (Start, End]
+// is handled by the block, either by one of its handlers or by
+// delegating it to an enclosing try block.
+type TryTable struct {
+	Start int64 // The address right after the OpTry instruction
+	End   int64 // The address at which the try body ends
+
+	Catches      []Catch
+	HasCatchAll  bool
+	CatchAllAddr int64 // The absolute address of the catch_all handler
+
+	// Delegate is set if the exceptions thrown in the block are
+	// passed on to the try block at index DelegateTarget.
+	// A DelegateTarget of -1 passes the exceptions to the caller.
+	Delegate       bool
+	DelegateTarget int
+}
+
 // block stores the information relevant for a block created by a control operator
 // sequence (if...else...end, loop...end, and block...end)
 type block struct {
@@ -140,6 +169,13 @@ type block struct {
 
 	patchOffsets []int64 // A list of offsets in the bytecode stream that need to be patched with the correct jump addresses
 
+	// Whether this block is created by a 'try' operator, in which case
+	// tryIndex is the index of its TryTable. inCatch is set once the
+	// first catch or catch_all handler of the block is reached.
+	tryBlock bool
+	tryIndex int
+	inCatch  bool
+
 	discard      disasm.StackInfo // Information about the stack created in this block, used while creating Discard instructions
 	branchTables []*BranchTable   // All branch tables that were defined in this block.
 }
@@ -147,6 +183,7 @@ type block struct {
 // BytecodeMetadata encapsulates metadata about a bytecode stream.
 type BytecodeMetadata struct {
 	BranchTables []*BranchTable
+	TryTables    []*TryTable
 	Instructions []InstructionMetadata
 
 	// Inbound jumps - used by the AOT/JIT scanner to
@@ -162,6 +199,7 @@ func Compile(disassembly []disasm.Instr) ([]byte, *BytecodeMetadata) {
 	buffer := new(bytes.Buffer)
 	metadata := make([]InstructionMetadata, 0, len(disassembly))
 	branchTables := []*BranchTable{}
+	tryTables := []*TryTable{}
 	inboundTargets := make(map[int64]struct{})
 
 	curBlockDepth := -1
@@ -186,7 +224,14 @@ func Compile(disassembly []disasm.Instr) ([]byte, *BytecodeMetadata) {
 			// memory_immediate has two fields, the alignment and the offset.
 			// The former is simply an optimization hint and can be safely
 			// discarded.
-			instr.Immediates = []interface{}{instr.Immediates[1].(uint32)}
+			// The offset is a uint64 value for 64-bit memories.
+			// In modules with more than one memory, it is preceded by the
+			// index of the memory.
+			if len(instr.Immediates) > 2 {
+				instr.Immediates = []interface{}{instr.Immediates[2], instr.Immediates[1]}
+			} else {
+				instr.Immediates = []interface{}{instr.Immediates[1]}
+			}
 		case ops.If:
 			curBlockDepth++
 			emitMetadata(OpJmpZ, buffer.Len(), instAndInt64Len)
@@ -214,6 +259,76 @@ func Compile(disassembly []disasm.Instr) ([]byte, *BytecodeMetadata) {
 				ifBlock: false,
 			}
 			continue
+		case ops.Try:
+			curBlockDepth++
+			emitMetadata(OpTry, buffer.Len(), instAndInt64Len)
+			buffer.WriteByte(OpTry)
+			binary.Write(buffer, binary.LittleEndian, int64(len(tryTables)))
+			blocks[curBlockDepth] = &block{
+				tryBlock: true,
+				tryIndex: len(tryTables),
+			}
+			tryTables = append(tryTables, &TryTable{Start: int64(buffer.Len())})
+			continue
+		case ops.Catch, ops.CatchAll:
+			// the try body, and each handler but the last one, continue
+			// to the end of the block.
+			tryBlock := blocks[curBlockDepth]
+			table := tryTables[tryBlock.tryIndex]
+			if !tryBlock.inCatch {
+				table.End = int64(buffer.Len())
+				tryBlock.inCatch = true
+			}
+			emitMetadata(OpJmp, buffer.Len(), instAndInt64Len)
+			buffer.WriteByte(OpJmp)
+			tryBlock.patchOffsets = append(tryBlock.patchOffsets, int64(buffer.Len()))
+			binary.Write(buffer, binary.LittleEndian, int64(0))
+
+			addr := int64(buffer.Len())
+			inboundTargets[addr] = struct{}{}
+			if instr.Op.Code == ops.Catch {
+				table.Catches = append(table.Catches, Catch{
+					Tag:  instr.Immediates[0].(uint32),
+					Addr: addr,
+				})
+			} else {
+				table.HasCatchAll = true
+				table.CatchAllAddr = addr
+			}
+			continue
+		case ops.Delegate:
+			tryBlock := blocks[curBlockDepth]
+			table := tryTables[tryBlock.tryIndex]
+			table.End = int64(buffer.Len())
+			table.Delegate = true
+			table.DelegateTarget = -1
+			// the label is relative to the block enclosing the try block.
+			label := int(instr.Immediates[0].(uint32))
+			for depth := curBlockDepth - 1 - label; depth >= 0; depth-- {
+				if b := blocks[depth]; b.tryBlock && !b.inCatch {
+					table.DelegateTarget = b.tryIndex
+					break
+				}
+			}
+
+			tryBlock.offset = int64(buffer.Len())
+			for _, offset := range tryBlock.patchOffsets {
+				code := buffer.Bytes()
+				buffer = patchOffset(code, offset, tryBlock.offset, inboundTargets)
+			}
+			for _, table := range tryBlock.branchTables {
+				table.patchTable(table.blocksLen-curBlockDepth-1, tryBlock.offset, inboundTargets)
+			}
+
+			delete(blocks, curBlockDepth)
+			curBlockDepth--
+			continue
+		case ops.Rethrow:
+			// rethrow refers to the exception caught by a try block
+			// through its index rather than its label.
+			label := int(instr.Immediates[0].(uint32))
+			tryBlock := blocks[curBlockDepth-label]
+			instr.Immediates = []interface{}{int64(tryBlock.tryIndex)}
 		case ops.Else:
 			emitMetadata(OpJmp, buffer.Len(), instAndInt64Len)
 			buffer.WriteByte(OpJmp)
@@ -233,6 +348,9 @@ func Compile(disassembly []disasm.Instr) ([]byte, *BytecodeMetadata) {
 			depth := curBlockDepth
 			block := blocks[depth]
 
+			if block.tryBlock && !block.inCatch {
+				tryTables[block.tryIndex].End = int64(buffer.Len())
+			}
 			if !block.loopBlock { // is a normal block
 				block.offset = int64(buffer.Len())
 				if block.ifBlock {
@@ -352,6 +470,7 @@ func Compile(disassembly []disasm.Instr) ([]byte, *BytecodeMetadata) {
 	}
 	return buffer.Bytes(), &BytecodeMetadata{
 		BranchTables:   branchTables,
+		TryTables:      tryTables,
 		Instructions:   metadata,
 		InboundTargets: inboundTargets,
 	}
@@ -0,0 +1,248 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// ErrMemoryLimit is returned by (*Memory).Grow when growing the memory would
+// exceed its maximum size, and by NewVM when the initial size of a memory
+// exceeds the limit set with MaxMemoryPages.
+var ErrMemoryLimit = errors.New("exec: memory size exceeds its maximum")
+
+// linearMemory is a linear memory of a module, other than the first one.
+type linearMemory struct {
+	data     []byte
+	memory64 bool // Whether the memory is indexed with 64-bit addresses
+}
+
+// Memory is a handle to a linear memory of a VM, allowing host functions to
+// read and write its content.
+type Memory struct {
+	vm    *VM
+	index uint32
+}
+
+// Memory returns a handle to the linear memory at the given index of the
+// memory index space of the VM's module.
+func (proc *Process) Memory(index uint32) (*Memory, error) {
+	if int(index) >= len(proc.vm.memories)+1 || proc.vm.module.GetMemory(int(index)) == nil {
+		return nil, wasm.InvalidLinearMemoryIndexError(index)
+	}
+	return &Memory{vm: proc.vm, index: index}, nil
+}
+
+// Bytes returns the content of the memory. The returned slice is only valid
+// until the memory is grown.
+func (mem *Memory) Bytes() []byte {
+	data, _ := mem.vm.linearMemory(mem.index)
+	return *data
+}
+
+// Size returns the current size of the memory in bytes.
+func (mem *Memory) Size() int {
+	return len(mem.Bytes())
+}
+
+// Is64 returns whether the memory is indexed with 64-bit addresses.
+func (mem *Memory) Is64() bool {
+	_, memory64 := mem.vm.linearMemory(mem.index)
+	return memory64
+}
+
+// ReadAt implements the ReaderAt interface: it copies into p
+// the content of the memory at offset off.
+func (mem *Memory) ReadAt(p []byte, off int64) (int, error) {
+	data := mem.Bytes()
+	if off < 0 || off > int64(len(data)) {
+		return 0, ErrOutOfBoundsMemoryAccess
+	}
+
+	length := copy(p, data[off:])
+
+	var err error
+	if length < len(p) {
+		err = io.ErrShortBuffer
+	}
+
+	return length, err
+}
+
+// WriteAt implements the WriterAt interface: it writes the content of p
+// into the memory at offset off.
+func (mem *Memory) WriteAt(p []byte, off int64) (int, error) {
+	data := mem.Bytes()
+	if off < 0 || off > int64(len(data)) {
+		return 0, ErrOutOfBoundsMemoryAccess
+	}
+
+	length := copy(data[off:], p)
+
+	var err error
+	if length < len(p) {
+		err = io.ErrShortWrite
+	}
+
+	return length, err
+}
+
+// MemoryView returns the n bytes of memory at ptr, without copying them.
+// The returned slice is only valid until the memory is grown.
+func (mem *Memory) MemoryView(ptr, n uint32) ([]byte, error) {
+	return mem.View(uint64(ptr), uint64(n))
+}
+
+// View is like MemoryView, for the 64-bit addresses of 64-bit memories. The
+// other methods of Memory taking a pointer only address the first 4 GiB of
+// the memory, but ReadAt and WriteAt.
+func (mem *Memory) View(ptr, n uint64) ([]byte, error) {
+	data := mem.Bytes()
+	if ptr > uint64(len(data)) || n > uint64(len(data))-ptr {
+		return nil, ErrOutOfBoundsMemoryAccess
+	}
+	return data[ptr : ptr+n : ptr+n], nil
+}
+
+// ReadUint16Le reads a little-endian uint16 at ptr.
+func (mem *Memory) ReadUint16Le(ptr uint32) (uint16, error) {
+	p, err := mem.MemoryView(ptr, 2)
+	if err != nil {
+		return 0, err
+	}
+	return endianess.Uint16(p), nil
+}
+
+// ReadUint32Le reads a little-endian uint32 at ptr.
+func (mem *Memory) ReadUint32Le(ptr uint32) (uint32, error) {
+	p, err := mem.MemoryView(ptr, 4)
+	if err != nil {
+		return 0, err
+	}
+	return endianess.Uint32(p), nil
+}
+
+// ReadUint64Le reads a little-endian uint64 at ptr.
+func (mem *Memory) ReadUint64Le(ptr uint32) (uint64, error) {
+	p, err := mem.MemoryView(ptr, 8)
+	if err != nil {
+		return 0, err
+	}
+	return endianess.Uint64(p), nil
+}
+
+// ReadFloat32 reads a little-endian float32 at ptr.
+func (mem *Memory) ReadFloat32(ptr uint32) (float32, error) {
+	v, err := mem.ReadUint32Le(ptr)
+	return math.Float32frombits(v), err
+}
+
+// ReadFloat64 reads a little-endian float64 at ptr.
+func (mem *Memory) ReadFloat64(ptr uint32) (float64, error) {
+	v, err := mem.ReadUint64Le(ptr)
+	return math.Float64frombits(v), err
+}
+
+// WriteUint16Le writes v at ptr in little-endian byte order.
+func (mem *Memory) WriteUint16Le(ptr uint32, v uint16) error {
+	p, err := mem.MemoryView(ptr, 2)
+	if err != nil {
+		return err
+	}
+	endianess.PutUint16(p, v)
+	return nil
+}
+
+// WriteUint32Le writes v at ptr in little-endian byte order.
+func (mem *Memory) WriteUint32Le(ptr uint32, v uint32) error {
+	p, err := mem.MemoryView(ptr, 4)
+	if err != nil {
+		return err
+	}
+	endianess.PutUint32(p, v)
+	return nil
+}
+
+// WriteUint64Le writes v at ptr in little-endian byte order.
+func (mem *Memory) WriteUint64Le(ptr uint32, v uint64) error {
+	p, err := mem.MemoryView(ptr, 8)
+	if err != nil {
+		return err
+	}
+	endianess.PutUint64(p, v)
+	return nil
+}
+
+// WriteFloat32 writes v at ptr in little-endian byte order.
+func (mem *Memory) WriteFloat32(ptr uint32, v float32) error {
+	return mem.WriteUint32Le(ptr, math.Float32bits(v))
+}
+
+// WriteFloat64 writes v at ptr in little-endian byte order.
+func (mem *Memory) WriteFloat64(ptr uint32, v float64) error {
+	return mem.WriteUint64Le(ptr, math.Float64bits(v))
+}
+
+// ReadBytes returns a copy of the n bytes of memory at ptr.
+func (mem *Memory) ReadBytes(ptr, n uint32) ([]byte, error) {
+	p, err := mem.MemoryView(ptr, n)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), p...), nil
+}
+
+// ReadString returns the string of n bytes at ptr.
+func (mem *Memory) ReadString(ptr, n uint32) (string, error) {
+	p, err := mem.MemoryView(ptr, n)
+	if err != nil {
+		return "", err
+	}
+	return string(p), nil
+}
+
+// ReadCString returns the NUL-terminated string at ptr, without its
+// terminating NUL byte. ErrOutOfBoundsMemoryAccess is returned if the
+// string is not terminated before the end of the memory.
+func (mem *Memory) ReadCString(ptr uint32) (string, error) {
+	data := mem.Bytes()
+	if uint64(ptr) >= uint64(len(data)) {
+		return "", ErrOutOfBoundsMemoryAccess
+	}
+	n := bytes.IndexByte(data[ptr:], 0)
+	if n < 0 {
+		return "", ErrOutOfBoundsMemoryAccess
+	}
+	return string(data[ptr : int(ptr)+n]), nil
+}
+
+// WriteBytes writes p to the memory at ptr. Nothing is written if p
+// doesn't fit in the memory.
+func (mem *Memory) WriteBytes(ptr uint32, p []byte) error {
+	if uint64(len(p)) > math.MaxUint32 {
+		return ErrOutOfBoundsMemoryAccess
+	}
+	view, err := mem.MemoryView(ptr, uint32(len(p)))
+	if err != nil {
+		return err
+	}
+	copy(view, p)
+	return nil
+}
+
+// Grow grows the memory by n pages of 64 KiB, and returns its previous size
+// in pages.
+func (mem *Memory) Grow(n uint64) (uint64, error) {
+	prev, ok := mem.vm.growLinearMemory(mem.index, n)
+	if !ok {
+		return uint64(prev), ErrMemoryLimit
+	}
+	return uint64(prev), nil
+}
@@ -13,211 +13,240 @@ import (
 // when it detects an out of bounds access to the linear memory.
 var ErrOutOfBoundsMemoryAccess = errors.New("exec: out of bounds memory access")
 
-func (vm *VM) fetchBaseAddr() int {
-	return int(vm.fetchUint32() + uint32(vm.popInt32()))
+// linearMemory returns the linear memory at the given index, and whether
+// it is indexed with 64-bit addresses.
+func (vm *VM) linearMemory(index uint32) (*[]byte, bool) {
+	if index == 0 {
+		return &vm.memory, vm.memory64
+	}
+	mem := &vm.memories[index-1]
+	return &mem.data, mem.memory64
 }
 
-// inBounds returns true when the next vm.fetchBaseAddr() + offset
-// indices are in bounds accesses to the linear memory.
-func (vm *VM) inBounds(offset uint32) bool {
-	addr := uint64(endianess.Uint32(vm.ctx.code[vm.ctx.pc:])) + uint64(uint32(vm.ctx.stack[len(vm.ctx.stack)-1]))
-	return addr+uint64(offset) < uint64(len(vm.memory))
-}
+// memAccess pops the address of the current load or store, and returns
+// the size bytes of memory it accesses. The VM traps if any of them is
+// out of bounds.
+func (vm *VM) memAccess(size uint64) []byte {
+	var index uint32
+	if vm.memories != nil {
+		index = vm.fetchUint32()
+	}
+	mem, memory64 := vm.linearMemory(index)
 
-// curMem returns a slice to the memory segment pointed to by
-// the current base address on the bytecode stream.
-func (vm *VM) curMem() []byte {
-	return vm.memory[vm.fetchBaseAddr():]
+	var offset, addr uint64
+	if memory64 {
+		offset = vm.fetchUint64()
+		addr = vm.popUint64()
+	} else {
+		offset = uint64(vm.fetchUint32())
+		addr = uint64(vm.popUint32())
+	}
+	ea := offset + addr
+	if ea < offset || ea > uint64(len(*mem)) || uint64(len(*mem))-ea < size {
+		panic(ErrOutOfBoundsMemoryAccess)
+	}
+	return (*mem)[ea : ea+size]
 }
 
 func (vm *VM) i32Load() {
-	if !vm.inBounds(3) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushUint32(endianess.Uint32(vm.curMem()))
+	vm.pushUint32(endianess.Uint32(vm.memAccess(4)))
 }
 
 func (vm *VM) i32Load8s() {
-	if !vm.inBounds(0) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushInt32(int32(int8(vm.memory[vm.fetchBaseAddr()])))
+	vm.pushInt32(int32(int8(vm.memAccess(1)[0])))
 }
 
 func (vm *VM) i32Load8u() {
-	if !vm.inBounds(0) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushUint32(uint32(uint8(vm.memory[vm.fetchBaseAddr()])))
+	vm.pushUint32(uint32(uint8(vm.memAccess(1)[0])))
 }
 
 func (vm *VM) i32Load16s() {
-	if !vm.inBounds(1) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushInt32(int32(int16(endianess.Uint16(vm.curMem()))))
+	vm.pushInt32(int32(int16(endianess.Uint16(vm.memAccess(2)))))
 }
 
 func (vm *VM) i32Load16u() {
-	if !vm.inBounds(1) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushUint32(uint32(endianess.Uint16(vm.curMem())))
+	vm.pushUint32(uint32(endianess.Uint16(vm.memAccess(2))))
 }
 
 func (vm *VM) i64Load() {
-	if !vm.inBounds(7) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushUint64(endianess.Uint64(vm.curMem()))
+	vm.pushUint64(endianess.Uint64(vm.memAccess(8)))
 }
 
 func (vm *VM) i64Load8s() {
-	if !vm.inBounds(0) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushInt64(int64(int8(vm.memory[vm.fetchBaseAddr()])))
+	vm.pushInt64(int64(int8(vm.memAccess(1)[0])))
 }
 
 func (vm *VM) i64Load8u() {
-	if !vm.inBounds(0) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushUint64(uint64(uint8(vm.memory[vm.fetchBaseAddr()])))
+	vm.pushUint64(uint64(uint8(vm.memAccess(1)[0])))
 }
 
 func (vm *VM) i64Load16s() {
-	if !vm.inBounds(1) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushInt64(int64(int16(endianess.Uint16(vm.curMem()))))
+	vm.pushInt64(int64(int16(endianess.Uint16(vm.memAccess(2)))))
 }
 
 func (vm *VM) i64Load16u() {
-	if !vm.inBounds(1) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushUint64(uint64(endianess.Uint16(vm.curMem())))
+	vm.pushUint64(uint64(endianess.Uint16(vm.memAccess(2))))
 }
 
 func (vm *VM) i64Load32s() {
-	if !vm.inBounds(3) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushInt64(int64(int32(endianess.Uint32(vm.curMem()))))
+	vm.pushInt64(int64(int32(endianess.Uint32(vm.memAccess(4)))))
 }
 
 func (vm *VM) i64Load32u() {
-	if !vm.inBounds(3) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushUint64(uint64(endianess.Uint32(vm.curMem())))
+	vm.pushUint64(uint64(endianess.Uint32(vm.memAccess(4))))
 }
 
 func (vm *VM) f32Store() {
 	v := math.Float32bits(vm.popFloat32())
-	if !vm.inBounds(3) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	endianess.PutUint32(vm.curMem(), v)
+	endianess.PutUint32(vm.memAccess(4), v)
 }
 
 func (vm *VM) f32Load() {
-	if !vm.inBounds(3) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushFloat32(math.Float32frombits(endianess.Uint32(vm.curMem())))
+	vm.pushFloat32(math.Float32frombits(endianess.Uint32(vm.memAccess(4))))
 }
 
 func (vm *VM) f64Store() {
 	v := math.Float64bits(vm.popFloat64())
-	if !vm.inBounds(7) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	endianess.PutUint64(vm.curMem(), v)
+	endianess.PutUint64(vm.memAccess(8), v)
 }
 
 func (vm *VM) f64Load() {
-	if !vm.inBounds(7) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.pushFloat64(math.Float64frombits(endianess.Uint64(vm.curMem())))
+	vm.pushFloat64(math.Float64frombits(endianess.Uint64(vm.memAccess(8))))
 }
 
 func (vm *VM) i32Store() {
 	v := vm.popUint32()
-	if !vm.inBounds(3) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	endianess.PutUint32(vm.curMem(), v)
+	endianess.PutUint32(vm.memAccess(4), v)
 }
 
 func (vm *VM) i32Store8() {
 	v := byte(uint8(vm.popUint32()))
-	if !vm.inBounds(0) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.memory[vm.fetchBaseAddr()] = v
+	vm.memAccess(1)[0] = v
 }
 
 func (vm *VM) i32Store16() {
 	v := uint16(vm.popUint32())
-	if !vm.inBounds(1) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	endianess.PutUint16(vm.curMem(), v)
+	endianess.PutUint16(vm.memAccess(2), v)
 }
 
 func (vm *VM) i64Store() {
 	v := vm.popUint64()
-	if !vm.inBounds(7) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	endianess.PutUint64(vm.curMem(), v)
+	endianess.PutUint64(vm.memAccess(8), v)
 }
 
 func (vm *VM) i64Store8() {
 	v := byte(uint8(vm.popUint64()))
-	if !vm.inBounds(0) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	vm.memory[vm.fetchBaseAddr()] = v
+	vm.memAccess(1)[0] = v
 }
 
 func (vm *VM) i64Store16() {
 	v := uint16(vm.popUint64())
-	if !vm.inBounds(1) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	endianess.PutUint16(vm.curMem(), v)
+	endianess.PutUint16(vm.memAccess(2), v)
 }
 
 func (vm *VM) i64Store32() {
 	v := uint32(vm.popUint64())
-	if !vm.inBounds(3) {
-		panic(ErrOutOfBoundsMemoryAccess)
-	}
-	endianess.PutUint32(vm.curMem(), v)
+	endianess.PutUint32(vm.memAccess(4), v)
 }
 
 func (vm *VM) currentMemory() {
-	_ = vm.fetchInt8() // reserved (https://github.com/WebAssembly/design/blob/27ac254c854994103c24834a994be16f74f54186/BinaryEncoding.md#memory-related-operators-described-here)
-	vm.pushInt32(int32(len(vm.memory) / wasmPageSize))
+	index := vm.fetchUint32()
+	mem, memory64 := vm.linearMemory(index)
+	vm.pushMemorySize(memory64, int64(len(*mem)/wasmPageSize))
 }
 
 func (vm *VM) growMemory() {
-	_ = vm.fetchInt8() // reserved (https://github.com/WebAssembly/design/blob/27ac254c854994103c24834a994be16f74f54186/BinaryEncoding.md#memory-related-operators-described-here)
-	curLen := len(vm.memory) / wasmPageSize
-	n := vm.popUint32()
+	index := vm.fetchUint32()
+	_, memory64 := vm.linearMemory(index)
+	var n uint64
+	if memory64 {
+		n = vm.popUint64()
+	} else {
+		n = uint64(vm.popUint32())
+	}
+	prev, ok := vm.growLinearMemory(index, n)
+	if !ok {
+		prev = -1
+	}
+	vm.pushMemorySize(memory64, prev)
+}
 
-	maxMem := vm.module.Memory.Entries[0].Limits.Maximum
-	newPage := uint64(n + uint32(len(vm.memory)/wasmPageSize))
+// growLinearMemory grows the linear memory at the given index by n pages,
+// and returns its previous size in pages. It returns false if the memory
+// would exceed its maximum size.
+func (vm *VM) growLinearMemory(index uint32, n uint64) (int64, bool) {
+	mem, memory64 := vm.linearMemory(index)
+	curLen := uint64(len(*mem) / wasmPageSize)
+	maxPages := uint64(1 << 16)
+	if memory64 {
+		maxPages = 1 << 48
+	}
+	if vm.maxMemoryPages < maxPages {
+		maxPages = vm.maxMemoryPages
+	}
+	if typ := vm.module.GetMemory(int(index)); typ != nil && typ.Limits.Maximum < maxPages {
+		maxPages = typ.Limits.Maximum
+	}
+	newPage := curLen + n
+
+	if newPage < curLen || newPage > maxPages || newPage > math.MaxInt64/wasmPageSize {
+		return int64(curLen), false
+	}
+
+	*mem = append(*mem, make([]byte, n*wasmPageSize)...)
+	return int64(curLen), true
+}
+
+// pushMemorySize pushes a size in pages, which is an i64 value for 64-bit
+// memories and an i32 value otherwise.
+func (vm *VM) pushMemorySize(memory64 bool, pages int64) {
+	if memory64 {
+		vm.pushInt64(pages)
+	} else {
+		vm.pushInt32(int32(pages))
+	}
+}
 
-	if newPage > 1<<16 || newPage > uint64(maxMem) {
-		vm.pushInt32(-1)
-		return
+// popMemoryRange pops the address of an access to n bytes of the memory
+// mem, and returns them. The VM traps if any of them is out of bounds.
+func (vm *VM) popMemoryRange(mem []byte, memory64 bool, n uint64) []byte {
+	var addr uint64
+	if memory64 {
+		addr = vm.popUint64()
+	} else {
+		addr = uint64(vm.popUint32())
+	}
+	if addr > uint64(len(mem)) || uint64(len(mem))-addr < n {
+		panic(ErrOutOfBoundsMemoryAccess)
 	}
+	return mem[addr : addr+n]
+}
 
-	vm.memory = append(vm.memory, make([]byte, n*wasmPageSize)...)
-	vm.pushInt32(int32(curLen))
+func (vm *VM) memoryCopy() {
+	dst, dst64 := vm.linearMemory(vm.fetchUint32())
+	src, src64 := vm.linearMemory(vm.fetchUint32())
+	var n uint64
+	if dst64 && src64 {
+		n = vm.popUint64()
+	} else {
+		n = uint64(vm.popUint32())
+	}
+	from := vm.popMemoryRange(*src, src64, n)
+	to := vm.popMemoryRange(*dst, dst64, n)
+	copy(to, from)
+}
+
+func (vm *VM) memoryFill() {
+	mem, memory64 := vm.linearMemory(vm.fetchUint32())
+	var n uint64
+	if memory64 {
+		n = vm.popUint64()
+	} else {
+		n = uint64(vm.popUint32())
+	}
+	val := byte(vm.popUint32())
+	p := vm.popMemoryRange(*mem, memory64, n)
+	for i := range p {
+		p[i] = val
+	}
 }
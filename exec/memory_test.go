@@ -0,0 +1,312 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+func TestMemory64(t *testing.T) {
+	storeLoadSig := wasm.FunctionSig{
+		Form:        0x60,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI64},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+	sizeSig := wasm.FunctionSig{
+		Form:        0x60,
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI64},
+	}
+
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{storeLoadSig, sizeSig}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0, 0, 1, 1}}
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{
+		Limits: wasm.ResizableLimits{Flags: wasm.LimitsMemory64 | 0x1, Initial: 1, Maximum: 2},
+	}}}
+	m.LinearMemoryIndexSpace = [][]byte{nil}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{
+		// (func (param i64) (result i32)
+		//   (i32.store (get_local 0) (i32.const 42))
+		//   (i32.load offset=4 (i64.sub (get_local 0) (i64.const 4))))
+		{Module: m, Code: []byte{
+			ops.GetLocal, 0, ops.I32Const, 42, ops.I32Store, 2, 0,
+			ops.GetLocal, 0, ops.I64Const, 4, ops.I64Sub, ops.I32Load, 2, 4,
+		}},
+		// (func (param i64) (result i32) (i32.load offset=0x100000000 (get_local 0)))
+		{Module: m, Code: []byte{
+			ops.GetLocal, 0, ops.I32Load, 2, 0x80, 0x80, 0x80, 0x80, 0x10,
+		}},
+		// (func (result i64) (memory.grow (i64.const 1)))
+		{Module: m, Code: []byte{ops.I64Const, 1, ops.GrowMemory, 0}},
+		// (func (result i64) (memory.size))
+		{Module: m, Code: []byte{ops.CurrentMemory, 0}},
+	}}
+	for i, typ := range m.Function.Types {
+		m.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{
+			Sig:  &m.Types.Entries[typ],
+			Body: &m.Code.Bodies[i],
+		})
+	}
+
+	vm, err := NewVM(m)
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	vm.RecoverPanic = true
+
+	res, err := vm.ExecCode(0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(uint32); got != 42 {
+		t.Errorf("store/load: got %d, want 42", got)
+	}
+
+	// Addresses beyond 4GiB must not wrap around.
+	if _, err := vm.ExecCode(1, 0); err != ErrOutOfBoundsMemoryAccess {
+		t.Errorf("load with a 64-bit offset: got error %v, want %v", err, ErrOutOfBoundsMemoryAccess)
+	}
+	if _, err := vm.ExecCode(0, 1<<32); err != ErrOutOfBoundsMemoryAccess {
+		t.Errorf("store to a 64-bit address: got error %v, want %v", err, ErrOutOfBoundsMemoryAccess)
+	}
+	if _, err := vm.ExecCode(0, ^uint64(1)); err != ErrOutOfBoundsMemoryAccess {
+		t.Errorf("store with overflowing address: got error %v, want %v", err, ErrOutOfBoundsMemoryAccess)
+	}
+
+	for i, want := range []uint64{1, ^uint64(0)} {
+		res, err := vm.ExecCode(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := res.(uint64); got != want {
+			t.Errorf("memory.grow #%d: got %d, want %d", i, int64(got), int64(want))
+		}
+	}
+	res, err = vm.ExecCode(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(uint64); got != 2 {
+		t.Errorf("memory.size: got %d, want 2", got)
+	}
+}
+
+func TestMultiMemory(t *testing.T) {
+	storeLoadSig := wasm.FunctionSig{
+		Form:        0x60,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+	sizeSig := wasm.FunctionSig{
+		Form:        0x60,
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{storeLoadSig, sizeSig}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0, 1, 1}}
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{
+		{Limits: wasm.ResizableLimits{Initial: 1}},
+		{Limits: wasm.ResizableLimits{Flags: 0x1, Initial: 1, Maximum: 2}},
+	}}
+	m.LinearMemoryIndexSpace = [][]byte{nil, {7}}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{
+		// (func (param i32) (result i32)
+		//   (i32.store 1 (get_local 0) (i32.const 42))
+		//   (i32.add (i32.load 1 (get_local 0)) (i32.load (get_local 0))))
+		{Module: m, Code: []byte{
+			ops.GetLocal, 0, ops.I32Const, 42, ops.I32Store, 0x42, 1, 0,
+			ops.GetLocal, 0, ops.I32Load, 0x42, 1, 0,
+			ops.GetLocal, 0, ops.I32Load, 2, 0,
+			ops.I32Add,
+		}},
+		// (func (result i32) (memory.grow 1 (i32.const 1)))
+		{Module: m, Code: []byte{ops.I32Const, 1, ops.GrowMemory, 1}},
+		// (func (result i32) (memory.size 1))
+		{Module: m, Code: []byte{ops.CurrentMemory, 1}},
+	}}
+	for i, typ := range m.Function.Types {
+		m.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{
+			Sig:  &m.Types.Entries[typ],
+			Body: &m.Code.Bodies[i],
+		})
+	}
+
+	vm, err := NewVM(m)
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	vm.RecoverPanic = true
+
+	res, err := vm.ExecCode(0, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(uint32); got != 42 {
+		t.Errorf("store/load: got %d, want 42", got)
+	}
+	if got := vm.Memory()[8]; got != 0 {
+		t.Errorf("first memory was written to: got %d, want 0", got)
+	}
+	if _, err := vm.ExecCode(0, wasmPageSize-2); err != ErrOutOfBoundsMemoryAccess {
+		t.Errorf("out of bounds store: got error %v, want %v", err, ErrOutOfBoundsMemoryAccess)
+	}
+
+	proc := NewProcess(vm)
+	mem, err := proc.Memory(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, 1)
+	for _, tc := range []struct {
+		off  int64
+		want byte
+	}{
+		{0, 7},
+		{8, 42},
+	} {
+		if _, err := mem.ReadAt(p, tc.off); err != nil {
+			t.Fatal(err)
+		}
+		if p[0] != tc.want {
+			t.Errorf("memory 1 at offset %d: got %d, want %d", tc.off, p[0], tc.want)
+		}
+	}
+	if _, err := proc.Memory(2); err != wasm.InvalidLinearMemoryIndexError(2) {
+		t.Errorf("invalid memory index: got error %v, want %v", err, wasm.InvalidLinearMemoryIndexError(2))
+	}
+
+	for i, want := range []uint32{1, 0xffffffff} {
+		res, err := vm.ExecCode(1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := res.(uint32); got != want {
+			t.Errorf("memory.grow #%d: got %d, want %d", i, int32(got), int32(want))
+		}
+	}
+	res, err = vm.ExecCode(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(uint32); got != 2 {
+		t.Errorf("memory.size: got %d, want 2", got)
+	}
+	if got := mem.Size(); got != 2*wasmPageSize {
+		t.Errorf("memory 1 size: got %d, want %d", got, 2*wasmPageSize)
+	}
+	if _, err := mem.Grow(1); err != ErrMemoryLimit {
+		t.Errorf("growing past the maximum: got error %v, want %v", err, ErrMemoryLimit)
+	}
+	if got := len(vm.Memory()); got != wasmPageSize {
+		t.Errorf("first memory size: got %d, want %d", got, wasmPageSize)
+	}
+}
+
+func TestBulkMemory(t *testing.T) {
+	sig := wasm.FunctionSig{
+		Form:       0x60,
+		ParamTypes: []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32},
+	}
+
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{sig}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0, 0}}
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.LinearMemoryIndexSpace = [][]byte{[]byte("abcdef")}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{
+		// (func (param i32 i32 i32)
+		//   (memory.copy (get_local 0) (get_local 1) (get_local 2)))
+		{Module: m, Code: []byte{ops.GetLocal, 0, ops.GetLocal, 1, ops.GetLocal, 2, ops.PrefixMisc, 10, 0, 0}},
+		// (func (param i32 i32 i32)
+		//   (memory.fill (get_local 0) (get_local 1) (get_local 2)))
+		{Module: m, Code: []byte{ops.GetLocal, 0, ops.GetLocal, 1, ops.GetLocal, 2, ops.PrefixMisc, 11, 0}},
+	}}
+	for i, typ := range m.Function.Types {
+		m.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{
+			Sig:  &m.Types.Entries[typ],
+			Body: &m.Code.Bodies[i],
+		})
+	}
+
+	vm, err := NewVM(m)
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	vm.RecoverPanic = true
+
+	for _, tc := range []struct {
+		fn      int64
+		args    []uint64
+		want    string
+		wantErr error
+	}{
+		{0, []uint64{2, 0, 4}, "ababcd", nil},
+		{0, []uint64{0, 1, 4}, "babccd", nil},
+		{1, []uint64{4, 'x', 2}, "babcxx", nil},
+		{1, []uint64{wasmPageSize, 0, 0}, "babcxx", nil},
+		{0, []uint64{0, wasmPageSize - 1, 2}, "babcxx", ErrOutOfBoundsMemoryAccess},
+		{1, []uint64{wasmPageSize - 1, 'y', 2}, "babcxx", ErrOutOfBoundsMemoryAccess},
+	} {
+		if _, err := vm.ExecCode(tc.fn, tc.args...); err != tc.wantErr {
+			t.Errorf("function %d%v: got error %v, want %v", tc.fn, tc.args, err, tc.wantErr)
+		}
+		if got := string(vm.Memory()[:6]); got != tc.want {
+			t.Errorf("function %d%v: got memory %q, want %q", tc.fn, tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestMemoryLimits(t *testing.T) {
+	newModule := func(initial uint64) *wasm.Module {
+		m := wasm.NewModule()
+		m.Start = nil
+		m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{
+			Limits: wasm.ResizableLimits{Flags: wasm.LimitsMemory64, Initial: initial, Maximum: 1 << 48},
+		}}}
+		m.LinearMemoryIndexSpace = [][]byte{nil}
+		return m
+	}
+
+	if _, err := NewVM(newModule(1 << 40)); err != ErrMemoryLimit {
+		t.Errorf("NewVM with a 64 PiB memory: got error %v, want %v", err, ErrMemoryLimit)
+	}
+
+	vm, err := NewVM(newModule(1), MaxMemoryPages(2))
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	mem, err := NewProcess(vm).Memory(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev, err := mem.Grow(1); err != nil || prev != 1 {
+		t.Errorf("Grow(1): got %d, %v, want 1, nil", prev, err)
+	}
+	if _, err := mem.Grow(1); err != ErrMemoryLimit {
+		t.Errorf("Grow past the limit: got error %v, want %v", err, ErrMemoryLimit)
+	}
+
+	for _, tc := range []struct {
+		ptr, n uint64
+		err    error
+	}{
+		{2*wasmPageSize - 4, 4, nil},
+		{2*wasmPageSize - 4, 5, ErrOutOfBoundsMemoryAccess},
+		{1 << 32, 1, ErrOutOfBoundsMemoryAccess},
+		{1, ^uint64(0), ErrOutOfBoundsMemoryAccess},
+	} {
+		p, err := mem.View(tc.ptr, tc.n)
+		if err != tc.err || (err == nil && uint64(len(p)) != tc.n) {
+			t.Errorf("View(%#x, %#x): got %d bytes, error %v, want error %v", tc.ptr, tc.n, len(p), err, tc.err)
+		}
+	}
+}
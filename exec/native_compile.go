@@ -156,7 +156,19 @@ func (vm *VM) tryNativeCompile() error {
 // [fp+pointerSize:fp+pointerSize*2]: sliceHeader for locals variables.
 func (vm *VM) nativeCodeInvocation(asmIndex uint32) {
 	block := vm.ctx.asm[asmIndex]
+	// Native code accesses globals through vm.globals, so the values of
+	// shared globals are copied in and out around the invocation.
+	for i, cell := range vm.shared {
+		if cell != nil {
+			vm.globals[i] = cell.Bits()
+		}
+	}
 	finishSignal := block.nativeUnit.Invoke(&vm.ctx.stack, &vm.ctx.locals, &vm.globals, &vm.memory)
+	for i, cell := range vm.shared {
+		if cell != nil {
+			cell.SetBits(vm.globals[i])
+		}
+	}
 
 	switch finishSignal.CompletionStatus() {
 	case compile.CompletionOK:
@@ -6,8 +6,10 @@ package exec
 
 import (
 	"fmt"
+	"math"
 	"testing"
 
+	"github.com/go-interpreter/wagon/wasm"
 	"github.com/go-interpreter/wagon/wasm/operators"
 )
 
@@ -66,3 +68,78 @@ func TestF64BinOps(t *testing.T) {
 		})
 	}
 }
+
+func TestSatConversions(t *testing.T) {
+	for _, tc := range []struct {
+		code uint32 // The opcode, prefixed by operators.PrefixMisc
+		arg  float64
+		want uint64
+	}{
+		{0, 1.5, 1},                    // i32.trunc_s:sat/f32
+		{0, -1.5, 0xffffffff},          // i32.trunc_s:sat/f32
+		{0, math.NaN(), 0},             // i32.trunc_s:sat/f32
+		{0, 1e10, math.MaxInt32},       // i32.trunc_s:sat/f32
+		{2, -1e10, 1 << 31},            // i32.trunc_s:sat/f64
+		{3, -1.5, 0},                   // i32.trunc_u:sat/f64
+		{3, 1e10, math.MaxUint32},      // i32.trunc_u:sat/f64
+		{5, 1e19, 9999999980506447872}, // i64.trunc_u:sat/f32
+		{6, math.Inf(-1), 1 << 63},     // i64.trunc_s:sat/f64
+		{6, 1e19, math.MaxInt64},       // i64.trunc_s:sat/f64
+		{7, 1e20, math.MaxUint64},      // i64.trunc_u:sat/f64
+	} {
+		op, err := operators.NewPrefixed(operators.PrefixMisc, tc.code)
+		if err != nil {
+			t.Fatalf("could not lookup operator 0xfc %d: %v", tc.code, err)
+		}
+		t.Run(fmt.Sprintf("%v(%v)", op.Name, tc.arg), func(t *testing.T) {
+			vm := new(VM)
+			vm.newFuncTable()
+			if op.Args[0] == wasm.ValueTypeF32 {
+				vm.pushFloat32(float32(tc.arg))
+			} else {
+				vm.pushFloat64(tc.arg)
+			}
+			vm.funcTable[op.Code]()
+			got := vm.popUint64()
+			if op.Returns == wasm.ValueTypeI32 {
+				got = uint64(uint32(got))
+			}
+			if got != tc.want {
+				t.Fatalf("got=%#x, want=%#x", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSignExtension(t *testing.T) {
+	for _, tc := range []struct {
+		opcode byte
+		arg    uint64
+		want   uint64
+	}{
+		{operators.I32Extend8S, 0x17f, 0x7f},
+		{operators.I32Extend8S, 0x80, 0xffffff80},
+		{operators.I32Extend16S, 0x8000, 0xffff8000},
+		{operators.I64Extend8S, 0xff, math.MaxUint64},
+		{operators.I64Extend16S, 0x1234, 0x1234},
+		{operators.I64Extend32S, 0x80000000, 0xffffffff80000000},
+	} {
+		op, err := operators.New(tc.opcode)
+		if err != nil {
+			t.Fatalf("could not lookup operator 0x%x: %v", tc.opcode, err)
+		}
+		t.Run(fmt.Sprintf("%v(%#x)", op.Name, tc.arg), func(t *testing.T) {
+			vm := new(VM)
+			vm.newFuncTable()
+			vm.pushUint64(tc.arg)
+			vm.funcTable[tc.opcode]()
+			got := vm.popUint64()
+			if op.Returns == wasm.ValueTypeI32 {
+				got = uint64(uint32(got))
+			}
+			if got != tc.want {
+				t.Fatalf("got=%#x, want=%#x", got, tc.want)
+			}
+		})
+	}
+}
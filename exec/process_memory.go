@@ -0,0 +1,92 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+// The methods below access the first linear memory of the VM, and return
+// ErrOutOfBoundsMemoryAccess when accessing bytes past its end. See Memory
+// for accessing the other memories.
+
+// mem returns a handle to the first linear memory of the VM.
+func (proc *Process) mem() *Memory {
+	return &Memory{vm: proc.vm}
+}
+
+// MemoryView returns the n bytes of memory at ptr, without copying them.
+// The returned slice is only valid until the memory is grown.
+func (proc *Process) MemoryView(ptr, n uint32) ([]byte, error) {
+	return proc.mem().MemoryView(ptr, n)
+}
+
+// ReadUint16Le reads a little-endian uint16 at ptr.
+func (proc *Process) ReadUint16Le(ptr uint32) (uint16, error) {
+	return proc.mem().ReadUint16Le(ptr)
+}
+
+// ReadUint32Le reads a little-endian uint32 at ptr.
+func (proc *Process) ReadUint32Le(ptr uint32) (uint32, error) {
+	return proc.mem().ReadUint32Le(ptr)
+}
+
+// ReadUint64Le reads a little-endian uint64 at ptr.
+func (proc *Process) ReadUint64Le(ptr uint32) (uint64, error) {
+	return proc.mem().ReadUint64Le(ptr)
+}
+
+// ReadFloat32 reads a little-endian float32 at ptr.
+func (proc *Process) ReadFloat32(ptr uint32) (float32, error) {
+	return proc.mem().ReadFloat32(ptr)
+}
+
+// ReadFloat64 reads a little-endian float64 at ptr.
+func (proc *Process) ReadFloat64(ptr uint32) (float64, error) {
+	return proc.mem().ReadFloat64(ptr)
+}
+
+// WriteUint16Le writes v at ptr in little-endian byte order.
+func (proc *Process) WriteUint16Le(ptr uint32, v uint16) error {
+	return proc.mem().WriteUint16Le(ptr, v)
+}
+
+// WriteUint32Le writes v at ptr in little-endian byte order.
+func (proc *Process) WriteUint32Le(ptr uint32, v uint32) error {
+	return proc.mem().WriteUint32Le(ptr, v)
+}
+
+// WriteUint64Le writes v at ptr in little-endian byte order.
+func (proc *Process) WriteUint64Le(ptr uint32, v uint64) error {
+	return proc.mem().WriteUint64Le(ptr, v)
+}
+
+// WriteFloat32 writes v at ptr in little-endian byte order.
+func (proc *Process) WriteFloat32(ptr uint32, v float32) error {
+	return proc.mem().WriteFloat32(ptr, v)
+}
+
+// WriteFloat64 writes v at ptr in little-endian byte order.
+func (proc *Process) WriteFloat64(ptr uint32, v float64) error {
+	return proc.mem().WriteFloat64(ptr, v)
+}
+
+// ReadBytes returns a copy of the n bytes of memory at ptr.
+func (proc *Process) ReadBytes(ptr, n uint32) ([]byte, error) {
+	return proc.mem().ReadBytes(ptr, n)
+}
+
+// ReadString returns the string of n bytes at ptr.
+func (proc *Process) ReadString(ptr, n uint32) (string, error) {
+	return proc.mem().ReadString(ptr, n)
+}
+
+// ReadCString returns the NUL-terminated string at ptr, without its
+// terminating NUL byte.
+func (proc *Process) ReadCString(ptr uint32) (string, error) {
+	return proc.mem().ReadCString(ptr)
+}
+
+// WriteBytes writes p to the memory at ptr. Nothing is written if p
+// doesn't fit in the memory.
+func (proc *Process) WriteBytes(ptr uint32, p []byte) error {
+	return proc.mem().WriteBytes(ptr, p)
+}
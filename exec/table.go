@@ -0,0 +1,170 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"errors"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// ErrTableLimit is returned by (*Table).Grow when growing the table would
+// exceed its maximum size.
+var ErrTableLimit = errors.New("exec: table size exceeds its maximum")
+
+// maxTableSize is the maximum number of elements of a table without a
+// smaller maximum, the same as the one of Web engines.
+const maxTableSize = 10000000
+
+// Table is a handle to a table of a VM, allowing the host to inspect and
+// modify its elements and to call the functions they refer to.
+type Table struct {
+	vm      *VM
+	index   uint32
+	initial uint64 // The initial size of the table
+	max     uint64
+}
+
+// Table returns a handle to the table at the given index of the table
+// index space of the VM's module. The elements of the tables are copied
+// into each VM, so the handle only modifies the table of this VM.
+func (vm *VM) Table(index uint32) (*Table, error) {
+	if int(index) >= len(vm.tables) {
+		return nil, wasm.InvalidTableIndexError(index)
+	}
+	t := &Table{vm: vm, index: index, max: maxTableSize}
+	if typ := vm.tableType(index); typ != nil {
+		if typ.Limits.Flags&0x1 != 0 && uint64(typ.Limits.Maximum) < t.max {
+			t.max = uint64(typ.Limits.Maximum)
+		}
+		t.initial = uint64(typ.Limits.Initial)
+	}
+	return t, nil
+}
+
+// tableType returns the type of the table at the given index, or nil if
+// the module doesn't declare it.
+func (vm *VM) tableType(index uint32) *wasm.Table {
+	m := vm.module
+	if m.Import != nil {
+		for _, entry := range m.Import.Entries {
+			imp, ok := entry.Type.(wasm.TableImport)
+			if !ok {
+				continue
+			}
+			if index == 0 {
+				return &imp.Type
+			}
+			index--
+		}
+	}
+	if m.Table == nil || int(index) >= len(m.Table.Entries) {
+		return nil
+	}
+	return &m.Table.Entries[index]
+}
+
+func (t *Table) entries() []wasm.TableEntry {
+	return t.vm.tables[t.index]
+}
+
+// resize allocates the elements of the table up to n. The elements are
+// only allocated by the module up to the last initialized one.
+func (t *Table) resize(n int) {
+	if n <= len(t.entries()) {
+		return
+	}
+	entries := make([]wasm.TableEntry, n)
+	copy(entries, t.entries())
+	t.vm.tables[t.index] = entries
+}
+
+// Len returns the number of elements in the table.
+func (t *Table) Len() int {
+	if n := len(t.entries()); uint64(n) > t.initial {
+		return n
+	}
+	return int(t.initial)
+}
+
+// Get returns the index of the function the element i of the table refers
+// to. It returns a wasm.UninitializedTableEntryError if the element is
+// not initialized.
+func (t *Table) Get(i uint32) (uint32, error) {
+	if int(i) >= t.Len() {
+		return 0, ErrUndefinedElementIndex
+	}
+	if int(i) >= len(t.entries()) {
+		return 0, wasm.UninitializedTableEntryError(i)
+	}
+	entry := t.entries()[i]
+	if !entry.Initialized {
+		return 0, wasm.UninitializedTableEntryError(i)
+	}
+	return entry.Index, nil
+}
+
+// Set sets the element i of the table to the function at index fnIndex in
+// the function index space.
+func (t *Table) Set(i uint32, fnIndex uint32) error {
+	if int(i) >= t.Len() {
+		return ErrUndefinedElementIndex
+	}
+	if int(fnIndex) >= len(t.vm.funcs) {
+		return InvalidFunctionIndexError(fnIndex)
+	}
+	t.resize(int(i) + 1)
+	t.entries()[i] = wasm.TableEntry{Index: fnIndex, Initialized: true}
+	return nil
+}
+
+// Grow grows the table by n uninitialized elements, and returns its
+// previous length. It returns ErrTableLimit if the table would exceed its
+// maximum size, or 10,000,000 elements if it has none.
+func (t *Table) Grow(n uint32) (int, error) {
+	prev := t.Len()
+	if uint64(prev)+uint64(n) > t.max {
+		return prev, ErrTableLimit
+	}
+	t.resize(prev + int(n))
+	return prev, nil
+}
+
+// Call calls the function the element i of the table refers to, with the
+// given arguments. sig is the signature the caller expects the function to
+// have, ErrSignatureMismatch is returned if the function's signature
+// differs. Like (*Process).Call, it may be called from a host function, and
+// returns ErrHostFunction if the element refers to a host function.
+func (t *Table) Call(i uint32, sig *wasm.FunctionSig, args ...uint64) (interface{}, error) {
+	fnIndex, err := t.Get(i)
+	if err != nil {
+		return nil, err
+	}
+	fn := t.vm.module.GetFunction(int(fnIndex))
+	if fn == nil {
+		return nil, InvalidFunctionIndexError(fnIndex)
+	}
+	if !sameSig(sig, fn.Sig) {
+		return nil, ErrSignatureMismatch
+	}
+	return t.vm.reenter(int64(fnIndex), args...)
+}
+
+func sameSig(a, b *wasm.FunctionSig) bool {
+	if len(a.ParamTypes) != len(b.ParamTypes) || len(a.ReturnTypes) != len(b.ReturnTypes) {
+		return false
+	}
+	for i := range a.ParamTypes {
+		if a.ParamTypes[i] != b.ParamTypes[i] {
+			return false
+		}
+	}
+	for i := range a.ReturnTypes {
+		if a.ReturnTypes[i] != b.ReturnTypes[i] {
+			return false
+		}
+	}
+	return true
+}
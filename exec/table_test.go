@@ -0,0 +1,230 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+func TestTable(t *testing.T) {
+	unarySig := wasm.FunctionSig{
+		Form:        0x60,
+		ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32},
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+	constSig := wasm.FunctionSig{
+		Form:        0x60,
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{unarySig, constSig}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0, 1, 1}}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{
+		// (func $inc (param i32) (result i32) (i32.add (get_local 0) (i32.const 1)))
+		{Module: m, Code: []byte{ops.GetLocal, 0, ops.I32Const, 1, ops.I32Add}},
+		// (func $seven (result i32) (i32.const 7))
+		{Module: m, Code: []byte{ops.I32Const, 7}},
+		// (func (result i32) (call_indirect (type 0) (i32.const 5) (i32.const 1)))
+		{Module: m, Code: []byte{ops.I32Const, 5, ops.I32Const, 1, ops.CallIndirect, 0, 0}},
+	}}
+	for i, typ := range m.Function.Types {
+		m.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{
+			Sig:  &m.Types.Entries[typ],
+			Body: &m.Code.Bodies[i],
+		})
+	}
+	m.Function.Types = append(m.Function.Types, 1)
+	m.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{
+		Sig:  &constSig,
+		Host: reflect.ValueOf(func(*Process) int32 { return 0 }),
+		Body: &wasm.FunctionBody{},
+	})
+	m.Table = &wasm.SectionTables{Entries: []wasm.Table{{
+		ElementType: wasm.ElemTypeAnyFunc,
+		Limits:      wasm.ResizableLimits{Flags: 1, Initial: 2, Maximum: 3},
+	}}}
+	m.TableIndexSpace = [][]wasm.TableEntry{{{Index: 1, Initialized: true}}}
+
+	vm, err := NewVM(m)
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	vm.RecoverPanic = true
+
+	if _, err := vm.Table(1); err != wasm.InvalidTableIndexError(1) {
+		t.Fatalf("Table(1): got error %v", err)
+	}
+	table, err := vm.Table(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := table.Len(); got != 2 {
+		t.Errorf("Len: got %d, want 2", got)
+	}
+	if got := len(m.TableIndexSpace[0]); got != 1 {
+		t.Errorf("Table allocated the elements of the module: got %d elements, want 1", got)
+	}
+
+	if got, err := table.Get(0); err != nil || got != 1 {
+		t.Errorf("Get(0): got %d, %v, want 1, nil", got, err)
+	}
+	if _, err := table.Get(1); err != wasm.UninitializedTableEntryError(1) {
+		t.Errorf("Get(1): got error %v", err)
+	}
+	if _, err := table.Get(2); err != ErrUndefinedElementIndex {
+		t.Errorf("Get(2): got error %v", err)
+	}
+
+	res, err := table.Call(0, &constSig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(uint32); got != 7 {
+		t.Errorf("Call(0): got %d, want 7", got)
+	}
+	if _, err := table.Call(0, &unarySig, 1); err != ErrSignatureMismatch {
+		t.Errorf("Call(0) with wrong signature: got error %v", err)
+	}
+
+	// The guest observes elements set by the host.
+	if _, err := vm.ExecCode(2); err == nil {
+		t.Error("call_indirect to an uninitialized element did not trap")
+	}
+	if err := table.Set(1, 0); err != nil {
+		t.Fatal(err)
+	}
+	res, err = vm.ExecCode(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(uint32); got != 6 {
+		t.Errorf("call_indirect after Set: got %d, want 6", got)
+	}
+	if err := table.Set(1, 4); err != InvalidFunctionIndexError(4) {
+		t.Errorf("Set with invalid function: got error %v", err)
+	}
+	if err := table.Set(0, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Call(0, &constSig); err != ErrHostFunction {
+		t.Errorf("Call(0) of a host function: got error %v, want %v", err, ErrHostFunction)
+	}
+
+	if prev, err := table.Grow(1); err != nil || prev != 2 {
+		t.Errorf("Grow(1): got %d, %v, want 2, nil", prev, err)
+	}
+	if _, err := table.Grow(1); err != ErrTableLimit {
+		t.Errorf("Grow past maximum: got error %v", err)
+	}
+	if got := table.Len(); got != 3 {
+		t.Errorf("Len after Grow: got %d, want 3", got)
+	}
+}
+
+func TestTableGrowLimit(t *testing.T) {
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Table = &wasm.SectionTables{Entries: []wasm.Table{{
+		ElementType: wasm.ElemTypeAnyFunc,
+		Limits:      wasm.ResizableLimits{Initial: 1},
+	}}}
+	m.TableIndexSpace = [][]wasm.TableEntry{nil}
+
+	vm, err := NewVM(m)
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	table, err := vm.Table(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Grow(math.MaxUint32); err != ErrTableLimit {
+		t.Errorf("Grow(MaxUint32): got error %v, want %v", err, ErrTableLimit)
+	}
+	if prev, err := table.Grow(2); err != nil || prev != 1 {
+		t.Errorf("Grow(2): got %d, %v, want 1, nil", prev, err)
+	}
+	if got := table.Len(); got != 3 {
+		t.Errorf("Len after Grow: got %d, want 3", got)
+	}
+}
+
+func TestTableInstances(t *testing.T) {
+	constSig := wasm.FunctionSig{
+		Form:        0x60,
+		ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+	}
+
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{constSig}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{0, 0}}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{
+		// (func $seven (result i32) (i32.const 7))
+		{Module: m, Code: []byte{ops.I32Const, 7}},
+		// (func (result i32) (call_indirect (type 0) (i32.const 0)))
+		{Module: m, Code: []byte{ops.I32Const, 0, ops.CallIndirect, 0, 0}},
+	}}
+	for i, typ := range m.Function.Types {
+		m.FunctionIndexSpace = append(m.FunctionIndexSpace, wasm.Function{
+			Sig:  &m.Types.Entries[typ],
+			Body: &m.Code.Bodies[i],
+		})
+	}
+	m.Table = &wasm.SectionTables{Entries: []wasm.Table{{
+		ElementType: wasm.ElemTypeAnyFunc,
+		Limits:      wasm.ResizableLimits{Initial: 1},
+	}}}
+	m.TableIndexSpace = [][]wasm.TableEntry{nil}
+
+	var tables [2]*Table
+	var vms [2]*VM
+	for i := range vms {
+		vm, err := NewVM(m)
+		if err != nil {
+			t.Fatalf("Could not instantiate vm: %v", err)
+		}
+		vm.RecoverPanic = true
+		if tables[i], err = vm.Table(0); err != nil {
+			t.Fatal(err)
+		}
+		vms[i] = vm
+	}
+
+	if err := tables[0].Set(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tables[0].Grow(1); err != nil {
+		t.Fatal(err)
+	}
+	res, err := vms[0].ExecCode(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.(uint32); got != 7 {
+		t.Errorf("call_indirect after Set: got %d, want 7", got)
+	}
+
+	// The other instance doesn't observe the changes.
+	if _, err := tables[1].Get(0); err != wasm.UninitializedTableEntryError(0) {
+		t.Errorf("Get(0) of the other instance: got error %v", err)
+	}
+	if got := tables[1].Len(); got != 1 {
+		t.Errorf("Len of the other instance: got %d, want 1", got)
+	}
+	if _, err := vms[1].ExecCode(1); err == nil {
+		t.Error("call_indirect of the other instance did not trap")
+	}
+	if got := len(m.TableIndexSpace[0]); got != 0 {
+		t.Errorf("Set modified the module: got %d elements, want 0", got)
+	}
+}
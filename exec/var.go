@@ -22,10 +22,25 @@ func (vm *VM) teeLocal() {
 
 func (vm *VM) getGlobal() {
 	index := vm.fetchUint32()
-	vm.pushUint64(vm.globals[int(index)])
+	vm.pushUint64(vm.getGlobalBits(index))
 }
 
 func (vm *VM) setGlobal() {
 	index := vm.fetchUint32()
-	vm.globals[int(index)] = vm.popUint64()
+	vm.setGlobalBits(index, vm.popUint64())
+}
+
+func (vm *VM) getGlobalBits(index uint32) uint64 {
+	if vm.shared != nil && vm.shared[index] != nil {
+		return vm.shared[index].Bits()
+	}
+	return vm.globals[index]
+}
+
+func (vm *VM) setGlobalBits(index uint32, v uint64) {
+	if vm.shared != nil && vm.shared[index] != nil {
+		vm.shared[index].SetBits(v)
+		return
+	}
+	vm.globals[index] = v
 }
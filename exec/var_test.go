@@ -0,0 +1,179 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package exec
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+var (
+	// (module
+	//   (global (export "g") (mut i32) (i32.const 42))
+	//   (func (export "get") (result i32) (get_global 0))
+	//   (func (export "set") (param i32) (set_global 0 (get_local 0))))
+	moduleExportGlobal = []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		0x01, 0x09, 0x02, 0x60, 0x00, 0x01, 0x7f, 0x60, 0x01, 0x7f, 0x00,
+		0x03, 0x03, 0x02, 0x00, 0x01,
+		0x06, 0x06, 0x01, 0x7f, 0x01, 0x41, 0x2a, 0x0b,
+		0x07, 0x11, 0x03,
+		0x01, 'g', 0x03, 0x00,
+		0x03, 'g', 'e', 't', 0x00, 0x00,
+		0x03, 's', 'e', 't', 0x00, 0x01,
+		0x0a, 0x0d, 0x02,
+		0x04, 0x00, 0x23, 0x00, 0x0b,
+		0x06, 0x00, 0x20, 0x00, 0x24, 0x00, 0x0b,
+	}
+	// (module
+	//   (import "env" "g" (global (mut i32)))
+	//   (func (export "get") (result i32) (get_global 0))
+	//   (func (export "set") (param i32) (set_global 0 (get_local 0))))
+	moduleImportGlobal = []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		0x01, 0x09, 0x02, 0x60, 0x00, 0x01, 0x7f, 0x60, 0x01, 0x7f, 0x00,
+		0x02, 0x0a, 0x01, 0x03, 'e', 'n', 'v', 0x01, 'g', 0x03, 0x7f, 0x01,
+		0x03, 0x03, 0x02, 0x00, 0x01,
+		0x07, 0x0d, 0x02,
+		0x03, 'g', 'e', 't', 0x00, 0x00,
+		0x03, 's', 'e', 't', 0x00, 0x01,
+		0x0a, 0x0d, 0x02,
+		0x04, 0x00, 0x23, 0x00, 0x0b,
+		0x06, 0x00, 0x20, 0x00, 0x24, 0x00, 0x0b,
+	}
+)
+
+func TestSharedGlobal(t *testing.T) {
+	exporter, err := wasm.ReadModule(bytes.NewReader(moduleExportGlobal), nil)
+	if err != nil {
+		t.Fatalf("Could not read exporting module: %v", err)
+	}
+	importer, err := wasm.ReadModule(bytes.NewReader(moduleImportGlobal), func(name string) (*wasm.Module, error) {
+		return exporter, nil
+	})
+	if err != nil {
+		t.Fatalf("Could not read importing module: %v", err)
+	}
+
+	vm1, err := NewVM(exporter)
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+	vm2, err := NewVM(importer)
+	if err != nil {
+		t.Fatalf("Could not instantiate vm: %v", err)
+	}
+
+	get := func(vm *VM) uint32 {
+		t.Helper()
+		res, err := vm.ExecCode(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.(uint32)
+	}
+
+	if got := get(vm2); got != 42 {
+		t.Errorf("imported global: got %d, want 42", got)
+	}
+	if _, err := vm2.ExecCode(1, 7); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(vm1); got != 7 {
+		t.Errorf("exported global after set by importer: got %d, want 7", got)
+	}
+
+	g := exporter.GetGlobal(0).Cell
+	if err := g.Set(int32(9)); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(vm2); got != 9 {
+		t.Errorf("imported global after set by host: got %d, want 9", got)
+	}
+	if v, ok := vm1.GetGlobal("g"); !ok || v != 9 {
+		t.Errorf("GetGlobal: got %d, %v, want 9, true", v, ok)
+	}
+	if cell, ok := vm1.ExportedGlobal("g"); !ok || cell != g {
+		t.Errorf("ExportedGlobal: got %p, %v, want the cell of the module %p", cell, ok, g)
+	}
+}
+
+func TestGlobalIsolation(t *testing.T) {
+	m, err := wasm.ReadModule(bytes.NewReader(moduleExportGlobal), nil)
+	if err != nil {
+		t.Fatalf("Could not read module: %v", err)
+	}
+	newVM := func() *VM {
+		t.Helper()
+		vm, err := NewVM(m)
+		if err != nil {
+			t.Fatalf("Could not instantiate vm: %v", err)
+		}
+		return vm
+	}
+	get := func(vm *VM) uint32 {
+		t.Helper()
+		res, err := vm.ExecCode(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res.(uint32)
+	}
+
+	vm1, vm2 := newVM(), newVM()
+	if _, err := vm1.ExecCode(1, 7); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(vm1); got != 7 {
+		t.Errorf("global after set: got %d, want 7", got)
+	}
+	if got := get(vm2); got != 42 {
+		t.Errorf("global of another instance: got %d, want 42", got)
+	}
+	if got := get(newVM()); got != 42 {
+		t.Errorf("global of a new instance: got %d, want 42", got)
+	}
+
+	g, ok := vm1.ExportedGlobal("g")
+	if !ok {
+		t.Fatal("ExportedGlobal: no global g")
+	}
+	if err := g.Set(int32(9)); err != nil {
+		t.Fatal(err)
+	}
+	if got := get(vm1); got != 9 {
+		t.Errorf("global after set by host: got %d, want 9", got)
+	}
+	if got := get(vm2); got != 42 {
+		t.Errorf("global of another instance after set by host: got %d, want 42", got)
+	}
+
+	vm1.Restart()
+	if got := get(vm1); got != 42 {
+		t.Errorf("global after restart: got %d, want 42", got)
+	}
+	if m.GetGlobal(0).Cell != nil {
+		t.Error("the instances bound the global of the module to a cell")
+	}
+}
+
+func TestImportGlobalTypeMismatch(t *testing.T) {
+	exporter, err := wasm.ReadModule(bytes.NewReader(moduleExportGlobal), nil)
+	if err != nil {
+		t.Fatalf("Could not read exporting module: %v", err)
+	}
+
+	// import the global as immutable.
+	raw := append([]byte(nil), moduleImportGlobal...)
+	raw[bytes.Index(raw, []byte{0x03, 0x7f, 0x01})+2] = 0x00
+	_, err = wasm.ReadModule(bytes.NewReader(raw), func(name string) (*wasm.Module, error) {
+		return exporter, nil
+	})
+	if _, ok := err.(wasm.InvalidImportError); !ok {
+		t.Fatalf("got error %v, want wasm.InvalidImportError", err)
+	}
+}
@@ -9,7 +9,6 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
-	"io"
 	"math"
 
 	"github.com/go-interpreter/wagon/disasm"
@@ -19,12 +18,18 @@ import (
 )
 
 var (
-	// ErrMultipleLinearMemories is returned by (*VM).NewVM when the module
-	// has more then one entries in the linear memory space.
+	// ErrMultipleLinearMemories is no longer returned, as modules may
+	// have more than one linear memory.
 	ErrMultipleLinearMemories = errors.New("exec: more than one linear memories in module")
 	// ErrInvalidArgumentCount is returned by (*VM).ExecCode when an invalid
 	// number of arguments to the WebAssembly function are passed to it.
 	ErrInvalidArgumentCount = errors.New("exec: invalid number of arguments to function")
+	// ErrCallStackExhausted is the error value used while trapping the VM
+	// when the calls nest deeper than the limit set with MaxCallDepth.
+	ErrCallStackExhausted = errors.New("exec: call stack exhausted")
+	// ErrHostFunction is returned by (*Process).Call when the function to
+	// call is a host function, which only the module may call.
+	ErrHostFunction = errors.New("exec: cannot call a host function")
 )
 
 // InvalidReturnTypeError is returned by (*VM).ExecCode when the module
@@ -50,6 +55,8 @@ type context struct {
 	asm     []asmBlock
 	pc      int64
 	curFunc int64
+
+	tries []tryState // allocated on entering the first try block
 }
 
 // VM is the execution context for executing WebAssembly bytecode.
@@ -60,6 +67,12 @@ type VM struct {
 	globals []uint64
 	memory  []byte
 	funcs   []function
+	tables  [][]wasm.TableEntry // The elements of the tables of the instance
+
+	// shared holds the cells of the exported globals and of the globals
+	// bound by an import or by the host, indexed like globals. It is nil
+	// if there are none.
+	shared []*wasm.Global
 
 	funcTable [256]func()
 
@@ -72,7 +85,28 @@ type VM struct {
 
 	abort bool // Flag for host functions to terminate execution
 
+	memory64 bool // Whether the first linear memory is indexed with 64-bit addresses
+
+	// memories holds the linear memories following the first one, in
+	// modules with more than one. It is nil otherwise.
+	memories []linearMemory
+
+	exception *Exception // The exception being thrown, if any
+
 	nativeBackend *nativeCompiler
+
+	allocators []Allocator // The allocators the module may export, in order of preference
+
+	fuel        uint64 // Instructions left to execute, if metered
+	metered     bool   // Whether the execution is limited by fuel
+	interrupted uint32 // Set atomically by Interrupt
+	limited     bool   // Whether the execution is metered or interruptible
+	interrupt   bool   // Whether the execution is interruptible
+
+	maxCallDepth int // Maximum number of nested calls, 0 if unlimited
+	callDepth    int // Number of nested calls of the execution
+
+	maxMemoryPages uint64 // Maximum size of the linear memories
 }
 
 // As per the WebAssembly spec: https://github.com/WebAssembly/design/blob/27ac254c854994103c24834a994be16f74f54186/Semantics.md#linear-memory
@@ -80,8 +114,17 @@ const wasmPageSize = 65536 // (64 KB)
 
 var endianess = binary.LittleEndian
 
+// defaultMaxMemoryPages is the default maximum size of linear memories,
+// which is the one of 32-bit memories.
+const defaultMaxMemoryPages = 1 << 16
+
 type config struct {
-	EnableAOT bool
+	EnableAOT       bool
+	Features        wasm.Features
+	Allocators      []Allocator
+	MaxCallDepth    int
+	MaxMemoryPages  uint64
+	EnableInterrupt bool
 }
 
 // VMOption describes a customization that can be applied to the VM.
@@ -96,27 +139,91 @@ func EnableAOT(v bool) VMOption {
 	}
 }
 
+// EnableFeatures restricts the features the module may use to f, for
+// instance to wasm.FeaturesMVP to only accept modules of the MVP. All the
+// features supported by wagon are enabled by default.
+func EnableFeatures(f wasm.Features) VMOption {
+	return func(c *config) {
+		c.Features = f
+	}
+}
+
+// MaxCallDepth limits the number of calls the execution of a function may
+// nest to n, beyond which the VM traps with ErrCallStackExhausted. The
+// number of nested calls is only bounded by the Go stack by default, whose
+// exhaustion is fatal.
+func MaxCallDepth(n int) VMOption {
+	return func(c *config) {
+		c.MaxCallDepth = n
+	}
+}
+
+// MaxMemoryPages limits the size of the linear memories to n pages of 64
+// KiB, beyond which memory.grow fails and the instantiation of a module
+// returns ErrMemoryLimit. The default limit is the maximum size of 32-bit
+// memories, 4 GiB: 64-bit memories may only exceed it if the limit is
+// raised, as the memories are allocated up front.
+func MaxMemoryPages(n uint64) VMOption {
+	return func(c *config) {
+		c.MaxMemoryPages = n
+	}
+}
+
+// EnableInterrupt allows the execution of the VM to be stopped with
+// (*VM).Interrupt. It is disabled by default, as checking for interruptions
+// slows down the execution of every instruction.
+func EnableInterrupt(v bool) VMOption {
+	return func(c *config) {
+		c.EnableInterrupt = v
+	}
+}
+
 // NewVM creates a new VM from a given module and options. If the module defines
 // a start function, it will be executed.
 func NewVM(module *wasm.Module, opts ...VMOption) (*VM, error) {
 	var vm VM
-	var options config
+	options := config{Features: wasm.FeaturesAll, Allocators: DefaultAllocators, MaxMemoryPages: defaultMaxMemoryPages}
 	for _, opt := range opts {
 		opt(&options)
 	}
+	if err := module.CheckFeatures(options.Features); err != nil {
+		return nil, err
+	}
+	vm.allocators = options.Allocators
+	vm.maxCallDepth = options.MaxCallDepth
+	vm.maxMemoryPages = options.MaxMemoryPages
+	vm.interrupt, vm.limited = options.EnableInterrupt, options.EnableInterrupt
 
-	if module.Memory != nil && len(module.Memory.Entries) != 0 {
-		if len(module.Memory.Entries) > 1 {
-			return nil, ErrMultipleLinearMemories
+	if n := len(module.LinearMemoryIndexSpace); n > 1 {
+		vm.memories = make([]linearMemory, n-1)
+	}
+	for i := range module.LinearMemoryIndexSpace {
+		mem := module.GetMemory(i)
+		if mem == nil {
+			continue
+		}
+		if mem.Limits.Initial > vm.maxMemoryPages || mem.Limits.Initial > math.MaxInt64/wasmPageSize {
+			return nil, ErrMemoryLimit
+		}
+		data := make([]byte, mem.Limits.Initial*wasmPageSize)
+		copy(data, module.LinearMemoryIndexSpace[i])
+		if i == 0 {
+			vm.memory, vm.memory64 = data, mem.Limits.Is64()
+		} else {
+			vm.memories[i-1] = linearMemory{data: data, memory64: mem.Limits.Is64()}
 		}
-		vm.memory = make([]byte, uint(module.Memory.Entries[0].Limits.Initial)*wasmPageSize)
-		copy(vm.memory, module.LinearMemoryIndexSpace[0])
+	}
+
+	vm.tables = make([][]wasm.TableEntry, len(module.TableIndexSpace))
+	for i, entries := range module.TableIndexSpace {
+		vm.tables[i] = append([]wasm.TableEntry(nil), entries...)
 	}
 
 	vm.funcs = make([]function, len(module.FunctionIndexSpace))
 	vm.globals = make([]uint64, len(module.GlobalIndexSpace))
-	vm.newFuncTable()
 	vm.module = module
+	vm.newGlobalCells()
+	vm.newFuncTable()
 
 	nNatives := 0
 	for i, fn := range module.FunctionIndexSpace {
@@ -139,6 +246,11 @@ func NewVM(module *wasm.Module, opts ...VMOption) (*VM, error) {
 		if err != nil {
 			return nil, err
 		}
+		for _, instr := range disassembly.Code {
+			if !options.Features.Has(instr.Op.Feature) {
+				return nil, wasm.DisabledFeatureError(instr.Op.Feature)
+			}
+		}
 
 		totalLocalVars := 0
 		totalLocalVars += len(fn.Sig.ParamTypes)
@@ -168,7 +280,8 @@ func NewVM(module *wasm.Module, opts ...VMOption) (*VM, error) {
 		}
 	}
 
-	if options.EnableAOT {
+	// The native backend only supports 32-bit accesses to a single memory.
+	if options.EnableAOT && !vm.memory64 && vm.memories == nil {
 		supportedBackend, backend := nativeBackend()
 		if supportedBackend {
 			vm.nativeBackend = backend
@@ -181,22 +294,60 @@ func NewVM(module *wasm.Module, opts ...VMOption) (*VM, error) {
 	return &vm, nil
 }
 
+// newGlobalCells sets the cells of the globals of the VM. The globals bound
+// to a cell by an import or by the host share the cell of the module, and
+// the exported globals get a cell of their own, which the host accesses
+// with ExportedGlobal.
+func (vm *VM) newGlobalCells() {
+	set := func(i uint32, cell *wasm.Global) {
+		if vm.shared == nil {
+			vm.shared = make([]*wasm.Global, len(vm.globals))
+		}
+		vm.shared[i] = cell
+	}
+	for i, global := range vm.module.GlobalIndexSpace {
+		if global.Cell != nil {
+			set(uint32(i), global.Cell)
+		}
+	}
+	if vm.module.Export == nil {
+		return
+	}
+	for _, name := range vm.module.Export.Names {
+		entry := vm.module.Export.Entries[name]
+		if entry.Kind != wasm.ExternalGlobal || int(entry.Index) >= len(vm.globals) {
+			continue
+		}
+		if vm.shared == nil || vm.shared[entry.Index] == nil {
+			set(entry.Index, &wasm.Global{Type: vm.module.GlobalIndexSpace[entry.Index].Type})
+		}
+	}
+}
+
+// resetGlobals sets the globals of the instance to their initial value.
+// The globals bound to the cell of their module are left untouched, as
+// they are shared with other instances or the host.
 func (vm *VM) resetGlobals() error {
 	for i, global := range vm.module.GlobalIndexSpace {
+		if global.Cell != nil {
+			continue
+		}
 		val, err := vm.module.ExecInitExpr(global.Init)
 		if err != nil {
 			return err
 		}
+		var bits uint64
 		switch v := val.(type) {
 		case int32:
-			vm.globals[i] = uint64(v)
+			bits = uint64(v)
 		case int64:
-			vm.globals[i] = uint64(v)
+			bits = uint64(v)
 		case float32:
-			vm.globals[i] = uint64(math.Float32bits(v))
+			bits = uint64(math.Float32bits(v))
 		case float64:
-			vm.globals[i] = uint64(math.Float64bits(v))
+			bits = uint64(math.Float64bits(v))
 		}
+		vm.setGlobalBits(uint32(i), bits)
 	}
 
 	return nil
@@ -224,7 +375,22 @@ func (vm *VM) GetGlobal(name string) (uint64, bool) {
 		return 0, false
 	}
 
-	return vm.globals[index], true
+	return vm.getGlobalBits(index), true
+}
+
+// ExportedGlobal returns the cell holding the value of the global exported
+// as name by this VM's Wasm module. Unless the global is bound to the cell
+// of the module by an import or by the host, the cell belongs to this VM.
+func (vm *VM) ExportedGlobal(name string) (*wasm.Global, bool) {
+	if vm.module.Export == nil || vm.shared == nil {
+		return nil, false
+	}
+	entry, ok := vm.module.Export.Entries[name]
+	if !ok || entry.Kind != wasm.ExternalGlobal || int(entry.Index) >= len(vm.shared) {
+		return nil, false
+	}
+	cell := vm.shared[entry.Index]
+	return cell, cell != nil
 }
 
 func (vm *VM) pushBool(v bool) {
@@ -365,6 +531,7 @@ func (vm *VM) ExecCode(fnIndex int64, args ...uint64) (rtrn interface{}, err err
 	}
 
 	vm.ctx.locals = make([]uint64, compiled.totalLocalVars)
+	vm.ctx.tries = nil
 	vm.ctx.pc = 0
 	vm.ctx.code = compiled.code
 	vm.ctx.asm = compiled.asm
@@ -375,6 +542,11 @@ func (vm *VM) ExecCode(fnIndex int64, args ...uint64) (rtrn interface{}, err err
 	}
 
 	res := vm.execCode(compiled)
+	if vm.exception != nil {
+		e := vm.exception
+		vm.exception = nil
+		return nil, e
+	}
 	if compiled.returns {
 		rtrnType := vm.module.GetFunction(int(fnIndex)).Sig.ReturnTypes[0]
 		switch rtrnType {
@@ -397,6 +569,9 @@ func (vm *VM) ExecCode(fnIndex int64, args ...uint64) (rtrn interface{}, err err
 func (vm *VM) execCode(compiled compiledFunction) uint64 {
 outer:
 	for int(vm.ctx.pc) < len(vm.ctx.code) && !vm.abort {
+		if vm.limited {
+			vm.consumeFuel()
+		}
 		op := vm.ctx.code[vm.ctx.pc]
 		vm.ctx.pc++
 		switch op {
@@ -464,15 +639,42 @@ outer:
 			vm.ctx.stack = vm.ctx.stack[:len(vm.ctx.stack)-int(place)]
 			vm.pushUint64(top)
 
+		case ops.ReturnCall, ops.ReturnCallIndirect:
+			var index int64
+			if op == ops.ReturnCall {
+				index = int64(vm.fetchUint32())
+			} else {
+				index = int64(vm.fetchIndirectTarget())
+			}
+			callee, ok := vm.tailCall(index)
+			if !ok {
+				break outer
+			}
+			compiled = callee
+			continue
+		case compile.OpTry:
+			vm.try(compiled)
+		case ops.Throw, ops.Rethrow:
+			if op == ops.Throw {
+				vm.throw()
+			} else {
+				vm.rethrow()
+			}
+			if !vm.catchException(compiled) {
+				break outer
+			}
 		case ops.WagonNativeExec:
 			i := vm.fetchUint32()
 			vm.nativeCodeInvocation(i)
 		default:
 			vm.funcTable[op]()
+			if vm.exception != nil && !vm.catchException(compiled) {
+				break outer
+			}
 		}
 	}
 
-	if compiled.returns && !vm.abort {
+	if compiled.returns && !vm.abort && vm.exception == nil {
 		return vm.ctx.stack[len(vm.ctx.stack)-1]
 	}
 	return 0
@@ -483,6 +685,7 @@ func (vm *VM) Restart() {
 	vm.resetGlobals()
 	vm.ctx.locals = make([]uint64, 0)
 	vm.abort = false
+	vm.exception = nil
 }
 
 // Close frees any resources managed by the VM.
@@ -510,45 +713,13 @@ func NewProcess(vm *VM) *Process {
 // ReadAt implements the ReaderAt interface: it copies into p
 // the content of memory at offset off.
 func (proc *Process) ReadAt(p []byte, off int64) (int, error) {
-	mem := proc.vm.Memory()
-
-	var length int
-	if len(mem) < len(p)+int(off) {
-		length = len(mem) - int(off)
-	} else {
-		length = len(p)
-	}
-
-	copy(p, mem[off:off+int64(length)])
-
-	var err error
-	if length < len(p) {
-		err = io.ErrShortBuffer
-	}
-
-	return length, err
+	return proc.mem().ReadAt(p, off)
 }
 
 // WriteAt implements the WriterAt interface: it writes the content of p
 // into the VM memory at offset off.
 func (proc *Process) WriteAt(p []byte, off int64) (int, error) {
-	mem := proc.vm.Memory()
-
-	var length int
-	if len(mem) < len(p)+int(off) {
-		length = len(mem) - int(off)
-	} else {
-		length = len(p)
-	}
-
-	copy(mem[off:], p[:length])
-
-	var err error
-	if length < len(p) {
-		err = io.ErrShortWrite
-	}
-
-	return length, err
+	return proc.mem().WriteAt(p, off)
 }
 
 // MemSize returns the current allocated memory size in bytes.
@@ -560,3 +731,74 @@ func (proc *Process) MemSize() int {
 func (proc *Process) Terminate() {
 	proc.vm.abort = true
 }
+
+// UnknownExportError is returned by (*Process).Call when the module doesn't
+// export a function with the given name.
+type UnknownExportError string
+
+func (e UnknownExportError) Error() string {
+	return fmt.Sprintf("exec: no function exported as %q", string(e))
+}
+
+// Call calls the function fn of the VM's module from a host function, and
+// returns its result the same way (*VM).ExecCode does. fn is either the name
+// the function is exported as, or its index in the function index space.
+// The execution context of the calling function is saved, and restored once
+// fn returns, allowing host functions to call back into the module.
+func (proc *Process) Call(fn interface{}, args ...uint64) (interface{}, error) {
+	vm := proc.vm
+	index, err := vm.funcIndex(fn)
+	if err != nil {
+		return nil, err
+	}
+	return vm.reenter(index, args...)
+}
+
+// reenter executes the function at the given index the same way ExecCode
+// does, saving and restoring the execution context of the function being
+// executed, if any, and whether it was terminated. It returns
+// ErrHostFunction if the function is a host function.
+func (vm *VM) reenter(index int64, args ...uint64) (interface{}, error) {
+	if _, ok := vm.funcs[index].(compiledFunction); !ok {
+		return nil, ErrHostFunction
+	}
+	prevCtxt, prevAbort := vm.ctx, vm.abort
+	vm.ctx = context{}
+	vm.abort = false
+	defer func() {
+		vm.ctx = prevCtxt
+		vm.abort = prevAbort
+	}()
+	return vm.ExecCode(index, args...)
+}
+
+// funcIndex returns the index of the function fn, which is either the name
+// it is exported as or its index.
+func (vm *VM) funcIndex(fn interface{}) (int64, error) {
+	var index int64
+	switch fn := fn.(type) {
+	case string:
+		if vm.module.Export == nil {
+			return 0, UnknownExportError(fn)
+		}
+		entry, ok := vm.module.Export.Entries[fn]
+		if !ok || entry.Kind != wasm.ExternalFunction {
+			return 0, UnknownExportError(fn)
+		}
+		index = int64(entry.Index)
+	case int:
+		index = int64(fn)
+	case int32:
+		index = int64(fn)
+	case int64:
+		index = fn
+	case uint32:
+		index = int64(fn)
+	default:
+		return 0, fmt.Errorf("exec: invalid function %v of type %T", fn, fn)
+	}
+	if index < 0 || index >= int64(len(vm.funcs)) {
+		return 0, InvalidFunctionIndexError(index)
+	}
+	return index, nil
+}
@@ -5,6 +5,7 @@
 package exec
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -110,3 +111,90 @@ func TestWriteOffset(t *testing.T) {
 		t.Fatal("Writing at offset didn't work")
 	}
 }
+
+func TestReadWriteOutOfRange(t *testing.T) {
+	buf := make([]byte, 1)
+	if _, err := smallMemoryProcess.ReadAt(buf, 4); err != ErrOutOfBoundsMemoryAccess {
+		t.Fatalf("ReadAt past the end: got error %v, want %v", err, ErrOutOfBoundsMemoryAccess)
+	}
+	if _, err := smallMemoryProcess.WriteAt(buf, -1); err != ErrOutOfBoundsMemoryAccess {
+		t.Fatalf("WriteAt before the start: got error %v, want %v", err, ErrOutOfBoundsMemoryAccess)
+	}
+	n, err := smallMemoryProcess.ReadAt(buf, 3)
+	if err == nil || n != 0 {
+		t.Fatalf("ReadAt at the end: got %d, %v", n, err)
+	}
+}
+
+func TestTypedAccessors(t *testing.T) {
+	vm := &VM{memory: make([]byte, 16)}
+	proc := &Process{vm: vm}
+
+	if err := proc.WriteUint64Le(0, 0x0102030405060708); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := vm.memory[:8], []byte{8, 7, 6, 5, 4, 3, 2, 1}; !bytes.Equal(got, want) {
+		t.Fatalf("WriteUint64Le: got %v, want %v", got, want)
+	}
+	if v, err := proc.ReadUint32Le(4); err != nil || v != 0x01020304 {
+		t.Fatalf("ReadUint32Le: got %#x, %v", v, err)
+	}
+	if v, err := proc.ReadUint16Le(0); err != nil || v != 0x0708 {
+		t.Fatalf("ReadUint16Le: got %#x, %v", v, err)
+	}
+
+	if err := proc.WriteFloat64(8, -2.5); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := proc.ReadFloat64(8); err != nil || v != -2.5 {
+		t.Fatalf("ReadFloat64: got %v, %v", v, err)
+	}
+	if err := proc.WriteFloat32(12, 1.5); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := proc.ReadFloat32(12); err != nil || v != 1.5 {
+		t.Fatalf("ReadFloat32: got %v, %v", v, err)
+	}
+
+	if err := proc.WriteBytes(2, []byte("hi\x00")); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := proc.ReadString(2, 2); err != nil || s != "hi" {
+		t.Fatalf("ReadString: got %q, %v", s, err)
+	}
+	if s, err := proc.ReadCString(2); err != nil || s != "hi" {
+		t.Fatalf("ReadCString: got %q, %v", s, err)
+	}
+
+	view, err := proc.MemoryView(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	view[0] = 'H'
+	if s, _ := proc.ReadString(2, 2); s != "Hi" {
+		t.Fatalf("MemoryView is not a view of the memory: got %q", s)
+	}
+
+	for _, tc := range []struct {
+		name string
+		err  error
+	}{
+		{"ReadUint32Le", func() error { _, err := proc.ReadUint32Le(13); return err }()},
+		{"ReadUint64Le", func() error { _, err := proc.ReadUint64Le(0xffffffff); return err }()},
+		{"WriteUint64Le", proc.WriteUint64Le(9, 0)},
+		{"ReadString", func() error { _, err := proc.ReadString(10, 7); return err }()},
+		{"ReadCString", func() error { _, err := proc.ReadCString(16); return err }()},
+		{"WriteBytes", proc.WriteBytes(15, []byte{1, 2})},
+		{"MemoryView", func() error { _, err := proc.MemoryView(17, 0); return err }()},
+	} {
+		if tc.err != ErrOutOfBoundsMemoryAccess {
+			t.Errorf("%s out of bounds: got error %v, want %v", tc.name, tc.err, ErrOutOfBoundsMemoryAccess)
+		}
+	}
+
+	// Unterminated strings are out of bounds too.
+	vm.memory[15] = 'x'
+	if _, err := proc.ReadCString(15); err != ErrOutOfBoundsMemoryAccess {
+		t.Errorf("unterminated ReadCString: got error %v, want %v", err, ErrOutOfBoundsMemoryAccess)
+	}
+}
@@ -0,0 +1,483 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gojs
+
+import (
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-interpreter/wagon/vfs"
+)
+
+// The fs object implements the subset of the Node.js fs module used by the
+// syscall package of the guest, with the asynchronous, callback based,
+// signatures. Callbacks are called before the methods return.
+
+// Flags of Node.js, as found in fs.constants on Linux.
+const (
+	nodeWRONLY    = 1
+	nodeRDWR      = 2
+	nodeCREAT     = 64
+	nodeEXCL      = 128
+	nodeTRUNC     = 512
+	nodeAPPEND    = 1024
+	nodeDIRECTORY = 65536
+)
+
+// Types of files, in the mode of stat objects.
+const (
+	sIFCHR = 0020000
+	sIFDIR = 0040000
+	sIFREG = 0100000
+	sIFLNK = 0120000
+)
+
+// file is a file opened by the program.
+type file struct {
+	f    vfs.File
+	name string
+}
+
+// errCodes are the codes of the errors returned by the file system.
+var errCodes = map[syscall.Errno]string{
+	syscall.EACCES:    "EACCES",
+	syscall.EBADF:     "EBADF",
+	syscall.EEXIST:    "EEXIST",
+	syscall.EINVAL:    "EINVAL",
+	syscall.EISDIR:    "EISDIR",
+	syscall.ELOOP:     "ELOOP",
+	syscall.ENOENT:    "ENOENT",
+	syscall.ENOSYS:    "ENOSYS",
+	syscall.ENOTDIR:   "ENOTDIR",
+	syscall.ENOTEMPTY: "ENOTEMPTY",
+	syscall.EPERM:     "EPERM",
+	syscall.ESPIPE:    "ESPIPE",
+	syscall.EXDEV:     "EXDEV",
+}
+
+// fsError returns the JavaScript error of a file system error.
+func fsError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*Error); ok {
+		return err
+	}
+	cause := err
+	switch e := err.(type) {
+	case *os.PathError:
+		cause = e.Err
+	case *os.LinkError:
+		cause = e.Err
+	case *os.SyscallError:
+		cause = e.Err
+	}
+	code := "EIO"
+	if errno, ok := cause.(syscall.Errno); ok && errCodes[errno] != "" {
+		code = errCodes[errno]
+	} else if os.IsNotExist(cause) {
+		code = "ENOENT"
+	} else if os.IsExist(cause) {
+		code = "EEXIST"
+	} else if os.IsPermission(cause) {
+		code = "EACCES"
+	}
+	return &Error{Code: code, Message: err.Error()}
+}
+
+func errnoError(code, op string) error {
+	return &Error{Code: code, Message: op + ": " + strings.ToLower(code)}
+}
+
+// fsMethod returns a method of fs calling fn with its arguments, and then
+// its callback with the error and the result of fn.
+func fsMethod(fn func(args []interface{}) (interface{}, error)) *Object {
+	return Func(func(this interface{}, args []interface{}) (interface{}, error) {
+		if len(args) == 0 {
+			return nil, &Error{Message: "callback is not a function"}
+		}
+		cb := args[len(args)-1]
+		result, err := fn(args[:len(args)-1])
+		if err != nil {
+			_, err = call(cb, Undefined, []interface{}{errorValue(fsError(err))})
+		} else {
+			_, err = call(cb, Undefined, []interface{}{nil, result})
+		}
+		return Undefined, err
+	})
+}
+
+// newFS returns the fs object.
+func (r *Runtime) newFS() *Object {
+	constants := NewObject(map[string]interface{}{
+		"O_RDONLY":    float64(0),
+		"O_WRONLY":    float64(nodeWRONLY),
+		"O_RDWR":      float64(nodeRDWR),
+		"O_CREAT":     float64(nodeCREAT),
+		"O_EXCL":      float64(nodeEXCL),
+		"O_TRUNC":     float64(nodeTRUNC),
+		"O_APPEND":    float64(nodeAPPEND),
+		"O_DIRECTORY": float64(nodeDIRECTORY),
+	})
+	fs := NewObject(map[string]interface{}{
+		"constants": constants,
+		"open":      fsMethod(r.fsOpen),
+		"close":     fsMethod(r.fsClose),
+		"read":      fsMethod(r.fsRead),
+		"write":     fsMethod(r.fsWrite),
+		"fstat":     fsMethod(r.fsFstat),
+		"stat":      fsMethod(r.fsStat),
+		"lstat":     fsMethod(r.fsStat),
+		"readdir":   fsMethod(r.fsReaddir),
+		"mkdir":     fsMethod(r.fsMkdir),
+		"unlink":    fsMethod(r.fsUnlink),
+		"rmdir":     fsMethod(r.fsRmdir),
+		"rename":    fsMethod(r.fsRename),
+		"fsync":     fsMethod(r.fsFsync),
+	})
+	for _, name := range []string{
+		"chmod", "fchmod", "chown", "fchown", "lchown", "utimes",
+		"truncate", "ftruncate", "readlink", "symlink", "link",
+	} {
+		name := name
+		fs.Props[name] = fsMethod(func([]interface{}) (interface{}, error) {
+			return nil, errnoError("ENOSYS", name)
+		})
+	}
+	return fs
+}
+
+// fsPath returns the name in the file system of the path p of the program.
+func (r *Runtime) fsPath(p string) (string, error) {
+	if r.cfg.FS == nil {
+		return "", errnoError("ENOSYS", "no file system")
+	}
+	name := strings.TrimPrefix(r.resolvePath(p), "/")
+	if name == "" {
+		name = "."
+	}
+	return name, nil
+}
+
+// stat returns the FileInfo of the path p.
+func (r *Runtime) stat(p string) (os.FileInfo, error) {
+	name, err := r.fsPath(p)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := r.cfg.FS.Stat(name)
+	return fi, fsError(err)
+}
+
+// getFile returns the file opened as fd.
+func (r *Runtime) getFile(fd int64) (*file, error) {
+	f, ok := r.files[int(fd)]
+	if !ok {
+		return nil, errnoError("EBADF", "bad file descriptor")
+	}
+	return f, nil
+}
+
+func (r *Runtime) fsOpen(args []interface{}) (interface{}, error) {
+	name, err := r.fsPath(stringArg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+	nodeFlags := intArg(args, 1)
+	var flag int
+	switch {
+	case nodeFlags&nodeRDWR != 0:
+		flag = os.O_RDWR
+	case nodeFlags&nodeWRONLY != 0:
+		flag = os.O_WRONLY
+	default:
+		flag = os.O_RDONLY
+	}
+	for _, f := range []struct {
+		node int64
+		os   int
+	}{
+		{nodeCREAT, os.O_CREATE},
+		{nodeEXCL, os.O_EXCL},
+		{nodeTRUNC, os.O_TRUNC},
+		{nodeAPPEND, os.O_APPEND},
+	} {
+		if nodeFlags&f.node != 0 {
+			flag |= f.os
+		}
+	}
+
+	f, err := r.cfg.FS.OpenFile(name, flag, os.FileMode(intArg(args, 2))&os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	if nodeFlags&nodeDIRECTORY != 0 {
+		if fi, err := f.Stat(); err != nil || !fi.IsDir() {
+			f.Close()
+			return nil, errnoError("ENOTDIR", "not a directory")
+		}
+	}
+	fd := 3
+	for r.files[fd] != nil {
+		fd++
+	}
+	r.files[fd] = &file{f: f, name: name}
+	return float64(fd), nil
+}
+
+func (r *Runtime) fsClose(args []interface{}) (interface{}, error) {
+	fd := intArg(args, 0)
+	if fd >= 0 && fd <= 2 {
+		return nil, nil
+	}
+	f, err := r.getFile(fd)
+	if err != nil {
+		return nil, err
+	}
+	delete(r.files, int(fd))
+	return nil, f.f.Close()
+}
+
+// buffer returns the part of the buffer passed to read and write.
+func buffer(args []interface{}) ([]byte, error) {
+	buf, ok := args[1].(*Uint8Array)
+	offset, length := intArg(args, 2), intArg(args, 3)
+	if !ok || offset < 0 || length < 0 || offset+length > int64(len(buf.Data)) {
+		return nil, errnoError("EINVAL", "invalid buffer")
+	}
+	return buf.Data[offset : offset+length], nil
+}
+
+// at calls fn with the file f positioned at position, a number or null,
+// and restores its position. It calls fn at the current position if
+// position is null.
+func at(f vfs.File, position interface{}, fn func() (int, error)) (int, error) {
+	pos, ok := position.(float64)
+	if !ok {
+		return fn()
+	}
+	cur, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(int64(pos), io.SeekStart); err != nil {
+		return 0, err
+	}
+	n, err := fn()
+	if _, err1 := f.Seek(cur, io.SeekStart); err == nil {
+		err = err1
+	}
+	return n, err
+}
+
+func (r *Runtime) fsRead(args []interface{}) (interface{}, error) {
+	if len(args) < 5 {
+		return nil, errnoError("EINVAL", "read")
+	}
+	p, err := buffer(args)
+	if err != nil {
+		return nil, err
+	}
+	fd := intArg(args, 0)
+	var n int
+	switch fd {
+	case 0:
+		n, err = r.cfg.Stdin.Read(p)
+	case 1, 2:
+		return nil, errnoError("EBADF", "read")
+	default:
+		var f *file
+		if f, err = r.getFile(fd); err != nil {
+			return nil, err
+		}
+		n, err = at(f.f, args[4], func() (int, error) { return f.f.Read(p) })
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return float64(n), nil
+}
+
+func (r *Runtime) fsWrite(args []interface{}) (interface{}, error) {
+	if len(args) < 5 {
+		return nil, errnoError("EINVAL", "write")
+	}
+	p, err := buffer(args)
+	if err != nil {
+		return nil, err
+	}
+	fd := intArg(args, 0)
+	var n int
+	switch fd {
+	case 0:
+		return nil, errnoError("EBADF", "write")
+	case 1:
+		n, err = r.cfg.Stdout.Write(p)
+	case 2:
+		n, err = r.cfg.Stderr.Write(p)
+	default:
+		var f *file
+		if f, err = r.getFile(fd); err != nil {
+			return nil, err
+		}
+		n, err = at(f.f, args[4], func() (int, error) { return f.f.Write(p) })
+	}
+	if err != nil {
+		return nil, err
+	}
+	return float64(n), nil
+}
+
+// statObject returns the stat object describing fi.
+func statObject(fi os.FileInfo) *Object {
+	mode := uint32(fi.Mode() & os.ModePerm)
+	switch {
+	case fi.IsDir():
+		mode |= sIFDIR
+	case fi.Mode()&os.ModeSymlink != 0:
+		mode |= sIFLNK
+	case fi.Mode()&os.ModeCharDevice != 0:
+		mode |= sIFCHR
+	default:
+		mode |= sIFREG
+	}
+	isDir := fi.IsDir()
+	mtime := float64(fi.ModTime().UnixNano() / 1e6)
+	return NewObject(map[string]interface{}{
+		"dev":     float64(0),
+		"ino":     float64(0),
+		"mode":    float64(mode),
+		"nlink":   float64(1),
+		"uid":     float64(0),
+		"gid":     float64(0),
+		"rdev":    float64(0),
+		"size":    float64(fi.Size()),
+		"blksize": float64(4096),
+		"blocks":  float64((fi.Size() + 511) / 512),
+		"atimeMs": mtime,
+		"mtimeMs": mtime,
+		"ctimeMs": mtime,
+		"isDirectory": Func(func(interface{}, []interface{}) (interface{}, error) {
+			return isDir, nil
+		}),
+	})
+}
+
+// stdioInfo describes the standard streams.
+type stdioInfo string
+
+func (fi stdioInfo) Name() string       { return string(fi) }
+func (fi stdioInfo) Size() int64        { return 0 }
+func (fi stdioInfo) Mode() os.FileMode  { return os.ModeDevice | os.ModeCharDevice | 0600 }
+func (fi stdioInfo) ModTime() time.Time { return time.Time{} }
+func (fi stdioInfo) IsDir() bool        { return false }
+func (fi stdioInfo) Sys() interface{}   { return nil }
+
+func (r *Runtime) fsFstat(args []interface{}) (interface{}, error) {
+	fd := intArg(args, 0)
+	switch fd {
+	case 0:
+		return statObject(stdioInfo("stdin")), nil
+	case 1:
+		return statObject(stdioInfo("stdout")), nil
+	case 2:
+		return statObject(stdioInfo("stderr")), nil
+	}
+	f, err := r.getFile(fd)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return statObject(fi), nil
+}
+
+func (r *Runtime) fsStat(args []interface{}) (interface{}, error) {
+	fi, err := r.stat(stringArg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+	return statObject(fi), nil
+}
+
+func (r *Runtime) fsReaddir(args []interface{}) (interface{}, error) {
+	name, err := r.fsPath(stringArg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+	infos, err := r.cfg.FS.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	names := &Array{Elems: make([]interface{}, len(infos))}
+	for i, fi := range infos {
+		names.Elems[i] = fi.Name()
+	}
+	return names, nil
+}
+
+func (r *Runtime) fsMkdir(args []interface{}) (interface{}, error) {
+	name, err := r.fsPath(stringArg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.cfg.FS.Mkdir(name, os.FileMode(intArg(args, 1))&os.ModePerm)
+}
+
+// remove removes the file at the path p, which must be a directory if dir
+// is true, and must not be one otherwise.
+func (r *Runtime) remove(p string, dir bool) error {
+	name, err := r.fsPath(p)
+	if err != nil {
+		return err
+	}
+	fi, err := r.cfg.FS.Stat(name)
+	switch {
+	case err != nil:
+		return err
+	case dir && !fi.IsDir():
+		return errnoError("ENOTDIR", "rmdir "+p)
+	case !dir && fi.IsDir():
+		return errnoError("EISDIR", "unlink "+p)
+	}
+	return r.cfg.FS.Remove(name)
+}
+
+func (r *Runtime) fsUnlink(args []interface{}) (interface{}, error) {
+	return nil, r.remove(stringArg(args, 0), false)
+}
+
+func (r *Runtime) fsRmdir(args []interface{}) (interface{}, error) {
+	return nil, r.remove(stringArg(args, 0), true)
+}
+
+func (r *Runtime) fsRename(args []interface{}) (interface{}, error) {
+	from, err := r.fsPath(stringArg(args, 0))
+	if err != nil {
+		return nil, err
+	}
+	to, err := r.fsPath(stringArg(args, 1))
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.cfg.FS.Rename(from, to)
+}
+
+func (r *Runtime) fsFsync(args []interface{}) (interface{}, error) {
+	if fd := intArg(args, 0); fd > 2 {
+		if _, err := r.getFile(fd); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
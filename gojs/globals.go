@@ -0,0 +1,186 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gojs
+
+import (
+	"path"
+	"strings"
+)
+
+// Constructors of the global object, which valueInstanceOf recognizes.
+var (
+	objectConstructor     = &Object{Props: map[string]interface{}{}}
+	arrayConstructor      = &Object{Props: map[string]interface{}{}}
+	uint8ArrayConstructor = &Object{Props: map[string]interface{}{}}
+)
+
+func init() {
+	objectConstructor.Construct = func(args []interface{}) (interface{}, error) {
+		return NewObject(nil), nil
+	}
+	arrayConstructor.Construct = func(args []interface{}) (interface{}, error) {
+		if len(args) == 1 {
+			if n, ok := args[0].(float64); ok {
+				a := &Array{Elems: make([]interface{}, int(n))}
+				for i := range a.Elems {
+					a.Elems[i] = Undefined
+				}
+				return a, nil
+			}
+		}
+		return &Array{Elems: append([]interface{}(nil), args...)}, nil
+	}
+	uint8ArrayConstructor.Construct = func(args []interface{}) (interface{}, error) {
+		n := int64(0)
+		if len(args) > 0 {
+			n = toInt(args[0])
+		}
+		if n < 0 {
+			return nil, &Error{Message: "Invalid typed array length"}
+		}
+		return &Uint8Array{Data: make([]byte, n)}, nil
+	}
+}
+
+// instanceOf returns whether v is an instance of the constructor t.
+func (r *Runtime) instanceOf(v, t interface{}) bool {
+	switch t {
+	case objectConstructor:
+		switch v.(type) {
+		case *Object, *Array, *Uint8Array:
+			return true
+		}
+	case arrayConstructor:
+		_, ok := v.(*Array)
+		return ok
+	case uint8ArrayConstructor:
+		_, ok := v.(*Uint8Array)
+		return ok
+	}
+	return false
+}
+
+// newGlobal returns the global object of the program.
+func (r *Runtime) newGlobal() *Object {
+	global := NewObject(map[string]interface{}{
+		"Object":     objectConstructor,
+		"Array":      arrayConstructor,
+		"Uint8Array": uint8ArrayConstructor,
+		"Date":       r.dateConstructor(),
+		"process":    r.newProcess(),
+		"path":       r.newPath(),
+		"fs":         r.newFS(),
+	})
+	global.Props["globalThis"] = global
+	for name, v := range r.cfg.Globals {
+		global.Props[name] = normalize(v)
+	}
+	return global
+}
+
+// dateConstructor returns the Date constructor, whose instances only
+// implement getTime and getTimezoneOffset.
+func (r *Runtime) dateConstructor() *Object {
+	return &Object{
+		Props: make(map[string]interface{}),
+		Construct: func(args []interface{}) (interface{}, error) {
+			now := r.cfg.Now()
+			_, offset := now.Zone()
+			return NewObject(map[string]interface{}{
+				"getTime": Func(func(interface{}, []interface{}) (interface{}, error) {
+					return float64(now.UnixNano() / 1e6), nil
+				}),
+				"getTimezoneOffset": Func(func(interface{}, []interface{}) (interface{}, error) {
+					return float64(-offset / 60), nil
+				}),
+			}), nil
+		},
+	}
+}
+
+// enosys returns the function of the methods that are not implemented.
+func enosys(name string) *Object {
+	return Func(func(interface{}, []interface{}) (interface{}, error) {
+		return nil, &Error{Code: "ENOSYS", Message: name + " is not implemented"}
+	})
+}
+
+// constant returns a function returning v.
+func constant(v interface{}) *Object {
+	return Func(func(interface{}, []interface{}) (interface{}, error) {
+		return v, nil
+	})
+}
+
+// newProcess returns the process object, which provides the working
+// directory of the program.
+func (r *Runtime) newProcess() *Object {
+	return NewObject(map[string]interface{}{
+		"pid":       float64(-1),
+		"ppid":      float64(-1),
+		"getuid":    constant(float64(-1)),
+		"getgid":    constant(float64(-1)),
+		"geteuid":   constant(float64(-1)),
+		"getegid":   constant(float64(-1)),
+		"getgroups": enosys("getgroups"),
+		"umask":     enosys("umask"),
+		"cwd": Func(func(interface{}, []interface{}) (interface{}, error) {
+			return r.cwd, nil
+		}),
+		"chdir": Func(func(this interface{}, args []interface{}) (interface{}, error) {
+			dir := r.resolvePath(stringArg(args, 0))
+			fi, err := r.stat(dir)
+			if err != nil {
+				return nil, err
+			}
+			if !fi.IsDir() {
+				return nil, &Error{Code: "ENOTDIR", Message: "not a directory: " + dir}
+			}
+			r.cwd = dir
+			return Undefined, nil
+		}),
+	})
+}
+
+// newPath returns the path object, which only implements resolve.
+func (r *Runtime) newPath() *Object {
+	return NewObject(map[string]interface{}{
+		"resolve": Func(func(this interface{}, args []interface{}) (interface{}, error) {
+			p := r.cwd
+			for i := range args {
+				if s := stringArg(args, i); strings.HasPrefix(s, "/") {
+					p = path.Clean(s)
+				} else {
+					p = path.Join(p, s)
+				}
+			}
+			return p, nil
+		}),
+	})
+}
+
+// resolvePath returns the absolute path of p.
+func (r *Runtime) resolvePath(p string) string {
+	if strings.HasPrefix(p, "/") {
+		return path.Clean(p)
+	}
+	return path.Join(r.cwd, p)
+}
+
+// stringArg returns the argument i, as a string.
+func stringArg(args []interface{}, i int) string {
+	if i >= len(args) {
+		return "undefined"
+	}
+	return toString(args[i])
+}
+
+// intArg returns the argument i, as an integer.
+func intArg(args []interface{}, i int) int64 {
+	if i >= len(args) {
+		return 0
+	}
+	return toInt(args[i])
+}
@@ -0,0 +1,323 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package gojs implements the imports of modules built by the Go toolchain
+// with GOOS=js GOARCH=wasm, in place of the wasm_exec.js support file, so
+// that Go programs can run with exec without a JavaScript engine.
+//
+// The JavaScript environment seen by the guest through syscall/js is
+// emulated in Go: a global object with Object, Array, Uint8Array and Date,
+// a process object, and an fs object exposing the standard streams and an
+// optional vfs.FS. More globals can be added through Config.Globals.
+//
+//	r := gojs.New(gojs.Config{Args: []string{"prog"}, Stdout: os.Stdout})
+//	m, err := wasm.ReadModule(f, r.Resolver(nil))
+//	...
+//	vm, err := exec.NewVM(m)
+//	...
+//	vm.RecoverPanic = true
+//	err = r.Run(vm)
+package gojs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/vfs"
+	"github.com/go-interpreter/wagon/wasi"
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// ModuleName is the name of the module the functions of the runtime are
+// imported from, since Go 1.21. Older versions of Go import them from
+// LegacyModuleName.
+const (
+	ModuleName       = "gojs"
+	LegacyModuleName = "go"
+)
+
+// ErrDeadlock is returned by Run when the program is blocked while no
+// event can wake it up.
+var ErrDeadlock = errors.New("gojs: all goroutines are asleep")
+
+// Config is the environment of a Go program.
+type Config struct {
+	Args []string // Command line arguments, starting with the program name
+	Env  []string // Environment variables, in the form "key=value"
+
+	// Standard streams of the program. They default to an empty input and
+	// to ioutil.Discard.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// FS is the file system exposed to the program, as its root directory.
+	// If it is nil, the program can only access its standard streams.
+	FS vfs.FS
+
+	// Globals are added to the global object seen by the program.
+	Globals map[string]interface{}
+
+	// Now returns the current time, it defaults to time.Now.
+	Now func() time.Time
+	// Rand is the source of random data, it defaults to crypto/rand.Reader.
+	Rand io.Reader
+}
+
+// Runtime runs a Go program. It serves a single VM.
+type Runtime struct {
+	cfg   Config
+	start time.Time // Origin of the monotonic clock
+
+	proc   *exec.Process
+	global *Object
+	goObj  *Object // The Go object of wasm_exec.js
+	values *valueTable
+	exited bool
+
+	timers    map[int32]time.Time // Deadlines of the scheduled timeout events
+	nextTimer int32
+
+	cwd   string
+	files map[int]*file
+}
+
+// New returns a runtime with the environment cfg.
+func New(cfg Config) *Runtime {
+	if cfg.Stdin == nil {
+		cfg.Stdin = eofReader{}
+	}
+	if cfg.Stdout == nil {
+		cfg.Stdout = ioutil.Discard
+	}
+	if cfg.Stderr == nil {
+		cfg.Stderr = ioutil.Discard
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Rand == nil {
+		cfg.Rand = randReader
+	}
+	return &Runtime{cfg: cfg, start: cfg.Now()}
+}
+
+// Module returns the host module exporting the functions of the runtime.
+func (r *Runtime) Module() (*wasm.Module, error) {
+	return exec.NewHostModule(map[string]interface{}{
+		"runtime.wasmExit":              r.wasmExit,
+		"runtime.wasmWrite":             r.wasmWrite,
+		"runtime.resetMemoryDataView":   r.resetMemoryDataView,
+		"runtime.nanotime1":             r.nanotime,
+		"runtime.nanotime":              r.nanotime,
+		"runtime.walltime":              r.walltime,
+		"runtime.walltime1":             r.walltime,
+		"runtime.scheduleTimeoutEvent":  r.scheduleTimeoutEvent,
+		"runtime.clearTimeoutEvent":     r.clearTimeoutEvent,
+		"runtime.getRandomData":         r.getRandomData,
+		"syscall/js.finalizeRef":        r.finalizeRef,
+		"syscall/js.stringVal":          r.stringVal,
+		"syscall/js.valueGet":           r.valueGet,
+		"syscall/js.valueSet":           r.valueSet,
+		"syscall/js.valueDelete":        r.valueDelete,
+		"syscall/js.valueIndex":         r.valueIndex,
+		"syscall/js.valueSetIndex":      r.valueSetIndex,
+		"syscall/js.valueCall":          r.valueCall,
+		"syscall/js.valueInvoke":        r.valueInvoke,
+		"syscall/js.valueNew":           r.valueNew,
+		"syscall/js.valueLength":        r.valueLength,
+		"syscall/js.valuePrepareString": r.valuePrepareString,
+		"syscall/js.valueLoadString":    r.valueLoadString,
+		"syscall/js.valueInstanceOf":    r.valueInstanceOf,
+		"syscall/js.copyBytesToGo":      r.copyBytesToGo,
+		"syscall/js.copyBytesToJS":      r.copyBytesToJS,
+		"debug":                         r.debug,
+	})
+}
+
+// Resolver returns a wasm.ResolveFunc resolving ModuleName and
+// LegacyModuleName to the host module of r, and the other modules with
+// next, if not nil.
+func (r *Runtime) Resolver(next wasm.ResolveFunc) wasm.ResolveFunc {
+	return func(name string) (*wasm.Module, error) {
+		if name == ModuleName || name == LegacyModuleName {
+			return r.Module()
+		}
+		if next == nil {
+			return nil, fmt.Errorf("gojs: unknown module %q", name)
+		}
+		return next(name)
+	}
+}
+
+// Imports returns whether the module m imports the functions of the
+// runtime.
+func Imports(m *wasm.Module) bool {
+	if m.Import == nil {
+		return false
+	}
+	for _, entry := range m.Import.Entries {
+		if entry.ModuleName == ModuleName || entry.ModuleName == LegacyModuleName {
+			return true
+		}
+	}
+	return false
+}
+
+// argsAddr is the address at which the command line arguments and the
+// environment are written, and argsEnd the end of the area they may use.
+const (
+	argsAddr = 4096
+	argsEnd  = 4096 + 8192
+)
+
+// Run runs the program instantiated by vm until it exits, waiting for its
+// timers to expire while it is idle. It returns nil if the program exits
+// with status 0, and a wasi.ExitError with its status otherwise.
+func (r *Runtime) Run(vm *exec.VM) error {
+	r.proc = exec.NewProcess(vm)
+	r.reset()
+
+	argc, argv, err := r.writeArgs()
+	if err != nil {
+		return err
+	}
+	_, err = r.proc.Call("run", argc, argv)
+	for err == nil && !r.exited {
+		if len(r.timers) == 0 {
+			// Let the program report the deadlock, as wasm_exec.js
+			// does when Node.js exits.
+			r.goObj.Props["_pendingEvent"] = NewObject(map[string]interface{}{"id": float64(0)})
+			if err = r.resume(); err == nil && !r.exited {
+				err = ErrDeadlock
+			}
+			break
+		}
+		id := r.nextTimeout()
+		if d := r.timers[id].Sub(r.cfg.Now()); d > 0 {
+			time.Sleep(d)
+		}
+		err = r.resume()
+		for err == nil && !r.exited && r.hasTimer(id) {
+			// The program failed to register the event, wasm_exec.js
+			// resumes it again.
+			err = r.resume()
+		}
+	}
+	if code, ok := err.(wasi.ExitError); ok && code == 0 {
+		return nil
+	}
+	return err
+}
+
+// reset initializes the JavaScript environment of the program.
+func (r *Runtime) reset() {
+	r.exited = false
+	r.timers = make(map[int32]time.Time)
+	r.nextTimer = 1
+	r.cwd = "/"
+	r.files = make(map[int]*file)
+
+	r.goObj = NewObject(map[string]interface{}{
+		"_pendingEvent":    nil,
+		"_makeFuncWrapper": Func(r.makeFuncWrapper),
+	})
+	r.global = r.newGlobal()
+	r.values = newValueTable(r.global, r.goObj)
+}
+
+// writeArgs writes the command line arguments and the environment to the
+// memory, and returns the arguments of the run export.
+func (r *Runtime) writeArgs() (argc, argv uint64, err error) {
+	offset := uint32(argsAddr)
+	var ptrs []uint32
+	str := func(s string) error {
+		ptrs = append(ptrs, offset)
+		if err := r.proc.WriteBytes(offset, append([]byte(s), 0)); err != nil {
+			return err
+		}
+		offset += uint32(len(s)) + 1
+		offset = (offset + 7) &^ 7
+		return nil
+	}
+	for _, arg := range r.cfg.Args {
+		if err := str(arg); err != nil {
+			return 0, 0, err
+		}
+	}
+	ptrs = append(ptrs, 0)
+	for _, kv := range r.cfg.Env {
+		if err := str(kv); err != nil {
+			return 0, 0, err
+		}
+	}
+	ptrs = append(ptrs, 0)
+
+	argv = uint64(offset)
+	for _, ptr := range ptrs {
+		if err := r.proc.WriteUint64Le(offset, uint64(ptr)); err != nil {
+			return 0, 0, err
+		}
+		offset += 8
+	}
+	if offset >= argsEnd {
+		return 0, 0, errors.New("gojs: the command line and environment are too long")
+	}
+	return uint64(len(r.cfg.Args)), argv, nil
+}
+
+// resume resumes the program, to handle its pending event or timeouts.
+func (r *Runtime) resume() error {
+	if r.exited {
+		return errors.New("gojs: the Go program has already exited")
+	}
+	_, err := r.proc.Call("resume")
+	return err
+}
+
+// makeFuncWrapper implements the _makeFuncWrapper method of the Go object,
+// which returns the JavaScript function calling the Go function of id.
+func (r *Runtime) makeFuncWrapper(this interface{}, args []interface{}) (interface{}, error) {
+	var id interface{} = Undefined
+	if len(args) > 0 {
+		id = args[0]
+	}
+	return Func(func(this interface{}, args []interface{}) (interface{}, error) {
+		event := NewObject(map[string]interface{}{
+			"id":   id,
+			"this": this,
+			"args": &Array{Elems: args},
+		})
+		r.goObj.Props["_pendingEvent"] = event
+		if err := r.resume(); err != nil {
+			// Exits and traps of the program end the whole run.
+			panic(err)
+		}
+		return get(event, "result"), nil
+	}), nil
+}
+
+// nextTimeout returns the id of the timeout event expiring first.
+func (r *Runtime) nextTimeout() int32 {
+	first := int32(0)
+	for id, deadline := range r.timers {
+		if first == 0 || deadline.Before(r.timers[first]) || deadline.Equal(r.timers[first]) && id < first {
+			first = id
+		}
+	}
+	return first
+}
+
+func (r *Runtime) hasTimer(id int32) bool {
+	_, ok := r.timers[id]
+	return ok
+}
+
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) { return 0, io.EOF }
@@ -0,0 +1,286 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gojs
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	wexec "github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/vfs"
+	"github.com/go-interpreter/wagon/wasi"
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// newProcess returns a process with one page of memory.
+func newProcess(t *testing.T) *wexec.Process {
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.LinearMemoryIndexSpace = [][]byte{make([]byte, 65536)}
+	vm, err := wexec.NewVM(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wexec.NewProcess(vm)
+}
+
+func TestValues(t *testing.T) {
+	proc := newProcess(t)
+	r := New(Config{})
+	r.reset()
+
+	obj := NewObject(nil)
+	for _, tc := range []struct {
+		v    interface{}
+		bits uint64 // Expected encoding, if not 0
+		want interface{}
+	}{
+		{v: Undefined, want: Undefined},
+		{v: 0, bits: 0x7FF80000<<32 | 1, want: float64(0)},
+		{v: int32(42), bits: math.Float64bits(42), want: float64(42)},
+		{v: math.NaN(), bits: 0x7FF80000 << 32},
+		{v: nil, bits: 0x7FF80000<<32 | 2, want: nil},
+		{v: true, bits: 0x7FF80000<<32 | 3, want: true},
+		{v: false, bits: 0x7FF80000<<32 | 4, want: false},
+		{v: r.global, bits: 0x7FF80001<<32 | 5, want: r.global},
+		{v: "str", bits: 0x7FF80002<<32 | 7, want: "str"},
+		{v: obj, bits: 0x7FF80001<<32 | 8, want: obj},
+		{v: r.goObj.Props["_makeFuncWrapper"], bits: 0x7FF80004<<32 | 9, want: r.goObj.Props["_makeFuncWrapper"]},
+	} {
+		r.storeValue(proc, 0, tc.v)
+		bits, _ := proc.ReadUint64Le(0)
+		if tc.v != Undefined && tc.bits != bits {
+			t.Errorf("%v: got reference %#x, want %#x", tc.v, bits, tc.bits)
+		}
+		got := r.loadValue(proc, 0)
+		if f, ok := tc.v.(float64); ok && math.IsNaN(f) {
+			if f, ok := got.(float64); !ok || !math.IsNaN(f) {
+				t.Errorf("NaN: loaded %v", got)
+			}
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%v: loaded %v, want %v", tc.v, got, tc.want)
+		}
+	}
+
+	// A value keeps its id while it is referenced, which is released once
+	// all its references are finalized.
+	r.storeValue(proc, 0, "str")
+	r.values.finalize(7)
+	if got := r.values.get(7); got != "str" {
+		t.Errorf("got %v after finalizing one of two references, want %q", got, "str")
+	}
+	r.values.finalize(7)
+	r.storeValue(proc, 0, "other")
+	if bits, _ := proc.ReadUint64Le(0); uint32(bits) != 7 {
+		t.Errorf("got id %d for a new value, want the released id 7", uint32(bits))
+	}
+	r.values.finalize(2) // Predefined values are never released.
+	if got := r.values.get(2); got != nil {
+		t.Errorf("got %v for null after finalizing it", got)
+	}
+}
+
+func TestGlobals(t *testing.T) {
+	fsys := vfs.NewMemFS()
+	fsys.Mkdir("dir", 0755)
+	r := New(Config{FS: fsys, Globals: map[string]interface{}{"answer": 42}})
+	r.reset()
+
+	if got := get(r.global, "answer"); got != float64(42) {
+		t.Errorf("got answer %v, want 42", got)
+	}
+	arr, err := construct(get(r.global, "Array"), []interface{}{float64(2)})
+	if err != nil || !r.instanceOf(arr, get(r.global, "Array")) || get(arr, "length") != float64(2) {
+		t.Errorf("new Array(2) returned %v, %v", arr, err)
+	}
+	if r.instanceOf(arr, get(r.global, "Uint8Array")) {
+		t.Errorf("an Array is an instance of Uint8Array")
+	}
+
+	process := get(r.global, "process")
+	if _, err := call(get(process, "chdir"), process, []interface{}{"missing"}); err == nil || err.(*Error).Code != "ENOENT" {
+		t.Errorf("chdir(missing) returned %v, want ENOENT", err)
+	}
+	if _, err := call(get(process, "chdir"), process, []interface{}{"dir"}); err != nil {
+		t.Fatal(err)
+	}
+	path := get(r.global, "path")
+	for _, tc := range []struct {
+		args []interface{}
+		want string
+	}{
+		{[]interface{}{"a"}, "/dir/a"},
+		{[]interface{}{"../a/", "b"}, "/a/b"},
+		{[]interface{}{"a", "/b", "c"}, "/b/c"},
+	} {
+		if got, _ := call(get(path, "resolve"), path, tc.args); got != tc.want {
+			t.Errorf("path.resolve%v = %v, want %q", tc.args, got, tc.want)
+		}
+	}
+}
+
+// fsCall calls the method name of fs, and returns the arguments of its
+// callback.
+func fsCall(t *testing.T, r *Runtime, name string, args ...interface{}) (interface{}, interface{}) {
+	var err, result interface{}
+	cb := Func(func(this interface{}, args []interface{}) (interface{}, error) {
+		err = args[0]
+		if len(args) > 1 {
+			result = args[1]
+		}
+		return Undefined, nil
+	})
+	fs := get(r.global, "fs")
+	if _, err := call(get(fs, name), fs, append(args, cb)); err != nil {
+		t.Fatalf("fs.%s threw %v", name, err)
+	}
+	return err, result
+}
+
+func TestFS(t *testing.T) {
+	fsys := vfs.NewMemFS()
+	fsys.Mkdir("dir", 0755)
+	r := New(Config{FS: fsys})
+	r.reset()
+
+	flags := float64(nodeWRONLY | nodeCREAT | nodeTRUNC)
+	err, fd := fsCall(t, r, "open", "/dir/f", flags, float64(0644))
+	if err != nil || fd != float64(3) {
+		t.Fatalf("open returned %v, %v", err, fd)
+	}
+	buf := &Uint8Array{Data: []byte("xxdata")}
+	if err, n := fsCall(t, r, "write", fd, buf, float64(2), float64(4), nil); err != nil || n != float64(4) {
+		t.Errorf("write returned %v, %v", err, n)
+	}
+	fsCall(t, r, "close", fd)
+	if data, _ := fsys.ReadFile("dir/f"); string(data) != "data" {
+		t.Errorf("got content %q, want %q", data, "data")
+	}
+
+	_, fd = fsCall(t, r, "open", "dir/f", float64(0), float64(0))
+	buf = &Uint8Array{Data: make([]byte, 3)}
+	if err, n := fsCall(t, r, "read", fd, buf, float64(0), float64(3), float64(1)); err != nil || n != float64(3) || string(buf.Data) != "ata" {
+		t.Errorf("read at 1 returned %v, %v, %q", err, n, buf.Data)
+	}
+	if err, n := fsCall(t, r, "read", fd, buf, float64(0), float64(3), nil); err != nil || n != float64(3) || string(buf.Data) != "dat" {
+		t.Errorf("read returned %v, %v, %q", err, n, buf.Data)
+	}
+	_, st := fsCall(t, r, "fstat", fd)
+	if get(st, "size") != float64(4) || get(st, "mode") != float64(sIFREG|0644) {
+		t.Errorf("got size %v and mode %o", get(st, "size"), int(toInt(get(st, "mode"))))
+	}
+	fsCall(t, r, "close", fd)
+
+	_, st = fsCall(t, r, "stat", "/dir")
+	if isDir, _ := call(get(st, "isDirectory"), st, nil); isDir != true {
+		t.Errorf("/dir is not a directory")
+	}
+	if _, names := fsCall(t, r, "readdir", "dir"); toString(names) != "f" {
+		t.Errorf("readdir returned %v", names)
+	}
+
+	for _, tc := range []struct {
+		method string
+		args   []interface{}
+		code   string
+	}{
+		{"open", []interface{}{"missing", float64(0), float64(0)}, "ENOENT"},
+		{"close", []interface{}{float64(3)}, "EBADF"},
+		{"unlink", []interface{}{"dir"}, "EISDIR"},
+		{"rmdir", []interface{}{"dir/f"}, "ENOTDIR"},
+		{"rmdir", []interface{}{"dir"}, "ENOTEMPTY"},
+		{"chmod", []interface{}{"dir", float64(0)}, "ENOSYS"},
+		{"rename", []interface{}{"dir/f", "dir/g"}, ""},
+		{"unlink", []interface{}{"dir/g"}, ""},
+		{"rmdir", []interface{}{"dir"}, ""},
+	} {
+		err, _ := fsCall(t, r, tc.method, tc.args...)
+		code := get(err, "code")
+		if tc.code == "" && err != nil || tc.code != "" && code != tc.code {
+			t.Errorf("%s%v: got error %v, want code %q", tc.method, tc.args, toString(err), tc.code)
+		}
+	}
+
+	r = New(Config{})
+	r.reset()
+	if err, _ := fsCall(t, r, "stat", "/"); get(err, "code") != "ENOSYS" {
+		t.Errorf("stat without a file system: got error %v, want ENOSYS", toString(err))
+	}
+}
+
+// buildProgram builds testdata/hello.go with GOOS=js GOARCH=wasm.
+func buildProgram(t *testing.T) []byte {
+	if testing.Short() {
+		t.Skip("skipping the build of a Go program in short mode")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("the go tool is not available")
+	}
+	dir, err := ioutil.TempDir("", "gojs-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := filepath.Join(dir, "hello.wasm")
+	cmd := exec.Command(goTool, "build", "-o", out, "hello.go")
+	cmd.Dir = "testdata"
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("could not build a GOOS=js program: %v\n%s", err, output)
+	}
+	wasmBytes, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wasmBytes
+}
+
+func TestRun(t *testing.T) {
+	wasmBytes := buildProgram(t)
+
+	fsys := vfs.NewMemFS()
+	fsys.Mkdir("dir", 0755)
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	r := New(Config{
+		Args:   []string{"hello", "a"},
+		Env:    []string{"GREETING=x"},
+		Stdout: stdout,
+		Stderr: stderr,
+		FS:     fsys,
+	})
+	m, err := wasm.ReadModule(bytes.NewReader(wasmBytes), r.Resolver(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Imports(m) {
+		t.Fatal("the program does not import the runtime")
+	}
+	vm, err := wexec.NewVM(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.RecoverPanic = true
+
+	if err := r.Run(vm); err != wasi.ExitError(3) {
+		t.Errorf("got error %v, want exit status 3", err)
+	}
+	want := "hello [a] x\ndata <nil>\nout.txt 4 false\n"
+	if got := stdout.String(); got != want {
+		t.Errorf("got output %q, want %q", got, want)
+	}
+	if got := stderr.String(); got != "to stderr\n" {
+		t.Errorf("got error output %q, want %q", got, "to stderr\n")
+	}
+}
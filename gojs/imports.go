@@ -0,0 +1,293 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gojs
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/wasi"
+)
+
+var randReader = rand.Reader
+
+// The imported functions take the stack pointer of the guest, sp, and
+// read their arguments and write their results on its stack, starting at
+// sp+8. Functions that may run Go code, calling functions that may call
+// back the Go functions wrapped with js.FuncOf, must read sp again with
+// getsp before writing their results, as the stack of the goroutine may
+// have moved.
+
+// must panics if err is not nil. Memory accesses out of bounds trap.
+func must(err error) {
+	if err != nil {
+		panic(err)
+	}
+}
+
+func getInt32(proc *exec.Process, addr uint32) int32 {
+	v, err := proc.ReadUint32Le(addr)
+	must(err)
+	return int32(v)
+}
+
+func getUint32(proc *exec.Process, addr uint32) uint32 {
+	v, err := proc.ReadUint32Le(addr)
+	must(err)
+	return v
+}
+
+func getInt64(proc *exec.Process, addr uint32) int64 {
+	v, err := proc.ReadUint64Le(addr)
+	must(err)
+	return int64(v)
+}
+
+func setInt64(proc *exec.Process, addr uint32, v int64) {
+	must(proc.WriteUint64Le(addr, uint64(v)))
+}
+
+func setUint8(proc *exec.Process, addr uint32, v byte) {
+	must(proc.WriteBytes(addr, []byte{v}))
+}
+
+func setBool(proc *exec.Process, addr uint32, v bool) {
+	if v {
+		setUint8(proc, addr, 1)
+	} else {
+		setUint8(proc, addr, 0)
+	}
+}
+
+// loadSlice returns the memory of the Go slice at addr.
+func loadSlice(proc *exec.Process, addr uint32) []byte {
+	p, err := proc.MemoryView(uint32(getInt64(proc, addr)), uint32(getInt64(proc, addr+8)))
+	must(err)
+	return p
+}
+
+// loadString returns the Go string at addr.
+func loadString(proc *exec.Process, addr uint32) string {
+	return string(loadSlice(proc, addr))
+}
+
+// loadValue returns the value of the reference at addr.
+func (r *Runtime) loadValue(proc *exec.Process, addr uint32) interface{} {
+	bits, err := proc.ReadUint64Le(addr)
+	must(err)
+	f := math.Float64frombits(bits)
+	if f == 0 {
+		return Undefined
+	}
+	if !math.IsNaN(f) {
+		return f
+	}
+	return r.values.get(uint32(bits))
+}
+
+// loadValues returns the values of the Go slice of references at addr.
+func (r *Runtime) loadValues(proc *exec.Process, addr uint32) []interface{} {
+	array := uint32(getInt64(proc, addr))
+	n := uint32(getInt64(proc, addr+8))
+	values := make([]interface{}, n)
+	for i := range values {
+		values[i] = r.loadValue(proc, array+uint32(i)*8)
+	}
+	return values
+}
+
+// storeValue writes a reference to v at addr.
+func (r *Runtime) storeValue(proc *exec.Process, addr uint32, v interface{}) {
+	const nanHead = 0x7FF80000
+
+	v = normalize(v)
+	if f, ok := v.(float64); ok && f != 0 {
+		if math.IsNaN(f) {
+			must(proc.WriteUint64Le(addr, nanHead<<32))
+			return
+		}
+		must(proc.WriteFloat64(addr, f))
+		return
+	}
+	if v == Undefined {
+		must(proc.WriteUint64Le(addr, 0))
+		return
+	}
+	id := r.values.ref(v)
+	must(proc.WriteUint64Le(addr, uint64(nanHead|typeFlag(v))<<32|uint64(id)))
+}
+
+// getsp returns the current stack pointer of the guest.
+func (r *Runtime) getsp(proc *exec.Process) uint32 {
+	sp, err := proc.Call("getsp")
+	must(err)
+	return sp.(uint32)
+}
+
+func (r *Runtime) wasmExit(proc *exec.Process, sp uint32) {
+	code := getInt32(proc, sp+8)
+	r.exited = true
+	panic(wasi.ExitError(uint32(code)))
+}
+
+func (r *Runtime) wasmWrite(proc *exec.Process, sp uint32) {
+	fd := getInt64(proc, sp+8)
+	p := uint32(getInt64(proc, sp+16))
+	n := getUint32(proc, sp+24)
+	data, err := proc.MemoryView(p, n)
+	must(err)
+	switch fd {
+	case 1:
+		r.cfg.Stdout.Write(data)
+	case 2:
+		r.cfg.Stderr.Write(data)
+	}
+}
+
+func (r *Runtime) resetMemoryDataView(proc *exec.Process, sp uint32) {}
+
+func (r *Runtime) nanotime(proc *exec.Process, sp uint32) {
+	setInt64(proc, sp+8, int64(r.cfg.Now().Sub(r.start)))
+}
+
+func (r *Runtime) walltime(proc *exec.Process, sp uint32) {
+	now := r.cfg.Now()
+	setInt64(proc, sp+8, now.Unix())
+	must(proc.WriteUint32Le(sp+16, uint32(now.Nanosecond())))
+}
+
+func (r *Runtime) scheduleTimeoutEvent(proc *exec.Process, sp uint32) {
+	delay := time.Duration(getInt64(proc, sp+8)) * time.Millisecond
+	id := r.nextTimer
+	r.nextTimer++
+	r.timers[id] = r.cfg.Now().Add(delay)
+	must(proc.WriteUint32Le(sp+16, uint32(id)))
+}
+
+func (r *Runtime) clearTimeoutEvent(proc *exec.Process, sp uint32) {
+	delete(r.timers, getInt32(proc, sp+8))
+}
+
+func (r *Runtime) getRandomData(proc *exec.Process, sp uint32) {
+	if _, err := io.ReadFull(r.cfg.Rand, loadSlice(proc, sp+8)); err != nil {
+		panic(fmt.Errorf("gojs: reading random data: %v", err))
+	}
+}
+
+func (r *Runtime) finalizeRef(proc *exec.Process, sp uint32) {
+	r.values.finalize(getUint32(proc, sp+8))
+}
+
+func (r *Runtime) stringVal(proc *exec.Process, sp uint32) {
+	r.storeValue(proc, sp+24, loadString(proc, sp+8))
+}
+
+func (r *Runtime) valueGet(proc *exec.Process, sp uint32) {
+	r.storeValue(proc, sp+32, get(r.loadValue(proc, sp+8), loadString(proc, sp+16)))
+}
+
+func (r *Runtime) valueSet(proc *exec.Process, sp uint32) {
+	set(r.loadValue(proc, sp+8), loadString(proc, sp+16), r.loadValue(proc, sp+32))
+}
+
+func (r *Runtime) valueDelete(proc *exec.Process, sp uint32) {
+	if o, ok := r.loadValue(proc, sp+8).(*Object); ok {
+		delete(o.Props, loadString(proc, sp+16))
+	}
+}
+
+func (r *Runtime) valueIndex(proc *exec.Process, sp uint32) {
+	r.storeValue(proc, sp+24, index(r.loadValue(proc, sp+8), getInt64(proc, sp+16)))
+}
+
+func (r *Runtime) valueSetIndex(proc *exec.Process, sp uint32) {
+	setIndex(r.loadValue(proc, sp+8), getInt64(proc, sp+16), r.loadValue(proc, sp+24))
+}
+
+// storeResult writes the result of a call at addr, or the error it threw,
+// followed by whether it succeeded.
+func (r *Runtime) storeResult(proc *exec.Process, addr uint32, result interface{}, err error) {
+	if err != nil {
+		r.storeValue(proc, addr, errorValue(err))
+		setBool(proc, addr+8, false)
+		return
+	}
+	r.storeValue(proc, addr, result)
+	setBool(proc, addr+8, true)
+}
+
+func (r *Runtime) valueCall(proc *exec.Process, sp uint32) {
+	v := r.loadValue(proc, sp+8)
+	fn := get(v, loadString(proc, sp+16))
+	args := r.loadValues(proc, sp+32)
+	result, err := call(fn, v, args)
+	sp = r.getsp(proc)
+	r.storeResult(proc, sp+56, result, err)
+}
+
+func (r *Runtime) valueInvoke(proc *exec.Process, sp uint32) {
+	fn := r.loadValue(proc, sp+8)
+	args := r.loadValues(proc, sp+16)
+	result, err := call(fn, Undefined, args)
+	sp = r.getsp(proc)
+	r.storeResult(proc, sp+40, result, err)
+}
+
+func (r *Runtime) valueNew(proc *exec.Process, sp uint32) {
+	fn := r.loadValue(proc, sp+8)
+	args := r.loadValues(proc, sp+16)
+	result, err := construct(fn, args)
+	sp = r.getsp(proc)
+	r.storeResult(proc, sp+40, result, err)
+}
+
+func (r *Runtime) valueLength(proc *exec.Process, sp uint32) {
+	setInt64(proc, sp+16, toInt(get(r.loadValue(proc, sp+8), "length")))
+}
+
+func (r *Runtime) valuePrepareString(proc *exec.Process, sp uint32) {
+	s := toString(r.loadValue(proc, sp+8))
+	r.storeValue(proc, sp+16, &Uint8Array{Data: []byte(s)})
+	setInt64(proc, sp+24, int64(len(s)))
+}
+
+func (r *Runtime) valueLoadString(proc *exec.Process, sp uint32) {
+	if s, ok := r.loadValue(proc, sp+8).(*Uint8Array); ok {
+		copy(loadSlice(proc, sp+16), s.Data)
+	}
+}
+
+func (r *Runtime) valueInstanceOf(proc *exec.Process, sp uint32) {
+	setBool(proc, sp+24, r.instanceOf(r.loadValue(proc, sp+8), r.loadValue(proc, sp+16)))
+}
+
+func (r *Runtime) copyBytesToGo(proc *exec.Process, sp uint32) {
+	dst := loadSlice(proc, sp+8)
+	src, ok := r.loadValue(proc, sp+32).(*Uint8Array)
+	if !ok {
+		setBool(proc, sp+48, false)
+		return
+	}
+	setInt64(proc, sp+40, int64(copy(dst, src.Data)))
+	setBool(proc, sp+48, true)
+}
+
+func (r *Runtime) copyBytesToJS(proc *exec.Process, sp uint32) {
+	dst, ok := r.loadValue(proc, sp+8).(*Uint8Array)
+	if !ok {
+		setBool(proc, sp+48, false)
+		return
+	}
+	setInt64(proc, sp+40, int64(copy(dst.Data, loadSlice(proc, sp+16))))
+	setBool(proc, sp+48, true)
+}
+
+func (r *Runtime) debug(proc *exec.Process, v uint32) {
+	fmt.Fprintln(r.cfg.Stderr, v)
+}
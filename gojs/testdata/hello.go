@@ -0,0 +1,31 @@
+// hello is the Go program run by the tests of gojs. It is built with
+// GOOS=js GOARCH=wasm.
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+func main() {
+	fmt.Println("hello", os.Args[1:], os.Getenv("GREETING"))
+
+	if err := ioutil.WriteFile("dir/out.txt", []byte("data"), 0644); err != nil {
+		fmt.Println(err)
+	}
+	data, err := ioutil.ReadFile("/dir/out.txt")
+	fmt.Println(string(data), err)
+	names, err := ioutil.ReadDir("dir")
+	for _, fi := range names {
+		fmt.Println(fi.Name(), fi.Size(), fi.IsDir())
+	}
+	if _, err := os.Stat("missing"); !os.IsNotExist(err) {
+		fmt.Println("stat missing:", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	println("to stderr")
+	os.Exit(3)
+}
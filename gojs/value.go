@@ -0,0 +1,363 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gojs
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// JavaScript values are represented in Go by Undefined, nil for null, bool,
+// float64 for numbers, string, *Object, *Array and *Uint8Array. Other
+// integer and float types are accepted where values are passed to the
+// guest, and converted to float64.
+
+type undefined struct{}
+
+// Undefined is the JavaScript undefined value.
+var Undefined = undefined{}
+
+// Object is a JavaScript object. It is a function if Call is not nil, and a
+// constructor if Construct is not nil.
+type Object struct {
+	Props map[string]interface{}
+
+	// Call is called when the object is called as a function, with the
+	// value of this. The error it returns is thrown to the guest.
+	Call func(this interface{}, args []interface{}) (interface{}, error)
+	// Construct is called when the object is used as a constructor.
+	Construct func(args []interface{}) (interface{}, error)
+}
+
+// NewObject returns an object with the properties props.
+func NewObject(props map[string]interface{}) *Object {
+	if props == nil {
+		props = make(map[string]interface{})
+	}
+	return &Object{Props: props}
+}
+
+// Func returns a JavaScript function calling fn.
+func Func(fn func(this interface{}, args []interface{}) (interface{}, error)) *Object {
+	return &Object{Props: make(map[string]interface{}), Call: fn}
+}
+
+// Array is a JavaScript array.
+type Array struct {
+	Elems []interface{}
+}
+
+// Uint8Array is a JavaScript Uint8Array.
+type Uint8Array struct {
+	Data []byte
+}
+
+// Error is a JavaScript error thrown to the guest, or passed to the
+// callbacks of fs. Code is the code of Node.js system errors, such as
+// "ENOENT", which the syscall package of the guest maps to errnos.
+type Error struct {
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+	return e.Code + ": " + e.Message
+}
+
+// errorValue returns the JavaScript value of an error.
+func errorValue(err error) *Object {
+	code, message := "", err.Error()
+	if e, ok := err.(*Error); ok {
+		code, message = e.Code, e.Message
+	}
+	o := NewObject(map[string]interface{}{"message": message})
+	if code != "" {
+		o.Props["code"] = code
+	}
+	return o
+}
+
+// normalize converts the Go numbers of v to float64.
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int:
+		return float64(v)
+	case int8:
+		return float64(v)
+	case int16:
+		return float64(v)
+	case int32:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case uint:
+		return float64(v)
+	case uint8:
+		return float64(v)
+	case uint16:
+		return float64(v)
+	case uint32:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float32:
+		return float64(v)
+	}
+	return v
+}
+
+// Type flags of the references to values.
+const (
+	typeFlagNone = iota
+	typeFlagObject
+	typeFlagString
+	typeFlagSymbol
+	typeFlagFunction
+)
+
+func typeFlag(v interface{}) uint32 {
+	switch v := v.(type) {
+	case *Object:
+		if v.Call != nil || v.Construct != nil {
+			return typeFlagFunction
+		}
+		return typeFlagObject
+	case *Array, *Uint8Array:
+		return typeFlagObject
+	case string:
+		return typeFlagString
+	}
+	return typeFlagNone
+}
+
+// valueTable holds the values the guest has references to, the way
+// wasm_exec.js does.
+type valueTable struct {
+	values []interface{}
+	refs   []int // Number of references of the guest, -1 for predefined values
+	ids    map[interface{}]uint32
+	pool   []uint32 // Unused ids
+}
+
+func newValueTable(global, goObj *Object) *valueTable {
+	t := &valueTable{
+		values: []interface{}{math.NaN(), float64(0), nil, true, false, global, goObj},
+		ids:    make(map[interface{}]uint32),
+	}
+	t.refs = make([]int, len(t.values))
+	for id, v := range t.values {
+		t.refs[id] = -1
+		if id != 0 {
+			t.ids[v] = uint32(id)
+		}
+	}
+	return t
+}
+
+// ref returns the id of v, adding a reference to it.
+func (t *valueTable) ref(v interface{}) uint32 {
+	id, ok := t.ids[v]
+	if !ok {
+		if n := len(t.pool); n > 0 {
+			id = t.pool[n-1]
+			t.pool = t.pool[:n-1]
+			t.values[id] = v
+			t.refs[id] = 0
+		} else {
+			id = uint32(len(t.values))
+			t.values = append(t.values, v)
+			t.refs = append(t.refs, 0)
+		}
+		t.ids[v] = id
+	}
+	if t.refs[id] >= 0 {
+		t.refs[id]++
+	}
+	return id
+}
+
+// get returns the value of id.
+func (t *valueTable) get(id uint32) interface{} {
+	if int(id) >= len(t.values) {
+		panic(fmt.Errorf("gojs: invalid reference %d", id))
+	}
+	return t.values[id]
+}
+
+// finalize removes a reference to the value of id.
+func (t *valueTable) finalize(id uint32) {
+	if int(id) >= len(t.refs) || t.refs[id] <= 0 {
+		return
+	}
+	t.refs[id]--
+	if t.refs[id] == 0 {
+		delete(t.ids, t.values[id])
+		t.values[id] = nil
+		t.pool = append(t.pool, id)
+	}
+}
+
+// get returns the property prop of v, the way Reflect.get does.
+func get(v interface{}, prop string) interface{} {
+	switch v := v.(type) {
+	case *Object:
+		if x, ok := v.Props[prop]; ok {
+			return x
+		}
+	case *Array:
+		if prop == "length" {
+			return float64(len(v.Elems))
+		}
+	case *Uint8Array:
+		if prop == "length" || prop == "byteLength" {
+			return float64(len(v.Data))
+		}
+	case string:
+		if prop == "length" {
+			return float64(len(utf16.Encode([]rune(v))))
+		}
+	}
+	return Undefined
+}
+
+// set sets the property prop of v to x.
+func set(v interface{}, prop string, x interface{}) {
+	if o, ok := v.(*Object); ok {
+		if o.Props == nil {
+			o.Props = make(map[string]interface{})
+		}
+		o.Props[prop] = x
+	}
+}
+
+// index returns the element i of v.
+func index(v interface{}, i int64) interface{} {
+	switch v := v.(type) {
+	case *Array:
+		if i >= 0 && i < int64(len(v.Elems)) {
+			return v.Elems[i]
+		}
+	case *Uint8Array:
+		if i >= 0 && i < int64(len(v.Data)) {
+			return float64(v.Data[i])
+		}
+	case *Object:
+		return get(v, strconv.FormatInt(i, 10))
+	}
+	return Undefined
+}
+
+// setIndex sets the element i of v to x.
+func setIndex(v interface{}, i int64, x interface{}) {
+	switch v := v.(type) {
+	case *Array:
+		if i < 0 {
+			return
+		}
+		for int64(len(v.Elems)) <= i {
+			v.Elems = append(v.Elems, Undefined)
+		}
+		v.Elems[i] = x
+	case *Uint8Array:
+		if f, ok := x.(float64); ok && i >= 0 && i < int64(len(v.Data)) {
+			v.Data[i] = byte(int64(f))
+		}
+	case *Object:
+		set(v, strconv.FormatInt(i, 10), x)
+	}
+}
+
+// call calls the function fn with this and args.
+func call(fn, this interface{}, args []interface{}) (interface{}, error) {
+	o, ok := fn.(*Object)
+	if !ok || o.Call == nil {
+		return nil, &Error{Message: fmt.Sprintf("%s is not a function", toString(fn))}
+	}
+	return o.Call(this, args)
+}
+
+// construct calls the constructor fn with args.
+func construct(fn interface{}, args []interface{}) (interface{}, error) {
+	o, ok := fn.(*Object)
+	if !ok || o.Construct == nil {
+		return nil, &Error{Message: fmt.Sprintf("%s is not a constructor", toString(fn))}
+	}
+	return o.Construct(args)
+}
+
+// toString converts v to a string, the way String does.
+func toString(v interface{}) string {
+	switch v := v.(type) {
+	case undefined:
+		return "undefined"
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return formatNumber(v)
+	case string:
+		return v
+	case *Array:
+		s := make([]string, len(v.Elems))
+		for i, e := range v.Elems {
+			if e != nil && e != Undefined {
+				s[i] = toString(e)
+			}
+		}
+		return strings.Join(s, ",")
+	case *Uint8Array:
+		s := make([]string, len(v.Data))
+		for i, b := range v.Data {
+			s[i] = strconv.Itoa(int(b))
+		}
+		return strings.Join(s, ",")
+	case *Object:
+		if v.Call != nil || v.Construct != nil {
+			return "function () { [native code] }"
+		}
+		if msg, ok := v.Props["message"].(string); ok {
+			return "Error: " + msg
+		}
+		return "[object Object]"
+	}
+	return fmt.Sprint(v)
+}
+
+// formatNumber formats f the way JavaScript does, for the common cases.
+func formatNumber(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == math.Trunc(f) && math.Abs(f) < 1e21:
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// toInt converts v to an integer, the way parseInt does for numbers.
+func toInt(v interface{}) int64 {
+	switch v := v.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return 0
+		}
+		return int64(v)
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	return 0
+}
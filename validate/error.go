@@ -28,6 +28,14 @@ func (e Error) Error() string {
 // are no values on the stack.
 var ErrStackUnderflow = errors.New("validate: stack underflow")
 
+// ErrTailCallTypeMismatch is returned if a return_call or return_call_indirect
+// targets a function whose number of results differs from the calling function.
+var ErrTailCallTypeMismatch = errors.New("validate: tail call result count does not match caller")
+
+// ErrRethrowOutsideCatch is returned if the label of a rethrow instruction
+// does not refer to a catch or catch_all block.
+var ErrRethrowOutsideCatch = errors.New("validate: rethrow target is not a catch block")
+
 // InvalidImmediateError is returned if the immediate value provided
 // is invalid for the given instruction.
 type InvalidImmediateError struct {
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"math"
 
 	"github.com/go-interpreter/wagon/wasm"
 	"github.com/go-interpreter/wagon/wasm/operators"
@@ -16,7 +17,7 @@ import (
 )
 
 // vibhavp: TODO: We do not verify whether blocks don't access for the parent block, do that.
-func verifyBody(fn *wasm.FunctionSig, body *wasm.FunctionBody, module *wasm.Module) (*mockVM, error) {
+func verifyBody(fn *wasm.FunctionSig, body *wasm.FunctionBody, module *wasm.Module, features wasm.Features) (*mockVM, error) {
 	vm := &mockVM{
 		stack:      make([]operand, 0, 6),
 		code:       bytes.NewReader(body.Code),
@@ -56,14 +57,29 @@ func verifyBody(fn *wasm.FunctionSig, body *wasm.FunctionBody, module *wasm.Modu
 			return vm, err
 		}
 
-		opStruct, err := ops.New(op)
-		if err != nil {
+		var opStruct ops.Op
+		if ops.IsPrefix(op) {
+			code, err := vm.fetchVarUint()
+			if err != nil {
+				return vm, err
+			}
+			opStruct, err = ops.NewPrefixed(op, code)
+			if err != nil {
+				return vm, err
+			}
+			op = opStruct.Code
+		} else if opStruct, err = ops.New(op); err != nil {
 			return vm, err
 		}
+		if !features.Has(opStruct.Feature) {
+			return vm, wasm.DisabledFeatureError(opStruct.Feature)
+		}
 
 		logger.Printf("PC: %d OP: %s unreachable: %v", vm.pc(), opStruct.Name, vm.topFrameUnreachable())
 
-		if !opStruct.Polymorphic {
+		// The operands of memory operators depend on the memory they
+		// access, and are checked once its index is read.
+		if !opStruct.Polymorphic && !isMemoryOp(op) {
 			if err := vm.adjustStack(opStruct); err != nil {
 				return vm, err
 			}
@@ -71,7 +87,7 @@ func verifyBody(fn *wasm.FunctionSig, body *wasm.FunctionBody, module *wasm.Modu
 
 		switch op {
 
-		case ops.Block, ops.If: // If operand is handled in adjustStack()
+		case ops.Block, ops.If, ops.Try: // If operand is handled in adjustStack()
 			sig, err := vm.fetchByte()
 			if err != nil {
 				return vm, err
@@ -111,6 +127,86 @@ func verifyBody(fn *wasm.FunctionSig, body *wasm.FunctionBody, module *wasm.Modu
 			}
 			vm.pushFrame(op, frame.endTypes, frame.endTypes)
 
+		case ops.Catch, ops.CatchAll:
+			frame, err := vm.popFrame()
+			if err != nil {
+				return vm, err
+			}
+			// catch may follow try or another catch, while catch_all
+			// must be the last handler of a try block.
+			if frame == nil || (frame.op != ops.Try && frame.op != ops.Catch) {
+				return vm, UnmatchedOpError(op)
+			}
+			vm.pushFrame(op, frame.endTypes, frame.endTypes)
+			if op == ops.Catch {
+				index, err := vm.fetchVarUint()
+				if err != nil {
+					return vm, err
+				}
+				sig, err := module.GetTagSig(index)
+				if err != nil {
+					return vm, err
+				}
+				for _, t := range sig.ParamTypes {
+					vm.pushOperand(t)
+				}
+			}
+
+		case ops.Delegate:
+			frame, err := vm.popFrame()
+			if err != nil {
+				return vm, err
+			}
+			if frame == nil || frame.op != ops.Try {
+				return vm, UnmatchedOpError(op)
+			}
+			// The label is relative to the block enclosing the try.
+			depth, err := vm.fetchVarUint()
+			if err != nil {
+				return vm, err
+			}
+			if int(depth) >= len(vm.ctrlFrames) {
+				return vm, InvalidLabelError(depth)
+			}
+			for _, t := range frame.endTypes {
+				vm.pushOperand(t)
+			}
+
+		case ops.Throw:
+			index, err := vm.fetchVarUint()
+			if err != nil {
+				return vm, err
+			}
+			sig, err := module.GetTagSig(index)
+			if err != nil {
+				return vm, err
+			}
+			for i := len(sig.ParamTypes) - 1; i >= 0; i-- {
+				t := sig.ParamTypes[i]
+				op, err := vm.popOperand()
+				if err != nil {
+					return vm, err
+				}
+				if !op.Equal(t) {
+					return vm, InvalidTypeError{t, op.Type}
+				}
+			}
+			vm.setUnreachable()
+
+		case ops.Rethrow:
+			depth, err := vm.fetchVarUint()
+			if err != nil {
+				return vm, err
+			}
+			frame := vm.getFrameFromDepth(int(depth))
+			if frame == nil {
+				return vm, InvalidLabelError(depth)
+			}
+			if frame.op != ops.Catch && frame.op != ops.CatchAll {
+				return vm, ErrRethrowOutsideCatch
+			}
+			vm.setUnreachable()
+
 		case ops.End:
 			// Block 'return' type is validated in popFrame().
 			frame, err := vm.popFrame()
@@ -333,11 +429,29 @@ func verifyBody(fn *wasm.FunctionSig, body *wasm.FunctionBody, module *wasm.Modu
 			if err != nil {
 				return vm, err
 			}
+			// the index of the memory follows the flags when bit 6 is set.
+			var memIndex uint32
+			if align&0x40 != 0 {
+				align &^= 0x40
+				if memIndex, err = vm.fetchVarUint(); err != nil {
+					return vm, err
+				}
+			}
 			// offset
-			_, err = vm.fetchVarUint()
+			offset, err := vm.fetchVarUint64()
 			if err != nil {
 				return vm, err
 			}
+			mem := module.GetMemory(int(memIndex))
+			if mem == nil && memIndex != 0 {
+				return vm, InvalidTableIndexError{"memory", memIndex}
+			}
+			if (mem == nil || !mem.Limits.Is64()) && offset > math.MaxUint32 {
+				return vm, InvalidImmediateError{OpName: opStruct.Name, ImmType: "32-bit offset"}
+			}
+			if err := vm.adjustMemoryOpStack(opStruct, mem); err != nil {
+				return vm, err
+			}
 
 			switch op {
 			case ops.I32Load8s, ops.I32Load8u, ops.I64Load8s, ops.I64Load8u:
@@ -359,28 +473,74 @@ func verifyBody(fn *wasm.FunctionSig, body *wasm.FunctionBody, module *wasm.Modu
 			}
 
 		case ops.CurrentMemory, ops.GrowMemory:
-			memIndex, err := vm.fetchByte()
+			memIndex, err := vm.fetchVarUint()
+			if err != nil {
+				return vm, err
+			}
+			mem := module.GetMemory(int(memIndex))
+			if mem == nil && memIndex != 0 {
+				return vm, InvalidTableIndexError{"memory", memIndex}
+			}
+			if err := vm.adjustMemoryOpStack(opStruct, mem); err != nil {
+				return vm, err
+			}
+
+		case ops.MemoryFill:
+			memIndex, err := vm.fetchVarUint()
 			if err != nil {
 				return vm, err
 			}
-			if memIndex != 0x00 {
-				return vm, InvalidTableIndexError{"memory", uint32(memIndex)}
+			mem := module.GetMemory(int(memIndex))
+			if mem == nil {
+				return vm, InvalidTableIndexError{"memory", memIndex}
+			}
+			if err := vm.adjustMemoryOpStack(opStruct, mem); err != nil {
+				return vm, err
 			}
 
-		case ops.Call:
+		case ops.MemoryCopy:
+			var mems [2]*wasm.Memory
+			for i := range mems {
+				memIndex, err := vm.fetchVarUint()
+				if err != nil {
+					return vm, err
+				}
+				if mems[i] = module.GetMemory(int(memIndex)); mems[i] == nil {
+					return vm, InvalidTableIndexError{"memory", memIndex}
+				}
+			}
+			// the size is an i64 value if both memories are 64-bit
+			// memories, and the addresses are indices of their memory.
+			dst64, src64 := mems[0].Limits.Is64(), mems[1].Limits.Is64()
+			args := []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32}
+			if dst64 && src64 {
+				args[0] = wasm.ValueTypeI64
+			}
+			if src64 {
+				args[1] = wasm.ValueTypeI64
+			}
+			if dst64 {
+				args[2] = wasm.ValueTypeI64
+			}
+			opStruct.Args = args
+			if err := vm.adjustStack(opStruct); err != nil {
+				return vm, err
+			}
+
+		case ops.Call, ops.ReturnCall:
 			index, err := vm.fetchVarUint()
 			if err != nil {
 				return vm, err
 			}
 
-			fn := module.GetFunction(int(index))
-			if fn == nil {
+			callee := module.GetFunction(int(index))
+			if callee == nil {
 				return vm, wasm.InvalidFunctionIndexError(index)
 			}
 
-			logger.Printf("Function being called: %v", fn)
-			for index := range fn.Sig.ParamTypes {
-				argType := fn.Sig.ParamTypes[len(fn.Sig.ParamTypes)-index-1]
+			logger.Printf("Function being called: %v", callee)
+			for index := range callee.Sig.ParamTypes {
+				argType := callee.Sig.ParamTypes[len(callee.Sig.ParamTypes)-index-1]
 				op, err := vm.popOperand()
 				if err != nil {
 					return vm, err
@@ -390,11 +550,18 @@ func verifyBody(fn *wasm.FunctionSig, body *wasm.FunctionBody, module *wasm.Modu
 				}
 			}
 
-			for _, t := range fn.Sig.ReturnTypes {
-				vm.pushOperand(t)
+			if op == ops.ReturnCall {
+				if err := matchingTailCallTypes(fn, callee.Sig); err != nil {
+					return vm, err
+				}
+				vm.setUnreachable()
+			} else {
+				for _, t := range callee.Sig.ReturnTypes {
+					vm.pushOperand(t)
+				}
 			}
 
-		case ops.CallIndirect:
+		case ops.CallIndirect, ops.ReturnCallIndirect:
 			if module.Table == nil || len(module.Table.Entries) == 0 {
 				return vm, NoSectionError(wasm.SectionIDTable)
 			}
@@ -428,12 +595,12 @@ func verifyBody(fn *wasm.FunctionSig, body *wasm.FunctionBody, module *wasm.Modu
 
 			fnExpectSig := module.Types.Entries[index]
 
-			op, err := vm.popOperand()
+			selector, err := vm.popOperand()
 			if err != nil {
 				return vm, err
 			}
-			if !op.Equal(wasm.ValueTypeI32) {
-				return vm, InvalidTypeError{wasm.ValueTypeI32, op.Type}
+			if !selector.Equal(wasm.ValueTypeI32) {
+				return vm, InvalidTypeError{wasm.ValueTypeI32, selector.Type}
 			}
 
 			for index := range fnExpectSig.ParamTypes {
@@ -446,8 +613,16 @@ func verifyBody(fn *wasm.FunctionSig, body *wasm.FunctionBody, module *wasm.Modu
 					return vm, InvalidTypeError{argType, op.Type}
 				}
 			}
-			for _, t := range fnExpectSig.ReturnTypes {
-				vm.pushOperand(t)
+
+			if op == ops.ReturnCallIndirect {
+				if err := matchingTailCallTypes(fn, &fnExpectSig); err != nil {
+					return vm, err
+				}
+				vm.setUnreachable()
+			} else {
+				for _, t := range fnExpectSig.ReturnTypes {
+					vm.pushOperand(t)
+				}
 			}
 
 		case ops.Drop:
@@ -513,9 +688,37 @@ func verifyBody(fn *wasm.FunctionSig, body *wasm.FunctionBody, module *wasm.Modu
 	return vm, nil
 }
 
+// matchingTailCallTypes checks that a function tail-calling callee returns
+// exactly the values produced by callee, as the callee's results are passed
+// to the caller's caller unchanged.
+func matchingTailCallTypes(caller, callee *wasm.FunctionSig) error {
+	if len(caller.ReturnTypes) != len(callee.ReturnTypes) {
+		return ErrTailCallTypeMismatch
+	}
+	for i, t := range caller.ReturnTypes {
+		if callee.ReturnTypes[i] != t {
+			return InvalidTypeError{t, callee.ReturnTypes[i]}
+		}
+	}
+	return nil
+}
+
 // VerifyModule verifies the given module according to WebAssembly verification
 // specs.
-func VerifyModule(module *wasm.Module) error {
+// As with wasm.ReadModule, the module may only use the given features, or
+// any feature supported by wagon if none is given.
+func VerifyModule(module *wasm.Module, features ...wasm.Features) error {
+	f := wasm.FeaturesAll
+	if len(features) != 0 {
+		f = wasm.FeaturesMVP
+		for _, feature := range features {
+			f |= feature
+		}
+	}
+	if err := module.CheckFeatures(f); err != nil {
+		return err
+	}
+
 	if module.Function == nil || module.Types == nil || len(module.Types.Entries) == 0 {
 		return nil
 	}
@@ -526,7 +729,7 @@ func VerifyModule(module *wasm.Module) error {
 	logger.Printf("There are %d functions", len(module.Function.Types))
 	for i, fn := range module.FunctionIndexSpace {
 		logger.Printf("Validating function: %q", fn.Name)
-		if vm, err := verifyBody(fn.Sig, fn.Body, module); err != nil {
+		if vm, err := verifyBody(fn.Sig, fn.Body, module, f); err != nil {
 			return Error{vm.pc(), i, err}
 		}
 		logger.Printf("No errors in function %d (%q)", i, fn.Name)
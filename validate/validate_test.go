@@ -154,7 +154,7 @@ func TestValidateAlignment(t *testing.T) {
 			sig := wasm.FunctionSig{Form: 0x60 /* Must always be 0x60 */}
 			fn := wasm.FunctionBody{Module: &mod, Code: tc.code}
 
-			_, err := verifyBody(&sig, &fn, &mod)
+			_, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll)
 			if err != tc.err {
 				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
 			}
@@ -214,7 +214,281 @@ func TestValidateMemory(t *testing.T) {
 			sig := wasm.FunctionSig{Form: 0x60 /* Must always be 0x60 */}
 			fn := wasm.FunctionBody{Module: &mod, Code: tc.code}
 
-			_, err := verifyBody(&sig, &fn, &mod)
+			_, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll)
+			if err != tc.err {
+				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestValidateMemory64(t *testing.T) {
+	tcs := []struct {
+		name string
+		code []byte
+		err  error
+	}{
+		{
+			name: "load with i64 address",
+			code: []byte{
+				operators.I64Const, 0,
+				operators.I32Load, 2, 0x80, 0x80, 0x80, 0x80, 0x10,
+				operators.Drop,
+			},
+			err: nil,
+		},
+		{
+			name: "load with i32 address",
+			code: []byte{
+				operators.I32Const, 0,
+				operators.I32Load, 2, 0,
+				operators.Drop,
+			},
+			err: InvalidTypeError{wasm.ValueTypeI64, wasm.ValueTypeI32},
+		},
+		{
+			name: "store with i64 address",
+			code: []byte{
+				operators.I64Const, 0,
+				operators.F32Const, 0, 0, 0, 0,
+				operators.F32Store, 2, 0,
+			},
+			err: nil,
+		},
+		{
+			name: "memory.grow",
+			code: []byte{
+				operators.I64Const, 1,
+				operators.GrowMemory, 0,
+				operators.CurrentMemory, 0,
+				operators.I64Add,
+				operators.Drop,
+			},
+			err: nil,
+		},
+		{
+			name: "memory.size",
+			code: []byte{
+				operators.CurrentMemory, 0,
+				operators.I32Eqz,
+				operators.Drop,
+			},
+			err: InvalidTypeError{wasm.ValueTypeI32, wasm.ValueTypeI64},
+		},
+	}
+
+	for i := range tcs {
+		tc := tcs[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mod := wasm.Module{
+				Memory: &wasm.SectionMemories{
+					Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Flags: wasm.LimitsMemory64, Initial: 1}}},
+				},
+			}
+			sig := wasm.FunctionSig{Form: 0x60 /* Must always be 0x60 */}
+			fn := wasm.FunctionBody{Module: &mod, Code: tc.code}
+
+			_, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll)
+			if err != tc.err {
+				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
+			}
+		})
+	}
+
+	// 32-bit memories only accept 32-bit offsets.
+	mod := wasm.Module{}
+	sig := wasm.FunctionSig{Form: 0x60}
+	fn := wasm.FunctionBody{Module: &mod, Code: []byte{
+		operators.I32Const, 0,
+		operators.I32Load, 2, 0x80, 0x80, 0x80, 0x80, 0x10,
+		operators.Drop,
+	}}
+	want := InvalidImmediateError{OpName: "i32.load", ImmType: "32-bit offset"}
+	if _, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll); err != want {
+		t.Fatalf("verify returned '%v', want '%v'", err, want)
+	}
+}
+
+func TestValidateMultiMemory(t *testing.T) {
+	tcs := []struct {
+		name string
+		code []byte
+		err  error
+	}{
+		{
+			name: "load from the first memory",
+			code: []byte{
+				operators.I32Const, 0,
+				operators.I32Load, 2, 0,
+				operators.Drop,
+			},
+			err: nil,
+		},
+		{
+			name: "load from the 64-bit memory",
+			code: []byte{
+				operators.I64Const, 0,
+				operators.I32Load, 0x42, 1, 0,
+				operators.Drop,
+			},
+			err: nil,
+		},
+		{
+			name: "store to the 64-bit memory with i32 address",
+			code: []byte{
+				operators.I32Const, 0,
+				operators.I32Const, 0,
+				operators.I32Store, 0x42, 1, 0,
+			},
+			err: InvalidTypeError{wasm.ValueTypeI64, wasm.ValueTypeI32},
+		},
+		{
+			name: "load invalid index",
+			code: []byte{
+				operators.I32Const, 0,
+				operators.I32Load, 0x42, 2, 0,
+				operators.Drop,
+			},
+			err: InvalidTableIndexError{"memory", 2},
+		},
+		{
+			name: "memory.grow",
+			code: []byte{
+				operators.I64Const, 1,
+				operators.GrowMemory, 1,
+				operators.CurrentMemory, 0,
+				operators.Drop,
+				operators.Drop,
+			},
+			err: nil,
+		},
+		{
+			name: "memory.size invalid index",
+			code: []byte{
+				operators.CurrentMemory, 2,
+				operators.Drop,
+			},
+			err: InvalidTableIndexError{"memory", 2},
+		},
+	}
+
+	for i := range tcs {
+		tc := tcs[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mod := wasm.Module{
+				Memory: &wasm.SectionMemories{
+					Entries: []wasm.Memory{
+						{Limits: wasm.ResizableLimits{Initial: 1}},
+						{Limits: wasm.ResizableLimits{Flags: wasm.LimitsMemory64, Initial: 1}},
+					},
+				},
+			}
+			sig := wasm.FunctionSig{Form: 0x60 /* Must always be 0x60 */}
+			fn := wasm.FunctionBody{Module: &mod, Code: tc.code}
+
+			_, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll)
+			if err != tc.err {
+				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestValidatePrefixed(t *testing.T) {
+	tcs := []struct {
+		name string
+		code []byte
+		err  error
+	}{
+		{
+			name: "i64.trunc_u:sat/f64",
+			code: []byte{
+				operators.F64Const, 0, 0, 0, 0, 0, 0, 0, 0,
+				operators.PrefixMisc, 7,
+				operators.I64Eqz,
+				operators.Drop,
+			},
+			err: nil,
+		},
+		{
+			name: "i32.trunc_s:sat/f64 of an f32",
+			code: []byte{
+				operators.F32Const, 0, 0, 0, 0,
+				operators.PrefixMisc, 2,
+				operators.Drop,
+			},
+			err: InvalidTypeError{wasm.ValueTypeF64, wasm.ValueTypeF32},
+		},
+		{
+			name: "invalid prefixed opcode",
+			code: []byte{
+				operators.PrefixMisc, 0x80, 0x01,
+			},
+			err: operators.InvalidPrefixedOpcodeError{Prefix: operators.PrefixMisc, Code: 128},
+		},
+		{
+			name: "memory.fill",
+			code: []byte{
+				operators.I32Const, 0,
+				operators.I32Const, 1,
+				operators.I32Const, 2,
+				operators.PrefixMisc, 11, 0,
+			},
+			err: nil,
+		},
+		{
+			name: "memory.copy from a 64-bit memory",
+			code: []byte{
+				operators.I32Const, 0,
+				operators.I64Const, 0,
+				operators.I32Const, 2,
+				operators.PrefixMisc, 10, 0, 1,
+			},
+			err: nil,
+		},
+		{
+			name: "memory.copy with an i64 size",
+			code: []byte{
+				operators.I32Const, 0,
+				operators.I64Const, 0,
+				operators.I64Const, 2,
+				operators.PrefixMisc, 10, 0, 1,
+			},
+			err: InvalidTypeError{wasm.ValueTypeI32, wasm.ValueTypeI64},
+		},
+		{
+			name: "memory.fill of a missing memory",
+			code: []byte{
+				operators.I32Const, 0,
+				operators.I32Const, 1,
+				operators.I32Const, 2,
+				operators.PrefixMisc, 11, 2,
+			},
+			err: InvalidTableIndexError{"memory", 2},
+		},
+	}
+
+	for i := range tcs {
+		tc := tcs[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mod := wasm.Module{
+				Memory: &wasm.SectionMemories{
+					Entries: []wasm.Memory{
+						{Limits: wasm.ResizableLimits{Initial: 1}},
+						{Limits: wasm.ResizableLimits{Flags: wasm.LimitsMemory64, Initial: 1}},
+					},
+				},
+			}
+			sig := wasm.FunctionSig{Form: 0x60 /* Must always be 0x60 */}
+			fn := wasm.FunctionBody{Module: &mod, Code: tc.code}
+
+			_, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll)
 			if err != tc.err {
 				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
 			}
@@ -392,7 +666,7 @@ func TestValidateFuncTypecheck(t *testing.T) {
 				},
 			}
 
-			_, err := verifyBody(&sig, &fn, &mod)
+			_, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll)
 			if err != tc.err {
 				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
 			}
@@ -551,7 +825,7 @@ func TestValidateLocalsGlobals(t *testing.T) {
 				},
 			}
 
-			_, err := verifyBody(&sig, &fn, &mod)
+			_, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll)
 			if err != tc.err {
 				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
 			}
@@ -939,7 +1213,7 @@ func TestValidateBlockTypecheck(t *testing.T) {
 			sig := wasm.FunctionSig{Form: 0x60 /* Must always be 0x60 */}
 			fn := wasm.FunctionBody{Module: &mod, Code: tc.code}
 
-			_, err := verifyBody(&sig, &fn, &mod)
+			_, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll)
 			if err != tc.err {
 				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
 			}
@@ -1126,7 +1400,7 @@ func TestValidateIfBlock(t *testing.T) {
 			sig := wasm.FunctionSig{Form: 0x60 /* Must always be 0x60 */}
 			fn := wasm.FunctionBody{Module: &mod, Code: tc.code}
 
-			_, err := verifyBody(&sig, &fn, &mod)
+			_, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll)
 			if err != tc.err {
 				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
 			}
@@ -1303,10 +1577,366 @@ func TestValidateStackTypechecking(t *testing.T) {
 			sig := wasm.FunctionSig{Form: 0x60 /* Must always be 0x60 */}
 			fn := wasm.FunctionBody{Module: &mod, Code: tc.code}
 
-			_, err := verifyBody(&sig, &fn, &mod)
+			_, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll)
 			if err != tc.err {
 				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
 			}
 		})
 	}
 }
+
+func TestValidateTailCall(t *testing.T) {
+	tcs := []struct {
+		name    string
+		returns []wasm.ValueType
+		code    []byte
+		err     error
+	}{
+		{
+			name:    "return_call",
+			returns: []wasm.ValueType{wasm.ValueTypeI32},
+			code: []byte{
+				operators.ReturnCall, 0,
+			},
+			err: nil,
+		},
+		{
+			name:    "return_call makes stack polymorphic",
+			returns: []wasm.ValueType{wasm.ValueTypeI32},
+			code: []byte{
+				operators.ReturnCall, 0,
+				operators.I32Add,
+			},
+			err: nil,
+		},
+		{
+			name:    "return_call result mismatch",
+			returns: []wasm.ValueType{wasm.ValueTypeI32},
+			code: []byte{
+				operators.I32Const, 1,
+				operators.I32Const, 2,
+				operators.ReturnCall, 1,
+			},
+			err: InvalidTypeError{wasm.ValueTypeI32, wasm.ValueTypeF32},
+		},
+		{
+			name:    "return_call result count mismatch",
+			returns: nil,
+			code: []byte{
+				operators.ReturnCall, 0,
+			},
+			err: ErrTailCallTypeMismatch,
+		},
+		{
+			name:    "return_call parameters underflow",
+			returns: []wasm.ValueType{wasm.ValueTypeF32},
+			code: []byte{
+				operators.I32Const, 1,
+				operators.ReturnCall, 1,
+			},
+			err: ErrStackUnderflow,
+		},
+		{
+			name:    "return_call_indirect",
+			returns: []wasm.ValueType{wasm.ValueTypeF32},
+			code: []byte{
+				operators.I32Const, 1,
+				operators.I32Const, 2,
+				operators.I32Const, 0,
+				operators.ReturnCallIndirect, 1, 0,
+			},
+			err: nil,
+		},
+		{
+			name:    "return_call_indirect result mismatch",
+			returns: []wasm.ValueType{wasm.ValueTypeF32},
+			code: []byte{
+				operators.I32Const, 0,
+				operators.ReturnCallIndirect, 0, 0,
+			},
+			err: InvalidTypeError{wasm.ValueTypeF32, wasm.ValueTypeI32},
+		},
+	}
+
+	for i := range tcs {
+		tc := tcs[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mod := wasm.Module{
+				FunctionIndexSpace: []wasm.Function{
+					{ // Function at index 0 returns an i32.
+						Sig: &wasm.FunctionSig{
+							Form:        0x60,
+							ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32},
+						},
+					},
+					{ // Function at index 1 returns an f32, consuming 2 i32's.
+						Sig: &wasm.FunctionSig{
+							Form:        0x60,
+							ParamTypes:  []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32},
+							ReturnTypes: []wasm.ValueType{wasm.ValueTypeF32},
+						},
+					},
+				},
+				Table: &wasm.SectionTables{
+					Entries: []wasm.Table{{ElementType: wasm.ElemTypeAnyFunc}},
+				},
+			}
+			mod.Types = &wasm.SectionTypes{
+				Entries: []wasm.FunctionSig{
+					*mod.FunctionIndexSpace[0].Sig,
+					*mod.FunctionIndexSpace[1].Sig,
+				},
+			}
+
+			sig := wasm.FunctionSig{Form: 0x60, ReturnTypes: tc.returns}
+			fn := wasm.FunctionBody{Module: &mod, Code: tc.code}
+
+			_, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll)
+			if err != tc.err {
+				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestValidateExceptions(t *testing.T) {
+	i32, empty := byte(wasm.ValueTypeI32), byte(wasm.BlockTypeEmpty)
+	tcs := []struct {
+		name string
+		code []byte
+		err  error
+	}{
+		{
+			name: "try catch",
+			code: []byte{
+				operators.Try, i32,
+				operators.I32Const, 1,
+				operators.Catch, 0,
+				operators.Catch, 1,
+				operators.I32Const, 2,
+				operators.CatchAll,
+				operators.I32Const, 3,
+				operators.End,
+			},
+			err: nil,
+		},
+		{
+			name: "catch pushes the tag params",
+			code: []byte{
+				operators.Try, i32,
+				operators.I32Const, 1,
+				operators.Catch, 0,
+				operators.I32Const, 1,
+				operators.End,
+			},
+			err: UnbalancedStackErr(wasm.ValueTypeI32),
+		},
+		{
+			name: "catch after catch_all",
+			code: []byte{
+				operators.Try, empty,
+				operators.CatchAll,
+				operators.Catch, 1,
+				operators.End,
+				operators.I32Const, 0,
+			},
+			err: UnmatchedOpError(operators.Catch),
+		},
+		{
+			name: "catch without try",
+			code: []byte{
+				operators.Block, empty,
+				operators.Catch, 1,
+				operators.End,
+				operators.I32Const, 0,
+			},
+			err: UnmatchedOpError(operators.Catch),
+		},
+		{
+			name: "invalid tag",
+			code: []byte{
+				operators.Try, empty,
+				operators.Catch, 2,
+				operators.End,
+				operators.I32Const, 0,
+			},
+			err: wasm.InvalidTagIndexError(2),
+		},
+		{
+			name: "delegate",
+			code: []byte{
+				operators.Try, i32,
+				operators.I32Const, 1,
+				operators.Delegate, 0,
+			},
+			err: nil,
+		},
+		{
+			name: "delegate invalid label",
+			code: []byte{
+				operators.Try, i32,
+				operators.I32Const, 1,
+				operators.Delegate, 1,
+			},
+			err: InvalidLabelError(1),
+		},
+		{
+			name: "throw",
+			code: []byte{
+				operators.F32Const, 0, 0, 0, 0,
+				operators.Throw, 0,
+			},
+			err: InvalidTypeError{wasm.ValueTypeI32, wasm.ValueTypeF32},
+		},
+		{
+			name: "throw makes stack polymorphic",
+			code: []byte{
+				operators.I32Const, 1,
+				operators.Throw, 0,
+				operators.I32Add,
+			},
+			err: nil,
+		},
+		{
+			name: "rethrow",
+			code: []byte{
+				operators.Try, i32,
+				operators.I32Const, 1,
+				operators.CatchAll,
+				operators.Block, empty,
+				operators.Rethrow, 1,
+				operators.End,
+				operators.I32Const, 1,
+				operators.End,
+			},
+			err: nil,
+		},
+		{
+			name: "rethrow outside catch",
+			code: []byte{
+				operators.Try, i32,
+				operators.Rethrow, 0,
+				operators.CatchAll,
+				operators.I32Const, 1,
+				operators.End,
+			},
+			err: ErrRethrowOutsideCatch,
+		},
+	}
+
+	for i := range tcs {
+		tc := tcs[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			mod := wasm.Module{
+				Types: &wasm.SectionTypes{
+					Entries: []wasm.FunctionSig{
+						{Form: 0x60, ParamTypes: []wasm.ValueType{wasm.ValueTypeI32}},
+						{Form: 0x60},
+					},
+				},
+				Tags: &wasm.SectionTags{
+					Entries: []wasm.Tag{{Type: 0}, {Type: 1}},
+				},
+			}
+
+			sig := wasm.FunctionSig{Form: 0x60, ReturnTypes: []wasm.ValueType{wasm.ValueTypeI32}}
+			fn := wasm.FunctionBody{Module: &mod, Code: tc.code}
+
+			_, err := verifyBody(&sig, &fn, &mod, wasm.FeaturesAll)
+			if err != tc.err {
+				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestValidateFeatures(t *testing.T) {
+	empty := byte(wasm.BlockTypeEmpty)
+	tcs := []struct {
+		name     string
+		code     []byte
+		features wasm.Features
+		err      error
+	}{
+		{
+			name: "return_call",
+			code: []byte{
+				operators.ReturnCall, 0,
+			},
+			features: wasm.FeatureTailCall,
+			err:      nil,
+		},
+		{
+			name: "return_call disabled",
+			code: []byte{
+				operators.ReturnCall, 0,
+			},
+			features: wasm.FeaturesMVP,
+			err:      wasm.DisabledFeatureError(wasm.FeatureTailCall),
+		},
+		{
+			name: "try disabled",
+			code: []byte{
+				operators.Try, empty,
+				operators.End,
+			},
+			features: wasm.FeatureTailCall,
+			err:      wasm.DisabledFeatureError(wasm.FeatureExceptions),
+		},
+		{
+			name: "i32.extend8_s disabled",
+			code: []byte{
+				operators.I32Const, 0,
+				operators.I32Extend8S,
+				operators.Drop,
+			},
+			features: wasm.FeaturesMVP,
+			err:      wasm.DisabledFeatureError(wasm.FeatureSignExtension),
+		},
+		{
+			name: "i32.trunc_s:sat/f32 disabled",
+			code: []byte{
+				operators.F32Const, 0, 0, 0, 0,
+				operators.PrefixMisc, 0,
+				operators.Drop,
+			},
+			features: wasm.FeatureSignExtension,
+			err:      wasm.DisabledFeatureError(wasm.FeatureSatConversion),
+		},
+	}
+
+	for i := range tcs {
+		tc := tcs[i]
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			sig := wasm.FunctionSig{Form: 0x60}
+			mod := wasm.Module{
+				FunctionIndexSpace: []wasm.Function{{Sig: &sig}},
+			}
+			fn := wasm.FunctionBody{Module: &mod, Code: tc.code}
+
+			_, err := verifyBody(&sig, &fn, &mod, tc.features)
+			if err != tc.err {
+				t.Fatalf("verify returned '%v', want '%v'", err, tc.err)
+			}
+		})
+	}
+
+	mod := wasm.Module{
+		Memory: &wasm.SectionMemories{
+			Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Flags: wasm.LimitsMemory64, Initial: 1}}},
+		},
+	}
+	want := wasm.DisabledFeatureError(wasm.FeatureMemory64)
+	if err := VerifyModule(&mod, wasm.FeaturesMVP); err != want {
+		t.Fatalf("verify returned '%v', want '%v'", err, want)
+	}
+	if err := VerifyModule(&mod); err != nil {
+		t.Fatalf("verify returned '%v', want '<nil>'", err)
+	}
+}
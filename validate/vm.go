@@ -56,6 +56,10 @@ func (vm *mockVM) fetchVarUint() (uint32, error) {
 	return leb128.ReadVarUint32(vm.code)
 }
 
+func (vm *mockVM) fetchVarUint64() (uint64, error) {
+	return leb128.ReadVarUint64(vm.code)
+}
+
 func (vm *mockVM) fetchVarInt() (int32, error) {
 	return leb128.ReadVarint32(vm.code)
 }
@@ -180,6 +184,45 @@ func (vm *mockVM) pushOperand(t wasm.ValueType) {
 	logger.Printf("Stack after push is %v. Pushed %v", vm.stack, o)
 }
 
+func isMemoryOp(op byte) bool {
+	switch op {
+	case ops.I32Load, ops.I64Load, ops.F32Load, ops.F64Load, ops.I32Load8s, ops.I32Load8u, ops.I32Load16s, ops.I32Load16u, ops.I64Load8s, ops.I64Load8u, ops.I64Load16s, ops.I64Load16u, ops.I64Load32s, ops.I64Load32u, ops.I32Store, ops.I64Store, ops.F32Store, ops.F64Store, ops.I32Store8, ops.I32Store16, ops.I64Store8, ops.I64Store16, ops.I64Store32, ops.CurrentMemory, ops.GrowMemory, ops.MemoryCopy, ops.MemoryFill:
+		return true
+	}
+	return false
+}
+
+// adjustMemoryOpStack adjusts the stack for the memory operator op,
+// accessing the linear memory mem, which may be nil if the module
+// doesn't declare it.
+func (vm *mockVM) adjustMemoryOpStack(op ops.Op, mem *wasm.Memory) error {
+	if mem != nil && mem.Limits.Is64() {
+		op = memory64Op(op)
+	}
+	return vm.adjustStack(op)
+}
+
+// memory64Op returns the signature of op when operating on a 64-bit linear
+// memory, where addresses and sizes in pages are i64 values.
+func memory64Op(op ops.Op) ops.Op {
+	switch op.Code {
+	case ops.I32Load, ops.I64Load, ops.F32Load, ops.F64Load, ops.I32Load8s, ops.I32Load8u, ops.I32Load16s, ops.I32Load16u, ops.I64Load8s, ops.I64Load8u, ops.I64Load16s, ops.I64Load16u, ops.I64Load32s, ops.I64Load32u, ops.I32Store, ops.I64Store, ops.F32Store, ops.F64Store, ops.I32Store8, ops.I32Store16, ops.I64Store8, ops.I64Store16, ops.I64Store32:
+		// the address is the last operand popped.
+		args := append([]wasm.ValueType(nil), op.Args...)
+		args[len(args)-1] = wasm.ValueTypeI64
+		op.Args = args
+	case ops.CurrentMemory:
+		op.Returns = wasm.ValueTypeI64
+	case ops.GrowMemory:
+		op.Args = []wasm.ValueType{wasm.ValueTypeI64}
+		op.Returns = wasm.ValueTypeI64
+	case ops.MemoryFill:
+		// the size and the address.
+		op.Args = []wasm.ValueType{wasm.ValueTypeI64, wasm.ValueTypeI32, wasm.ValueTypeI64}
+	}
+	return op
+}
+
 func (vm *mockVM) adjustStack(op ops.Op) error {
 	for _, t := range op.Args {
 		op, err := vm.popOperand()
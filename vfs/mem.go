@@ -0,0 +1,454 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vfs
+
+import (
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// MemFS is a file system held in memory, for instance to run guests in
+// tests. It implements FS, and is safe for concurrent use.
+type MemFS struct {
+	mu    sync.Mutex
+	nodes map[string]*memNode // By name, "." being the root directory
+}
+
+type memNode struct {
+	mode    os.FileMode
+	data    []byte
+	modTime time.Time
+}
+
+// NewMemFS returns an empty in-memory file system.
+func NewMemFS() *MemFS {
+	return &MemFS{nodes: map[string]*memNode{
+		".": {mode: os.ModeDir | 0755, modTime: time.Now()},
+	}}
+}
+
+// parent returns the directory holding the file name. fsys.mu must be
+// held.
+func (fsys *MemFS) parent(op, name string) (*memNode, error) {
+	dir, ok := fsys.nodes[path.Dir(name)]
+	if !ok {
+		return nil, &os.PathError{Op: op, Path: name, Err: syscall.ENOENT}
+	}
+	if !dir.mode.IsDir() {
+		return nil, &os.PathError{Op: op, Path: name, Err: syscall.ENOTDIR}
+	}
+	return dir, nil
+}
+
+// OpenFile opens the named file, with the flags and permissions of
+// os.OpenFile.
+func (fsys *MemFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	if !ValidPath(name) {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EINVAL}
+	}
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+
+	node, ok := fsys.nodes[name]
+	switch {
+	case !ok && flag&os.O_CREATE == 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.ENOENT}
+	case !ok:
+		dir, err := fsys.parent("open", name)
+		if err != nil {
+			return nil, err
+		}
+		node = &memNode{mode: perm & os.ModePerm, modTime: time.Now()}
+		fsys.nodes[name] = node
+		dir.modTime = node.modTime
+	case flag&os.O_CREATE != 0 && flag&os.O_EXCL != 0:
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EEXIST}
+	}
+
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	if node.mode.IsDir() && writable {
+		return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+	}
+	if writable && flag&os.O_TRUNC != 0 {
+		node.data = nil
+		node.modTime = time.Now()
+	}
+	return &memFile{fsys: fsys, name: name, node: node, flag: flag}, nil
+}
+
+// Stat returns a FileInfo describing the named file.
+func (fsys *MemFS) Stat(name string) (os.FileInfo, error) {
+	if !ValidPath(name) {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: syscall.EINVAL}
+	}
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	node, ok := fsys.nodes[name]
+	if !ok {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: syscall.ENOENT}
+	}
+	return node.info(name), nil
+}
+
+// ReadDir returns the entries of the named directory, sorted by name.
+func (fsys *MemFS) ReadDir(name string) ([]os.FileInfo, error) {
+	if !ValidPath(name) {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: syscall.EINVAL}
+	}
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	node, ok := fsys.nodes[name]
+	if !ok {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: syscall.ENOENT}
+	}
+	if !node.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: name, Err: syscall.ENOTDIR}
+	}
+	names := fsys.children(name)
+	infos := make([]os.FileInfo, len(names))
+	for i, name := range names {
+		infos[i] = fsys.nodes[name].info(name)
+	}
+	return infos, nil
+}
+
+// children returns the names of the entries of the directory name, sorted.
+// fsys.mu must be held.
+func (fsys *MemFS) children(name string) []string {
+	prefix := name + "/"
+	if name == "." {
+		prefix = ""
+	}
+	var names []string
+	for name := range fsys.nodes {
+		if name != "." && strings.HasPrefix(name, prefix) && !strings.Contains(name[len(prefix):], "/") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Mkdir creates the directory name.
+func (fsys *MemFS) Mkdir(name string, perm os.FileMode) error {
+	if !ValidPath(name) {
+		return &os.PathError{Op: "mkdir", Path: name, Err: syscall.EINVAL}
+	}
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	if _, ok := fsys.nodes[name]; ok {
+		return &os.PathError{Op: "mkdir", Path: name, Err: syscall.EEXIST}
+	}
+	dir, err := fsys.parent("mkdir", name)
+	if err != nil {
+		return err
+	}
+	node := &memNode{mode: os.ModeDir | perm&os.ModePerm, modTime: time.Now()}
+	fsys.nodes[name] = node
+	dir.modTime = node.modTime
+	return nil
+}
+
+// Remove removes the named file or empty directory.
+func (fsys *MemFS) Remove(name string) error {
+	if !ValidPath(name) || name == "." {
+		return &os.PathError{Op: "remove", Path: name, Err: syscall.EINVAL}
+	}
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	node, ok := fsys.nodes[name]
+	if !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: syscall.ENOENT}
+	}
+	if node.mode.IsDir() && len(fsys.children(name)) != 0 {
+		return &os.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+	}
+	delete(fsys.nodes, name)
+	fsys.nodes[path.Dir(name)].modTime = time.Now()
+	return nil
+}
+
+// Rename renames (moves) oldname to newname. If newname already exists and
+// is not a directory, Rename replaces it.
+func (fsys *MemFS) Rename(oldname, newname string) error {
+	if !ValidPath(oldname) || !ValidPath(newname) || oldname == "." || newname == "." {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EINVAL}
+	}
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	fail := func(err error) error {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: err}
+	}
+
+	node, ok := fsys.nodes[oldname]
+	if !ok {
+		return fail(syscall.ENOENT)
+	}
+	dir, err := fsys.parent("rename", newname)
+	if err != nil {
+		return fail(err.(*os.PathError).Err)
+	}
+	if oldname == newname {
+		return nil
+	}
+	if node.mode.IsDir() && strings.HasPrefix(newname, oldname+"/") {
+		return fail(syscall.EINVAL)
+	}
+	if target, ok := fsys.nodes[newname]; ok {
+		switch {
+		case target.mode.IsDir():
+			return fail(syscall.EEXIST)
+		case node.mode.IsDir():
+			return fail(syscall.ENOTDIR)
+		}
+	}
+
+	// Move the node along with the content of directories.
+	prefix := oldname + "/"
+	var moved []string
+	for name := range fsys.nodes {
+		if strings.HasPrefix(name, prefix) {
+			moved = append(moved, name)
+		}
+	}
+	for _, name := range moved {
+		fsys.nodes[newname+"/"+name[len(prefix):]] = fsys.nodes[name]
+		delete(fsys.nodes, name)
+	}
+	delete(fsys.nodes, oldname)
+	fsys.nodes[newname] = node
+	now := time.Now()
+	fsys.nodes[path.Dir(oldname)].modTime = now
+	dir.modTime = now
+	return nil
+}
+
+// MkdirAll creates the directory name, along with any necessary parents.
+func (fsys *MemFS) MkdirAll(name string, perm os.FileMode) error {
+	if !ValidPath(name) {
+		return &os.PathError{Op: "mkdir", Path: name, Err: syscall.EINVAL}
+	}
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	for _, dir := range parents(name) {
+		node, ok := fsys.nodes[dir]
+		if !ok {
+			fsys.nodes[dir] = &memNode{mode: os.ModeDir | perm&os.ModePerm, modTime: time.Now()}
+			continue
+		}
+		if !node.mode.IsDir() {
+			return &os.PathError{Op: "mkdir", Path: dir, Err: syscall.ENOTDIR}
+		}
+	}
+	return nil
+}
+
+// parents returns name and its parent directories but the root, from the
+// outermost one.
+func parents(name string) []string {
+	if name == "." {
+		return nil
+	}
+	elems := strings.Split(name, "/")
+	dirs := make([]string, len(elems))
+	for i := range elems {
+		dirs[i] = strings.Join(elems[:i+1], "/")
+	}
+	return dirs
+}
+
+// WriteFile writes data to the named file, creating it if necessary.
+func (fsys *MemFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	f, err := fsys.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// ReadFile returns the content of the named file.
+func (fsys *MemFS) ReadFile(name string) ([]byte, error) {
+	if !ValidPath(name) {
+		return nil, &os.PathError{Op: "read", Path: name, Err: syscall.EINVAL}
+	}
+	fsys.mu.Lock()
+	defer fsys.mu.Unlock()
+	node, ok := fsys.nodes[name]
+	if !ok {
+		return nil, &os.PathError{Op: "read", Path: name, Err: syscall.ENOENT}
+	}
+	if node.mode.IsDir() {
+		return nil, &os.PathError{Op: "read", Path: name, Err: syscall.EISDIR}
+	}
+	return append([]byte(nil), node.data...), nil
+}
+
+func (node *memNode) info(name string) os.FileInfo {
+	return &memFileInfo{
+		name:    path.Base(name),
+		size:    int64(len(node.data)),
+		mode:    node.mode,
+		modTime: node.modTime,
+	}
+}
+
+// memFile is a file opened in a MemFS.
+type memFile struct {
+	fsys   *MemFS
+	name   string
+	node   *memNode
+	flag   int
+	offset int64
+	closed bool
+	dirPos int // Number of entries already returned by Readdir
+}
+
+func (f *memFile) check(op string) error {
+	if f.closed {
+		return &os.PathError{Op: op, Path: f.name, Err: syscall.EBADF}
+	}
+	return nil
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if err := f.check("read"); err != nil {
+		return 0, err
+	}
+	if f.flag&os.O_WRONLY != 0 {
+		return 0, &os.PathError{Op: "read", Path: f.name, Err: syscall.EBADF}
+	}
+	if f.node.mode.IsDir() {
+		return 0, &os.PathError{Op: "read", Path: f.name, Err: syscall.EISDIR}
+	}
+	if f.offset >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if err := f.check("write"); err != nil {
+		return 0, err
+	}
+	if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+		return 0, &os.PathError{Op: "write", Path: f.name, Err: syscall.EBADF}
+	}
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.node.data))
+	}
+	end := f.offset + int64(len(p))
+	if end > int64(len(f.node.data)) {
+		data := make([]byte, end)
+		copy(data, f.node.data)
+		f.node.data = data
+	}
+	copy(f.node.data[f.offset:], p)
+	f.offset = end
+	f.node.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if err := f.check("seek"); err != nil {
+		return 0, err
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.node.data))
+	case io.SeekStart:
+	default:
+		offset = -1
+	}
+	if offset < 0 {
+		return 0, &os.PathError{Op: "seek", Path: f.name, Err: syscall.EINVAL}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *memFile) Close() error {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if err := f.check("close"); err != nil {
+		return err
+	}
+	f.closed = true
+	return nil
+}
+
+func (f *memFile) Stat() (os.FileInfo, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if err := f.check("stat"); err != nil {
+		return nil, err
+	}
+	return f.node.info(f.name), nil
+}
+
+func (f *memFile) Readdir(n int) ([]os.FileInfo, error) {
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	if err := f.check("readdir"); err != nil {
+		return nil, err
+	}
+	if !f.node.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+	}
+
+	names := f.fsys.children(f.name)
+	if f.dirPos > len(names) {
+		f.dirPos = len(names)
+	}
+	names = names[f.dirPos:]
+	if n > 0 {
+		if len(names) == 0 {
+			return nil, io.EOF
+		}
+		if len(names) > n {
+			names = names[:n]
+		}
+	}
+	f.dirPos += len(names)
+
+	infos := make([]os.FileInfo, len(names))
+	for i, name := range names {
+		infos[i] = f.fsys.nodes[name].info(name)
+	}
+	return infos, nil
+}
+
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (fi *memFileInfo) Name() string       { return fi.name }
+func (fi *memFileInfo) Size() int64        { return fi.size }
+func (fi *memFileInfo) Mode() os.FileMode  { return fi.mode }
+func (fi *memFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *memFileInfo) IsDir() bool        { return fi.mode.IsDir() }
+func (fi *memFileInfo) Sys() interface{}   { return nil }
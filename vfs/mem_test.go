@@ -0,0 +1,91 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vfs
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestMemFS(t *testing.T) {
+	fsys := NewMemFS()
+	if err := fsys.MkdirAll("a/b", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.WriteFile("a/b/c.txt", []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.WriteFile("a/d.txt", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := fsys.OpenFile("a/b/c.txt", os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(f, ", world"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Read(make([]byte, 1)); cause(err) != syscall.EBADF {
+		t.Errorf("reading a write-only file: got %v", err)
+	}
+	f.Close()
+	if _, err := f.Write(nil); cause(err) != syscall.EBADF {
+		t.Errorf("writing a closed file: got %v", err)
+	}
+
+	f, err = fsys.OpenFile("a/b/c.txt", os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(7, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil || string(data) != "world" {
+		t.Errorf("got %q (%v), want %q", data, err, "world")
+	}
+
+	dir, err := fsys.OpenFile("a", os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos, err := dir.Readdir(1)
+	if err != nil || len(infos) != 1 || infos[0].Name() != "b" || !infos[0].IsDir() {
+		t.Fatalf("got first entry %v (%v), want directory b", infos, err)
+	}
+	infos, err = dir.Readdir(-1)
+	if err != nil || len(infos) != 1 || infos[0].Name() != "d.txt" || infos[0].Mode() != 0600 {
+		t.Fatalf("got remaining entries %v (%v), want d.txt", infos, err)
+	}
+	if _, err := dir.Readdir(1); err != io.EOF {
+		t.Errorf("got error %v at the end of the directory, want EOF", err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		flag  int
+		errno error
+	}{
+		{"missing", os.O_RDONLY, syscall.ENOENT},
+		{"missing/file", os.O_CREATE | os.O_WRONLY, syscall.ENOENT},
+		{"a/d.txt/file", os.O_CREATE | os.O_WRONLY, syscall.ENOTDIR},
+		{"a/d.txt", os.O_CREATE | os.O_EXCL | os.O_WRONLY, syscall.EEXIST},
+		{"a", os.O_RDWR, syscall.EISDIR},
+		{"../a", os.O_RDONLY, syscall.EINVAL},
+		{"a//b", os.O_RDONLY, syscall.EINVAL},
+	} {
+		if _, err := fsys.OpenFile(tc.name, tc.flag, 0644); cause(err) != tc.errno {
+			t.Errorf("opening %s: got %v, want %v", tc.name, err, tc.errno)
+		}
+	}
+}
+
+func TestMemFSOperations(t *testing.T) {
+	testFS(t, NewMemFS())
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vfs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// OS returns a file system rooted at the directory dir of the host, in
+// which guests are jailed: names can't refer to files outside of dir, and
+// symbolic links are never followed, so that operations on a name that
+// goes through one fail with syscall.ELOOP.
+//
+// Symbolic links are checked before each operation, so OS doesn't protect
+// against a host process concurrently replacing a directory within dir
+// with a symbolic link.
+func OS(dir string) FS {
+	return osFS(dir)
+}
+
+type osFS string
+
+// path returns the path on the host of the file name, after checking that
+// none of its elements, but possibly the last one when follow is false,
+// is a symbolic link.
+func (dir osFS) path(op, name string, follow bool) (string, error) {
+	if !ValidPath(name) {
+		return "", &os.PathError{Op: op, Path: name, Err: syscall.EINVAL}
+	}
+	p := string(dir)
+	if name == "." {
+		return p, nil
+	}
+	elems := strings.Split(name, "/")
+	for i, elem := range elems {
+		p = filepath.Join(p, elem)
+		if i == len(elems)-1 && !follow {
+			break
+		}
+		fi, err := os.Lstat(p)
+		if os.IsNotExist(err) {
+			// The remaining elements don't exist either, the operation
+			// will fail or create the file.
+			return filepath.Join(string(dir), filepath.FromSlash(name)), nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return "", &os.PathError{Op: op, Path: name, Err: syscall.ELOOP}
+		}
+	}
+	return p, nil
+}
+
+func (dir osFS) OpenFile(name string, flag int, perm os.FileMode) (File, error) {
+	p, err := dir.path("open", name, true)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(p, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (dir osFS) Stat(name string) (os.FileInfo, error) {
+	p, err := dir.path("stat", name, true)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(p)
+}
+
+func (dir osFS) ReadDir(name string) ([]os.FileInfo, error) {
+	p, err := dir.path("readdir", name, true)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	infos, err := f.Readdir(-1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
+
+func (dir osFS) Mkdir(name string, perm os.FileMode) error {
+	p, err := dir.path("mkdir", name, false)
+	if err != nil {
+		return err
+	}
+	return os.Mkdir(p, perm)
+}
+
+// Remove and Rename operate on symbolic links themselves, which is safe:
+// they don't access the file the link points to.
+
+func (dir osFS) Remove(name string) error {
+	if name == "." {
+		return &os.PathError{Op: "remove", Path: name, Err: syscall.EINVAL}
+	}
+	p, err := dir.path("remove", name, false)
+	if err != nil {
+		return err
+	}
+	return os.Remove(p)
+}
+
+func (dir osFS) Rename(oldname, newname string) error {
+	if oldname == "." || newname == "." {
+		return &os.LinkError{Op: "rename", Old: oldname, New: newname, Err: syscall.EINVAL}
+	}
+	oldpath, err := dir.path("rename", oldname, false)
+	if err != nil {
+		return err
+	}
+	newpath, err := dir.path("rename", newname, false)
+	if err != nil {
+		return err
+	}
+	return os.Rename(oldpath, newpath)
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "wagon-vfs-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestOS(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	testFS(t, OS(dir))
+}
+
+func TestOSJail(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	outside := tempDir(t)
+	defer os.RemoveAll(outside)
+
+	if err := ioutil.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, target := range map[string]string{
+		"out":          outside,
+		"secret.txt":   filepath.Join(outside, "secret.txt"),
+		"dir/parent":   "..",
+		"dir/new.txt":  filepath.Join(outside, "new.txt"),
+		"dir/relative": "../dir",
+	} {
+		if err := os.Symlink(target, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			t.Skipf("symbolic links are not supported: %v", err)
+		}
+	}
+
+	fsys := OS(dir)
+	for _, tc := range []struct {
+		name string
+		flag int
+		want error
+	}{
+		{"../secret.txt", os.O_RDONLY, syscall.EINVAL},
+		{`..\secret.txt`, os.O_RDONLY, syscall.EINVAL},
+		{"/etc/passwd", os.O_RDONLY, syscall.EINVAL},
+		{"out/secret.txt", os.O_RDONLY, syscall.ELOOP},
+		{"secret.txt", os.O_RDONLY, syscall.ELOOP},
+		{"dir/parent/dir", os.O_RDONLY, syscall.ELOOP},
+		{"dir/relative", os.O_RDONLY, syscall.ELOOP},
+		{"dir/new.txt", os.O_WRONLY | os.O_CREATE, syscall.ELOOP},
+		{"out/new.txt", os.O_WRONLY | os.O_CREATE, syscall.ELOOP},
+	} {
+		f, err := fsys.OpenFile(tc.name, tc.flag, 0644)
+		if err == nil {
+			f.Close()
+		}
+		if got := cause(err); got != tc.want {
+			t.Errorf("opening %s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	if _, err := fsys.Stat("out"); cause(err) != syscall.ELOOP {
+		t.Errorf("stat of a symbolic link: got %v, want %v", err, syscall.ELOOP)
+	}
+	if _, err := fsys.ReadDir("out"); cause(err) != syscall.ELOOP {
+		t.Errorf("readdir of a symbolic link: got %v, want %v", err, syscall.ELOOP)
+	}
+	if err := fsys.Rename("dir/new.txt", "out/new.txt"); cause(err) != syscall.ELOOP {
+		t.Errorf("rename through a symbolic link: got %v, want %v", err, syscall.ELOOP)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "new.txt")); !os.IsNotExist(err) {
+		t.Errorf("a file was created outside of the jail")
+	}
+
+	// Symbolic links themselves can be removed.
+	if err := fsys.Remove("out"); err != nil {
+		t.Errorf("removing a symbolic link: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outside, "secret.txt")); err != nil {
+		t.Errorf("removing a symbolic link removed its target: %v", err)
+	}
+}
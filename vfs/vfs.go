@@ -0,0 +1,72 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package vfs provides virtual file systems, for host functions to expose
+// files to guests without granting them access to the whole disk of the
+// host.
+//
+// Two file systems are provided: OS, a directory of the host in which
+// guests are jailed, and MemFS, a file system held in memory.
+package vfs
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// FS is a file system.
+//
+// Names are slash-separated paths relative to the root of the file system,
+// such as "dir/file.txt", with "." naming the root itself. They never
+// contain ".." or empty elements nor backslashes, and never start with a
+// slash: methods fail with an *os.PathError wrapping syscall.EINVAL for
+// names that are not valid, see ValidPath.
+type FS interface {
+	// OpenFile opens the named file, with the flags and permissions of
+	// os.OpenFile.
+	OpenFile(name string, flag int, perm os.FileMode) (File, error)
+	// Stat returns a FileInfo describing the named file.
+	Stat(name string) (os.FileInfo, error)
+	// ReadDir returns the entries of the named directory, sorted by name.
+	ReadDir(name string) ([]os.FileInfo, error)
+	// Mkdir creates the directory name.
+	Mkdir(name string, perm os.FileMode) error
+	// Remove removes the named file or empty directory.
+	Remove(name string) error
+	// Rename renames (moves) oldname to newname. If newname already
+	// exists and is not a directory, Rename replaces it.
+	Rename(oldname, newname string) error
+}
+
+// File is a file opened in an FS. *os.File implements File.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Seeker
+	io.Closer
+
+	// Stat returns a FileInfo describing the file.
+	Stat() (os.FileInfo, error)
+	// Readdir reads the content of a directory the way (*os.File).Readdir
+	// does.
+	Readdir(n int) ([]os.FileInfo, error)
+}
+
+// ValidPath returns whether name is a valid name for an FS. Backslashes are
+// rejected, as they separate the elements of paths on Windows.
+func ValidPath(name string) bool {
+	if name == "." {
+		return true
+	}
+	if name == "" || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") || strings.Contains(name, `\`) {
+		return false
+	}
+	for _, elem := range strings.Split(name, "/") {
+		if elem == "" || elem == "." || elem == ".." {
+			return false
+		}
+	}
+	return true
+}
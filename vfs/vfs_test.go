@@ -0,0 +1,154 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vfs
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// cause returns the error wrapped by err, if any.
+func cause(err error) error {
+	switch e := err.(type) {
+	case *os.PathError:
+		return e.Err
+	case *os.LinkError:
+		return e.Err
+	}
+	return err
+}
+
+func TestValidPath(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		want bool
+	}{
+		{".", true},
+		{"a", true},
+		{"a/b.txt", true},
+		{"", false},
+		{"/a", false},
+		{"a/", false},
+		{"a//b", false},
+		{"./a", false},
+		{"a/../b", false},
+		{"..", false},
+		{`..\secret.txt`, false},
+		{`a\b`, false},
+	} {
+		if got := ValidPath(tc.name); got != tc.want {
+			t.Errorf("ValidPath(%q) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// testFS exercises the operations of an empty file system.
+func testFS(t *testing.T, fsys FS) {
+	write := func(name, data string) {
+		t.Helper()
+		f, err := fsys.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	read := func(name string) string {
+		t.Helper()
+		f, err := fsys.OpenFile(name, os.O_RDONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		data, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	list := func(name string) []string {
+		t.Helper()
+		infos, err := fsys.ReadDir(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, fi := range infos {
+			names = append(names, fi.Name())
+		}
+		return names
+	}
+
+	if err := fsys.Mkdir("dir", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Mkdir("dir/sub", 0755); err != nil {
+		t.Fatal(err)
+	}
+	write("dir/b.txt", "b")
+	write("dir/a.txt", "a")
+	write("c.txt", "c")
+	if got := list("dir"); len(got) != 3 || got[0] != "a.txt" || got[1] != "b.txt" || got[2] != "sub" {
+		t.Errorf("got entries %q, want [a.txt b.txt sub]", got)
+	}
+
+	if err := fsys.Rename("dir/a.txt", "dir/sub/moved.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Rename("c.txt", "dir/b.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if got := read("dir/b.txt"); got != "c" {
+		t.Errorf("got %q after replacing dir/b.txt, want %q", got, "c")
+	}
+	if err := fsys.Rename("dir", "renamed"); err != nil {
+		t.Fatal(err)
+	}
+	if got := read("renamed/sub/moved.txt"); got != "a" {
+		t.Errorf("got %q in the renamed directory, want %q", got, "a")
+	}
+	if got := list("."); len(got) != 1 || got[0] != "renamed" {
+		t.Errorf("got root entries %q, want [renamed]", got)
+	}
+
+	for _, tc := range []struct {
+		what string
+		err  error
+		want error
+	}{
+		{"mkdir of an existing directory", fsys.Mkdir("renamed", 0755), syscall.EEXIST},
+		{"mkdir in a missing directory", fsys.Mkdir("missing/dir", 0755), syscall.ENOENT},
+		{"remove of a missing file", fsys.Remove("missing"), syscall.ENOENT},
+		{"remove of a directory that is not empty", fsys.Remove("renamed/sub"), syscall.ENOTEMPTY},
+		{"remove of the root", fsys.Remove("."), syscall.EINVAL},
+		{"rename of a missing file", fsys.Rename("missing", "other"), syscall.ENOENT},
+		{"rename of a file over a directory", fsys.Rename("renamed/b.txt", "renamed/sub"), syscall.EEXIST},
+		{"rename escaping the root", fsys.Rename("renamed/b.txt", "../b.txt"), syscall.EINVAL},
+		{"readdir of a file", readDirErr(fsys, "renamed/b.txt"), syscall.ENOTDIR},
+	} {
+		if got := cause(tc.err); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.what, tc.err, tc.want)
+		}
+	}
+
+	if err := fsys.Remove("renamed/sub/moved.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.Remove("renamed/sub"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Stat("renamed/sub"); cause(err) != syscall.ENOENT {
+		t.Errorf("got %v after removing renamed/sub, want %v", err, syscall.ENOENT)
+	}
+}
+
+func readDirErr(fsys FS, name string) error {
+	_, err := fsys.ReadDir(name)
+	return err
+}
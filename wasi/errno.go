@@ -0,0 +1,84 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wasi
+
+import (
+	"os"
+	"syscall"
+)
+
+// Errno is an error code returned by the functions of WASI.
+type Errno uint32
+
+// The error codes of WASI used by this package.
+const (
+	ErrnoSuccess     Errno = 0
+	Errno2big        Errno = 1
+	ErrnoAcces       Errno = 2
+	ErrnoBadf        Errno = 8
+	ErrnoExist       Errno = 20
+	ErrnoFault       Errno = 21
+	ErrnoInval       Errno = 28
+	ErrnoIO          Errno = 29
+	ErrnoIsdir       Errno = 31
+	ErrnoLoop        Errno = 32
+	ErrnoNametoolong Errno = 37
+	ErrnoNoent       Errno = 44
+	ErrnoNosys       Errno = 52
+	ErrnoNotdir      Errno = 54
+	ErrnoNotempty    Errno = 55
+	ErrnoNotsup      Errno = 58
+	ErrnoPerm        Errno = 63
+	ErrnoSpipe       Errno = 70
+	ErrnoXdev        Errno = 75
+	ErrnoNotcapable  Errno = 76
+)
+
+var syscallErrnos = map[syscall.Errno]Errno{
+	syscall.E2BIG:        Errno2big,
+	syscall.EACCES:       ErrnoAcces,
+	syscall.EBADF:        ErrnoBadf,
+	syscall.EEXIST:       ErrnoExist,
+	syscall.EINVAL:       ErrnoInval,
+	syscall.EISDIR:       ErrnoIsdir,
+	syscall.ELOOP:        ErrnoLoop,
+	syscall.ENAMETOOLONG: ErrnoNametoolong,
+	syscall.ENOENT:       ErrnoNoent,
+	syscall.ENOSYS:       ErrnoNosys,
+	syscall.ENOTDIR:      ErrnoNotdir,
+	syscall.ENOTEMPTY:    ErrnoNotempty,
+	syscall.EPERM:        ErrnoPerm,
+	syscall.ESPIPE:       ErrnoSpipe,
+	syscall.EXDEV:        ErrnoXdev,
+}
+
+// errnoOf returns the WASI error code corresponding to a file system error.
+func errnoOf(err error) Errno {
+	if err == nil {
+		return ErrnoSuccess
+	}
+	switch e := err.(type) {
+	case *os.PathError:
+		err = e.Err
+	case *os.LinkError:
+		err = e.Err
+	case *os.SyscallError:
+		err = e.Err
+	}
+	if e, ok := err.(syscall.Errno); ok {
+		if errno, ok := syscallErrnos[e]; ok {
+			return errno
+		}
+	}
+	switch {
+	case os.IsNotExist(err):
+		return ErrnoNoent
+	case os.IsExist(err):
+		return ErrnoExist
+	case os.IsPermission(err):
+		return ErrnoAcces
+	}
+	return ErrnoIO
+}
@@ -0,0 +1,518 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wasi
+
+import (
+	"io"
+	"math"
+	"os"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/vfs"
+)
+
+// fileDesc is a file descriptor of the guest.
+type fileDesc struct {
+	file    vfs.File // The open file, nil for preopened directories
+	fs      vfs.FS   // The file system of the file, nil for standard streams
+	path    string   // The name of the file in fs
+	preopen string   // The guest path of a preopened directory
+	isDir   bool
+	append  bool
+}
+
+// File types of WASI.
+const (
+	filetypeUnknown         = 0
+	filetypeCharacterDevice = 2
+	filetypeDirectory       = 3
+	filetypeRegularFile     = 4
+	filetypeSymbolicLink    = 7
+)
+
+// Flags of path_open.
+const (
+	oflagCreat     = 1 << 0
+	oflagDirectory = 1 << 1
+	oflagExcl      = 1 << 2
+	oflagTrunc     = 1 << 3
+
+	fdflagAppend = 1 << 0
+
+	rightFDRead  = 1 << 1
+	rightFDWrite = 1 << 6
+	rightsAll    = 1<<30 - 1
+)
+
+func (w *WASI) fd(fd uint32) (*fileDesc, Errno) {
+	d, ok := w.fds[fd]
+	if !ok {
+		return nil, ErrnoBadf
+	}
+	return d, ErrnoSuccess
+}
+
+// memoryView is like MemoryView, for a size computed in 64 bits, so that the
+// products of guest counts and structure sizes can't wrap around.
+func memoryView(proc *exec.Process, ptr uint32, n uint64) ([]byte, Errno) {
+	if n > math.MaxUint32 {
+		return nil, ErrnoFault
+	}
+	p, err := proc.MemoryView(ptr, uint32(n))
+	if err != nil {
+		return nil, ErrnoFault
+	}
+	return p, ErrnoSuccess
+}
+
+// iovecs returns the buffers of the n iovec structures at iovs.
+func iovecs(proc *exec.Process, iovs, n uint32) ([][]byte, Errno) {
+	view, errno := memoryView(proc, iovs, uint64(n)*8)
+	if errno != ErrnoSuccess {
+		return nil, errno
+	}
+	bufs := make([][]byte, n)
+	for i := range bufs {
+		iov := view[i*8 : i*8+8]
+		var err error
+		bufs[i], err = proc.MemoryView(le.Uint32(iov[0:]), le.Uint32(iov[4:]))
+		if err != nil {
+			return nil, ErrnoFault
+		}
+	}
+	return bufs, ErrnoSuccess
+}
+
+func (w *WASI) fdRead(proc *exec.Process, fd, iovs, iovsLen, nread uint32) Errno {
+	d, errno := w.fd(fd)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	if d.isDir {
+		return ErrnoIsdir
+	}
+	bufs, errno := iovecs(proc, iovs, iovsLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	total := 0
+	for _, buf := range bufs {
+		n, err := d.file.Read(buf)
+		total += n
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errnoOf(err)
+		}
+		if n < len(buf) {
+			break
+		}
+	}
+	if proc.WriteUint32Le(nread, uint32(total)) != nil {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+func (w *WASI) fdWrite(proc *exec.Process, fd, iovs, iovsLen, nwritten uint32) Errno {
+	d, errno := w.fd(fd)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	if d.isDir {
+		return ErrnoIsdir
+	}
+	bufs, errno := iovecs(proc, iovs, iovsLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	total := 0
+	for _, buf := range bufs {
+		n, err := d.file.Write(buf)
+		total += n
+		if err != nil {
+			return errnoOf(err)
+		}
+	}
+	if proc.WriteUint32Le(nwritten, uint32(total)) != nil {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+func (w *WASI) fdSeek(proc *exec.Process, fd uint32, offset int64, whence, newOffset uint32) Errno {
+	d, errno := w.fd(fd)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	if d.isDir {
+		return ErrnoIsdir
+	}
+	if whence > io.SeekEnd {
+		return ErrnoInval
+	}
+	off, err := d.file.Seek(offset, int(whence))
+	if err != nil {
+		return errnoOf(err)
+	}
+	if proc.WriteUint64Le(newOffset, uint64(off)) != nil {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+func (w *WASI) fdTell(proc *exec.Process, fd, offset uint32) Errno {
+	return w.fdSeek(proc, fd, 0, io.SeekCurrent, offset)
+}
+
+func (w *WASI) fdClose(proc *exec.Process, fd uint32) Errno {
+	d, errno := w.fd(fd)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	delete(w.fds, fd)
+	if d.file != nil {
+		return errnoOf(d.file.Close())
+	}
+	return ErrnoSuccess
+}
+
+func filetype(mode os.FileMode) byte {
+	switch {
+	case mode.IsDir():
+		return filetypeDirectory
+	case mode.IsRegular():
+		return filetypeRegularFile
+	case mode&os.ModeSymlink != 0:
+		return filetypeSymbolicLink
+	case mode&os.ModeCharDevice != 0:
+		return filetypeCharacterDevice
+	}
+	return filetypeUnknown
+}
+
+// stat returns a FileInfo describing the file of d.
+func (d *fileDesc) stat() (os.FileInfo, Errno) {
+	if d.file == nil {
+		fi, err := d.fs.Stat(d.path)
+		return fi, errnoOf(err)
+	}
+	fi, err := d.file.Stat()
+	return fi, errnoOf(err)
+}
+
+func (w *WASI) fdFdstatGet(proc *exec.Process, fd, buf uint32) Errno {
+	d, errno := w.fd(fd)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	fi, errno := d.stat()
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	p, err := proc.MemoryView(buf, 24)
+	if err != nil {
+		return ErrnoFault
+	}
+	for i := range p {
+		p[i] = 0
+	}
+	p[0] = filetype(fi.Mode())
+	if d.append {
+		le.PutUint16(p[2:], fdflagAppend)
+	}
+	le.PutUint64(p[8:], rightsAll)
+	le.PutUint64(p[16:], rightsAll)
+	return ErrnoSuccess
+}
+
+// writeFilestat writes the filestat structure describing fi at buf.
+func writeFilestat(proc *exec.Process, buf uint32, fi os.FileInfo) Errno {
+	p, err := proc.MemoryView(buf, 64)
+	if err != nil {
+		return ErrnoFault
+	}
+	for i := range p {
+		p[i] = 0
+	}
+	p[16] = filetype(fi.Mode())
+	le.PutUint64(p[24:], 1)
+	le.PutUint64(p[32:], uint64(fi.Size()))
+	mtime := uint64(fi.ModTime().UnixNano())
+	le.PutUint64(p[40:], mtime)
+	le.PutUint64(p[48:], mtime)
+	le.PutUint64(p[56:], mtime)
+	return ErrnoSuccess
+}
+
+func (w *WASI) fdFilestatGet(proc *exec.Process, fd, buf uint32) Errno {
+	d, errno := w.fd(fd)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	fi, errno := d.stat()
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	return writeFilestat(proc, buf, fi)
+}
+
+func (w *WASI) fdPrestatGet(proc *exec.Process, fd, buf uint32) Errno {
+	d, errno := w.fd(fd)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	if d.preopen == "" {
+		return ErrnoBadf
+	}
+	// The tag of a directory is 0.
+	if proc.WriteUint32Le(buf, 0) != nil || proc.WriteUint32Le(buf+4, uint32(len(d.preopen))) != nil {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+func (w *WASI) fdPrestatDirName(proc *exec.Process, fd, buf, bufLen uint32) Errno {
+	d, errno := w.fd(fd)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	if d.preopen == "" {
+		return ErrnoBadf
+	}
+	name := d.preopen
+	if int(bufLen) < len(name) {
+		name = name[:bufLen]
+	}
+	if proc.WriteBytes(buf, []byte(name)) != nil {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+const direntSize = 24
+
+func (w *WASI) fdReaddir(proc *exec.Process, fd, buf, bufLen uint32, cookie uint64, bufused uint32) Errno {
+	d, errno := w.fd(fd)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	if !d.isDir {
+		return ErrnoNotdir
+	}
+	p, err := proc.MemoryView(buf, bufLen)
+	if err != nil {
+		return ErrnoFault
+	}
+
+	// The directory is read again on each call, cookies being indices
+	// in the sorted list of its entries.
+	infos, err := d.fs.ReadDir(d.path)
+	if err != nil {
+		return errnoOf(err)
+	}
+
+	n := 0
+	for i := cookie; i < uint64(len(infos)) && n < len(p); i++ {
+		name := infos[i].Name()
+		var dirent [direntSize]byte
+		le.PutUint64(dirent[0:], i+1)
+		le.PutUint64(dirent[8:], i+1)
+		le.PutUint32(dirent[16:], uint32(len(name)))
+		dirent[20] = filetype(infos[i].Mode())
+		n += copy(p[n:], dirent[:])
+		n += copy(p[n:], name)
+	}
+	if proc.WriteUint32Le(bufused, uint32(n)) != nil {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+// resolve returns the name in the file system of dirfd of the file at the
+// guest path p, relative to dirfd.
+func (w *WASI) resolve(proc *exec.Process, dirfd, p, pLen uint32) (*fileDesc, string, Errno) {
+	d, errno := w.fd(dirfd)
+	if errno != ErrnoSuccess {
+		return nil, "", errno
+	}
+	if !d.isDir {
+		return nil, "", ErrnoNotdir
+	}
+	guestPath, err := proc.ReadString(p, pLen)
+	if err != nil {
+		return nil, "", ErrnoFault
+	}
+	if strings.HasPrefix(guestPath, "/") {
+		return nil, "", ErrnoNotcapable
+	}
+	name := path.Clean(path.Join(d.path, guestPath))
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return nil, "", ErrnoNotcapable
+	}
+	return d, name, ErrnoSuccess
+}
+
+func (w *WASI) pathOpen(proc *exec.Process, dirfd, dirflags, p, pLen, oflags uint32, rightsBase, rightsInheriting uint64, fdflags, fdPtr uint32) Errno {
+	dir, name, errno := w.resolve(proc, dirfd, p, pLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+
+	write := rightsBase&rightFDWrite != 0 || oflags&(oflagCreat|oflagTrunc) != 0 || fdflags&fdflagAppend != 0
+	flag := os.O_RDONLY
+	switch {
+	case oflags&oflagDirectory != 0:
+	case write && rightsBase&rightFDRead != 0:
+		flag = os.O_RDWR
+	case write:
+		flag = os.O_WRONLY
+	}
+	if oflags&oflagCreat != 0 {
+		flag |= os.O_CREATE
+	}
+	if oflags&oflagExcl != 0 {
+		flag |= os.O_EXCL
+	}
+	if oflags&oflagTrunc != 0 {
+		flag |= os.O_TRUNC
+	}
+	if fdflags&fdflagAppend != 0 {
+		flag |= os.O_APPEND
+	}
+
+	f, err := dir.fs.OpenFile(name, flag, 0644)
+	if err != nil {
+		return errnoOf(err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errnoOf(err)
+	}
+	if oflags&oflagDirectory != 0 && !fi.IsDir() {
+		f.Close()
+		return ErrnoNotdir
+	}
+
+	fd := uint32(3)
+	for w.fds[fd] != nil {
+		fd++
+	}
+	if proc.WriteUint32Le(fdPtr, fd) != nil {
+		f.Close()
+		return ErrnoFault
+	}
+	w.fds[fd] = &fileDesc{
+		file:   f,
+		fs:     dir.fs,
+		path:   name,
+		isDir:  fi.IsDir(),
+		append: fdflags&fdflagAppend != 0,
+	}
+	return ErrnoSuccess
+}
+
+func (w *WASI) pathFilestatGet(proc *exec.Process, dirfd, flags, p, pLen, buf uint32) Errno {
+	dir, name, errno := w.resolve(proc, dirfd, p, pLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	fi, err := dir.fs.Stat(name)
+	if err != nil {
+		return errnoOf(err)
+	}
+	return writeFilestat(proc, buf, fi)
+}
+
+func (w *WASI) pathCreateDirectory(proc *exec.Process, dirfd, p, pLen uint32) Errno {
+	dir, name, errno := w.resolve(proc, dirfd, p, pLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	return errnoOf(dir.fs.Mkdir(name, 0755))
+}
+
+func (w *WASI) pathRemoveDirectory(proc *exec.Process, dirfd, p, pLen uint32) Errno {
+	dir, name, errno := w.resolve(proc, dirfd, p, pLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	fi, err := dir.fs.Stat(name)
+	if err != nil {
+		return errnoOf(err)
+	}
+	if !fi.IsDir() {
+		return ErrnoNotdir
+	}
+	return errnoOf(dir.fs.Remove(name))
+}
+
+func (w *WASI) pathUnlinkFile(proc *exec.Process, dirfd, p, pLen uint32) Errno {
+	dir, name, errno := w.resolve(proc, dirfd, p, pLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	// Stat fails on symbolic links of file systems that don't follow
+	// them, which can still be unlinked.
+	if fi, err := dir.fs.Stat(name); err == nil && fi.IsDir() {
+		return ErrnoIsdir
+	}
+	return errnoOf(dir.fs.Remove(name))
+}
+
+func (w *WASI) pathRename(proc *exec.Process, oldfd, oldPath, oldLen, newfd, newPath, newLen uint32) Errno {
+	oldDir, oldname, errno := w.resolve(proc, oldfd, oldPath, oldLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	newDir, newname, errno := w.resolve(proc, newfd, newPath, newLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	if oldDir.fs != newDir.fs {
+		return ErrnoXdev
+	}
+	return errnoOf(oldDir.fs.Rename(oldname, newname))
+}
+
+// stdioFile is a vfs.File for the standard streams of the guest.
+type stdioFile struct {
+	r io.Reader
+	w io.Writer
+}
+
+func (f stdioFile) Read(p []byte) (int, error) {
+	if f.r == nil {
+		return 0, syscall.EBADF
+	}
+	return f.r.Read(p)
+}
+
+func (f stdioFile) Write(p []byte) (int, error) {
+	if f.w == nil {
+		return 0, syscall.EBADF
+	}
+	return f.w.Write(p)
+}
+
+func (stdioFile) Seek(int64, int) (int64, error)     { return 0, syscall.ESPIPE }
+func (stdioFile) Close() error                       { return nil }
+func (stdioFile) Stat() (os.FileInfo, error)         { return stdioInfo{}, nil }
+func (stdioFile) Readdir(int) ([]os.FileInfo, error) { return nil, syscall.ENOTDIR }
+
+type stdioInfo struct{}
+
+func (stdioInfo) Name() string       { return "" }
+func (stdioInfo) Size() int64        { return 0 }
+func (stdioInfo) Mode() os.FileMode  { return os.ModeDevice | os.ModeCharDevice | 0666 }
+func (stdioInfo) ModTime() time.Time { return time.Time{} }
+func (stdioInfo) IsDir() bool        { return false }
+func (stdioInfo) Sys() interface{}   { return nil }
@@ -0,0 +1,59 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wasi
+
+import (
+	"reflect"
+
+	"github.com/go-interpreter/wagon/exec"
+)
+
+// unsupportedFuncs lists the functions of WASI that are not implemented,
+// with the types of their parameters: 'i' for i32 and 'I' for i64.
+var unsupportedFuncs = map[string]string{
+	"fd_advise":               "iIIi",
+	"fd_allocate":             "iII",
+	"fd_datasync":             "i",
+	"fd_fdstat_set_flags":     "ii",
+	"fd_fdstat_set_rights":    "iII",
+	"fd_filestat_set_size":    "iI",
+	"fd_filestat_set_times":   "iIIi",
+	"fd_pread":                "iiiIi",
+	"fd_pwrite":               "iiiIi",
+	"fd_renumber":             "ii",
+	"fd_sync":                 "i",
+	"path_filestat_set_times": "iiiiIIi",
+	"path_link":               "iiiiiii",
+	"path_readlink":           "iiiiii",
+	"path_symlink":            "iiiii",
+	"proc_raise":              "i",
+	"sock_accept":             "iii",
+	"sock_recv":               "iiiiii",
+	"sock_send":               "iiiii",
+	"sock_shutdown":           "ii",
+}
+
+// unsupported returns host functions returning ErrnoNosys for the
+// functions of unsupportedFuncs, so that modules importing them can be
+// instantiated.
+func unsupported() map[string]interface{} {
+	funcs := make(map[string]interface{}, len(unsupportedFuncs))
+	result := []reflect.Value{reflect.ValueOf(ErrnoNosys)}
+	for name, params := range unsupportedFuncs {
+		in := []reflect.Type{reflect.TypeOf((*exec.Process)(nil))}
+		for _, p := range params {
+			if p == 'I' {
+				in = append(in, reflect.TypeOf(uint64(0)))
+			} else {
+				in = append(in, reflect.TypeOf(uint32(0)))
+			}
+		}
+		typ := reflect.FuncOf(in, []reflect.Type{result[0].Type()}, false)
+		funcs[name] = reflect.MakeFunc(typ, func([]reflect.Value) []reflect.Value {
+			return result
+		}).Interface()
+	}
+	return funcs
+}
@@ -0,0 +1,381 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package wasi implements the wasi_snapshot_preview1 host functions, to
+// run modules targeting WASI, such as the wasm32-wasi binaries produced by
+// Rust, TinyGo or clang, with exec.
+//
+// A WASI instance serves a single VM:
+//
+//	w := wasi.New(wasi.Config{
+//		Args:     []string{"prog", "arg"},
+//		Stdout:   os.Stdout,
+//		Preopens: map[string]vfs.FS{"/data": vfs.OS("data")},
+//	})
+//	m, err := wasm.ReadModule(r, w.Resolver(nil))
+//	...
+//	vm, err := exec.NewVM(m)
+//	...
+//	vm.RecoverPanic = true
+//	_, err = vm.ExecCode(int64(start.Index))
+//	if code, ok := err.(wasi.ExitError); ok {
+//		...
+//	}
+//
+// Functions of WASI that are not supported return ErrnoNosys.
+package wasi
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/vfs"
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// ModuleName is the name of the module the functions of WASI are imported
+// from.
+const ModuleName = "wasi_snapshot_preview1"
+
+var le = binary.LittleEndian
+
+// Config is the environment of a guest.
+type Config struct {
+	Args []string // Command line arguments, starting with the program name
+	Env  []string // Environment variables, in the form "key=value"
+
+	// Standard streams of the guest. They default to an empty input and
+	// to ioutil.Discard.
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Preopens maps the paths under which the guest sees its preopened
+	// directories to the file systems they expose.
+	Preopens map[string]vfs.FS
+
+	// Now returns the current time, it defaults to time.Now.
+	Now func() time.Time
+	// Rand is the source of random_get, it defaults to crypto/rand.Reader.
+	Rand io.Reader
+}
+
+// ExitError is the error returned by (*exec.VM).ExecCode when the guest
+// calls proc_exit, with the exit status of the guest. The VM must recover
+// panics for it to be returned, see exec.VM.RecoverPanic.
+type ExitError uint32
+
+func (e ExitError) Error() string {
+	return fmt.Sprintf("wasi: exit status %d", uint32(e))
+}
+
+// WASI holds the state of the WASI functions imported by a module: its
+// environment and its file descriptors.
+type WASI struct {
+	cfg   Config
+	start time.Time // Origin of the monotonic clock
+	fds   map[uint32]*fileDesc
+}
+
+// New returns a WASI instance with the environment cfg.
+func New(cfg Config) *WASI {
+	if cfg.Stdin == nil {
+		cfg.Stdin = eofReader{}
+	}
+	if cfg.Stdout == nil {
+		cfg.Stdout = ioutil.Discard
+	}
+	if cfg.Stderr == nil {
+		cfg.Stderr = ioutil.Discard
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Rand == nil {
+		cfg.Rand = rand.Reader
+	}
+
+	w := &WASI{cfg: cfg, start: cfg.Now()}
+	w.fds = map[uint32]*fileDesc{
+		0: {file: stdioFile{r: cfg.Stdin}},
+		1: {file: stdioFile{w: cfg.Stdout}},
+		2: {file: stdioFile{w: cfg.Stderr}},
+	}
+	dirs := make([]string, 0, len(cfg.Preopens))
+	for dir := range cfg.Preopens {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for i, dir := range dirs {
+		w.fds[uint32(3+i)] = &fileDesc{
+			fs:      cfg.Preopens[dir],
+			path:    ".",
+			preopen: dir,
+			isDir:   true,
+		}
+	}
+	return w
+}
+
+// Module returns the host module exporting the functions of WASI.
+func (w *WASI) Module() (*wasm.Module, error) {
+	funcs := map[string]interface{}{
+		"args_get":              w.argsGet,
+		"args_sizes_get":        w.argsSizesGet,
+		"environ_get":           w.environGet,
+		"environ_sizes_get":     w.environSizesGet,
+		"clock_res_get":         w.clockResGet,
+		"clock_time_get":        w.clockTimeGet,
+		"random_get":            w.randomGet,
+		"proc_exit":             w.procExit,
+		"sched_yield":           w.schedYield,
+		"poll_oneoff":           w.pollOneoff,
+		"fd_read":               w.fdRead,
+		"fd_write":              w.fdWrite,
+		"fd_seek":               w.fdSeek,
+		"fd_tell":               w.fdTell,
+		"fd_close":              w.fdClose,
+		"fd_fdstat_get":         w.fdFdstatGet,
+		"fd_filestat_get":       w.fdFilestatGet,
+		"fd_prestat_get":        w.fdPrestatGet,
+		"fd_prestat_dir_name":   w.fdPrestatDirName,
+		"fd_readdir":            w.fdReaddir,
+		"path_open":             w.pathOpen,
+		"path_filestat_get":     w.pathFilestatGet,
+		"path_create_directory": w.pathCreateDirectory,
+		"path_remove_directory": w.pathRemoveDirectory,
+		"path_unlink_file":      w.pathUnlinkFile,
+		"path_rename":           w.pathRename,
+	}
+	for name, fn := range unsupported() {
+		funcs[name] = fn
+	}
+	return exec.NewHostModule(funcs)
+}
+
+// Resolver returns a wasm.ResolveFunc resolving ModuleName to the host
+// module of w, and the other modules with next, if not nil.
+func (w *WASI) Resolver(next wasm.ResolveFunc) wasm.ResolveFunc {
+	return func(name string) (*wasm.Module, error) {
+		if name == ModuleName {
+			return w.Module()
+		}
+		if next == nil {
+			return nil, fmt.Errorf("wasi: unknown module %q", name)
+		}
+		return next(name)
+	}
+}
+
+// Imports returns whether the module m imports functions of WASI.
+func Imports(m *wasm.Module) bool {
+	if m.Import == nil {
+		return false
+	}
+	for _, entry := range m.Import.Entries {
+		if entry.ModuleName == ModuleName {
+			return true
+		}
+	}
+	return false
+}
+
+// writeStrings writes strs as NUL-terminated strings to buf, and pointers
+// to them to list.
+func writeStrings(proc *exec.Process, strs []string, list, buf uint32) Errno {
+	for _, s := range strs {
+		if err := proc.WriteUint32Le(list, buf); err != nil {
+			return ErrnoFault
+		}
+		if err := proc.WriteBytes(buf, append([]byte(s), 0)); err != nil {
+			return ErrnoFault
+		}
+		list += 4
+		buf += uint32(len(s)) + 1
+	}
+	return ErrnoSuccess
+}
+
+// writeSizes writes the number of strings in strs to countPtr, and their
+// total size to sizePtr.
+func writeSizes(proc *exec.Process, strs []string, countPtr, sizePtr uint32) Errno {
+	size := 0
+	for _, s := range strs {
+		size += len(s) + 1
+	}
+	if proc.WriteUint32Le(countPtr, uint32(len(strs))) != nil || proc.WriteUint32Le(sizePtr, uint32(size)) != nil {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+func (w *WASI) argsGet(proc *exec.Process, argv, argvBuf uint32) Errno {
+	return writeStrings(proc, w.cfg.Args, argv, argvBuf)
+}
+
+func (w *WASI) argsSizesGet(proc *exec.Process, argc, argvBufSize uint32) Errno {
+	return writeSizes(proc, w.cfg.Args, argc, argvBufSize)
+}
+
+func (w *WASI) environGet(proc *exec.Process, environ, environBuf uint32) Errno {
+	return writeStrings(proc, w.cfg.Env, environ, environBuf)
+}
+
+func (w *WASI) environSizesGet(proc *exec.Process, count, bufSize uint32) Errno {
+	return writeSizes(proc, w.cfg.Env, count, bufSize)
+}
+
+// Clocks of clock_time_get.
+const (
+	clockRealtime = iota
+	clockMonotonic
+	clockProcessCPUTime
+	clockThreadCPUTime
+)
+
+// now returns the time of the clock id, in nanoseconds.
+func (w *WASI) now(id uint32) (uint64, Errno) {
+	switch id {
+	case clockRealtime:
+		return uint64(w.cfg.Now().UnixNano()), ErrnoSuccess
+	case clockMonotonic, clockProcessCPUTime, clockThreadCPUTime:
+		return uint64(w.cfg.Now().Sub(w.start)), ErrnoSuccess
+	}
+	return 0, ErrnoInval
+}
+
+func (w *WASI) clockResGet(proc *exec.Process, id, resolution uint32) Errno {
+	if _, errno := w.now(id); errno != ErrnoSuccess {
+		return errno
+	}
+	if proc.WriteUint64Le(resolution, uint64(time.Microsecond)) != nil {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+func (w *WASI) clockTimeGet(proc *exec.Process, id uint32, precision uint64, timePtr uint32) Errno {
+	t, errno := w.now(id)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	if proc.WriteUint64Le(timePtr, t) != nil {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+func (w *WASI) randomGet(proc *exec.Process, buf, bufLen uint32) Errno {
+	p, err := proc.MemoryView(buf, bufLen)
+	if err != nil {
+		return ErrnoFault
+	}
+	if _, err := io.ReadFull(w.cfg.Rand, p); err != nil {
+		return ErrnoIO
+	}
+	return ErrnoSuccess
+}
+
+func (w *WASI) procExit(proc *exec.Process, code uint32) {
+	panic(ExitError(code))
+}
+
+func (w *WASI) schedYield(proc *exec.Process) Errno {
+	runtime.Gosched()
+	return ErrnoSuccess
+}
+
+// Layout of the subscriptions and events of poll_oneoff.
+const (
+	subscriptionSize = 48
+	eventSize        = 32
+
+	eventTypeClock = 0
+
+	subclockAbstime = 1
+)
+
+// pollOneoff supports clock subscriptions, by sleeping until the first of
+// them expires. Subscriptions to file descriptors are reported with
+// ErrnoNotsup. Each subscription produces at most one of the events at out,
+// and the number of events written is stored at nevents.
+func (w *WASI) pollOneoff(proc *exec.Process, in, out, nsubscriptions, nevents uint32) Errno {
+	if nsubscriptions == 0 {
+		return ErrnoInval
+	}
+	subs, errno := memoryView(proc, in, uint64(nsubscriptions)*subscriptionSize)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	events, errno := memoryView(proc, out, uint64(nsubscriptions)*eventSize)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+
+	type clockSub struct {
+		userdata uint64
+		timeout  time.Duration
+	}
+	var clocks []clockSub
+	n := uint32(0)
+	event := func(userdata uint64, errno Errno, typ byte) {
+		e := events[n*eventSize : (n+1)*eventSize]
+		for i := range e {
+			e[i] = 0
+		}
+		le.PutUint64(e[0:], userdata)
+		le.PutUint16(e[8:], uint16(errno))
+		e[10] = typ
+		n++
+	}
+
+	for i := uint32(0); i < nsubscriptions; i++ {
+		sub := subs[i*subscriptionSize : (i+1)*subscriptionSize]
+		userdata := le.Uint64(sub[0:])
+		typ := sub[8]
+		if typ != eventTypeClock {
+			event(userdata, ErrnoNotsup, typ)
+			continue
+		}
+		timeout := int64(le.Uint64(sub[24:]))
+		if le.Uint16(sub[40:])&subclockAbstime != 0 {
+			now, errno := w.now(le.Uint32(sub[16:]))
+			if errno != ErrnoSuccess {
+				event(userdata, errno, typ)
+				continue
+			}
+			timeout -= int64(now)
+		}
+		clocks = append(clocks, clockSub{userdata, time.Duration(timeout)})
+	}
+
+	if n == 0 && len(clocks) != 0 {
+		sort.SliceStable(clocks, func(i, j int) bool { return clocks[i].timeout < clocks[j].timeout })
+		first := clocks[0].timeout
+		if first > 0 {
+			time.Sleep(first)
+		}
+		for _, c := range clocks {
+			if c.timeout <= first {
+				event(c.userdata, ErrnoSuccess, eventTypeClock)
+			}
+		}
+	}
+
+	if proc.WriteUint32Le(nevents, n) != nil {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
+}
+
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) { return 0, io.EOF }
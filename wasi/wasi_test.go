@@ -0,0 +1,296 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wasi
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/vfs"
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+// helloModule returns the encoding of a module whose _start function
+// writes "hello\n" to its standard output, and exits with status 3.
+func helloModule(t *testing.T) []byte {
+	i32 := wasm.ValueTypeI32
+	m := wasm.NewModule()
+	m.Types = &wasm.SectionTypes{Entries: []wasm.FunctionSig{
+		{Form: wasm.TypeFunc, ParamTypes: []wasm.ValueType{i32, i32, i32, i32}, ReturnTypes: []wasm.ValueType{i32}},
+		{Form: wasm.TypeFunc, ParamTypes: []wasm.ValueType{i32}},
+		{Form: wasm.TypeFunc},
+	}}
+	m.Import = &wasm.SectionImports{Entries: []wasm.ImportEntry{
+		{ModuleName: ModuleName, FieldName: "fd_write", Type: wasm.FuncImport{Type: 0}},
+		{ModuleName: ModuleName, FieldName: "proc_exit", Type: wasm.FuncImport{Type: 1}},
+	}}
+	m.Function = &wasm.SectionFunctions{Types: []uint32{2}}
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.Export = &wasm.SectionExports{
+		Entries: map[string]wasm.ExportEntry{
+			"_start": {FieldStr: "_start", Kind: wasm.ExternalFunction, Index: 2},
+		},
+		Names: []string{"_start"},
+	}
+	m.Code = &wasm.SectionCode{Bodies: []wasm.FunctionBody{{Code: []byte{
+		// (drop (call $fd_write (i32.const 1) (i32.const 0) (i32.const 1) (i32.const 16)))
+		ops.I32Const, 1, ops.I32Const, 0, ops.I32Const, 1, ops.I32Const, 16, ops.Call, 0, ops.Drop,
+		// (call $proc_exit (i32.const 3))
+		ops.I32Const, 3, ops.Call, 1,
+	}}}}
+	m.Data = &wasm.SectionData{Entries: []wasm.DataSegment{{
+		Offset: []byte{ops.I32Const, 0, ops.End},
+		// An iovec pointing to "hello\n" at address 8.
+		Data: []byte{8, 0, 0, 0, 6, 0, 0, 0, 'h', 'e', 'l', 'l', 'o', '\n'},
+	}}}
+	m.Sections = []wasm.Section{m.Types, m.Import, m.Function, m.Memory, m.Export, m.Code, m.Data}
+
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, m); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRunGuest(t *testing.T) {
+	stdout := new(bytes.Buffer)
+	w := New(Config{Stdout: stdout})
+	m, err := wasm.ReadModule(bytes.NewReader(helloModule(t)), w.Resolver(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Imports(m) {
+		t.Errorf("the module should import WASI")
+	}
+	vm, err := exec.NewVM(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vm.RecoverPanic = true
+	start, _ := vm.GetExportEntry("_start")
+	_, err = vm.ExecCode(int64(start.Index))
+	if err != ExitError(3) {
+		t.Errorf("got error %v, want %v", err, ExitError(3))
+	}
+	if got := stdout.String(); got != "hello\n" {
+		t.Errorf("got output %q, want %q", got, "hello\n")
+	}
+}
+
+// newProcess returns a process with one page of memory.
+func newProcess(t *testing.T) *exec.Process {
+	m := wasm.NewModule()
+	m.Start = nil
+	m.Memory = &wasm.SectionMemories{Entries: []wasm.Memory{{Limits: wasm.ResizableLimits{Initial: 1}}}}
+	m.LinearMemoryIndexSpace = [][]byte{nil}
+	vm, err := exec.NewVM(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return exec.NewProcess(vm)
+}
+
+func check(t *testing.T, what string, got, want Errno) {
+	t.Helper()
+	if got != want {
+		t.Fatalf("%s: got errno %d, want %d", what, got, want)
+	}
+}
+
+func TestArgsEnviron(t *testing.T) {
+	proc := newProcess(t)
+	w := New(Config{Args: []string{"prog", "-v"}, Env: []string{"A=1"}})
+
+	check(t, "args_sizes_get", w.argsSizesGet(proc, 0, 4), ErrnoSuccess)
+	if n, _ := proc.ReadUint32Le(0); n != 2 {
+		t.Errorf("got %d arguments, want 2", n)
+	}
+	if size, _ := proc.ReadUint32Le(4); size != 8 {
+		t.Errorf("got arguments of %d bytes, want 8", size)
+	}
+	check(t, "args_get", w.argsGet(proc, 0, 16), ErrnoSuccess)
+	for i, want := range []string{"prog", "-v"} {
+		ptr, _ := proc.ReadUint32Le(uint32(4 * i))
+		if got, _ := proc.ReadCString(ptr); got != want {
+			t.Errorf("argument %d: got %q, want %q", i, got, want)
+		}
+	}
+
+	check(t, "environ_get", w.environGet(proc, 0, 16), ErrnoSuccess)
+	ptr, _ := proc.ReadUint32Le(0)
+	if got, _ := proc.ReadCString(ptr); got != "A=1" {
+		t.Errorf("got environment %q, want %q", got, "A=1")
+	}
+
+	check(t, "args_get out of memory", w.argsGet(proc, 0, 65535), ErrnoFault)
+}
+
+// writePath writes a path to the memory of proc at address 1024, and
+// returns its address and length.
+func writePath(proc *exec.Process, p string) (uint32, uint32) {
+	proc.WriteBytes(1024, []byte(p))
+	return 1024, uint32(len(p))
+}
+
+func TestFiles(t *testing.T) {
+	fsys := vfs.NewMemFS()
+	if err := fsys.MkdirAll("dir", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := fsys.WriteFile("dir/a.txt", []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	proc := newProcess(t)
+	w := New(Config{Preopens: map[string]vfs.FS{"/data": fsys}})
+
+	check(t, "fd_prestat_get", w.fdPrestatGet(proc, 3, 0), ErrnoSuccess)
+	if n, _ := proc.ReadUint32Le(4); n != 5 {
+		t.Errorf("got a preopen name of %d bytes, want 5", n)
+	}
+	check(t, "fd_prestat_dir_name", w.fdPrestatDirName(proc, 3, 0, 5), ErrnoSuccess)
+	if name, _ := proc.ReadString(0, 5); name != "/data" {
+		t.Errorf("got preopen %q, want /data", name)
+	}
+	check(t, "fd_prestat_get of stdout", w.fdPrestatGet(proc, 1, 0), ErrnoBadf)
+
+	// Read dir/a.txt in two parts.
+	p, n := writePath(proc, "dir/a.txt")
+	check(t, "path_open", w.pathOpen(proc, 3, 0, p, n, 0, rightFDRead, 0, 0, 0), ErrnoSuccess)
+	fd, _ := proc.ReadUint32Le(0)
+	proc.WriteUint32Le(16, 100) // iovec {100, 2}
+	proc.WriteUint32Le(20, 2)
+	check(t, "fd_read", w.fdRead(proc, fd, 16, 1, 8), ErrnoSuccess)
+	check(t, "fd_read", w.fdRead(proc, fd, 16, 1, 8), ErrnoSuccess)
+	if got, _ := proc.ReadString(100, 2); got != "ll" {
+		t.Errorf("got %q, want %q", got, "ll")
+	}
+	// 1<<29 iovecs of 8 bytes wrap around to 0 bytes in 32 bits.
+	check(t, "fd_read with 1<<29 iovecs", w.fdRead(proc, fd, 16, 1<<29, 8), ErrnoFault)
+	check(t, "fd_seek", w.fdSeek(proc, fd, -1, 2, 8), ErrnoSuccess)
+	if off, _ := proc.ReadUint64Le(8); off != 4 {
+		t.Errorf("got offset %d, want 4", off)
+	}
+	check(t, "fd_filestat_get", w.fdFilestatGet(proc, fd, 200), ErrnoSuccess)
+	if size, _ := proc.ReadUint64Le(200 + 32); size != 5 {
+		t.Errorf("got size %d, want 5", size)
+	}
+	check(t, "fd_write to a read-only file", w.fdWrite(proc, fd, 16, 1, 8), ErrnoBadf)
+	check(t, "fd_close", w.fdClose(proc, fd), ErrnoSuccess)
+	check(t, "fd_close", w.fdClose(proc, fd), ErrnoBadf)
+
+	// Create out.txt.
+	p, n = writePath(proc, "dir/../out.txt")
+	check(t, "path_open", w.pathOpen(proc, 3, 0, p, n, oflagCreat|oflagTrunc, rightFDWrite, 0, 0, 0), ErrnoSuccess)
+	fd, _ = proc.ReadUint32Le(0)
+	proc.WriteBytes(100, []byte("xyz"))
+	proc.WriteUint32Le(20, 3)
+	check(t, "fd_write", w.fdWrite(proc, fd, 16, 1, 8), ErrnoSuccess)
+	if data, err := fsys.ReadFile("out.txt"); err != nil || string(data) != "xyz" {
+		t.Errorf("got out.txt %q (%v), want %q", data, err, "xyz")
+	}
+
+	// List dir.
+	p, n = writePath(proc, "dir")
+	check(t, "path_open", w.pathOpen(proc, 3, 0, p, n, oflagDirectory, rightFDRead, 0, 0, 0), ErrnoSuccess)
+	fd, _ = proc.ReadUint32Le(0)
+	check(t, "fd_readdir", w.fdReaddir(proc, fd, 300, 100, 0, 8), ErrnoSuccess)
+	if used, _ := proc.ReadUint32Le(8); used != direntSize+5 {
+		t.Errorf("got %d bytes of entries, want %d", used, direntSize+5)
+	}
+	if name, _ := proc.ReadString(300+direntSize, 5); name != "a.txt" {
+		t.Errorf("got entry %q, want a.txt", name)
+	}
+	check(t, "fd_read of a directory", w.fdRead(proc, fd, 16, 1, 8), ErrnoIsdir)
+
+	for _, tc := range []struct {
+		path   string
+		oflags uint32
+		errno  Errno
+	}{
+		{"../etc/passwd", 0, ErrnoNotcapable},
+		{"/etc/passwd", 0, ErrnoNotcapable},
+		{"missing", 0, ErrnoNoent},
+		{"dir/a.txt", oflagCreat | oflagExcl, ErrnoExist},
+		{"dir/a.txt", oflagDirectory, ErrnoNotdir},
+	} {
+		p, n := writePath(proc, tc.path)
+		check(t, "path_open "+tc.path, w.pathOpen(proc, 3, 0, p, n, tc.oflags, rightFDRead, 0, 0, 0), tc.errno)
+	}
+}
+
+func TestPaths(t *testing.T) {
+	fsys := vfs.NewMemFS()
+	if err := fsys.WriteFile("a.txt", []byte("a"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	proc := newProcess(t)
+	w := New(Config{Preopens: map[string]vfs.FS{"/data": fsys, "/other": vfs.NewMemFS()}})
+
+	pathCall := func(fn func(*exec.Process, uint32, uint32, uint32) Errno, dirfd uint32, name string) Errno {
+		p, n := writePath(proc, name)
+		return fn(proc, dirfd, p, n)
+	}
+	rename := func(oldfd uint32, oldname string, newfd uint32, newname string) Errno {
+		proc.WriteBytes(1024, []byte(oldname))
+		proc.WriteBytes(2048, []byte(newname))
+		return w.pathRename(proc, oldfd, 1024, uint32(len(oldname)), newfd, 2048, uint32(len(newname)))
+	}
+
+	check(t, "path_create_directory", pathCall(w.pathCreateDirectory, 3, "dir"), ErrnoSuccess)
+	check(t, "path_create_directory", pathCall(w.pathCreateDirectory, 3, "dir"), ErrnoExist)
+	check(t, "path_rename", rename(3, "a.txt", 3, "dir/b.txt"), ErrnoSuccess)
+	if data, err := fsys.ReadFile("dir/b.txt"); err != nil || string(data) != "a" {
+		t.Errorf("got dir/b.txt %q (%v), want %q", data, err, "a")
+	}
+	check(t, "path_rename across preopens", rename(3, "dir/b.txt", 4, "b.txt"), ErrnoXdev)
+	check(t, "path_rename out of the preopen", rename(3, "dir/b.txt", 3, "../b.txt"), ErrnoNotcapable)
+	check(t, "path_remove_directory", pathCall(w.pathRemoveDirectory, 3, "dir"), ErrnoNotempty)
+	check(t, "path_remove_directory of a file", pathCall(w.pathRemoveDirectory, 3, "dir/b.txt"), ErrnoNotdir)
+	check(t, "path_unlink_file of a directory", pathCall(w.pathUnlinkFile, 3, "dir"), ErrnoIsdir)
+	check(t, "path_unlink_file", pathCall(w.pathUnlinkFile, 3, "dir/b.txt"), ErrnoSuccess)
+	check(t, "path_unlink_file", pathCall(w.pathUnlinkFile, 3, "dir/b.txt"), ErrnoNoent)
+	check(t, "path_remove_directory", pathCall(w.pathRemoveDirectory, 3, "dir"), ErrnoSuccess)
+}
+
+func TestClocks(t *testing.T) {
+	now := time.Unix(1000, 0)
+	proc := newProcess(t)
+	w := New(Config{Now: func() time.Time { return now }})
+
+	check(t, "clock_time_get", w.clockTimeGet(proc, clockRealtime, 0, 0), ErrnoSuccess)
+	if got, _ := proc.ReadUint64Le(0); got != uint64(now.UnixNano()) {
+		t.Errorf("got time %d, want %d", got, now.UnixNano())
+	}
+	check(t, "clock_time_get", w.clockTimeGet(proc, 42, 0, 0), ErrnoInval)
+
+	// Two relative clock subscriptions, of 1ms and 1s.
+	for i, timeout := range []time.Duration{time.Second, time.Millisecond} {
+		sub := uint32(i * subscriptionSize)
+		proc.WriteUint64Le(sub, uint64(i+1))
+		proc.WriteUint32Le(sub+16, clockMonotonic)
+		proc.WriteUint64Le(sub+24, uint64(timeout))
+	}
+	check(t, "poll_oneoff", w.pollOneoff(proc, 0, 1000, 2, 2000), ErrnoSuccess)
+	if n, _ := proc.ReadUint32Le(2000); n != 1 {
+		t.Fatalf("got %d events, want 1", n)
+	}
+	if userdata, _ := proc.ReadUint64Le(1000); userdata != 2 {
+		t.Errorf("got event of subscription %d, want 2", userdata)
+	}
+}
+
+func TestPollOneoffBounds(t *testing.T) {
+	proc := newProcess(t)
+	w := New(Config{})
+
+	check(t, "poll_oneoff without subscriptions", w.pollOneoff(proc, 0, 1000, 0, 2000), ErrnoInval)
+	// 1<<28 subscriptions of 48 bytes wrap around to 0 bytes in 32 bits.
+	check(t, "poll_oneoff with 1<<28 subscriptions", w.pollOneoff(proc, 0, 1000, 1<<28, 2000), ErrnoFault)
+	check(t, "poll_oneoff with 1<<32-1 subscriptions", w.pollOneoff(proc, 0, 1000, 1<<32-1, 2000), ErrnoFault)
+	check(t, "poll_oneoff past the memory", w.pollOneoff(proc, 0, 65536-eventSize, 2, 2000), ErrnoFault)
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Features is a set of WebAssembly proposals a module may use, in addition
+// to the MVP.
+type Features uint64
+
+const (
+	FeatureTailCall       Features = 1 << iota // return_call and return_call_indirect
+	FeatureExceptions                          // Tags and try, catch, throw, rethrow and delegate
+	FeatureMutableGlobals                      // Import and export of mutable globals
+	FeatureMemory64                            // Linear memories indexed with 64-bit addresses
+	FeatureMultiMemory                         // More than one linear memory
+	FeatureExtendedConst                       // Arithmetic in constant expressions
+	FeatureSignExtension                       // The extend8_s, extend16_s and extend32_s operators
+	FeatureSatConversion                       // Non-trapping, saturating, float-to-int conversions
+	FeatureBulkMemory                          // The memory.copy and memory.fill operators
+
+	// FeaturesMVP only allows modules of the MVP.
+	FeaturesMVP Features = 0
+	// FeaturesAll allows all the features supported by wagon.
+	FeaturesAll = FeatureTailCall | FeatureExceptions | FeatureMutableGlobals |
+		FeatureMemory64 | FeatureMultiMemory | FeatureExtendedConst |
+		FeatureSignExtension | FeatureSatConversion | FeatureBulkMemory
+)
+
+var featureNames = []struct {
+	f    Features
+	name string
+}{
+	{FeatureTailCall, "tail-call"},
+	{FeatureExceptions, "exceptions"},
+	{FeatureMutableGlobals, "mutable-globals"},
+	{FeatureMemory64, "memory64"},
+	{FeatureMultiMemory, "multi-memory"},
+	{FeatureExtendedConst, "extended-const"},
+	{FeatureSignExtension, "sign-extension"},
+	{FeatureSatConversion, "saturating-float-to-int"},
+	{FeatureBulkMemory, "bulk-memory"},
+}
+
+// Has returns whether all the features of g are in f.
+func (f Features) Has(g Features) bool {
+	return f&g == g
+}
+
+func (f Features) String() string {
+	if f == FeaturesMVP {
+		return "mvp"
+	}
+	var names []string
+	for _, feature := range featureNames {
+		if f&feature.f != 0 {
+			names = append(names, feature.name)
+			f &^= feature.f
+		}
+	}
+	if f != 0 {
+		names = append(names, fmt.Sprintf("%#x", uint64(f)))
+	}
+	return strings.Join(names, "|")
+}
+
+// featuresOf combines features, or returns FeaturesAll if there are none.
+func featuresOf(features []Features) Features {
+	if len(features) == 0 {
+		return FeaturesAll
+	}
+	var f Features
+	for _, feature := range features {
+		f |= feature
+	}
+	return f
+}
+
+// DisabledFeatureError is returned when a module uses a feature that is not
+// enabled.
+type DisabledFeatureError Features
+
+func (e DisabledFeatureError) Error() string {
+	return fmt.Sprintf("wasm: feature %s is not enabled", Features(e))
+}
+
+// CheckFeatures returns a DisabledFeatureError if the module uses a feature
+// that is not in f. Only the sections of the module are checked, operators
+// of function bodies are checked by their validation or disassembly.
+func (m *Module) CheckFeatures(f Features) error {
+	var used Features
+
+	memories := 0
+	globals := []GlobalVar{}
+	if m.Import != nil {
+		for _, entry := range m.Import.Entries {
+			switch imp := entry.Type.(type) {
+			case MemoryImport:
+				memories++
+				if imp.Type.Limits.Is64() {
+					used |= FeatureMemory64
+				}
+			case GlobalVarImport:
+				globals = append(globals, imp.Type)
+				if imp.Type.Mutable {
+					used |= FeatureMutableGlobals
+				}
+			case TagImport:
+				used |= FeatureExceptions
+			}
+		}
+	}
+	if m.Memory != nil {
+		memories += len(m.Memory.Entries)
+		for _, mem := range m.Memory.Entries {
+			if mem.Limits.Is64() {
+				used |= FeatureMemory64
+			}
+		}
+	}
+	if memories > 1 {
+		used |= FeatureMultiMemory
+	}
+	if m.Tags != nil && len(m.Tags.Entries) != 0 {
+		used |= FeatureExceptions
+	}
+
+	var exprs [][]byte
+	if m.Global != nil {
+		for _, global := range m.Global.Globals {
+			globals = append(globals, global.Type)
+			exprs = append(exprs, global.Init)
+		}
+	}
+	if m.Export != nil {
+		for _, entry := range m.Export.Entries {
+			if entry.Kind == ExternalGlobal && int(entry.Index) < len(globals) && globals[entry.Index].Mutable {
+				used |= FeatureMutableGlobals
+			}
+		}
+	}
+	if m.Elements != nil {
+		for _, elem := range m.Elements.Entries {
+			exprs = append(exprs, elem.Offset)
+		}
+	}
+	if m.Data != nil {
+		for _, data := range m.Data.Entries {
+			if data.Index != 0 {
+				used |= FeatureMultiMemory
+			}
+			exprs = append(exprs, data.Offset)
+		}
+	}
+	for _, expr := range exprs {
+		err := scanInitExpr(bytes.NewReader(expr), func(op byte) {
+			switch op {
+			case i32Add, i32Sub, i32Mul, i64Add, i64Sub, i64Mul:
+				used |= FeatureExtendedConst
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if disabled := used &^ f; disabled != 0 {
+		return DisabledFeatureError(disabled)
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wasm_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+func TestFeatures(t *testing.T) {
+	header := []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}
+	for _, tc := range []struct {
+		name    string
+		section []byte
+		feature wasm.Features
+	}{
+		{
+			name:    "mvp",
+			section: []byte{0x05, 0x03, 0x01, 0x00, 0x01}, // (memory 1)
+			feature: wasm.FeaturesMVP,
+		},
+		{
+			name:    "memory64",
+			section: []byte{0x05, 0x03, 0x01, 0x04, 0x01}, // (memory i64 1)
+			feature: wasm.FeatureMemory64,
+		},
+		{
+			name:    "multi-memory",
+			section: []byte{0x05, 0x05, 0x02, 0x00, 0x01, 0x00, 0x01}, // (memory 1) (memory 1)
+			feature: wasm.FeatureMultiMemory,
+		},
+		{
+			name: "exceptions",
+			section: []byte{
+				0x01, 0x04, 0x01, 0x60, 0x00, 0x00, // (type (func))
+				0x0d, 0x03, 0x01, 0x00, 0x00, // (tag (type 0))
+			},
+			feature: wasm.FeatureExceptions,
+		},
+		{
+			name: "mutable-globals",
+			section: []byte{
+				// (global (mut i32) (i32.const 0))
+				0x06, 0x06, 0x01, 0x7f, 0x01, 0x41, 0x00, 0x0b,
+				// (export "g" (global 0))
+				0x07, 0x05, 0x01, 0x01, 'g', 0x03, 0x00,
+			},
+			feature: wasm.FeatureMutableGlobals,
+		},
+		{
+			name: "extended-const",
+			section: []byte{
+				// (global i32 (i32.add (i32.const 1) (i32.const 2)))
+				0x06, 0x09, 0x01, 0x7f, 0x00, 0x41, 0x01, 0x41, 0x02, 0x6a, 0x0b,
+			},
+			feature: wasm.FeatureExtendedConst,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := append(append([]byte(nil), header...), tc.section...)
+
+			if _, err := wasm.ReadModule(bytes.NewReader(raw), nil); err != nil {
+				t.Fatalf("with all features: %v", err)
+			}
+			if _, err := wasm.ReadModule(bytes.NewReader(raw), nil, tc.feature); err != nil {
+				t.Fatalf("with feature %v: %v", tc.feature, err)
+			}
+			if tc.feature == wasm.FeaturesMVP {
+				return
+			}
+			want := wasm.DisabledFeatureError(tc.feature)
+			if _, err := wasm.DecodeModule(bytes.NewReader(raw), wasm.FeaturesAll&^tc.feature); err != want {
+				t.Fatalf("without feature %v: got error %v, want %v", tc.feature, err, want)
+			}
+			if _, err := wasm.ReadModule(bytes.NewReader(raw), nil, wasm.FeaturesMVP); err != want {
+				t.Fatalf("with mvp: got error %v, want %v", err, want)
+			}
+		})
+	}
+}
+
+func TestFeaturesString(t *testing.T) {
+	for _, tc := range []struct {
+		f    wasm.Features
+		want string
+	}{
+		{wasm.FeaturesMVP, "mvp"},
+		{wasm.FeatureTailCall, "tail-call"},
+		{wasm.FeatureMemory64 | wasm.FeatureExceptions, "exceptions|memory64"},
+		{1 << 63, "0x8000000000000000"},
+	} {
+		if got := tc.f.String(); got != tc.want {
+			t.Errorf("got %q, want %q", got, tc.want)
+		}
+	}
+	if got, want := wasm.DisabledFeatureError(wasm.FeatureTailCall).Error(), "wasm: feature tail-call is not enabled"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
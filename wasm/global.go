@@ -0,0 +1,110 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wasm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrImmutableGlobal is returned when setting the value of an immutable global.
+var ErrImmutableGlobal = errors.New("wasm: cannot set immutable global variable")
+
+// InvalidGlobalValueError is returned when the Go value used to set a global
+// does not match its value type.
+type InvalidGlobalValueError struct {
+	Type  ValueType
+	Value interface{}
+}
+
+func (e InvalidGlobalValueError) Error() string {
+	return fmt.Sprintf("wasm: invalid value %v (%T) for global of type %v", e.Value, e.Value, e.Type)
+}
+
+// Global is a global variable instance. Globals that are exported or
+// imported by a module are shared, through their GlobalEntry, between all
+// the module instances importing them and the host, so that a write to a
+// mutable global is observed by all of them.
+type Global struct {
+	Type GlobalVar
+	bits uint64
+}
+
+// NewGlobal returns a global of the given type, initialized to v.
+// v must be an int32, int64, float32 or float64, depending on the value
+// type of the global.
+func NewGlobal(typ GlobalVar, v interface{}) (*Global, error) {
+	g := &Global{Type: typ}
+	bits, err := globalBits(typ.Type, v)
+	if err != nil {
+		return nil, err
+	}
+	g.bits = bits
+	return g, nil
+}
+
+// Get returns the value of the global as an int32, int64, float32 or
+// float64, depending on its value type.
+func (g *Global) Get() interface{} {
+	switch g.Type.Type {
+	case ValueTypeI32:
+		return int32(g.bits)
+	case ValueTypeI64:
+		return int64(g.bits)
+	case ValueTypeF32:
+		return math.Float32frombits(uint32(g.bits))
+	case ValueTypeF64:
+		return math.Float64frombits(g.bits)
+	}
+	return nil
+}
+
+// Set sets the value of a mutable global. The type of v must match the
+// value type of the global, as returned by Get.
+func (g *Global) Set(v interface{}) error {
+	if !g.Type.Mutable {
+		return ErrImmutableGlobal
+	}
+	bits, err := globalBits(g.Type.Type, v)
+	if err != nil {
+		return err
+	}
+	g.bits = bits
+	return nil
+}
+
+// Bits returns the raw value of the global, as stored by the VM.
+func (g *Global) Bits() uint64 {
+	return g.bits
+}
+
+// SetBits sets the raw value of the global, as stored by the VM.
+// Unlike Set, it does not check for mutability.
+func (g *Global) SetBits(bits uint64) {
+	g.bits = bits
+}
+
+func globalBits(typ ValueType, v interface{}) (uint64, error) {
+	switch v := v.(type) {
+	case int32:
+		if typ == ValueTypeI32 {
+			return uint64(uint32(v)), nil
+		}
+	case int64:
+		if typ == ValueTypeI64 {
+			return uint64(v), nil
+		}
+	case float32:
+		if typ == ValueTypeF32 {
+			return uint64(math.Float32bits(v)), nil
+		}
+	case float64:
+		if typ == ValueTypeF64 {
+			return math.Float64bits(v), nil
+		}
+	}
+	return 0, InvalidGlobalValueError{typ, v}
+}
@@ -0,0 +1,49 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wasm_test
+
+import (
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+func TestGlobal(t *testing.T) {
+	for _, tc := range []struct {
+		typ wasm.ValueType
+		v   interface{}
+	}{
+		{wasm.ValueTypeI32, int32(-1)},
+		{wasm.ValueTypeI64, int64(-1)},
+		{wasm.ValueTypeF32, float32(1.5)},
+		{wasm.ValueTypeF64, float64(-2.5)},
+	} {
+		g, err := wasm.NewGlobal(wasm.GlobalVar{Type: tc.typ, Mutable: true}, tc.v)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.typ, err)
+		}
+		if got := g.Get(); got != tc.v {
+			t.Errorf("%v: got %v, want %v", tc.typ, got, tc.v)
+		}
+		if err := g.Set(tc.v); err != nil {
+			t.Errorf("%v: %v", tc.typ, err)
+		}
+		if err := g.Set(uint8(0)); err != (wasm.InvalidGlobalValueError{Type: tc.typ, Value: uint8(0)}) {
+			t.Errorf("%v: got error %v", tc.typ, err)
+		}
+	}
+
+	g, err := wasm.NewGlobal(wasm.GlobalVar{Type: wasm.ValueTypeI32}, int32(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Set(int32(2)); err != wasm.ErrImmutableGlobal {
+		t.Errorf("got error %v, want %v", err, wasm.ErrImmutableGlobal)
+	}
+
+	if _, err := wasm.NewGlobal(wasm.GlobalVar{Type: wasm.ValueTypeI64}, int32(1)); err == nil {
+		t.Error("NewGlobal accepted a value of the wrong type")
+	}
+}
@@ -28,6 +28,7 @@ type ImportEntry struct {
 	// If Kind is Table, Type is a TableImport containing the type of the imported table
 	// If Kind is Memory, Type is a MemoryImport containing the type of the imported memory
 	// If the Kind is Global, Type is a GlobalVarImport
+	// If the Kind is Tag, Type is a TagImport
 	Type Import
 }
 
@@ -80,7 +81,22 @@ func (t GlobalVarImport) MarshalWASM(w io.Writer) error {
 	return t.Type.MarshalWASM(w)
 }
 
+// TagImport is the type of an imported exception tag.
+type TagImport struct {
+	Type Tag
+}
+
+func (TagImport) isImport() {}
+func (TagImport) Kind() External {
+	return ExternalTag
+}
+func (t TagImport) MarshalWASM(w io.Writer) error {
+	return t.Type.MarshalWASM(w)
+}
+
 var (
+	// ErrImportMutGlobal is no longer returned, as mutable globals are
+	// shared between the importing and exporting modules.
 	ErrImportMutGlobal           = errors.New("wasm: cannot import global mutable variable")
 	ErrNoExportsInImportedModule = errors.New("wasm: imported module has no exports")
 )
@@ -194,33 +210,64 @@ func (module *Module) resolveImports(resolve ResolveFunc) error {
 			module.imports.Funcs = append(module.imports.Funcs, funcs)
 			funcs++
 		case ExternalGlobal:
-			glb := importedModule.GetGlobal(int(index))
-			if glb == nil {
-				return InvalidGlobalIndexError(index)
+			cell, err := importedModule.globalCell(index)
+			if err != nil {
+				return err
 			}
-			if glb.Type.Mutable {
-				return ErrImportMutGlobal
+			if cell.Type != importEntry.Type.(GlobalVarImport).Type {
+				return InvalidImportError{importEntry.ModuleName, importEntry.FieldName, index}
 			}
-			module.GlobalIndexSpace = append(module.GlobalIndexSpace, *glb)
+			module.GlobalIndexSpace = append(module.GlobalIndexSpace, *importedModule.GetGlobal(int(index)))
 			module.imports.Globals++
 
-			// In both cases below, index should be always 0 (according to the MVP)
+			// Below, index should be always 0 for tables (according to the MVP)
 			// We check it against the length of the index space anyway.
 		case ExternalTable:
 			if int(index) >= len(importedModule.TableIndexSpace) {
 				return InvalidTableIndexError(index)
 			}
-			module.TableIndexSpace[0] = importedModule.TableIndexSpace[0]
+			// The index space is only allocated for the tables the
+			// module defines.
+			if len(module.TableIndexSpace) == 0 {
+				module.TableIndexSpace = make([][]TableEntry, 1)
+			}
+			module.TableIndexSpace[0] = importedModule.TableIndexSpace[index]
 			module.imports.Tables++
 		case ExternalMemory:
 			if int(index) >= len(importedModule.LinearMemoryIndexSpace) {
 				return InvalidLinearMemoryIndexError(index)
 			}
-			module.LinearMemoryIndexSpace[0] = importedModule.LinearMemoryIndexSpace[0]
+			module.LinearMemoryIndexSpace[module.imports.Memories] = importedModule.LinearMemoryIndexSpace[index]
 			module.imports.Memories++
+		case ExternalTag:
+			tag := importedModule.GetTag(int(index))
+			if tag == nil {
+				return InvalidTagIndexError(index)
+			}
+			importIndex := importEntry.Type.(TagImport).Type.Type
+			if int(importIndex) >= len(module.Types.Entries) || int(tag.Type) >= len(importedModule.Types.Entries) {
+				return InvalidImportError{importEntry.ModuleName, importEntry.FieldName, importIndex}
+			}
+			if !sameTypes(importedModule.Types.Entries[tag.Type].ParamTypes, module.Types.Entries[importIndex].ParamTypes) {
+				return InvalidImportError{importEntry.ModuleName, importEntry.FieldName, importIndex}
+			}
+			module.TagIndexSpace = append(module.TagIndexSpace, Tag{Attribute: tag.Attribute, Type: importIndex})
+			module.imports.Tags++
 		default:
 			return InvalidExternalError(exportEntry.Kind)
 		}
 	}
 	return nil
 }
+
+func sameTypes(a, b []ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
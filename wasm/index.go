@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 )
 
@@ -42,6 +43,21 @@ func (e InvalidValueTypeInitExprError) Error() string {
 	return fmt.Sprintf("wasm: Wanted initializer expression to return %v value, got %v", e.Wanted, e.Got)
 }
 
+// InvalidTagIndexError is returned when a tag index is out of the tag index space.
+type InvalidTagIndexError uint32
+
+func (e InvalidTagIndexError) Error() string {
+	return fmt.Sprintf("wasm: Invalid index to tag index space: %d", uint32(e))
+}
+
+// InvalidTagTypeError is returned when a tag refers to a missing type, or to
+// a function type with results.
+type InvalidTagTypeError uint32
+
+func (e InvalidTagTypeError) Error() string {
+	return fmt.Sprintf("wasm: Invalid type for tag: %d", uint32(e))
+}
+
 type InvalidLinearMemoryIndexError uint32
 
 func (e InvalidLinearMemoryIndexError) Error() string {
@@ -166,6 +182,30 @@ func (m *Module) populateGlobals() error {
 	return nil
 }
 
+// globalCell returns the cell of the global at the given index, creating
+// and initializing it if needed. It is called when the global is imported
+// by another module: from then on, the instances of m share the value of
+// the global with those of the importing module.
+func (m *Module) globalCell(index uint32) (*Global, error) {
+	glb := m.GetGlobal(int(index))
+	if glb == nil {
+		return nil, InvalidGlobalIndexError(index)
+	}
+	if glb.Cell != nil {
+		return glb.Cell, nil
+	}
+	val, err := m.ExecInitExpr(glb.Init)
+	if err != nil {
+		return nil, err
+	}
+	cell, err := NewGlobal(glb.Type, val)
+	if err != nil {
+		return nil, err
+	}
+	glb.Cell = cell
+	return cell, nil
+}
+
 // GetGlobal returns a *GlobalEntry, based on the global index space.
 // Returns nil when the index is invalid
 func (m *Module) GetGlobal(i int) *GlobalEntry {
@@ -203,6 +243,69 @@ func (m *Module) GetGlobalType(i uint32) (*GlobalVar, error) {
 	return &m.Global.Globals[i].Type, nil
 }
 
+func (m *Module) populateTags() error {
+	if m.Tags == nil {
+		return nil
+	}
+
+	for _, tag := range m.Tags.Entries {
+		if m.Types == nil || int(tag.Type) >= len(m.Types.Entries) {
+			return InvalidTagTypeError(tag.Type)
+		}
+		if len(m.Types.Entries[tag.Type].ReturnTypes) != 0 {
+			return InvalidTagTypeError(tag.Type)
+		}
+	}
+	m.TagIndexSpace = append(m.TagIndexSpace, m.Tags.Entries...)
+	logger.Printf("There are %d entries in the tag index space.", len(m.TagIndexSpace))
+	return nil
+}
+
+// GetTag returns a *Tag, based on the tag index space.
+// Returns nil when the index is invalid
+func (m *Module) GetTag(i int) *Tag {
+	if i >= len(m.TagIndexSpace) || i < 0 {
+		return nil
+	}
+
+	return &m.TagIndexSpace[i]
+}
+
+// GetTagSig returns the signature of the values carried by the tag at index
+// i of the tag index space. Unlike GetTag, it also works on decoded modules
+// whose index spaces have not been populated.
+func (m *Module) GetTagSig(i uint32) (*FunctionSig, error) {
+	var tag *Tag
+	if len(m.TagIndexSpace) != 0 {
+		tag = m.GetTag(int(i))
+	} else {
+		var tagindex uint32
+		if m.Import != nil {
+			for _, importEntry := range m.Import.Entries {
+				if importEntry.Type.Kind() != ExternalTag {
+					continue
+				}
+				if tagindex == i {
+					t := importEntry.Type.(TagImport).Type
+					tag = &t
+					break
+				}
+				tagindex++
+			}
+		}
+		if tag == nil && m.Tags != nil && i-tagindex < uint32(len(m.Tags.Entries)) {
+			tag = &m.Tags.Entries[i-tagindex]
+		}
+	}
+	if tag == nil {
+		return nil, InvalidTagIndexError(i)
+	}
+	if m.Types == nil || int(tag.Type) >= len(m.Types.Entries) {
+		return nil, InvalidTagTypeError(tag.Type)
+	}
+	return &m.Types.Entries[tag.Type], nil
+}
+
 func (m *Module) populateTables() error {
 	if m.Table == nil || len(m.Table.Entries) == 0 || m.Elements == nil || len(m.Elements.Entries) == 0 {
 		return nil
@@ -265,14 +368,15 @@ func (m *Module) GetTableElement(index int) (uint32, error) {
 	return entry.Index, nil
 }
 
+// pageSize is the size of a page of linear memory.
+const pageSize = 65536
+
 func (m *Module) populateLinearMemory() error {
 	if m.Data == nil || len(m.Data.Entries) == 0 {
 		return nil
 	}
-	// each module can only have a single linear memory in the MVP
-
 	for _, entry := range m.Data.Entries {
-		if entry.Index != 0 {
+		if int(entry.Index) >= len(m.LinearMemoryIndexSpace) {
 			return InvalidLinearMemoryIndexError(entry.Index)
 		}
 
@@ -280,15 +384,40 @@ func (m *Module) populateLinearMemory() error {
 		if err != nil {
 			return err
 		}
-		off, ok := val.(int32)
-		if !ok {
-			return InvalidValueTypeInitExprError{reflect.Int32, reflect.TypeOf(val).Kind()}
+		// offsets into 64-bit memories are i64 values.
+		var offset uint64
+		if mem := m.GetMemory(int(entry.Index)); mem != nil && mem.Limits.Is64() {
+			off, ok := val.(int64)
+			if !ok {
+				return InvalidValueTypeInitExprError{reflect.Int64, reflect.TypeOf(val).Kind()}
+			}
+			offset = uint64(off)
+		} else {
+			off, ok := val.(int32)
+			if !ok {
+				return InvalidValueTypeInitExprError{reflect.Int32, reflect.TypeOf(val).Kind()}
+			}
+			offset = uint64(uint32(off))
+		}
+
+		// The segments must fit in the initial size of the memory, which
+		// also prevents an unbounded allocation below.
+		if mem := m.GetMemory(int(entry.Index)); mem != nil {
+			size := uint64(math.MaxUint64)
+			if mem.Limits.Initial < math.MaxUint64/pageSize {
+				size = mem.Limits.Initial * pageSize
+			}
+			if n := uint64(len(m.LinearMemoryIndexSpace[entry.Index])); n > size {
+				size = n // An imported memory larger than its import
+			}
+			if offset > size || uint64(len(entry.Data)) > size-offset {
+				return OutsizeError{"Memory", offset + uint64(len(entry.Data)), size}
+			}
 		}
-		offset := uint32(off)
 
 		memory := m.LinearMemoryIndexSpace[entry.Index]
-		if uint64(offset)+uint64(len(entry.Data)) > uint64(len(memory)) {
-			data := make([]byte, uint64(offset)+uint64(len(entry.Data)))
+		if offset+uint64(len(entry.Data)) > uint64(len(memory)) {
+			data := make([]byte, offset+uint64(len(entry.Data)))
 			copy(data, memory)
 			copy(data[offset:], entry.Data)
 			m.LinearMemoryIndexSpace[int(entry.Index)] = data
@@ -308,3 +437,46 @@ func (m *Module) GetLinearMemoryData(index int) (byte, error) {
 
 	return m.LinearMemoryIndexSpace[0][index], nil
 }
+
+// GetMemory returns the type of the linear memory at index i of the memory
+// index space, in which imported memories come first. It returns nil if the
+// index is invalid.
+func (m *Module) GetMemory(i int) *Memory {
+	if m.Import != nil {
+		for _, entry := range m.Import.Entries {
+			imp, ok := entry.Type.(MemoryImport)
+			if !ok {
+				continue
+			}
+			if i == 0 {
+				return &imp.Type
+			}
+			i--
+		}
+	}
+	if m.Memory == nil || i < 0 || i >= len(m.Memory.Entries) {
+		return nil
+	}
+	return &m.Memory.Entries[i]
+}
+
+// memoryCount returns the number of linear memories of the module,
+// imported ones included. It is at least 1, so that the first entry of
+// the linear memory index space always exists.
+func (m *Module) memoryCount() int {
+	n := 0
+	if m.Import != nil {
+		for _, entry := range m.Import.Entries {
+			if _, ok := entry.Type.(MemoryImport); ok {
+				n++
+			}
+		}
+	}
+	if m.Memory != nil {
+		n += len(m.Memory.Entries)
+	}
+	if n == 0 {
+		n = 1
+	}
+	return n
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"reflect"
 
 	"github.com/go-interpreter/wagon/wasm/leb128"
 )
@@ -21,10 +22,26 @@ const (
 	f64Const  byte = 0x44
 	getGlobal byte = 0x23
 	end       byte = 0x0b
+
+	// Operators of the extended constant expressions proposal.
+	i32Add byte = 0x6a
+	i32Sub byte = 0x6b
+	i32Mul byte = 0x6c
+	i64Add byte = 0x7c
+	i64Sub byte = 0x7d
+	i64Mul byte = 0x7e
 )
 
 var ErrEmptyInitExpr = errors.New("wasm: Initializer expression produces no value")
 
+// ErrInitExprGlobalCycle is returned when the initializer expression of a
+// global refers to the global itself, directly or through other globals.
+var ErrInitExprGlobalCycle = errors.New("wasm: Initializer expression refers to itself")
+
+// ErrInitExprStackUnderflow is returned when an operator of an initializer
+// expression has fewer operands than it requires.
+var ErrInitExprStackUnderflow = errors.New("wasm: Initializer expression stack underflow")
+
 type InvalidInitExprOpError byte
 
 func (e InvalidInitExprOpError) Error() string {
@@ -38,52 +55,62 @@ func (e InvalidGlobalIndexError) Error() string {
 }
 
 func readInitExpr(r io.Reader) ([]byte, error) {
-	b := make([]byte, 1)
 	buf := new(bytes.Buffer)
-	r = io.TeeReader(r, buf)
+	if err := scanInitExpr(io.TeeReader(r, buf), nil); err != nil {
+		return nil, err
+	}
+
+	if buf.Len() == 0 {
+		return nil, ErrEmptyInitExpr
+	}
+
+	return buf.Bytes(), nil
+}
+
+// scanInitExpr reads an initializer expression from r, up to and including
+// its end opcode. If visit is not nil, it is called with each opcode read.
+func scanInitExpr(r io.Reader, visit func(op byte)) error {
+	b := make([]byte, 1)
 
-outer:
 	for {
 		_, err := io.ReadFull(r, b)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		if visit != nil {
+			visit(b[0])
 		}
 		switch b[0] {
 		case i32Const:
 			_, err := leb128.ReadVarint32(r)
 			if err != nil {
-				return nil, err
+				return err
 			}
 		case i64Const:
 			_, err := leb128.ReadVarint64(r)
 			if err != nil {
-				return nil, err
+				return err
 			}
 		case f32Const:
 			if _, err := readU32(r); err != nil {
-				return nil, err
+				return err
 			}
 		case f64Const:
 			if _, err := readU64(r); err != nil {
-				return nil, err
+				return err
 			}
 		case getGlobal:
 			_, err := leb128.ReadVarUint32(r)
 			if err != nil {
-				return nil, err
+				return err
 			}
+		case i32Add, i32Sub, i32Mul, i64Add, i64Sub, i64Mul:
 		case end:
-			break outer
+			return nil
 		default:
-			return nil, InvalidInitExprOpError(b[0])
+			return InvalidInitExprOpError(b[0])
 		}
 	}
-
-	if buf.Len() == 0 {
-		return nil, ErrEmptyInitExpr
-	}
-
-	return buf.Bytes(), nil
 }
 
 // ExecInitExpr executes an initializer expression and returns an interface{} value
@@ -91,14 +118,25 @@ outer:
 // It returns an error if the expression is invalid, and nil when the expression
 // yields no value.
 func (m *Module) ExecInitExpr(expr []byte) (interface{}, error) {
+	return m.execInitExpr(expr, 0)
+}
+
+// execInitExpr executes expr, which is the initializer expression of a
+// global referred to by depth other initializer expressions.
+func (m *Module) execInitExpr(expr []byte, depth int) (interface{}, error) {
 	var stack []uint64
-	var lastVal ValueType
+	var types []ValueType
 	r := bytes.NewReader(expr)
 
 	if r.Len() == 0 {
 		return nil, ErrEmptyInitExpr
 	}
 
+	push := func(v uint64, t ValueType) {
+		stack = append(stack, v)
+		types = append(types, t)
+	}
+
 	for {
 		b, err := r.ReadByte()
 		if err == io.EOF {
@@ -112,29 +150,25 @@ func (m *Module) ExecInitExpr(expr []byte) (interface{}, error) {
 			if err != nil {
 				return nil, err
 			}
-			stack = append(stack, uint64(i))
-			lastVal = ValueTypeI32
+			push(uint64(i), ValueTypeI32)
 		case i64Const:
 			i, err := leb128.ReadVarint64(r)
 			if err != nil {
 				return nil, err
 			}
-			stack = append(stack, uint64(i))
-			lastVal = ValueTypeI64
+			push(uint64(i), ValueTypeI64)
 		case f32Const:
 			i, err := readU32(r)
 			if err != nil {
 				return nil, err
 			}
-			stack = append(stack, uint64(i))
-			lastVal = ValueTypeF32
+			push(uint64(i), ValueTypeF32)
 		case f64Const:
 			i, err := readU64(r)
 			if err != nil {
 				return nil, err
 			}
-			stack = append(stack, i)
-			lastVal = ValueTypeF64
+			push(i, ValueTypeF64)
 		case getGlobal:
 			index, err := leb128.ReadVarUint32(r)
 			if err != nil {
@@ -144,7 +178,49 @@ func (m *Module) ExecInitExpr(expr []byte) (interface{}, error) {
 			if globalVar == nil {
 				return nil, InvalidGlobalIndexError(index)
 			}
-			lastVal = globalVar.Type.Type
+			if globalVar.Cell != nil {
+				push(globalVar.Cell.Bits(), globalVar.Type.Type)
+				break
+			}
+			// The global is neither imported nor bound by the host: its
+			// value is the one of its own initializer expression.
+			if depth >= len(m.GlobalIndexSpace) {
+				return nil, ErrInitExprGlobalCycle
+			}
+			v, err := m.execInitExpr(globalVar.Init, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			bits, err := globalBits(globalVar.Type.Type, v)
+			if err != nil {
+				return nil, err
+			}
+			push(bits, globalVar.Type.Type)
+		case i32Add, i32Sub, i32Mul, i64Add, i64Sub, i64Mul:
+			t := ValueTypeI32
+			if b >= i64Add {
+				t = ValueTypeI64
+			}
+			if len(stack) < 2 {
+				return nil, ErrInitExprStackUnderflow
+			}
+			for _, got := range types[len(types)-2:] {
+				if got != t {
+					return nil, InvalidValueTypeInitExprError{valueTypeKind(t), valueTypeKind(got)}
+				}
+			}
+			x, y := stack[len(stack)-2], stack[len(stack)-1]
+			stack, types = stack[:len(stack)-2], types[:len(types)-2]
+			var v uint64
+			switch b {
+			case i32Add, i64Add:
+				v = x + y
+			case i32Sub, i64Sub:
+				v = x - y
+			case i32Mul, i64Mul:
+				v = x * y
+			}
+			push(v, t)
 		case end:
 			break
 		default:
@@ -157,7 +233,7 @@ func (m *Module) ExecInitExpr(expr []byte) (interface{}, error) {
 	}
 
 	v := stack[len(stack)-1]
-	switch lastVal {
+	switch lastVal := types[len(types)-1]; lastVal {
 	case ValueTypeI32:
 		return int32(v), nil
 	case ValueTypeI64:
@@ -170,3 +246,19 @@ func (m *Module) ExecInitExpr(expr []byte) (interface{}, error) {
 		panic(fmt.Sprintf("Invalid value type produced by initializer expression: %d", int8(lastVal)))
 	}
 }
+
+// valueTypeKind returns the kind of the Go values ExecInitExpr returns for
+// values of type t.
+func valueTypeKind(t ValueType) reflect.Kind {
+	switch t {
+	case ValueTypeI32:
+		return reflect.Int32
+	case ValueTypeI64:
+		return reflect.Int64
+	case ValueTypeF32:
+		return reflect.Float32
+	case ValueTypeF64:
+		return reflect.Float64
+	}
+	return reflect.Invalid
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wasm_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+func TestExecInitExpr(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		globals []wasm.GlobalEntry
+		expr    []byte
+		want    interface{}
+		err     error
+	}{
+		{
+			name: "i32.const",
+			expr: []byte{0x41, 0x2a, 0x0b},
+			want: int32(42),
+		},
+		{
+			name: "i32.add",
+			expr: []byte{0x41, 0x2a, 0x41, 0x02, 0x6a, 0x0b},
+			want: int32(44),
+		},
+		{
+			name: "i32.sub",
+			expr: []byte{0x41, 0x02, 0x41, 0x03, 0x6b, 0x0b},
+			want: int32(-1),
+		},
+		{
+			name: "i32.mul overflow",
+			expr: []byte{0x41, 0x80, 0x80, 0x80, 0x80, 0x04, 0x41, 0x04, 0x6c, 0x0b},
+			want: int32(0),
+		},
+		{
+			name: "i64 arithmetic",
+			expr: []byte{0x42, 0x03, 0x42, 0x04, 0x7e, 0x42, 0x02, 0x7d, 0x42, 0x01, 0x7c, 0x0b},
+			want: int64(11),
+		},
+		{
+			name: "mixed types",
+			expr: []byte{0x41, 0x01, 0x42, 0x01, 0x6a, 0x0b},
+			err:  wasm.InvalidValueTypeInitExprError{Wanted: reflect.Int32, Got: reflect.Int64},
+		},
+		{
+			name: "global.get",
+			globals: []wasm.GlobalEntry{
+				{Type: wasm.GlobalVar{Type: wasm.ValueTypeI32}, Init: []byte{0x41, 0x80, 0x08, 0x0b}},
+			},
+			expr: []byte{0x23, 0x00, 0x41, 0x10, 0x6a, 0x0b},
+			want: int32(1040),
+		},
+		{
+			name: "global.get cycle",
+			globals: []wasm.GlobalEntry{
+				{Type: wasm.GlobalVar{Type: wasm.ValueTypeI32}, Init: []byte{0x23, 0x00, 0x0b}},
+			},
+			expr: []byte{0x23, 0x00, 0x0b},
+			err:  wasm.ErrInitExprGlobalCycle,
+		},
+		{
+			name: "stack underflow",
+			expr: []byte{0x42, 0x01, 0x7c, 0x0b},
+			err:  wasm.ErrInitExprStackUnderflow,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := wasm.Module{GlobalIndexSpace: tc.globals}
+			got, err := m.ExecInitExpr(tc.expr)
+			if err != tc.err {
+				t.Fatalf("got error %v, want %v", err, tc.err)
+			}
+			if got != tc.want {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDataOffsetExtendedConst(t *testing.T) {
+	raw := []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		// memory section: (memory 1)
+		0x05, 0x03, 0x01, 0x00, 0x01,
+		// global section: (global i32 (i32.const 1024))
+		0x06, 0x07, 0x01, 0x7f, 0x00, 0x41, 0x80, 0x08, 0x0b,
+		// data section: (data (i32.add (global.get 0) (i32.const 16)) "x")
+		0x0b, 0x0a, 0x01, 0x00, 0x23, 0x00, 0x41, 0x10, 0x6a, 0x0b, 0x01, 'x',
+	}
+
+	m, err := wasm.ReadModule(bytes.NewReader(raw), nil)
+	if err != nil {
+		t.Fatalf("error reading module %v", err)
+	}
+	mem := m.LinearMemoryIndexSpace[0]
+	if len(mem) != 1041 || mem[1040] != 'x' {
+		t.Fatalf("data segment was not written at offset 1040")
+	}
+}
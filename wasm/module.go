@@ -45,6 +45,7 @@ type Module struct {
 	Function *SectionFunctions
 	Table    *SectionTables
 	Memory   *SectionMemories
+	Tags     *SectionTags
 	Global   *SectionGlobals
 	Export   *SectionExports
 	Start    *SectionStartFunction
@@ -56,6 +57,7 @@ type Module struct {
 	// The function index space of the module
 	FunctionIndexSpace []Function
 	GlobalIndexSpace   []GlobalEntry
+	TagIndexSpace      []Tag
 
 	// function indices into the global function space
 	// the limit of each table is its capacity (cap)
@@ -67,6 +69,7 @@ type Module struct {
 		Globals  int
 		Tables   int
 		Memories int
+		Tags     int
 	}
 }
 
@@ -86,6 +89,24 @@ func (m *Module) Custom(name string) *SectionCustom {
 	return nil
 }
 
+// StripNames removes the name section of the module, if it has one.
+func (m *Module) StripNames() {
+	sections := m.Sections[:0]
+	for _, s := range m.Sections {
+		if c, ok := s.(*SectionCustom); !ok || c.Name != CustomSectionName {
+			sections = append(sections, s)
+		}
+	}
+	m.Sections = sections
+	customs := m.Customs[:0]
+	for _, c := range m.Customs {
+		if c.Name != CustomSectionName {
+			customs = append(customs, c)
+		}
+	}
+	m.Customs = customs
+}
+
 // NewModule creates a new empty module
 func NewModule() *Module {
 	return &Module{
@@ -107,7 +128,7 @@ type ResolveFunc func(name string) (*Module, error)
 
 // DecodeModule is the same as ReadModule, but it only decodes the module without
 // initializing the index space or resolving imports.
-func DecodeModule(r io.Reader) (*Module, error) {
+func DecodeModule(r io.Reader, features ...Features) (*Module, error) {
 	reader := &readpos.ReadPos{
 		R:      r,
 		CurPos: 0,
@@ -131,19 +152,27 @@ func DecodeModule(r io.Reader) (*Module, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := m.CheckFeatures(featuresOf(features)); err != nil {
+		return nil, err
+	}
 
 	return m, nil
 }
 
 // ReadModule reads a module from the reader r. resolvePath must take a string
 // and a return a reader to the module pointed to by the string.
-func ReadModule(r io.Reader, resolvePath ResolveFunc) (*Module, error) {
-	m, err := DecodeModule(r)
+//
+// The module may only use the given features, which are combined. All the
+// features supported by wagon are enabled if none is given, and the module
+// must be of the MVP if FeaturesMVP is given. A DisabledFeatureError is
+// returned if the module uses any other feature.
+func ReadModule(r io.Reader, resolvePath ResolveFunc, features ...Features) (*Module, error) {
+	m, err := DecodeModule(r, features...)
 	if err != nil {
 		return nil, err
 	}
 
-	m.LinearMemoryIndexSpace = make([][]byte, 1)
+	m.LinearMemoryIndexSpace = make([][]byte, m.memoryCount())
 	if m.Table != nil {
 		m.TableIndexSpace = make([][]TableEntry, int(len(m.Table.Entries)))
 	}
@@ -161,6 +190,7 @@ func ReadModule(r io.Reader, resolvePath ResolveFunc) (*Module, error) {
 
 	for _, fn := range []func() error{
 		m.populateGlobals,
+		m.populateTags,
 		m.populateFunctions,
 		m.populateTables,
 		m.populateLinearMemory,
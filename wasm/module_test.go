@@ -311,3 +311,59 @@ func TestFunctionName(t *testing.T) {
 		}
 	}
 }
+
+func TestDataSegmentOutOfBounds(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		raw  []byte
+		err  error
+	}{
+		{
+			name: "memory",
+			raw: []byte{
+				// (memory 1)
+				0x05, 0x03, 0x01, 0x00, 0x01,
+				// (data (i32.const 0x10000) "x")
+				0x0b, 0x09, 0x01, 0x00, 0x41, 0x80, 0x80, 0x04, 0x0b, 0x01, 'x',
+			},
+			err: wasm.OutsizeError{ImmType: "Memory", Size: 0x10001, Max: 0x10000},
+		},
+		{
+			name: "memory64",
+			raw: []byte{
+				// (memory i64 1)
+				0x05, 0x03, 0x01, 0x04, 0x01,
+				// (data (i64.const 0x10000000000) "x")
+				0x0b, 0x0c, 0x01, 0x00, 0x42, 0x80, 0x80, 0x80, 0x80, 0x80, 0x20, 0x0b, 0x01, 'x',
+			},
+			err: wasm.OutsizeError{ImmType: "Memory", Size: 0x10000000001, Max: 0x10000},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			raw := append([]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00}, tc.raw...)
+			if _, err := wasm.ReadModule(bytes.NewReader(raw), nil); err != tc.err {
+				t.Errorf("got error %v, want %v", err, tc.err)
+			}
+		})
+	}
+}
+
+func TestStripNames(t *testing.T) {
+	names := &wasm.SectionCustom{Name: wasm.CustomSectionName}
+	other := &wasm.SectionCustom{Name: "producers"}
+	types := &wasm.SectionTypes{}
+	m := wasm.NewModule()
+	m.Sections = []wasm.Section{types, names, other}
+	m.Customs = []*wasm.SectionCustom{names, other}
+
+	m.StripNames()
+	if want := []wasm.Section{types, other}; !reflect.DeepEqual(m.Sections, want) {
+		t.Errorf("got sections %v, want %v", m.Sections, want)
+	}
+	if want := []*wasm.SectionCustom{other}; !reflect.DeepEqual(m.Customs, want) {
+		t.Errorf("got custom sections %v, want %v", m.Customs, want)
+	}
+	if m.Custom(wasm.CustomSectionName) != nil {
+		t.Error("the name section was not removed")
+	}
+}
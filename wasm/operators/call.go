@@ -4,7 +4,19 @@
 
 package operators
 
+import (
+	"github.com/go-interpreter/wagon/wasm"
+)
+
 var (
 	Call         = newPolymorphicOp(0x10, "call")
 	CallIndirect = newPolymorphicOp(0x11, "call_indirect")
+
+	// Tail calls, see https://github.com/WebAssembly/tail-call
+	ReturnCall         = newPolymorphicOp(0x12, "return_call")
+	ReturnCallIndirect = newPolymorphicOp(0x13, "return_call_indirect")
 )
+
+func init() {
+	setFeature(wasm.FeatureTailCall, ReturnCall, ReturnCallIndirect)
+}
@@ -20,4 +20,16 @@ var (
 	BrIf        = newOp(0x0d, "br_if", []wasm.ValueType{wasm.ValueTypeI32}, noReturn)
 	BrTable     = newPolymorphicOp(0x0e, "br_table")
 	Return      = newPolymorphicOp(0x0f, "return")
+
+	// Exception handling, see https://github.com/WebAssembly/exception-handling
+	Try      = newOp(0x06, "try", nil, noReturn)
+	Catch    = newPolymorphicOp(0x07, "catch")
+	Throw    = newPolymorphicOp(0x08, "throw")
+	Rethrow  = newPolymorphicOp(0x09, "rethrow")
+	Delegate = newOp(0x18, "delegate", nil, noReturn)
+	CatchAll = newOp(0x19, "catch_all", nil, noReturn)
 )
+
+func init() {
+	setFeature(wasm.FeatureExceptions, Try, Catch, Throw, Rethrow, Delegate, CatchAll)
+}
@@ -6,6 +6,7 @@ package operators
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/go-interpreter/wagon/wasm"
 )
@@ -62,3 +63,43 @@ var (
 	F64ConvertUI64 = newConversionOp(0xba, "f64.convert_u/i64")
 	F64PromoteF32  = newConversionOp(0xbb, "f64.promote/f32")
 )
+
+// Sign-extension operators.
+var (
+	I32Extend8S  = newOp(0xc0, "i32.extend8_s", []wasm.ValueType{wasm.ValueTypeI32}, wasm.ValueTypeI32)
+	I32Extend16S = newOp(0xc1, "i32.extend16_s", []wasm.ValueType{wasm.ValueTypeI32}, wasm.ValueTypeI32)
+	I64Extend8S  = newOp(0xc2, "i64.extend8_s", []wasm.ValueType{wasm.ValueTypeI64}, wasm.ValueTypeI64)
+	I64Extend16S = newOp(0xc3, "i64.extend16_s", []wasm.ValueType{wasm.ValueTypeI64}, wasm.ValueTypeI64)
+	I64Extend32S = newOp(0xc4, "i64.extend32_s", []wasm.ValueType{wasm.ValueTypeI64}, wasm.ValueTypeI64)
+)
+
+// PrefixMisc is the prefix of the non-trapping float-to-int conversions and
+// of the bulk memory operators.
+const PrefixMisc = 0xfc
+
+func newSatConversionOp(internal byte, code uint32, name string) byte {
+	matches := reCvrtOp.FindStringSubmatch(strings.Replace(name, ":sat", "", 1))
+	if len(matches) == 0 {
+		panic(name + " is not a conversion operator")
+	}
+	return newPrefixedOp(internal, PrefixMisc, code, name, []wasm.ValueType{valType(matches[2])}, valType(matches[1]))
+}
+
+// Non-trapping float-to-int conversions, which saturate instead of trapping
+// on overflow, and convert NaN to 0.
+var (
+	I32TruncSSatF32 = newSatConversionOp(0xe0, 0, "i32.trunc_s:sat/f32")
+	I32TruncUSatF32 = newSatConversionOp(0xe1, 1, "i32.trunc_u:sat/f32")
+	I32TruncSSatF64 = newSatConversionOp(0xe2, 2, "i32.trunc_s:sat/f64")
+	I32TruncUSatF64 = newSatConversionOp(0xe3, 3, "i32.trunc_u:sat/f64")
+	I64TruncSSatF32 = newSatConversionOp(0xe4, 4, "i64.trunc_s:sat/f32")
+	I64TruncUSatF32 = newSatConversionOp(0xe5, 5, "i64.trunc_u:sat/f32")
+	I64TruncSSatF64 = newSatConversionOp(0xe6, 6, "i64.trunc_s:sat/f64")
+	I64TruncUSatF64 = newSatConversionOp(0xe7, 7, "i64.trunc_u:sat/f64")
+)
+
+func init() {
+	setFeature(wasm.FeatureSignExtension, I32Extend8S, I32Extend16S, I64Extend8S, I64Extend16S, I64Extend32S)
+	setFeature(wasm.FeatureSatConversion, I32TruncSSatF32, I32TruncUSatF32, I32TruncSSatF64, I32TruncUSatF64,
+		I64TruncSSatF32, I64TruncUSatF32, I64TruncSSatF64, I64TruncUSatF64)
+}
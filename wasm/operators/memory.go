@@ -39,3 +39,14 @@ var (
 	CurrentMemory = newOp(0x3f, "memory.size", nil, wasm.ValueTypeI32)
 	GrowMemory    = newOp(0x40, "memory.grow", []wasm.ValueType{wasm.ValueTypeI32}, wasm.ValueTypeI32)
 )
+
+// Operators of the bulk memory proposal. Only memory.copy and memory.fill
+// are supported.
+var (
+	MemoryCopy = newPrefixedOp(0xe8, PrefixMisc, 10, "memory.copy", []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32}, noReturn)
+	MemoryFill = newPrefixedOp(0xe9, PrefixMisc, 11, "memory.fill", []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32}, noReturn)
+)
+
+func init() {
+	setFeature(wasm.FeatureBulkMemory, MemoryCopy, MemoryFill)
+}
@@ -28,6 +28,16 @@ type Op struct {
 	Polymorphic bool
 	Args        []wasm.ValueType // an array of value types used by the operator as arguments, is nil for polymorphic operators
 	Returns     wasm.ValueType   // the value returned (pushed) by the operator, is 0 for polymorphic operators
+
+	// The proposal the operator belongs to, if it is not an operator
+	// of the MVP.
+	Feature wasm.Features
+
+	// Prefixed operators are encoded with their Prefix byte followed by
+	// PrefixedCode, as a varuint32. Their Code is only used by wagon
+	// internally, see NewPrefixed.
+	Prefix       byte
+	PrefixedCode uint32
 }
 
 func (o Op) IsValid() bool {
@@ -64,6 +74,33 @@ func newPolymorphicOp(code byte, name string) byte {
 	return code
 }
 
+// setFeature marks the operators with the given opcodes as belonging to
+// the proposal f.
+func setFeature(f wasm.Features, codes ...byte) {
+	for _, code := range codes {
+		ops[code].Feature = f
+	}
+}
+
+// newPrefixedOp registers the prefixed operator prefix code with the
+// internal opcode internal.
+func newPrefixedOp(internal, prefix byte, code uint32, name string, args []wasm.ValueType, returns wasm.ValueType) byte {
+	newOp(internal, name, args, returns)
+	ops[internal].Prefix = prefix
+	ops[internal].PrefixedCode = code
+	internalOpcodes[internal] = true
+	prefixedOps[prefixedOpcode{prefix, code}] = internal
+	return internal
+}
+
+type prefixedOpcode struct {
+	prefix byte
+	code   uint32
+}
+
+// prefixedOps maps the prefixed opcodes to their internal opcodes.
+var prefixedOps = map[prefixedOpcode]byte{}
+
 type InvalidOpcodeError byte
 
 func (e InvalidOpcodeError) Error() string {
@@ -85,3 +122,29 @@ func New(code byte) (Op, error) {
 	}
 	return op, nil
 }
+
+// InvalidPrefixedOpcodeError is returned by NewPrefixed for unknown
+// prefixed opcodes.
+type InvalidPrefixedOpcodeError struct {
+	Prefix byte
+	Code   uint32
+}
+
+func (e InvalidPrefixedOpcodeError) Error() string {
+	return fmt.Sprintf("Invalid opcode: %#x %d", e.Prefix, e.Code)
+}
+
+// IsPrefix returns whether code is the prefix of two-byte opcodes.
+func IsPrefix(code byte) bool {
+	return code == PrefixMisc
+}
+
+// NewPrefixed returns the Op object for the opcode code prefixed by prefix.
+// If it is invalid, an InvalidPrefixedOpcodeError is returned.
+func NewPrefixed(prefix byte, code uint32) (Op, error) {
+	internal, ok := prefixedOps[prefixedOpcode{prefix, code}]
+	if !ok {
+		return Op{}, InvalidPrefixedOpcodeError{prefix, code}
+	}
+	return ops[internal], nil
+}
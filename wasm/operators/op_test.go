@@ -28,3 +28,21 @@ func TestNew(t *testing.T) {
 		t.Fatalf("0xff: operator %v is valid (should be invalid)", op2)
 	}
 }
+
+func TestNewPrefixed(t *testing.T) {
+	op, err := NewPrefixed(PrefixMisc, 11)
+	if err != nil {
+		t.Fatalf("unexpected error from NewPrefixed: %v", err)
+	}
+	if op.Name != "memory.fill" || op.Code != MemoryFill || op.Prefix != PrefixMisc || op.PrefixedCode != 11 {
+		t.Fatalf("0xfc 11: unexpected Op %v", op)
+	}
+
+	// The internal opcodes of prefixed operators are not valid opcodes.
+	if _, err := New(MemoryFill); err == nil {
+		t.Fatalf("%#x: expected error while getting Op value", MemoryFill)
+	}
+	if _, err := NewPrefixed(PrefixMisc, 1000); err != (InvalidPrefixedOpcodeError{PrefixMisc, 1000}) {
+		t.Fatalf("0xfc 1000: got error %v", err)
+	}
+}
@@ -6,9 +6,9 @@ package operators
 
 import "github.com/go-interpreter/wagon/wasm"
 
-// These opcodes implement optimizations in wagon execution, and are invalid
-// opcodes for any uses other than internal use. Expect them to change at any
-// time.
+// These opcodes implement optimizations in wagon execution, or stand for
+// prefixed operators, see newPrefixedOp. They are invalid opcodes for any
+// uses other than internal use. Expect them to change at any time.
 // If these opcodes are ever used in future wasm instructions, feel free to
 // reassign them to other free opcodes.
 var (
@@ -45,8 +45,27 @@ const (
 	SectionIDElement  SectionID = 9
 	SectionIDCode     SectionID = 10
 	SectionIDData     SectionID = 11
+	SectionIDTag      SectionID = 13
 )
 
+// sectionOrder is the position of each known non-custom section in a module.
+// Sections introduced by proposals do not necessarily have IDs that increase
+// in the order in which they must appear.
+var sectionOrder = map[SectionID]uint8{
+	SectionIDType:     1,
+	SectionIDImport:   2,
+	SectionIDFunction: 3,
+	SectionIDTable:    4,
+	SectionIDMemory:   5,
+	SectionIDTag:      6,
+	SectionIDGlobal:   7,
+	SectionIDExport:   8,
+	SectionIDStart:    9,
+	SectionIDElement:  10,
+	SectionIDCode:     11,
+	SectionIDData:     12,
+}
+
 func (s SectionID) String() string {
 	n, ok := map[SectionID]string{
 		SectionIDCustom:   "custom",
@@ -61,6 +80,7 @@ func (s SectionID) String() string {
 		SectionIDElement:  "element",
 		SectionIDCode:     "code",
 		SectionIDData:     "data",
+		SectionIDTag:      "tag",
 	}[s]
 	if !ok {
 		return "unknown"
@@ -106,7 +126,7 @@ func (e MissingSectionError) Error() string {
 }
 
 type sectionsReader struct {
-	lastSecOrder uint8 // previous non-custom sectionid
+	lastSecOrder uint8 // order of the previous non-custom section
 	m            *Module
 }
 
@@ -139,10 +159,14 @@ func (sr *sectionsReader) readSection(r *readpos.ReadPos) (bool, error) {
 		return false, err
 	}
 	if id != uint8(SectionIDCustom) {
-		if id <= sr.lastSecOrder {
+		order, ok := sectionOrder[SectionID(id)]
+		if !ok {
+			return false, InvalidSectionIDError(id)
+		}
+		if order <= sr.lastSecOrder {
 			return false, fmt.Errorf("wasm: sections must occur at most once and in the prescribed order")
 		}
-		sr.lastSecOrder = id
+		sr.lastSecOrder = order
 	}
 
 	s := RawSection{ID: SectionID(id)}
@@ -190,6 +214,10 @@ func (sr *sectionsReader) readSection(r *readpos.ReadPos) (bool, error) {
 		logger.Println("section memory")
 		m.Memory = &SectionMemories{}
 		sec = m.Memory
+	case SectionIDTag:
+		logger.Println("section tag")
+		m.Tags = &SectionTags{}
+		sec = m.Tags
 	case SectionIDGlobal:
 		logger.Println("section global")
 		m.Global = &SectionGlobals{}
@@ -410,6 +438,14 @@ func (i *ImportEntry) UnmarshalWASM(r io.Reader) error {
 		if err == nil {
 			i.Type = GlobalVarImport{gl}
 		}
+	case ExternalTag:
+		logger.Println("importing tag")
+		var tag Tag
+
+		err = tag.UnmarshalWASM(r)
+		if err == nil {
+			i.Type = TagImport{tag}
+		}
 	default:
 		return InvalidExternalError(kind)
 	}
@@ -546,6 +582,44 @@ func (s *SectionMemories) WritePayload(w io.Writer) error {
 	return nil
 }
 
+// SectionTags declares the exception tags defined by a module.
+type SectionTags struct {
+	RawSection
+	Entries []Tag
+}
+
+func (*SectionTags) SectionID() SectionID {
+	return SectionIDTag
+}
+
+func (s *SectionTags) ReadPayload(r io.Reader) error {
+	count, err := leb128.ReadVarUint32(r)
+	if err != nil {
+		return err
+	}
+	s.Entries = make([]Tag, 0, getInitialCap(count))
+	for i := uint32(0); i < count; i++ {
+		var entry Tag
+		if err = entry.UnmarshalWASM(r); err != nil {
+			return err
+		}
+		s.Entries = append(s.Entries, entry)
+	}
+	return nil
+}
+
+func (s *SectionTags) WritePayload(w io.Writer) error {
+	if _, err := leb128.WriteVarUint32(w, uint32(len(s.Entries))); err != nil {
+		return err
+	}
+	for _, e := range s.Entries {
+		if err := e.MarshalWASM(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // SectionGlobals defines the value of all global variables declared in a module.
 type SectionGlobals struct {
 	RawSection
@@ -590,6 +664,13 @@ func (s *SectionGlobals) WritePayload(w io.Writer) error {
 type GlobalEntry struct {
 	Type GlobalVar // Type holds information about the value type and mutability of the variable
 	Init []byte    // Init is an initializer expression that computes the initial value of the variable
+
+	// Cell, if non-nil, holds the value of a global shared by the
+	// instances of the module with other modules or the host. It is set
+	// when the global is imported by another module, or by the host to
+	// bind the global to a value of its own, and takes precedence over
+	// Init. The instances of a module otherwise each have their own value.
+	Cell *Global
 }
 
 func (g *GlobalEntry) UnmarshalWASM(r io.Reader) error {
@@ -1002,17 +1083,39 @@ func (s *SectionData) WritePayload(w io.Writer) error {
 
 // DataSegment describes a group of repeated elements that begin at a specified offset in the linear memory
 type DataSegment struct {
-	Index  uint32 // The index into the global linear memory space, should always be 0 in the MVP.
+	Index  uint32 // The index into the global linear memory space
 	Offset []byte // initializer expression for computing the offset for placing elements, should return an i32 value
 	Data   []byte
 }
 
-func (s *DataSegment) UnmarshalWASM(r io.Reader) error {
-	var err error
+// Flags of the data segments header. Segments of the MVP are always
+// written to the first memory, and have a zero header. Segments
+// written to other memories have an explicit memory index following the
+// header.
+const (
+	dataSegmentActive         = 0x0
+	dataSegmentActiveMemIndex = 0x2
+)
 
-	if s.Index, err = leb128.ReadVarUint32(r); err != nil {
+// ErrInvalidDataSegmentFlags is returned when decoding a data segment
+// with an unsupported header.
+var ErrInvalidDataSegmentFlags = errors.New("wasm: invalid data segment flags")
+
+func (s *DataSegment) UnmarshalWASM(r io.Reader) error {
+	flags, err := leb128.ReadVarUint32(r)
+	if err != nil {
 		return err
 	}
+	switch flags {
+	case dataSegmentActive:
+		s.Index = 0
+	case dataSegmentActiveMemIndex:
+		if s.Index, err = leb128.ReadVarUint32(r); err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidDataSegmentFlags
+	}
 	if s.Offset, err = readInitExpr(r); err != nil {
 		return err
 	}
@@ -1021,8 +1124,17 @@ func (s *DataSegment) UnmarshalWASM(r io.Reader) error {
 }
 
 func (s *DataSegment) MarshalWASM(w io.Writer) error {
-	if _, err := leb128.WriteVarUint32(w, s.Index); err != nil {
-		return err
+	if s.Index == 0 {
+		if _, err := leb128.WriteVarUint32(w, dataSegmentActive); err != nil {
+			return err
+		}
+	} else {
+		if _, err := leb128.WriteVarUint32(w, dataSegmentActiveMemIndex); err != nil {
+			return err
+		}
+		if _, err := leb128.WriteVarUint32(w, s.Index); err != nil {
+			return err
+		}
 	}
 	if _, err := w.Write(s.Offset); err != nil {
 		return err
@@ -1047,6 +1159,9 @@ const (
 	NameModule   = NameType(0)
 	NameFunction = NameType(1)
 	NameLocal    = NameType(2)
+
+	// NameGlobal is a subsection of the extended name section proposal.
+	NameGlobal = NameType(7)
 )
 
 // NameSection is a custom section that stores names of modules, functions and locals for debugging purposes.
@@ -1102,6 +1217,8 @@ func (s *NameSection) Decode(typ NameType) (NameSubsection, error) {
 		sub = &FunctionNames{}
 	case NameLocal:
 		sub = &LocalNames{}
+	case NameGlobal:
+		sub = &GlobalNames{}
 	default:
 		return nil, fmt.Errorf("unsupported name subsection: %x", typ)
 	}
@@ -1121,6 +1238,7 @@ func (s *NameSection) Decode(typ NameType) (NameSubsection, error) {
 //	* ModuleName
 //	* FunctionNames
 //	* LocalNames
+//	* GlobalNames
 type NameSubsection interface {
 	Marshaler
 	Unmarshaler
@@ -1160,6 +1278,22 @@ func (s *FunctionNames) MarshalWASM(w io.Writer) error {
 	return s.Names.MarshalWASM(w)
 }
 
+// GlobalNames is a set of names for globals.
+type GlobalNames struct {
+	Names NameMap
+}
+
+func (*GlobalNames) isNameSubsection() {}
+
+func (s *GlobalNames) UnmarshalWASM(r io.Reader) error {
+	s.Names = make(NameMap)
+	return s.Names.UnmarshalWASM(r)
+}
+
+func (s *GlobalNames) MarshalWASM(w io.Writer) error {
+	return s.Names.MarshalWASM(w)
+}
+
 // LocalNames is a set of local variable names for functions.
 type LocalNames struct {
 	// Funcs maps a function index to a set of variable names.
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"io/ioutil"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/go-interpreter/wagon/wasm"
@@ -89,3 +90,104 @@ func TestSectionCustom(t *testing.T) {
 
 	})
 }
+
+func TestSectionTags(t *testing.T) {
+	raw := []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		// type section: (func (param i32))
+		0x01, 0x05, 0x01, 0x60, 0x01, 0x7f, 0x00,
+		// tag section: (tag (type 0))
+		0x0d, 0x03, 0x01, 0x00, 0x00,
+	}
+
+	m, err := wasm.ReadModule(bytes.NewReader(raw), nil)
+	if err != nil {
+		t.Fatalf("error reading module %v", err)
+	}
+	if m.Tags == nil || len(m.Tags.Entries) != 1 {
+		t.Fatalf("got tag section %+v, want 1 entry", m.Tags)
+	}
+	if len(m.TagIndexSpace) != 1 {
+		t.Fatalf("got %d tags in the index space, want 1", len(m.TagIndexSpace))
+	}
+	sig, err := m.GetTagSig(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sig.ParamTypes) != 1 || sig.ParamTypes[0] != wasm.ValueTypeI32 {
+		t.Fatalf("got tag signature %v, want (func (param i32))", sig)
+	}
+	if _, err := m.GetTagSig(1); err != wasm.InvalidTagIndexError(1) {
+		t.Fatalf("got error %v, want %v", err, wasm.InvalidTagIndexError(1))
+	}
+
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), raw) {
+		t.Fatalf("encoded module mismatch:\ngot:  %x\nwant: %x", buf.Bytes(), raw)
+	}
+}
+
+func TestSectionDataMemoryIndex(t *testing.T) {
+	raw := []byte{
+		0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00,
+		// memory section: (memory 1) (memory 1)
+		0x05, 0x05, 0x02, 0x00, 0x01, 0x00, 0x01,
+		// data section: (data (i32.const 0) "a") (data 1 (i32.const 2) "b")
+		0x0b, 0x0e, 0x02,
+		0x00, 0x41, 0x00, 0x0b, 0x01, 'a',
+		0x02, 0x01, 0x41, 0x02, 0x0b, 0x01, 'b',
+	}
+
+	m, err := wasm.ReadModule(bytes.NewReader(raw), nil)
+	if err != nil {
+		t.Fatalf("error reading module %v", err)
+	}
+	if got := m.Data.Entries[1].Index; got != 1 {
+		t.Fatalf("got data segment memory index %d, want 1", got)
+	}
+	want := [][]byte{[]byte("a"), []byte("\x00\x00b")}
+	if !reflect.DeepEqual(m.LinearMemoryIndexSpace, want) {
+		t.Fatalf("got linear memories %q, want %q", m.LinearMemoryIndexSpace, want)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, m); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), raw) {
+		t.Fatalf("encoded module mismatch:\ngot:  %x\nwant: %x", buf.Bytes(), raw)
+	}
+}
+
+func TestGlobalNames(t *testing.T) {
+	raw := []byte{
+		// subsection 7: 2 names, $sp and $heap
+		0x07, 0x0b, 0x02, 0x00, 0x02, 's', 'p', 0x02, 0x04, 'h', 'e', 'a', 'p',
+	}
+	var nSec wasm.NameSection
+	if err := nSec.UnmarshalWASM(bytes.NewReader(raw)); err != nil {
+		t.Fatal(err)
+	}
+	sub, err := nSec.Decode(wasm.NameGlobal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, ok := sub.(*wasm.GlobalNames)
+	if !ok {
+		t.Fatalf("got a %T, want a *wasm.GlobalNames", sub)
+	}
+	if want := (wasm.NameMap{0: "sp", 2: "heap"}); !reflect.DeepEqual(names.Names, want) {
+		t.Fatalf("got %v, want %v", names.Names, want)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := nSec.MarshalWASM(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), raw) {
+		t.Fatalf("got %x, want %x", buf.Bytes(), raw)
+	}
+}
@@ -254,6 +254,9 @@ func (t *Table) UnmarshalWASM(r io.Reader) error {
 	if err != nil {
 		return err
 	}
+	if t.Limits.Is64() {
+		return errors.New("wasm: invalid limit flag for a table")
+	}
 	return err
 }
 
@@ -279,6 +282,41 @@ func (m *Memory) MarshalWASM(w io.Writer) error {
 	return m.Limits.MarshalWASM(w)
 }
 
+// TagAttributeException is the only valid attribute of a tag, describing
+// an exception that can be thrown and caught.
+const TagAttributeException uint8 = 0x00
+
+// Tag describes an exception tag declared or imported by a module, as defined
+// by the exception handling proposal:
+// https://github.com/WebAssembly/exception-handling
+type Tag struct {
+	Attribute uint8  // must be TagAttributeException
+	Type      uint32 // index into the type section, the signature must have no results
+}
+
+// UnmarshalWASM decodes the attribute and the type index of the tag from r.
+func (t *Tag) UnmarshalWASM(r io.Reader) error {
+	a, err := ReadByte(r)
+	if err != nil {
+		return err
+	}
+	if a != TagAttributeException {
+		return fmt.Errorf("wasm: invalid tag attribute: %d", a)
+	}
+	t.Attribute = a
+	t.Type, err = leb128.ReadVarUint32(r)
+	return err
+}
+
+// MarshalWASM encodes the attribute and the type index of the tag to w.
+func (t *Tag) MarshalWASM(w io.Writer) error {
+	if err := writeByte(w, t.Attribute); err != nil {
+		return err
+	}
+	_, err := leb128.WriteVarUint32(w, t.Type)
+	return err
+}
+
 // External describes the kind of the entry being imported or exported.
 type External uint8
 
@@ -287,6 +325,7 @@ const (
 	ExternalTable    External = 1
 	ExternalMemory   External = 2
 	ExternalGlobal   External = 3
+	ExternalTag      External = 4
 )
 
 func (e External) String() string {
@@ -299,6 +338,8 @@ func (e External) String() string {
 		return "memory"
 	case ExternalGlobal:
 		return "global"
+	case ExternalTag:
+		return "tag"
 	default:
 		return "<unknown external_kind>"
 	}
@@ -316,11 +357,24 @@ func (e External) MarshalWASM(w io.Writer) error {
 	return err
 }
 
+// LimitsMemory64 is set in the flags of the limits of a 64-bit linear
+// memory, as defined by the memory64 proposal:
+// https://github.com/WebAssembly/memory64
+const LimitsMemory64 uint8 = 0x04
+
 // ResizableLimits describe the limit of a table or linear memory.
+//
+// Initial and Maximum are uint64 values, to hold the limits of the 64-bit
+// memories of the memory64 proposal.
 type ResizableLimits struct {
-	Flags   uint8  // 1 if the Maximum field is valid, 0 otherwise
-	Initial uint32 // initial length (in units of table elements or wasm pages)
-	Maximum uint32 // If flags is 1, it describes the maximum size of the table or memory
+	Flags   uint8  // bit 0 is set if the Maximum field is valid, bit 2 for 64-bit memories
+	Initial uint64 // initial length (in units of table elements or wasm pages)
+	Maximum uint64 // If flags bit 0 is set, it describes the maximum size of the table or memory
+}
+
+// Is64 returns whether the limits are those of a 64-bit linear memory.
+func (lim *ResizableLimits) Is64() bool {
+	return lim.Flags&LimitsMemory64 != 0
 }
 
 func (lim *ResizableLimits) UnmarshalWASM(r io.Reader) error {
@@ -329,19 +383,30 @@ func (lim *ResizableLimits) UnmarshalWASM(r io.Reader) error {
 	if err != nil {
 		return err
 	}
-	if f != 0 && f != 1 {
+	if f&^(0x1|LimitsMemory64) != 0 {
 		return errors.New("wasm: invalid limit flag")
 	}
 	lim.Flags = f
 
-	lim.Initial, err = leb128.ReadVarUint32(r)
+	readLimit := func() (uint64, error) {
+		if lim.Is64() {
+			return leb128.ReadVarUint64(r)
+		}
+		v, err := leb128.ReadVarUint32(r)
+		return uint64(v), err
+	}
+
+	lim.Initial, err = readLimit()
 	if err != nil {
 		return err
 	}
 
 	lim.Maximum = math.MaxUint32
+	if lim.Is64() {
+		lim.Maximum = math.MaxUint64
+	}
 	if lim.Flags&0x1 != 0 {
-		m, err := leb128.ReadVarUint32(r)
+		m, err := readLimit()
 		if err != nil {
 			return err
 		}
@@ -352,17 +417,26 @@ func (lim *ResizableLimits) UnmarshalWASM(r io.Reader) error {
 
 func (lim *ResizableLimits) MarshalWASM(w io.Writer) error {
 	f := lim.Flags
-	if f != 0 && f != 1 {
+	if f&^(0x1|LimitsMemory64) != 0 {
 		return errors.New("wasm: invalid limit flag")
 	}
 	if _, err := w.Write([]byte{f}); err != nil {
 		return err
 	}
-	if _, err := leb128.WriteVarUint32(w, lim.Initial); err != nil {
+	writeLimit := func(v uint64) error {
+		var err error
+		if lim.Is64() {
+			_, err = leb128.WriteVarUint64(w, v)
+		} else {
+			_, err = leb128.WriteVarUint32(w, uint32(v))
+		}
+		return err
+	}
+	if err := writeLimit(lim.Initial); err != nil {
 		return err
 	}
 	if lim.Flags&0x1 != 0 {
-		if _, err := leb128.WriteVarUint32(w, lim.Maximum); err != nil {
+		if err := writeLimit(lim.Maximum); err != nil {
 			return err
 		}
 	}
@@ -0,0 +1,52 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wasm_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+func TestResizableLimits(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		raw  []byte
+		lim  wasm.ResizableLimits
+	}{
+		{"no maximum", []byte{0x00, 0x01}, wasm.ResizableLimits{Initial: 1, Maximum: math.MaxUint32}},
+		{"maximum", []byte{0x01, 0x01, 0x02}, wasm.ResizableLimits{Flags: 1, Initial: 1, Maximum: 2}},
+		{"memory64", []byte{0x04, 0x01}, wasm.ResizableLimits{Flags: 4, Initial: 1, Maximum: math.MaxUint64}},
+		{
+			"memory64 maximum",
+			[]byte{0x05, 0x01, 0x80, 0x80, 0x80, 0x80, 0x10},
+			wasm.ResizableLimits{Flags: 5, Initial: 1, Maximum: 1 << 32},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var lim wasm.ResizableLimits
+			if err := lim.UnmarshalWASM(bytes.NewReader(tc.raw)); err != nil {
+				t.Fatal(err)
+			}
+			if lim != tc.lim {
+				t.Fatalf("got %+v, want %+v", lim, tc.lim)
+			}
+			buf := new(bytes.Buffer)
+			if err := lim.MarshalWASM(buf); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buf.Bytes(), tc.raw) {
+				t.Fatalf("encoded limits: got %x, want %x", buf.Bytes(), tc.raw)
+			}
+		})
+	}
+
+	var table wasm.Table
+	if err := table.UnmarshalWASM(bytes.NewReader([]byte{0x70, 0x04, 0x01})); err == nil {
+		t.Error("64-bit table limits were accepted")
+	}
+}
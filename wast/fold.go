@@ -0,0 +1,255 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wast
+
+import (
+	"strings"
+
+	"github.com/go-interpreter/wagon/disasm"
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/go-interpreter/wagon/wasm/operators"
+)
+
+// expr is a folded instruction, with the expressions computing its operands.
+type expr struct {
+	op     byte
+	text   string
+	pushes int // The number of values the instruction pushes
+	args   []*expr
+
+	// label is the number of the label of a block, loop, if or try, and
+	// body the instructions of the block, loop, then branch or do branch.
+	label    int
+	body     []*expr
+	branches []branch // The else, catch and catch_all branches
+	delegate string   // The delegate instruction ending a try, if any
+}
+
+type branch struct {
+	text string
+	body []*expr
+}
+
+// foldFrame is a block being folded.
+type foldFrame struct {
+	e     *expr
+	arity int // The number of values taken by a branch to the block
+}
+
+// list returns the list of expressions the instructions of the block are
+// appended to.
+func (f *foldFrame) list() *[]*expr {
+	if n := len(f.e.branches); n > 0 {
+		return &f.e.branches[n-1].body
+	}
+	return &f.e.body
+}
+
+// take removes and returns the expressions computing the last n operands,
+// or as many of them as precede the instruction.
+func (f *foldFrame) take(n int) []*expr {
+	l := f.list()
+	i := len(*l)
+	for i > 0 && len(*l)-i < n && (*l)[i-1].pushes == 1 {
+		i--
+	}
+	args := append([]*expr(nil), (*l)[i:]...)
+	*l = (*l)[:i]
+	return args
+}
+
+// writeFolded writes the body of a function in the folded form.
+func (w *writer) writeFolded(instr []disasm.Instr) {
+	root := &expr{}
+	frames := []*foldFrame{{e: root, arity: w.results}}
+	block := 0
+	for _, ins := range instr {
+		f := frames[len(frames)-1]
+		switch ins.Op.Code {
+		case operators.Block, operators.Loop, operators.If, operators.Try:
+			block++
+			e := &expr{op: ins.Op.Code, text: w.instrText(ins, block), label: block}
+			if ins.Immediates[0].(wasm.BlockType) != wasm.BlockTypeEmpty {
+				e.pushes = 1
+			}
+			if ins.Op.Code == operators.If {
+				e.args = f.take(1)
+			}
+			l := f.list()
+			*l = append(*l, e)
+			w.pushLabel(ins.Op.Code, block)
+			frame := &foldFrame{e: e, arity: e.pushes}
+			if ins.Op.Code == operators.Loop {
+				frame.arity = 0
+			}
+			frames = append(frames, frame)
+			continue
+		case operators.Else, operators.Catch, operators.CatchAll:
+			if len(frames) > 1 {
+				f.e.branches = append(f.e.branches, branch{text: w.instrText(ins, block-1)})
+				continue
+			}
+		case operators.End, operators.Delegate:
+			if len(frames) > 1 {
+				block--
+				w.popLabel()
+				if ins.Op.Code == operators.Delegate {
+					f.e.delegate = w.instrText(ins, block)
+				}
+				frames = frames[:len(frames)-1]
+				continue
+			}
+		}
+		pops, pushes := w.arity(ins, frames)
+		e := &expr{op: ins.Op.Code, text: w.instrText(ins, block), pushes: pushes}
+		e.args = f.take(pops)
+		l := f.list()
+		*l = append(*l, e)
+	}
+	for _, e := range root.body {
+		w.writeExpr(e, 2)
+	}
+}
+
+// arity returns the number of operands of ins, and of values it pushes.
+func (w *writer) arity(ins disasm.Instr, frames []*foldFrame) (pops, pushes int) {
+	labelArity := func(d uint32) int {
+		if int(d) >= len(frames) {
+			return 0
+		}
+		return frames[len(frames)-1-int(d)].arity
+	}
+	switch ins.Op.Code {
+	case operators.Call, operators.ReturnCall:
+		sig := w.funcSig(ins.Immediates[0].(uint32))
+		pops, pushes = len(sig.ParamTypes), len(sig.ReturnTypes)
+		if ins.Op.Code == operators.ReturnCall {
+			pushes = 0
+		}
+	case operators.CallIndirect, operators.ReturnCallIndirect:
+		sig := w.typeSig(ins.Immediates[0].(uint32))
+		pops, pushes = len(sig.ParamTypes)+1, len(sig.ReturnTypes)
+		if ins.Op.Code == operators.ReturnCallIndirect {
+			pushes = 0
+		}
+	case operators.Throw:
+		pops = len(w.tagSig(ins.Immediates[0].(uint32)).ParamTypes)
+	case operators.Br:
+		pops = labelArity(ins.Immediates[0].(uint32))
+	case operators.BrIf:
+		pushes = labelArity(ins.Immediates[0].(uint32))
+		pops = pushes + 1
+	case operators.BrTable:
+		n := ins.Immediates[0].(uint32)
+		pops = labelArity(ins.Immediates[n+1].(uint32)) + 1
+	case operators.Return:
+		pops = frames[0].arity
+	case operators.Drop, operators.SetLocal, operators.SetGlobal:
+		pops = 1
+	case operators.Select:
+		pops, pushes = 3, 1
+	case operators.GetLocal, operators.GetGlobal:
+		pushes = 1
+	case operators.TeeLocal:
+		pops, pushes = 1, 1
+	default:
+		if !ins.Op.Polymorphic {
+			pops = len(ins.Op.Args)
+			if ins.Op.Returns != wasm.ValueType(wasm.BlockTypeEmpty) {
+				pushes = 1
+			}
+		}
+	}
+	return pops, pushes
+}
+
+// writeExpr writes the folded expression e, indented by the given number
+// of tabs.
+func (w *writer) writeExpr(e *expr, indent int) {
+	w.WriteString("\n" + strings.Repeat(tab, indent) + "(" + e.text)
+	if e.label != 0 && !w.names {
+		w.Print("  ;; label = @%d", e.label)
+	}
+	for _, a := range e.args {
+		w.writeExpr(a, indent+1)
+	}
+	switch {
+	case e.label == 0:
+	case e.op == operators.If:
+		w.writeBranch("then", e.body, indent+1)
+		for _, b := range e.branches {
+			w.writeBranch(b.text, b.body, indent+1)
+		}
+	case e.op == operators.Try:
+		w.writeBranch("do", e.body, indent+1)
+		for _, b := range e.branches {
+			w.writeBranch(b.text, b.body, indent+1)
+		}
+		if e.delegate != "" {
+			w.WriteString("\n" + strings.Repeat(tab, indent+1) + "(" + e.delegate + ")")
+		}
+	default:
+		for _, b := range e.body {
+			w.writeExpr(b, indent+1)
+		}
+		if len(e.body) == 0 && !w.names {
+			w.WriteString("\n" + strings.Repeat(tab, indent))
+		}
+	}
+	w.WriteString(")")
+}
+
+// writeBranch writes a branch of an if or try.
+func (w *writer) writeBranch(text string, body []*expr, indent int) {
+	w.WriteString("\n" + strings.Repeat(tab, indent) + "(" + text)
+	for _, e := range body {
+		w.writeExpr(e, indent+1)
+	}
+	w.WriteString(")")
+}
+
+// funcSig returns the signature of the function of the given index.
+func (w *writer) funcSig(index uint32) wasm.FunctionSig {
+	if w.m.Import != nil {
+		for _, e := range w.m.Import.Entries {
+			if im, ok := e.Type.(wasm.FuncImport); ok {
+				if index == 0 {
+					return w.typeSig(im.Type)
+				}
+				index--
+			}
+		}
+	}
+	if w.m.Function != nil && int(index) < len(w.m.Function.Types) {
+		return w.typeSig(w.m.Function.Types[index])
+	}
+	return wasm.FunctionSig{}
+}
+
+// tagSig returns the signature of the tag of the given index.
+func (w *writer) tagSig(index uint32) wasm.FunctionSig {
+	if w.m.Import != nil {
+		for _, e := range w.m.Import.Entries {
+			if im, ok := e.Type.(wasm.TagImport); ok {
+				if index == 0 {
+					return w.typeSig(im.Type.Type)
+				}
+				index--
+			}
+		}
+	}
+	if w.m.Tags != nil && int(index) < len(w.m.Tags.Entries) {
+		return w.typeSig(w.m.Tags.Entries[index].Type)
+	}
+	return wasm.FunctionSig{}
+}
+
+// typeSig returns the signature of the given index in the type section.
+func (w *writer) typeSig(index uint32) wasm.FunctionSig {
+	if w.m.Types == nil || int(index) >= len(w.m.Types.Entries) {
+		return wasm.FunctionSig{}
+	}
+	return w.m.Types.Entries[index]
+}
@@ -0,0 +1,584 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wast
+
+import (
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+
+	"github.com/go-interpreter/wagon/disasm"
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+// opcodes maps the names of the operators to them. Both the names of the
+// operators package and those of the current text format are recognized,
+// e.g. get_local and local.get, or i32.wrap/i64 and i32.wrap_i64.
+var opcodes = make(map[string]ops.Op)
+
+func init() {
+	add := func(op ops.Op) {
+		opcodes[op.Name] = op
+		if name := newName(op.Name); name != op.Name {
+			opcodes[name] = op
+		}
+	}
+	for code := 0; code < 256; code++ {
+		if op, err := ops.New(byte(code)); err == nil {
+			add(op)
+		}
+	}
+	for code := uint32(0); code < 32; code++ {
+		if op, err := ops.NewPrefixed(ops.PrefixMisc, code); err == nil {
+			add(op)
+		}
+	}
+	for old, name := range map[string]string{
+		"get_local":      "local.get",
+		"set_local":      "local.set",
+		"tee_local":      "local.tee",
+		"get_global":     "global.get",
+		"set_global":     "global.set",
+		"current_memory": "memory.size",
+		"grow_memory":    "memory.grow",
+	} {
+		if op, ok := opcodes[old]; ok {
+			opcodes[name] = op
+		} else {
+			opcodes[old] = opcodes[name]
+		}
+	}
+}
+
+// newName returns the name of a conversion operator in the current text
+// format, from the old one: i32.trunc_s/f32 is i32.trunc_f32_s,
+// i32.trunc_s:sat/f32 is i32.trunc_sat_f32_s and i32.wrap/i64 is
+// i32.wrap_i64.
+func newName(name string) string {
+	i := strings.IndexByte(name, '/')
+	if i < 0 {
+		return name
+	}
+	op, from := name[:i], name[i+1:]
+	sat := strings.HasSuffix(op, ":sat")
+	op = strings.TrimSuffix(op, ":sat")
+	sign := ""
+	if strings.HasSuffix(op, "_s") || strings.HasSuffix(op, "_u") {
+		op, sign = op[:len(op)-2], op[len(op)-2:]
+	}
+	if sat {
+		op += "_sat"
+	}
+	return op + "_" + from + sign
+}
+
+// funcBuilder compiles the code of a function, or a constant expression.
+type funcBuilder struct {
+	b      *moduleBuilder
+	locals *space
+	labels []string // Identifiers of the enclosing blocks, innermost last
+	instrs []disasm.Instr
+}
+
+// funcBody compiles the locals and instructions at c of the function index
+// of type typ, whose parameters have the identifiers params.
+func (b *moduleBuilder) funcBody(index, typ uint32, params []*Token, c *cursor) wasm.FunctionBody {
+	f := &funcBuilder{b: b, locals: newSpace("local")}
+	for i := range b.typeEntries[typ].ParamTypes {
+		var id *Token
+		if i < len(params) {
+			id = params[i]
+		}
+		f.locals.define(id)
+	}
+
+	var body wasm.FunctionBody
+	addLocal := func(t wasm.ValueType) {
+		if n := len(body.Locals); n > 0 && body.Locals[n-1].Type == t {
+			body.Locals[n-1].Count++
+			return
+		}
+		body.Locals = append(body.Locals, wasm.LocalEntry{Count: 1, Type: t})
+	}
+	for c.peekList("local") {
+		lc := c.list("local")
+		if id := lc.optID(); id != nil {
+			f.locals.define(id)
+			addLocal(valueType(lc))
+			lc.close()
+			continue
+		}
+		for !lc.done() {
+			f.locals.define(nil)
+			addLocal(valueType(lc))
+		}
+	}
+
+	f.seq(c)
+	c.close()
+	body.Code = f.code()
+	if len(f.locals.names) > 0 {
+		b.localNames[index] = f.locals.nameMap()
+	}
+	return body
+}
+
+// constExpr compiles the instructions at c of a constant expression.
+func (b *moduleBuilder) constExpr(c *cursor) []byte {
+	f := &funcBuilder{b: b, locals: newSpace("local")}
+	f.seq(c)
+	c.close()
+	f.emit(ops.End)
+	return f.code()
+}
+
+func (f *funcBuilder) code() []byte {
+	code, err := disasm.Assemble(f.instrs)
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
+
+func (f *funcBuilder) emit(code byte, immediates ...interface{}) {
+	op, err := ops.New(code)
+	if err != nil {
+		panic(err)
+	}
+	f.instrs = append(f.instrs, disasm.Instr{Op: op, Immediates: immediates})
+}
+
+// optLabel compiles the optional identifier of a block.
+func (f *funcBuilder) optLabel(c *cursor) string {
+	if id := c.optID(); id != nil {
+		return id.Text
+	}
+	return ""
+}
+
+func (f *funcBuilder) pushLabel(label string) {
+	f.labels = append(f.labels, label)
+}
+
+func (f *funcBuilder) popLabel() {
+	f.labels = f.labels[:len(f.labels)-1]
+}
+
+// endLabel compiles the optional identifier following an end or else, which
+// must be the label of the block.
+func (f *funcBuilder) endLabel(c *cursor) {
+	if id := c.optID(); id != nil && id.Text != f.labels[len(f.labels)-1] {
+		errorf(id, "mismatching label %s", id.Text)
+	}
+}
+
+// label returns the depth of the label n, an identifier or a number.
+func (f *funcBuilder) label(n *node) uint32 {
+	if n.tok.Kind == VAR {
+		for i := len(f.labels) - 1; i >= 0; i-- {
+			if f.labels[i] == n.tok.Text {
+				return uint32(len(f.labels) - 1 - i)
+			}
+		}
+		errorf(n.tok, "unknown label %s", n.tok.Text)
+	}
+	if n.tok.Kind != NAT {
+		errorf(n.tok, "expected a label, got %s", describe(n))
+	}
+	return uint32(parseInt(n.tok, 32, false))
+}
+
+// blockType compiles the type of a block, which may have at most one
+// result and no parameter.
+func (f *funcBuilder) blockType(c *cursor) wasm.BlockType {
+	pos := c.pos()
+	var sig wasm.FunctionSig
+	if c.peekList("type") {
+		typ, _ := f.b.typeUse(c)
+		sig = f.b.typeEntries[typ]
+	} else {
+		sig, _ = f.b.signature(c)
+	}
+	switch {
+	case len(sig.ParamTypes) > 0:
+		errorf(pos, "block parameters are not supported")
+	case len(sig.ReturnTypes) > 1:
+		errorf(pos, "multiple block results are not supported")
+	case len(sig.ReturnTypes) == 1:
+		return wasm.BlockType(sig.ReturnTypes[0])
+	}
+	return wasm.BlockTypeEmpty
+}
+
+// expectEnd compiles the end of a block in the flat syntax.
+func (f *funcBuilder) expectEnd(c *cursor, pos *Token) {
+	if !c.peek().isAtom("end") {
+		errorf(pos, "missing end")
+	}
+	c.next()
+	f.endLabel(c)
+	f.emit(ops.End)
+}
+
+// seq compiles the instructions at c, up to the end of the list or a keyword
+// ending a block in the flat syntax, which is not consumed.
+func (f *funcBuilder) seq(c *cursor) {
+	for !c.done() {
+		n := c.next()
+		if n.isList() {
+			f.folded(n)
+			continue
+		}
+		switch n.tok.Text {
+		case "end", "else", "catch", "catch_all", "delegate":
+			c.i--
+			return
+		case "block", "loop":
+			f.pushLabel(f.optLabel(c))
+			f.emit(opcodes[n.tok.Text].Code, f.blockType(c))
+			f.seq(c)
+			f.expectEnd(c, n.tok)
+			f.popLabel()
+		case "if":
+			f.pushLabel(f.optLabel(c))
+			f.emit(ops.If, f.blockType(c))
+			f.seq(c)
+			if c.peek().isAtom("else") {
+				c.next()
+				f.endLabel(c)
+				f.elseBranch(c)
+			}
+			f.expectEnd(c, n.tok)
+			f.popLabel()
+		case "try":
+			f.pushLabel(f.optLabel(c))
+			f.emit(ops.Try, f.blockType(c))
+			f.seq(c)
+			for c.peek().isAtom("catch") {
+				c.next()
+				f.emit(ops.Catch, f.b.tags.index(c.next()))
+				f.seq(c)
+			}
+			if c.peek().isAtom("catch_all") {
+				c.next()
+				f.emit(ops.CatchAll)
+				f.seq(c)
+			}
+			if c.peek().isAtom("delegate") {
+				c.next()
+				f.popLabel()
+				f.emit(ops.Delegate, f.label(c.next()))
+				continue
+			}
+			f.expectEnd(c, n.tok)
+			f.popLabel()
+		default:
+			f.instrs = append(f.instrs, f.instr(n.tok, c))
+		}
+	}
+}
+
+// elseBranch compiles the instructions at c of an else branch. The else of
+// an empty branch is omitted, as by the reference encoder.
+func (f *funcBuilder) elseBranch(c *cursor) {
+	n := len(f.instrs)
+	f.emit(ops.Else)
+	f.seq(c)
+	if len(f.instrs) == n+1 {
+		f.instrs = f.instrs[:n]
+	}
+}
+
+// folded compiles the folded instruction n.
+func (f *funcBuilder) folded(n *node) {
+	c := elems(n)
+	switch kw := n.keyword(); kw {
+	case "":
+		errorf(n.tok, "expected an instruction, got %s", describe(n))
+	case "block", "loop":
+		f.pushLabel(f.optLabel(c))
+		f.emit(opcodes[kw].Code, f.blockType(c))
+		f.seq(c)
+		c.close()
+		f.emit(ops.End)
+		f.popLabel()
+	case "if":
+		label := f.optLabel(c)
+		bt := f.blockType(c)
+		for !c.done() && !c.peekList("then") {
+			f.operand(c.next())
+		}
+		f.pushLabel(label)
+		f.emit(ops.If, bt)
+		tc := c.list("then")
+		f.seq(tc)
+		tc.close()
+		if c.peekList("else") {
+			ec := c.list("else")
+			f.elseBranch(ec)
+			ec.close()
+		}
+		c.close()
+		f.emit(ops.End)
+		f.popLabel()
+	case "try":
+		f.pushLabel(f.optLabel(c))
+		f.emit(ops.Try, f.blockType(c))
+		dc := c.list("do")
+		f.seq(dc)
+		dc.close()
+		if c.peekList("delegate") {
+			f.popLabel()
+			dc := c.list("delegate")
+			f.emit(ops.Delegate, f.label(dc.next()))
+			dc.close()
+			c.close()
+			return
+		}
+		for c.peekList("catch") {
+			cc := c.list("catch")
+			f.emit(ops.Catch, f.b.tags.index(cc.next()))
+			f.seq(cc)
+			cc.close()
+		}
+		if c.peekList("catch_all") {
+			cc := c.list("catch_all")
+			f.emit(ops.CatchAll)
+			f.seq(cc)
+			cc.close()
+		}
+		c.close()
+		f.emit(ops.End)
+		f.popLabel()
+	default:
+		ins := f.instr(n.list[0].tok, c)
+		for !c.done() {
+			f.operand(c.next())
+		}
+		f.instrs = append(f.instrs, ins)
+	}
+}
+
+// operand compiles n, an operand of a folded instruction.
+func (f *funcBuilder) operand(n *node) {
+	if !n.isList() {
+		errorf(n.tok, "expected a folded instruction, got %s", describe(n))
+	}
+	f.folded(n)
+}
+
+// instr compiles the plain instruction tok, whose immediates are at c.
+func (f *funcBuilder) instr(tok *Token, c *cursor) disasm.Instr {
+	op, ok := opcodes[tok.Text]
+	if !ok || tok.Kind == STRING {
+		errorf(tok, "unknown operator %s", tok.Text)
+	}
+	ins := disasm.Instr{Op: op}
+	switch op.Code {
+	case ops.Block, ops.Loop, ops.If, ops.Else, ops.End, ops.Try, ops.Catch, ops.CatchAll, ops.Delegate:
+		errorf(tok, "unexpected %s", tok.Text)
+	case ops.Br, ops.BrIf, ops.Rethrow:
+		ins.Immediates = []interface{}{f.label(c.next())}
+	case ops.BrTable:
+		var targets []interface{}
+		for c.peekIndex() {
+			targets = append(targets, f.label(c.next()))
+		}
+		if len(targets) == 0 {
+			errorf(c.pos(), "expected a label, got %s", describeNext(c))
+		}
+		ins.Immediates = append([]interface{}{uint32(len(targets) - 1)}, targets...)
+	case ops.Call, ops.ReturnCall:
+		ins.Immediates = []interface{}{f.b.funcs.index(c.next())}
+	case ops.CallIndirect, ops.ReturnCallIndirect:
+		table := uint32(0)
+		if c.peekIndex() {
+			n := c.next()
+			if !c.peekList("type", "param", "result") {
+				// The type index alone, in the old syntax.
+				ins.Immediates = []interface{}{f.b.types.index(n), table}
+				break
+			}
+			table = f.b.tables.index(n)
+		}
+		typ, _ := f.b.typeUse(c)
+		ins.Immediates = []interface{}{typ, table}
+	case ops.GetLocal, ops.SetLocal, ops.TeeLocal:
+		ins.Immediates = []interface{}{f.locals.index(c.next())}
+	case ops.GetGlobal, ops.SetGlobal:
+		ins.Immediates = []interface{}{f.b.globals.index(c.next())}
+	case ops.Throw:
+		ins.Immediates = []interface{}{f.b.tags.index(c.next())}
+	case ops.I32Const:
+		ins.Immediates = []interface{}{int32(parseInt(c.next().tok, 32, true))}
+	case ops.I64Const:
+		ins.Immediates = []interface{}{int64(parseInt(c.next().tok, 64, true))}
+	case ops.F32Const:
+		ins.Immediates = []interface{}{math.Float32frombits(uint32(parseFloat(c.next().tok, 32)))}
+	case ops.F64Const:
+		ins.Immediates = []interface{}{math.Float64frombits(parseFloat(c.next().tok, 64))}
+	case ops.CurrentMemory, ops.GrowMemory, ops.MemoryFill:
+		ins.Immediates = []interface{}{f.memory(c)}
+	case ops.MemoryCopy:
+		dst := f.memory(c)
+		src := dst
+		if dst != 0 || c.peekIndex() {
+			src = f.b.mems.index(c.next())
+		}
+		ins.Immediates = []interface{}{dst, src}
+	default:
+		if align, ok := naturalAlignment(op.Name); ok {
+			ins.Immediates = f.memArg(c, align)
+		}
+	}
+	return ins
+}
+
+func describeNext(c *cursor) string {
+	if c.done() {
+		return ")"
+	}
+	return describe(c.peek())
+}
+
+// memory compiles the optional memory index of an instruction.
+func (f *funcBuilder) memory(c *cursor) uint32 {
+	if c.peekIndex() {
+		return f.b.mems.index(c.next())
+	}
+	return 0
+}
+
+// naturalAlignment returns the alignment, in log 2, of the access of a load
+// or store operator.
+func naturalAlignment(name string) (uint32, bool) {
+	i := strings.Index(name, ".load")
+	if i < 0 {
+		i = strings.Index(name, ".store")
+	}
+	if i < 0 {
+		return 0, false
+	}
+	size := strings.TrimLeft(name[i+1:], "loadstre")
+	if j := strings.IndexByte(size, '_'); j >= 0 {
+		size = size[:j]
+	}
+	if size == "" {
+		size = name[1:3] // The size of the type
+	}
+	n, _ := strconv.Atoi(size)
+	return uint32(bits.TrailingZeros(uint(n / 8))), true
+}
+
+// memArg compiles the memory index, offset and alignment of a load or store.
+func (f *funcBuilder) memArg(c *cursor, align uint32) []interface{} {
+	mem := f.memory(c)
+	var offset uint64
+	if n := c.peek(); n != nil && n.tok.Kind != STRING && strings.HasPrefix(n.tok.Text, "offset=") {
+		c.next()
+		offset = parseInt(&Token{Kind: NAT, Text: n.tok.Text[7:], Line: n.tok.Line, Column: n.tok.Column}, 64, false)
+		if !f.b.is64(mem) && offset > math.MaxUint32 {
+			errorf(n.tok, "offset out of range")
+		}
+	}
+	if n := c.peek(); n != nil && n.tok.Kind != STRING && strings.HasPrefix(n.tok.Text, "align=") {
+		c.next()
+		a := parseInt(&Token{Kind: NAT, Text: n.tok.Text[6:], Line: n.tok.Line, Column: n.tok.Column}, 32, false)
+		if a == 0 || a&(a-1) != 0 {
+			errorf(n.tok, "alignment must be a power of two")
+		}
+		align = uint32(bits.TrailingZeros64(a))
+	}
+
+	imm := []interface{}{align, uint32(offset)}
+	if f.b.is64(mem) {
+		imm[1] = offset
+	}
+	if mem != 0 {
+		imm = append(imm, mem)
+	}
+	return imm
+}
+
+// parseInt returns the value of the integer tok of the given size, which
+// may be negative if signed is set. A negative value is returned in two's
+// complement.
+func parseInt(tok *Token, size int, signed bool) uint64 {
+	if tok.Kind != NAT && (tok.Kind != INT || !signed) || !isNat(tok.Text) && !isInt(tok.Text) {
+		errorf(tok, "expected an integer, got %s", tok.Text)
+	}
+	s := strings.Replace(tok.Text, "_", "", -1)
+	neg := false
+	switch s[0] {
+	case '-':
+		neg = true
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+	base := 10
+	if strings.HasPrefix(s, "0x") {
+		s, base = s[2:], 16
+	}
+	v, err := strconv.ParseUint(s, base, size)
+	if err != nil || neg && v > 1<<uint(size-1) {
+		errorf(tok, "constant out of range")
+	}
+	if neg {
+		v = -v
+		if size < 64 {
+			v &= 1<<uint(size) - 1
+		}
+	}
+	return v
+}
+
+// parseFloat returns the bits of the float tok of the given size.
+func parseFloat(tok *Token, size int) uint64 {
+	if tok.Kind != NAT && tok.Kind != INT && tok.Kind != FLOAT {
+		errorf(tok, "expected a float, got %s", tok.Text)
+	}
+	s := strings.Replace(tok.Text, "_", "", -1)
+	var sign uint64
+	switch s[0] {
+	case '-':
+		sign = 1 << uint(size-1)
+		s = s[1:]
+	case '+':
+		s = s[1:]
+	}
+
+	mantBits := uint(23)
+	if size == 64 {
+		mantBits = 52
+	}
+	nan := uint64(1)<<uint(size-1) - 1<<mantBits // Exponent of all ones
+	switch {
+	case s == "inf":
+		return sign | nan
+	case s == "nan":
+		return sign | nan | 1<<(mantBits-1)
+	case strings.HasPrefix(s, "nan:0x"):
+		payload, err := strconv.ParseUint(s[6:], 16, 64)
+		if err != nil || payload == 0 || payload >= 1<<mantBits {
+			errorf(tok, "constant out of range")
+		}
+		return sign | nan | payload
+	}
+
+	if strings.HasPrefix(s, "0x") && !strings.ContainsAny(s, "pP") {
+		s += "p0"
+	}
+	f, err := strconv.ParseFloat(s, size)
+	if err != nil {
+		errorf(tok, "constant out of range")
+	}
+	if size == 32 {
+		return sign | uint64(math.Float32bits(float32(f)))
+	}
+	return sign | math.Float64bits(f)
+}
@@ -0,0 +1,1002 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wast
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+
+	"github.com/go-interpreter/wagon/wasm"
+	ops "github.com/go-interpreter/wagon/wasm/operators"
+)
+
+// ParseModule parses the text format of a module read from r, which may be
+// written as a module form or as its fields alone. Errors in the text are
+// returned as a *SyntaxError.
+//
+// The module is returned as decoded by wasm.DecodeModule: it is not
+// validated, and its imports are not resolved. The identifiers of the
+// module, its functions and their locals are kept in a name section.
+func ParseModule(r io.Reader) (*wasm.Module, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	bin, err := encodeText(buf)
+	if err != nil {
+		return nil, err
+	}
+	return wasm.DecodeModule(bytes.NewReader(bin))
+}
+
+// encodeText returns the binary encoding of the module whose text is buf,
+// written as a module form or as its fields alone.
+func encodeText(buf []byte) ([]byte, error) {
+	nodes, err := readNodes(newScanner("", buf))
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 1 && nodes[0].keyword() == "module" {
+		return encodeModule(nodes[0])
+	}
+	return encodeFields("", nodes)
+}
+
+// errorf reports an error at the token tok, by panicking with a
+// *SyntaxError that catchError recovers.
+func errorf(tok *Token, format string, args ...interface{}) {
+	panic(&SyntaxError{Line: tok.Line, Column: tok.Column, Msg: fmt.Sprintf(format, args...)})
+}
+
+// catchError recovers from errorf, setting *err to the error of the file.
+func catchError(file string, err *error) {
+	if e := recover(); e != nil {
+		serr, ok := e.(*SyntaxError)
+		if !ok {
+			panic(e)
+		}
+		serr.File = file
+		*err = serr
+	}
+}
+
+// node is an s-expression: an atom, or a parenthesized list.
+type node struct {
+	tok  *Token  // The atom, or the opening parenthesis of the list
+	end  *Token  // The closing parenthesis of the list
+	list []*node // The elements of the list
+}
+
+func (n *node) isList() bool {
+	return n.tok.Kind == LPAR
+}
+
+// isAtom returns whether n is the keyword or reserved word kw.
+func (n *node) isAtom(kw string) bool {
+	return n != nil && !n.isList() && n.tok.Kind != STRING && n.tok.Text == kw
+}
+
+// keyword returns the keyword starting the list n, or "" if n is not such a
+// list.
+func (n *node) keyword() string {
+	if n == nil || !n.isList() || len(n.list) == 0 {
+		return ""
+	}
+	if first := n.list[0]; !first.isList() && first.tok.Kind != STRING {
+		return first.tok.Text
+	}
+	return ""
+}
+
+// readNodes reads the s-expressions of the text of s.
+func readNodes(s *Scanner) (nodes []*node, err error) {
+	defer func() {
+		if len(s.Errors) > 0 {
+			nodes, err = nil, s.Errors[0]
+		}
+	}()
+	defer catchError(s.file, &err)
+	for tok := s.Next(); tok.Kind != EOF; tok = s.Next() {
+		nodes = append(nodes, readNode(s, tok))
+	}
+	return nodes, nil
+}
+
+// readNode reads the s-expression starting with the token tok.
+func readNode(s *Scanner, tok *Token) *node {
+	n := &node{tok: tok}
+	switch tok.Kind {
+	case RPAR:
+		errorf(tok, "unexpected )")
+	case LPAR:
+		for {
+			t := s.Next()
+			switch t.Kind {
+			case RPAR:
+				n.end = t
+				return n
+			case EOF:
+				errorf(tok, "unclosed (")
+			}
+			n.list = append(n.list, readNode(s, t))
+		}
+	}
+	return n
+}
+
+// cursor iterates over the elements of a list.
+type cursor struct {
+	n *node
+	i int
+}
+
+// elems returns a cursor over the elements of the list n following its
+// keyword.
+func elems(n *node) *cursor {
+	return &cursor{n: n, i: 1}
+}
+
+func (c *cursor) done() bool {
+	return c.i >= len(c.n.list)
+}
+
+// peek returns the next element, or nil at the end of the list.
+func (c *cursor) peek() *node {
+	if c.done() {
+		return nil
+	}
+	return c.n.list[c.i]
+}
+
+// pos returns the token of the next element, or the end of the list.
+func (c *cursor) pos() *Token {
+	if c.done() {
+		return c.n.end
+	}
+	return c.n.list[c.i].tok
+}
+
+func (c *cursor) next() *node {
+	if c.done() {
+		errorf(c.n.end, "unexpected )")
+	}
+	c.i++
+	return c.n.list[c.i-1]
+}
+
+// atom returns the next element, which must be an atom of the given kind.
+func (c *cursor) atom(kind TokenKind, what string) *Token {
+	n := c.next()
+	if n.tok.Kind != kind {
+		errorf(n.tok, "expected %s, got %s", what, describe(n))
+	}
+	return n.tok
+}
+
+func (c *cursor) str() string {
+	return c.atom(STRING, "a string").Text
+}
+
+// list returns a cursor over the next element, which must be a list starting
+// with the keyword kw.
+func (c *cursor) list(kw string) *cursor {
+	n := c.next()
+	if n.keyword() != kw {
+		errorf(n.tok, "expected (%s ...), got %s", kw, describe(n))
+	}
+	return elems(n)
+}
+
+// peekList returns whether the next element is a list starting with one of
+// the keywords.
+func (c *cursor) peekList(kws ...string) bool {
+	kw := c.peek().keyword()
+	for _, k := range kws {
+		if kw == k {
+			return true
+		}
+	}
+	return false
+}
+
+// optID returns the next element if it is an identifier, and nil otherwise.
+func (c *cursor) optID() *Token {
+	if n := c.peek(); n != nil && n.tok.Kind == VAR {
+		c.i++
+		return n.tok
+	}
+	return nil
+}
+
+// peekIndex returns whether the next element is an index.
+func (c *cursor) peekIndex() bool {
+	n := c.peek()
+	return n != nil && (n.tok.Kind == VAR || n.tok.Kind == NAT)
+}
+
+// close checks that the list has no element left.
+func (c *cursor) close() {
+	if !c.done() {
+		errorf(c.pos(), "unexpected %s", describe(c.peek()))
+	}
+}
+
+// describe returns a description of n for errors.
+func describe(n *node) string {
+	switch {
+	case n.isList():
+		if kw := n.keyword(); kw != "" {
+			return "(" + kw + " ...)"
+		}
+		return "list"
+	case n.tok.Kind == STRING:
+		return strconv.Quote(n.tok.Text)
+	}
+	return n.tok.Text
+}
+
+// space is an index space of a module, or the locals of a function.
+type space struct {
+	kind  string // Kind of the entries, for errors
+	names map[string]uint32
+	n     uint32 // Number of entries
+}
+
+func newSpace(kind string) *space {
+	return &space{kind: kind, names: make(map[string]uint32)}
+}
+
+// define adds an entry with the identifier id, if not nil, and returns its
+// index.
+func (s *space) define(id *Token) uint32 {
+	if id != nil {
+		if _, ok := s.names[id.Text]; ok {
+			errorf(id, "duplicate %s %s", s.kind, id.Text)
+		}
+		s.names[id.Text] = s.n
+	}
+	s.n++
+	return s.n - 1
+}
+
+// index returns the index referred to by n, a number or an identifier.
+func (s *space) index(n *node) uint32 {
+	switch n.tok.Kind {
+	case NAT:
+		return uint32(parseInt(n.tok, 32, false))
+	case VAR:
+		if i, ok := s.names[n.tok.Text]; ok {
+			return i
+		}
+		errorf(n.tok, "unknown %s %s", s.kind, n.tok.Text)
+	}
+	errorf(n.tok, "expected a %s index, got %s", s.kind, describe(n))
+	panic("unreachable")
+}
+
+// nameMap returns the names of the entries.
+func (s *space) nameMap() wasm.NameMap {
+	names := make(wasm.NameMap, len(s.names))
+	for name, i := range s.names {
+		names[i] = name[1:] // Strip the $
+	}
+	return names
+}
+
+// moduleBuilder compiles the fields of a module.
+//
+// The fields are compiled in two passes, as they may refer to the
+// identifiers of the fields following them: the first one defines the
+// entries of the index spaces and the explicit types, and the second one, in
+// the order of the text, compiles the type uses, code and segments.
+type moduleBuilder struct {
+	types   *space
+	funcs   *space
+	tables  *space
+	mems    *space
+	globals *space
+	tags    *space
+
+	typeEntries   []wasm.FunctionSig
+	imports       []wasm.ImportEntry
+	funcTypes     []uint32
+	bodies        []wasm.FunctionBody
+	tableEntries  []wasm.Table
+	memEntries    []wasm.Memory
+	tagEntries    []wasm.Tag
+	globalEntries []wasm.GlobalEntry
+	exports       *wasm.SectionExports
+	start         *wasm.SectionStartFunction
+	elems         []wasm.ElementSegment
+	data          []wasm.DataSegment
+
+	customs []custom
+
+	mem64      []bool // Whether each memory is a 64-bit memory
+	defined    bool   // Whether an entry has been defined, after which imports are invalid
+	localNames map[uint32]wasm.NameMap
+	later      []func() // Second pass
+}
+
+// custom is a custom section of a @custom annotation.
+type custom struct {
+	sec *wasm.SectionCustom
+	key int // The sort key of its placement, see sectionKey
+}
+
+// sectionNames are the names of the non-custom sections in the placements
+// of custom sections, in the order of the sections in a module.
+var sectionNames = []struct {
+	name string
+	id   wasm.SectionID
+}{
+	{"type", wasm.SectionIDType},
+	{"import", wasm.SectionIDImport},
+	{"func", wasm.SectionIDFunction},
+	{"table", wasm.SectionIDTable},
+	{"memory", wasm.SectionIDMemory},
+	{"tag", wasm.SectionIDTag},
+	{"global", wasm.SectionIDGlobal},
+	{"export", wasm.SectionIDExport},
+	{"start", wasm.SectionIDStart},
+	{"elem", wasm.SectionIDElement},
+	{"code", wasm.SectionIDCode},
+	{"data", wasm.SectionIDData},
+}
+
+// sectionKey returns the key sorting the non-custom section id among the
+// sections of a module: the sections are sorted by key, the custom sections
+// placed before a section getting the key of the section minus one, and
+// those placed after it the key plus one.
+func sectionKey(id wasm.SectionID) int {
+	for i, s := range sectionNames {
+		if s.id == id {
+			return 2 * (i + 1)
+		}
+	}
+	return 2 * (len(sectionNames) + 1)
+}
+
+// compileModule compiles the module form n.
+func compileModule(n *node) (*wasm.Module, error) {
+	buf, err := encodeModule(n)
+	if err != nil {
+		return nil, err
+	}
+	return wasm.DecodeModule(bytes.NewReader(buf))
+}
+
+// encodeModule returns the binary encoding of the module form n. The
+// encoding of a binary module is returned as is, without being decoded.
+func encodeModule(n *node) ([]byte, error) {
+	c := elems(n)
+	name := ""
+	if id := c.optID(); id != nil {
+		name = id.Text[1:]
+	}
+	switch {
+	case c.peek().isAtom("binary"):
+		c.next()
+		var buf bytes.Buffer
+		for !c.done() {
+			buf.WriteString(c.str())
+		}
+		return buf.Bytes(), nil
+	case c.peek().isAtom("quote"):
+		c.next()
+		var buf bytes.Buffer
+		for !c.done() {
+			buf.WriteString(c.str())
+			buf.WriteByte(' ')
+		}
+		return encodeText(buf.Bytes())
+	}
+	return encodeFields(name, n.list[c.i:])
+}
+
+// encodeFields returns the binary encoding of the fields of a module named
+// name.
+func encodeFields(name string, fields []*node) (bin []byte, err error) {
+	defer catchError("", &err)
+	b := &moduleBuilder{
+		types:      newSpace("type"),
+		funcs:      newSpace("function"),
+		tables:     newSpace("table"),
+		mems:       newSpace("memory"),
+		globals:    newSpace("global"),
+		tags:       newSpace("tag"),
+		exports:    &wasm.SectionExports{Entries: make(map[string]wasm.ExportEntry)},
+		localNames: make(map[uint32]wasm.NameMap),
+	}
+	for _, f := range fields {
+		b.field(f)
+	}
+	for _, f := range b.later {
+		f()
+	}
+
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, b.module(name)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// module returns the compiled module, whose sections are only set in its
+// Sections field, for it to be encoded.
+func (b *moduleBuilder) module(name string) *wasm.Module {
+	var sections []wasm.Section
+	if len(b.typeEntries) > 0 {
+		sections = append(sections, &wasm.SectionTypes{Entries: b.typeEntries})
+	}
+	if len(b.imports) > 0 {
+		sections = append(sections, &wasm.SectionImports{Entries: b.imports})
+	}
+	if len(b.funcTypes) > 0 {
+		sections = append(sections, &wasm.SectionFunctions{Types: b.funcTypes})
+	}
+	if len(b.tableEntries) > 0 {
+		sections = append(sections, &wasm.SectionTables{Entries: b.tableEntries})
+	}
+	if len(b.memEntries) > 0 {
+		sections = append(sections, &wasm.SectionMemories{Entries: b.memEntries})
+	}
+	if len(b.tagEntries) > 0 {
+		sections = append(sections, &wasm.SectionTags{Entries: b.tagEntries})
+	}
+	if len(b.globalEntries) > 0 {
+		sections = append(sections, &wasm.SectionGlobals{Globals: b.globalEntries})
+	}
+	if len(b.exports.Names) > 0 {
+		sections = append(sections, b.exports)
+	}
+	if b.start != nil {
+		sections = append(sections, b.start)
+	}
+	if len(b.elems) > 0 {
+		sections = append(sections, &wasm.SectionElements{Entries: b.elems})
+	}
+	if len(b.bodies) > 0 {
+		sections = append(sections, &wasm.SectionCode{Bodies: b.bodies})
+	}
+	if len(b.data) > 0 {
+		sections = append(sections, &wasm.SectionData{Entries: b.data})
+	}
+	keys := make([]int, len(sections))
+	for i, s := range sections {
+		keys[i] = sectionKey(s.SectionID())
+	}
+	named := false
+	for _, c := range b.customs {
+		sections = append(sections, c.sec)
+		keys = append(keys, c.key)
+		named = named || c.sec.Name == wasm.CustomSectionName
+	}
+	if names := b.nameSection(name); names != nil && !named {
+		// The name section goes after the data section, and before the
+		// custom sections placed after the last section.
+		sections = append(sections, names)
+		keys = append(keys, sectionKey(wasm.SectionIDCustom))
+	}
+	sort.Stable(sectionsByKey{sections, keys})
+	return &wasm.Module{Sections: sections}
+}
+
+type sectionsByKey struct {
+	sections []wasm.Section
+	keys     []int
+}
+
+func (s sectionsByKey) Len() int           { return len(s.sections) }
+func (s sectionsByKey) Less(i, j int) bool { return s.keys[i] < s.keys[j] }
+func (s sectionsByKey) Swap(i, j int) {
+	s.sections[i], s.sections[j] = s.sections[j], s.sections[i]
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+}
+
+// nameSection returns the name section of the identifiers of the module, or
+// nil if there is none.
+func (b *moduleBuilder) nameSection(name string) *wasm.SectionCustom {
+	names := wasm.NameSection{Types: make(map[wasm.NameType][]byte)}
+	add := func(typ wasm.NameType, sub wasm.NameSubsection) {
+		buf := new(bytes.Buffer)
+		sub.MarshalWASM(buf)
+		names.Types[typ] = buf.Bytes()
+	}
+	if name != "" {
+		add(wasm.NameModule, &wasm.ModuleName{Name: name})
+	}
+	if len(b.funcs.names) > 0 {
+		add(wasm.NameFunction, &wasm.FunctionNames{Names: b.funcs.nameMap()})
+	}
+	if len(b.localNames) > 0 {
+		add(wasm.NameLocal, &wasm.LocalNames{Funcs: b.localNames})
+	}
+	if len(b.globals.names) > 0 {
+		add(wasm.NameGlobal, &wasm.GlobalNames{Names: b.globals.nameMap()})
+	}
+	if len(names.Types) == 0 {
+		return nil
+	}
+	buf := new(bytes.Buffer)
+	names.MarshalWASM(buf)
+	return &wasm.SectionCustom{Name: wasm.CustomSectionName, Data: buf.Bytes()}
+}
+
+// field compiles the module field n.
+func (b *moduleBuilder) field(n *node) {
+	c := elems(n)
+	switch kw := n.keyword(); kw {
+	case "type":
+		id := c.optID()
+		fc := c.list("func")
+		sig, _ := b.signature(fc)
+		fc.close()
+		c.close()
+		b.types.define(id)
+		b.typeEntries = append(b.typeEntries, sig)
+	case "import":
+		if b.defined {
+			errorf(n.tok, "import after a definition")
+		}
+		imp := &wasm.ImportEntry{ModuleName: c.str(), FieldName: c.str()}
+		desc := c.next()
+		switch kw := desc.keyword(); kw {
+		case "func", "table", "memory", "global", "tag":
+			b.entity(kw, elems(desc), imp)
+		default:
+			errorf(desc.tok, "expected an import description, got %s", describe(desc))
+		}
+		c.close()
+	case "func", "table", "memory", "global", "tag":
+		b.entity(kw, c, nil)
+	case "export":
+		b.later = append(b.later, func() {
+			name := c.str()
+			desc := c.next()
+			kind, space := b.external(desc)
+			dc := elems(desc)
+			index := space.index(dc.next())
+			dc.close()
+			c.close()
+			b.export(n.tok, name, kind, index)
+		})
+	case "start":
+		b.later = append(b.later, func() {
+			if b.start != nil {
+				errorf(n.tok, "multiple start sections")
+			}
+			b.start = &wasm.SectionStartFunction{Index: b.funcs.index(c.next())}
+			c.close()
+		})
+	case "elem":
+		b.later = append(b.later, func() { b.elem(c) })
+	case "data":
+		b.later = append(b.later, func() { b.dataSegment(c) })
+	case "@custom":
+		b.custom(c)
+	default:
+		errorf(n.tok, "expected a module field, got %s", describe(n))
+	}
+}
+
+// custom compiles the annotation (@custom name place? data*) of a custom
+// section, whose place is (before first), (after last), or (before sec) or
+// (after sec) for the name of a section. It is placed last by default.
+func (b *moduleBuilder) custom(c *cursor) {
+	cu := custom{sec: &wasm.SectionCustom{Name: c.str()}, key: 2*(len(sectionNames)+1) + 1}
+	if c.peekList("before", "after") {
+		pc := elems(c.next())
+		where := pc.next()
+		switch {
+		case pc.n.keyword() == "before" && where.isAtom("first"):
+			cu.key = 0
+		case pc.n.keyword() == "after" && where.isAtom("last"):
+		default:
+			cu.key = -1
+			for _, s := range sectionNames {
+				if where.isAtom(s.name) {
+					cu.key = sectionKey(s.id) - 1
+					if pc.n.keyword() == "after" {
+						cu.key += 2
+					}
+				}
+			}
+			if cu.key < 0 {
+				errorf(where.tok, "expected a section, got %s", describe(where))
+			}
+		}
+		pc.close()
+	}
+	cu.sec.Data = dataStrings(c)
+	b.customs = append(b.customs, cu)
+}
+
+// external returns the kind and index space of an export description.
+func (b *moduleBuilder) external(desc *node) (wasm.External, *space) {
+	switch desc.keyword() {
+	case "func":
+		return wasm.ExternalFunction, b.funcs
+	case "table":
+		return wasm.ExternalTable, b.tables
+	case "memory":
+		return wasm.ExternalMemory, b.mems
+	case "global":
+		return wasm.ExternalGlobal, b.globals
+	case "tag":
+		return wasm.ExternalTag, b.tags
+	}
+	errorf(desc.tok, "expected an export description, got %s", describe(desc))
+	panic("unreachable")
+}
+
+func (b *moduleBuilder) export(tok *Token, name string, kind wasm.External, index uint32) {
+	if _, ok := b.exports.Entries[name]; ok {
+		errorf(tok, "duplicate export %q", name)
+	}
+	b.exports.Entries[name] = wasm.ExportEntry{FieldStr: name, Kind: kind, Index: index}
+	b.exports.Names = append(b.exports.Names, name)
+}
+
+// entity compiles the definition or import of a function, table, memory,
+// global or tag. imp is the import of an import field, otherwise the
+// definition may be followed by inline exports and import.
+func (b *moduleBuilder) entity(kw string, c *cursor, imp *wasm.ImportEntry) {
+	id := c.optID()
+	kind, space := b.external(c.n)
+	var exports []*cursor
+	if imp == nil {
+		for c.peekList("export") {
+			exports = append(exports, c.list("export"))
+		}
+		if c.peekList("import") {
+			ic := c.list("import")
+			if b.defined {
+				errorf(ic.n.tok, "import after a definition")
+			}
+			imp = &wasm.ImportEntry{ModuleName: ic.str(), FieldName: ic.str()}
+			ic.close()
+		}
+	}
+	b.defined = b.defined || imp == nil
+
+	index := space.define(id)
+	b.later = append(b.later, func() {
+		for _, ec := range exports {
+			name := ec.str()
+			ec.close()
+			b.export(ec.n.tok, name, kind, index)
+		}
+	})
+	if imp != nil {
+		b.importEntity(kw, c, imp)
+		return
+	}
+
+	switch kw {
+	case "func":
+		i := len(b.funcTypes)
+		b.funcTypes = append(b.funcTypes, 0)
+		b.bodies = append(b.bodies, wasm.FunctionBody{})
+		b.later = append(b.later, func() {
+			typ, params := b.typeUse(c)
+			b.funcTypes[i] = typ
+			b.bodies[i] = b.funcBody(index, typ, params, c)
+		})
+	case "table":
+		if c.peek() != nil && !c.peekIndex() {
+			// (table elemtype (elem funcidx*))
+			elemType := b.elemType(c)
+			ec := c.list("elem")
+			c.close()
+			size := uint64(len(ec.n.list) - 1)
+			b.tableEntries = append(b.tableEntries, wasm.Table{
+				ElementType: elemType,
+				Limits:      wasm.ResizableLimits{Flags: 1, Initial: size, Maximum: size},
+			})
+			b.later = append(b.later, func() {
+				b.elems = append(b.elems, wasm.ElementSegment{
+					Index:  index,
+					Offset: constOffset(false),
+					Elems:  indices(ec, b.funcs),
+				})
+			})
+			return
+		}
+		b.tableEntries = append(b.tableEntries, b.table(c))
+		c.close()
+	case "memory":
+		is64 := c.peek().isAtom("i64")
+		if c.peekList("data") || is64 && c.i+1 < len(c.n.list) && c.n.list[c.i+1].keyword() == "data" {
+			// (memory i64? (data string*))
+			if is64 {
+				c.next()
+			}
+			dc := c.list("data")
+			c.close()
+			data := dataStrings(dc)
+			pages := (uint64(len(data)) + 65535) / 65536
+			lim := wasm.ResizableLimits{Flags: 1, Initial: pages, Maximum: pages}
+			if is64 {
+				lim.Flags |= wasm.LimitsMemory64
+			}
+			b.memEntries = append(b.memEntries, wasm.Memory{Limits: lim})
+			b.mem64 = append(b.mem64, is64)
+			b.later = append(b.later, func() {
+				b.data = append(b.data, wasm.DataSegment{Index: index, Offset: constOffset(is64), Data: data})
+			})
+			return
+		}
+		mem := b.memory(c)
+		c.close()
+		b.memEntries = append(b.memEntries, mem)
+		b.mem64 = append(b.mem64, mem.Limits.Is64())
+	case "global":
+		i := len(b.globalEntries)
+		b.globalEntries = append(b.globalEntries, wasm.GlobalEntry{Type: b.globalType(c)})
+		b.later = append(b.later, func() {
+			b.globalEntries[i].Init = b.constExpr(c)
+		})
+	case "tag":
+		i := len(b.tagEntries)
+		b.tagEntries = append(b.tagEntries, wasm.Tag{Attribute: wasm.TagAttributeException})
+		b.later = append(b.later, func() {
+			b.tagEntries[i].Type, _ = b.typeUse(c)
+			c.close()
+		})
+	}
+}
+
+// importEntity compiles the description of an import.
+func (b *moduleBuilder) importEntity(kw string, c *cursor, imp *wasm.ImportEntry) {
+	i := len(b.imports)
+	b.imports = append(b.imports, *imp)
+	switch kw {
+	case "func":
+		b.later = append(b.later, func() {
+			typ, _ := b.typeUse(c)
+			c.close()
+			b.imports[i].Type = wasm.FuncImport{Type: typ}
+		})
+		return
+	case "table":
+		b.imports[i].Type = wasm.TableImport{Type: b.table(c)}
+	case "memory":
+		mem := b.memory(c)
+		b.imports[i].Type = wasm.MemoryImport{Type: mem}
+		b.mem64 = append(b.mem64, mem.Limits.Is64())
+	case "global":
+		b.imports[i].Type = wasm.GlobalVarImport{Type: b.globalType(c)}
+	case "tag":
+		b.later = append(b.later, func() {
+			typ, _ := b.typeUse(c)
+			c.close()
+			b.imports[i].Type = wasm.TagImport{Type: wasm.Tag{Attribute: wasm.TagAttributeException, Type: typ}}
+		})
+		return
+	}
+	c.close()
+}
+
+// signature compiles the parameters and results at c, and returns the
+// identifiers of the parameters, nil for those without one.
+func (b *moduleBuilder) signature(c *cursor) (wasm.FunctionSig, []*Token) {
+	sig := wasm.FunctionSig{Form: wasm.TypeFunc}
+	var ids []*Token
+	for c.peekList("param") {
+		pc := c.list("param")
+		if id := pc.optID(); id != nil {
+			ids = append(ids, id)
+			sig.ParamTypes = append(sig.ParamTypes, valueType(pc))
+			pc.close()
+			continue
+		}
+		for !pc.done() {
+			ids = append(ids, nil)
+			sig.ParamTypes = append(sig.ParamTypes, valueType(pc))
+		}
+	}
+	for c.peekList("result") {
+		rc := c.list("result")
+		for !rc.done() {
+			sig.ReturnTypes = append(sig.ReturnTypes, valueType(rc))
+		}
+	}
+	return sig, ids
+}
+
+// typeUse compiles the type use at c, a type index and or an inline
+// signature, and returns the index of its type and the identifiers of its
+// parameters. The inline signatures without a type index use the first type
+// with the same signature, which is added to the types if there is none.
+func (b *moduleBuilder) typeUse(c *cursor) (uint32, []*Token) {
+	if c.peekList("type") {
+		tc := c.list("type")
+		pos := tc.pos()
+		typ := b.types.index(tc.next())
+		tc.close()
+		if int(typ) >= len(b.typeEntries) {
+			errorf(pos, "unknown type %d", typ)
+		}
+		if c.peekList("param", "result") {
+			pos := c.pos()
+			sig, ids := b.signature(c)
+			if !equalSigs(sig, b.typeEntries[typ]) {
+				errorf(pos, "inline function type does not match the type %d", typ)
+			}
+			return typ, ids
+		}
+		return typ, nil
+	}
+
+	sig, ids := b.signature(c)
+	for i, t := range b.typeEntries {
+		if equalSigs(sig, t) {
+			return uint32(i), ids
+		}
+	}
+	b.typeEntries = append(b.typeEntries, sig)
+	return uint32(len(b.typeEntries) - 1), ids
+}
+
+func equalSigs(a, b wasm.FunctionSig) bool {
+	if len(a.ParamTypes) != len(b.ParamTypes) || len(a.ReturnTypes) != len(b.ReturnTypes) {
+		return false
+	}
+	for i, t := range a.ParamTypes {
+		if b.ParamTypes[i] != t {
+			return false
+		}
+	}
+	for i, t := range a.ReturnTypes {
+		if b.ReturnTypes[i] != t {
+			return false
+		}
+	}
+	return true
+}
+
+func valueType(c *cursor) wasm.ValueType {
+	return c.atom(VALUE_TYPE, "a value type").Data.(wasm.ValueType)
+}
+
+func (b *moduleBuilder) elemType(c *cursor) wasm.ElemType {
+	n := c.next()
+	if !n.isAtom("funcref") && !n.isAtom("anyfunc") {
+		errorf(n.tok, "expected an element type, got %s", describe(n))
+	}
+	return wasm.ElemTypeAnyFunc
+}
+
+// limits compiles the limits of a table or memory, in units of entries or
+// pages.
+func limits(c *cursor, max uint64) wasm.ResizableLimits {
+	var lim wasm.ResizableLimits
+	tok := c.atom(NAT, "a size")
+	lim.Initial = parseInt(tok, 64, false)
+	if lim.Initial > max {
+		errorf(tok, "size out of range")
+	}
+	if c.peek() != nil && c.peek().tok.Kind == NAT {
+		tok := c.atom(NAT, "a size")
+		lim.Flags = 1
+		lim.Maximum = parseInt(tok, 64, false)
+		if lim.Maximum > max {
+			errorf(tok, "size out of range")
+		}
+	}
+	return lim
+}
+
+func (b *moduleBuilder) table(c *cursor) wasm.Table {
+	lim := limits(c, 1<<32-1)
+	return wasm.Table{ElementType: b.elemType(c), Limits: lim}
+}
+
+func (b *moduleBuilder) memory(c *cursor) wasm.Memory {
+	if c.peek().isAtom("i64") {
+		c.next()
+		lim := limits(c, 1<<48)
+		lim.Flags |= wasm.LimitsMemory64
+		return wasm.Memory{Limits: lim}
+	}
+	return wasm.Memory{Limits: limits(c, 65536)}
+}
+
+// globalType compiles a value type or a (mut valtype) list.
+func (b *moduleBuilder) globalType(c *cursor) wasm.GlobalVar {
+	if c.peekList("mut") {
+		mc := c.list("mut")
+		t := valueType(mc)
+		mc.close()
+		return wasm.GlobalVar{Type: t, Mutable: true}
+	}
+	return wasm.GlobalVar{Type: valueType(c)}
+}
+
+// indices compiles the indices in space of the rest of c.
+func indices(c *cursor, space *space) []uint32 {
+	var indices []uint32
+	for !c.done() {
+		indices = append(indices, space.index(c.next()))
+	}
+	return indices
+}
+
+// dataStrings returns the concatenation of the strings of the rest of c.
+func dataStrings(c *cursor) []byte {
+	var buf bytes.Buffer
+	for !c.done() {
+		buf.WriteString(c.str())
+	}
+	return buf.Bytes()
+}
+
+// segmentTarget compiles the optional identifier of an element or data
+// segment, and the index of its table or memory: a (table x) or (memory x)
+// list, or an index in the old syntax.
+func (b *moduleBuilder) segmentTarget(c *cursor, kw string, space *space) uint32 {
+	if n := c.peek(); n != nil && n.tok.Kind == VAR {
+		_, isTarget := space.names[n.tok.Text]
+		if !isTarget || c.i+1 < len(c.n.list) && c.n.list[c.i+1].keyword() == kw {
+			c.next() // The identifier of the segment
+		}
+	}
+	if c.peekList(kw) {
+		tc := c.list(kw)
+		index := space.index(tc.next())
+		tc.close()
+		return index
+	}
+	if c.peekIndex() {
+		return space.index(c.next())
+	}
+	return 0
+}
+
+// offset compiles the offset of a segment, an (offset instr*) list or a
+// folded instruction.
+func (b *moduleBuilder) offset(c *cursor) []byte {
+	n := c.next()
+	if n.keyword() == "offset" {
+		return b.constExpr(elems(n))
+	}
+	if !n.isList() {
+		errorf(n.tok, "expected an offset, got %s", describe(n))
+	}
+	return b.constExpr(&cursor{n: &node{tok: n.tok, end: n.end, list: []*node{n}}})
+}
+
+func (b *moduleBuilder) elem(c *cursor) {
+	table := b.segmentTarget(c, "table", b.tables)
+	offset := b.offset(c)
+	if c.peek().isAtom("func") {
+		c.next()
+	}
+	b.elems = append(b.elems, wasm.ElementSegment{Index: table, Offset: offset, Elems: indices(c, b.funcs)})
+}
+
+func (b *moduleBuilder) dataSegment(c *cursor) {
+	mem := b.segmentTarget(c, "memory", b.mems)
+	offset := b.offset(c)
+	b.data = append(b.data, wasm.DataSegment{Index: mem, Offset: offset, Data: dataStrings(c)})
+}
+
+// constOffset returns the constant expression of the offset 0.
+func constOffset(is64 bool) []byte {
+	if is64 {
+		return []byte{ops.I64Const, 0, ops.End}
+	}
+	return []byte{ops.I32Const, 0, ops.End}
+}
+
+// is64 returns whether the memory mem is a 64-bit memory.
+func (b *moduleBuilder) is64(mem uint32) bool {
+	return int(mem) < len(b.mem64) && b.mem64[mem]
+}
@@ -0,0 +1,298 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wast
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// encodeCode returns the encoding of the sections of m, except its custom
+// sections.
+func encodeCode(t *testing.T, m *wasm.Module) []byte {
+	var secs []wasm.Section
+	for _, s := range m.Sections {
+		if s.SectionID() != wasm.SectionIDCustom {
+			secs = append(secs, s)
+		}
+	}
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, &wasm.Module{Sections: secs}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseSpec(t *testing.T) {
+	var fnames []string
+	for _, pattern := range []string{
+		"../wasm/testdata/*.wast",
+		"../exec/testdata/*.wast",
+		"../exec/testdata/spec/*.wast",
+	} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fnames = append(fnames, matches...)
+	}
+	for _, fname := range fnames {
+		raw, err := ioutil.ReadFile(strings.TrimSuffix(fname, ".wast") + ".wasm")
+		if err != nil {
+			continue
+		}
+		t.Run(filepath.Base(fname), func(t *testing.T) {
+			want, err := wasm.DecodeModule(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			src, err := ioutil.ReadFile(fname)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The .wasm file is the encoding of the first module of the script.
+			nodes, err := readNodes(newScanner(fname, src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := compileModule(nodes[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(encodeCode(t, got), encodeCode(t, want)) {
+				t.Errorf("the module differs from %s", strings.TrimSuffix(fname, ".wast")+".wasm")
+			}
+		})
+	}
+}
+
+func TestParseModule(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		want string // an equivalent source
+	}{
+		{
+			name: "fields",
+			src:  `(func (result i32) (i32.const 1))`,
+			want: `(module (type (func (result i32))) (func (type 0) i32.const 1))`,
+		},
+		{
+			name: "identifiers",
+			src: `(module
+				(func $f (param $x i32) (result i32) (call $g (get_local $x)))
+				(func $g (param i32) (result i32) (local.get 0)))`,
+			want: `(module
+				(type (func (param i32) (result i32)))
+				(func (type 0) local.get 0 call 1)
+				(func (type 0) local.get 0))`,
+		},
+		{
+			name: "labels",
+			src: `(func $f (param i32)
+				(block $out (loop $in
+					(br_if $out (local.get 0))
+					(br $in))))`,
+			want: `(func (param i32)
+				block loop local.get 0 br_if 1 br 0 end end)`,
+		},
+		{
+			name: "if",
+			src: `(func (param i32) (result i32)
+				(if (result i32) (local.get 0) (then (i32.const 1)) (else (i32.const 2))))`,
+			want: `(func (param i32) (result i32)
+				local.get 0 if (result i32) i32.const 1 else i32.const 2 end)`,
+		},
+		{
+			name: "inline export and import",
+			src: `(module
+				(func $log (export "log") (import "env" "log") (param i32))
+				(memory (export "mem") 1)
+				(func (export "run") (call $log (i32.const 0))))`,
+			want: `(module
+				(type (func (param i32)))
+				(type (func))
+				(import "env" "log" (func (type 0)))
+				(func (type 1) i32.const 0 call 0)
+				(memory 1)
+				(export "log" (func 0))
+				(export "mem" (memory 0))
+				(export "run" (func 1)))`,
+		},
+		{
+			name: "memory data",
+			src:  `(memory (data "hi" "\00\ff"))`,
+			want: `(memory 1 1) (data (i32.const 0) "hi\00\ff")`,
+		},
+		{
+			name: "table elem",
+			src:  `(table anyfunc (elem $f $f)) (func $f)`,
+			want: `(table 2 2 anyfunc) (elem (i32.const 0) 0 0) (func)`,
+		},
+		{
+			name: "old opcode names",
+			src: `(func (param f32) (result i64)
+				(i64.extend_u/i32 (i32.trunc_s/f32 (get_local 0))))`,
+			want: `(func (param f32) (result i64)
+				local.get 0 i32.trunc_f32_s i64.extend_i32_u)`,
+		},
+		{
+			name: "float literals",
+			src: `(global f64 (f64.const 0x1p-2)) (global f32 (f32.const nan:0x400000))
+				(global f64 (f64.const 1_000.5e1)) (global f32 (f32.const -0x1.fffffep127))`,
+			want: `(global f64 (f64.const 0.25)) (global f32 (f32.const nan))
+				(global f64 (f64.const 10005)) (global f32 (f32.const -3.4028234663852886e+38))`,
+		},
+		{
+			name: "memory arguments",
+			src:  `(func (i64.store32 offset=8 align=2 (i32.const 0) (i64.load8_s (i32.const 1))))`,
+			want: `(func i32.const 0 i32.const 1 i64.load8_s offset=0 align=1 i64.store32 offset=8 align=2)`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseModule(strings.NewReader(tc.src))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := ParseModule(strings.NewReader(tc.want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(encodeCode(t, got), encodeCode(t, want)) {
+				t.Errorf("the module of %s differs from the one of %s", tc.src, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseNames(t *testing.T) {
+	m, err := ParseModule(strings.NewReader(`(module $m
+		(import "env" "f" (func $imported))
+		(func $add (param $a i32) (param i32) (local $sum i32))
+		(func)
+		(global i32 (i32.const 0))
+		(global $g i32 (i32.const 0)))`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := m.Custom(wasm.CustomSectionName)
+	if s == nil {
+		t.Fatal("the module has no name section")
+	}
+	var names wasm.NameSection
+	if err := names.UnmarshalWASM(bytes.NewReader(s.Data)); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		typ  wasm.NameType
+		want wasm.NameSubsection
+	}{
+		{wasm.NameModule, &wasm.ModuleName{Name: "m"}},
+		{wasm.NameFunction, &wasm.FunctionNames{Names: wasm.NameMap{0: "imported", 1: "add"}}},
+		{wasm.NameLocal, &wasm.LocalNames{Funcs: map[uint32]wasm.NameMap{1: {0: "a", 2: "sum"}}}},
+		{wasm.NameGlobal, &wasm.GlobalNames{Names: wasm.NameMap{1: "g"}}},
+	} {
+		got, err := names.Decode(tc.typ)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("got names %+v, want %+v", got, tc.want)
+		}
+	}
+}
+
+func TestParseCustom(t *testing.T) {
+	m, err := ParseModule(strings.NewReader(`(module $m
+		(@custom "last" "a" "b")
+		(func)
+		(@custom "first" (before first) "")
+		(@custom "code" (after code) "\00")
+		(@custom "global" (before global) ""))`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range m.Sections {
+		if c, ok := s.(*wasm.SectionCustom); ok {
+			got = append(got, fmt.Sprintf("%s=%q", c.Name, c.Data))
+		} else {
+			got = append(got, s.SectionID().String())
+		}
+	}
+	want := []string{`first=""`, "type", "function", `global=""`, "code", `code="\x00"`, `name="\x00\x02\x01m"`, `last="ab"`}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got sections %q, want %q", got, want)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src          string
+		line, column int
+		msg          string
+	}{
+		{"(module (func (i32.const 1)", 1, 9, "unclosed ("},
+		{"(module\n  (func (call $missing)))", 2, 15, "unknown function $missing"},
+		{"(func $f) (func $f)", 1, 17, "duplicate function $f"},
+		{"(func\n  (i32.add (i32.const)))", 2, 22, "unexpected )"},
+		{"(func (br $nowhere))", 1, 11, "unknown label $nowhere"},
+		{"(func (i32.frob))", 1, 8, "unknown operator i32.frob"},
+		{"(func (export \"a\")) (func (export \"a\"))", 1, 27, "duplicate export \"a\""},
+		{"(func) (import \"env\" \"f\" (func))", 1, 8, "import after a definition"},
+		{"(func (i32.const 0x1_0000_0000))", 1, 18, "constant out of range"},
+		{"(@custom \"a\" (after funcs))", 1, 21, "expected a section"},
+	} {
+		_, err := ParseModule(strings.NewReader(tc.src))
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("%s: got error %v, want a *SyntaxError", tc.src, err)
+			continue
+		}
+		if serr.Line != tc.line || serr.Column != tc.column || !strings.Contains(serr.Msg, tc.msg) {
+			t.Errorf("%s: got error %v, want %d:%d: ...%s...", tc.src, err, tc.line, tc.column, tc.msg)
+		}
+	}
+}
+
+func TestParseNumbers(t *testing.T) {
+	for _, tc := range []struct {
+		text   string
+		size   int
+		signed bool
+		float  bool
+		want   uint64
+	}{
+		{"42", 32, false, false, 42},
+		{"-1", 32, true, false, 0xffffffff},
+		{"0xffff_ffff", 32, true, false, 0xffffffff},
+		{"-0x8000_0000_0000_0000", 64, true, false, 1 << 63},
+		{"1.5", 32, false, true, 0x3fc00000},
+		{"-inf", 32, false, true, 0xff800000},
+		{"nan", 64, false, true, 0x7ff8000000000000},
+		{"-nan:0x1", 32, false, true, 0xff800001},
+		{"0x1p-1074", 64, false, true, 1},
+		{"0x10", 64, false, true, 0x4030000000000000},
+	} {
+		s := newScanner("", []byte(tc.text))
+		tok := s.Next()
+		var got uint64
+		if tc.float {
+			got = parseFloat(tok, tc.size)
+		} else {
+			got = parseInt(tok, tc.size, tc.signed)
+		}
+		if got != tc.want {
+			t.Errorf("%s: got %#x, want %#x", tc.text, got, tc.want)
+		}
+	}
+}
@@ -24,6 +24,7 @@ type Scanner struct {
 	ch    rune
 	eof   bool
 	token *Token
+	str   bytes.Buffer // The text of the string literal being scanned
 
 	offset int
 
@@ -34,23 +35,23 @@ type Scanner struct {
 }
 
 func NewScanner(path string) *Scanner {
-	var s Scanner
-
-	s.file = path
 	buf, err := ioutil.ReadFile(path)
 	if err != nil {
+		s := &Scanner{file: path}
 		s.raise(err)
-		return &s
+		return s
 	}
+	return newScanner(path, buf)
+}
 
-	s.inBuf = bytes.NewBuffer(buf)
-
-	s.eof = false
-	s.Line = 1
-	s.Column = 1
-	s.offset = 0
-
-	return &s
+// newScanner returns a scanner of the text buf, read from file.
+func newScanner(file string, buf []byte) *Scanner {
+	return &Scanner{
+		file:   file,
+		inBuf:  bytes.NewBuffer(buf),
+		Line:   1,
+		Column: 1,
+	}
 }
 
 const (
@@ -180,7 +181,8 @@ func (s *Scanner) Next() (token *Token) {
 
 func (s *Scanner) scanString() {
 	s.token.Kind = STRING
-	s.token.Text = ""
+	s.str.Reset()
+	defer func() { s.token.Text = s.str.String() }()
 	for !s.eof {
 		switch {
 		case s.eof || s.match('\n'):
@@ -195,7 +197,7 @@ func (s *Scanner) scanString() {
 		// case s.matchIf(isStringRune):
 		default:
 			s.next()
-			s.token.Text += string(s.ch)
+			s.str.WriteRune(s.ch)
 		}
 	}
 }
@@ -204,21 +206,21 @@ func (s *Scanner) scanEscape() bool {
 	// escape slash is already matched
 	switch s.next() {
 	case 'n':
-		s.token.Text += "\n"
+		s.str.WriteString("\n")
 	case 'r':
-		s.token.Text += "\r"
+		s.str.WriteString("\r")
 	case 't':
-		s.token.Text += "\t"
+		s.str.WriteString("\t")
 	case '\\':
-		s.token.Text += "\\"
+		s.str.WriteString("\\")
 	case '\'':
-		s.token.Text += "'"
+		s.str.WriteString("'")
 	case '"':
-		s.token.Text += "\""
+		s.str.WriteString("\"")
 	case 'u': // unicode
 		if !s.match('{') {
 			s.errorf("missing opening '{' in unicode escape sequence")
-			s.token.Text += "\\u"
+			s.str.WriteString("\\u")
 			return false
 		}
 
@@ -230,34 +232,32 @@ func (s *Scanner) scanEscape() bool {
 		switch {
 		case len(esc) == 0 && s.match('}'):
 			s.errorf("empty unicode escape sequence")
-			s.token.Text += "\\u{}"
+			s.str.WriteString("\\u{}")
 			return false
 		case len(esc) == 0 && !s.match('}'):
 			rtext := safeRune(s.peek())
 			s.errorf("unexpected character in unicode escape sequence '%s'", rtext)
-			s.token.Text += "\\u" + rtext
+			s.str.WriteString("\\u" + rtext)
 			return false
 		case len(esc) > 0 && !s.match('}'):
 			s.errorf("missing closing '}' in unicode escape sequence")
-			s.token.Text += "\\u{" + esc
+			s.str.WriteString("\\u{" + esc)
 			return false
 		}
 
 		n, err := strconv.ParseInt(esc, 16, 0)
 		if err != nil {
 			s.raise(err)
-			s.token.Text += "\\u{" + esc + "}"
+			s.str.WriteString("\\u{" + esc + "}")
 			return false
 		}
 
-		u := make([]byte, 4)
-		utf8.EncodeRune(u, rune(n))
-		s.token.Text += string(bytes.Trim(u, "\x00")) // remove NULL bytes
+		s.str.WriteRune(rune(n))
 	default: // hexadecimal
 		if !isHexDigit(s.ch) {
 			rtext := safeRune(s.ch)
 			s.errorf("unexpected character in hexadecimal escape sequence '%s'", rtext)
-			s.token.Text += "\\" + rtext
+			s.str.WriteString("\\" + rtext)
 			return false
 		}
 
@@ -265,7 +265,7 @@ func (s *Scanner) scanEscape() bool {
 		if !s.matchIf(isHexDigit) {
 			rtext := safeRune(s.peek())
 			s.errorf("unexpected character in hexadecimal escape sequence '%s'", rtext)
-			s.token.Text += "\\" + esc + rtext
+			s.str.WriteString("\\" + esc + rtext)
 			return false
 		}
 		esc += string(s.ch)
@@ -273,10 +273,10 @@ func (s *Scanner) scanEscape() bool {
 		n, err := strconv.ParseInt(esc, 16, 0)
 		if err != nil {
 			s.raise(err)
-			s.token.Text += "\\" + esc
+			s.str.WriteString("\\" + esc)
 			return false
 		}
-		s.token.Text += string(n)
+		s.str.WriteByte(byte(n))
 	}
 	return true
 }
@@ -313,6 +313,18 @@ func (s *Scanner) scanReserved() {
 	// Basic instruction / reserved word
 	if k, ok := tokenKindOf[s.token.Text]; ok {
 		s.token.Kind = k
+		return
+	}
+
+	switch text := s.token.Text; {
+	case isNat(text):
+		s.token.Kind = NAT
+	case isInt(text):
+		s.token.Kind = INT
+	case isFloat(text):
+		s.token.Kind = FLOAT
+	default:
+		s.token.Kind = RESERVED
 	}
 }
 
@@ -331,11 +343,13 @@ func (s *Scanner) scanTypedReserved(t string) {
 		return
 	}
 
-	s.errorf("unkown operator")
+	// Operators missing from typedKindOf, which the parser looks up by
+	// their text.
+	s.token.Kind = RESERVED
 }
 
 func (s *Scanner) scanLineComment() {
-	for s.next() != '\n' {
+	for r := s.next(); r != '\n' && r != eofRune; r = s.next() {
 	}
 }
 
@@ -360,11 +374,29 @@ const (
 	scanWarnPrefix = "warning: "
 )
 
+// SyntaxError is an error at a position of a text file.
+type SyntaxError struct {
+	File   string // Name of the file, empty if unknown
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("wast: %d:%d: %s", e.Line, e.Column, e.Msg)
+	}
+	return fmt.Sprintf("wast: %s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
 // errorf generates a new scanner error appended to the scanner's Errors field
 func (s *Scanner) errorf(fmtStr string, args ...interface{}) {
-	pfx := fmt.Sprintf("%s ~ line %d, column %d\n  => ", s.file, s.token.Line, s.token.Column)
-	err := fmt.Errorf(scanErrPrefix+pfx+fmtStr+"\n", args...)
-	s.Errors = append(s.Errors, err)
+	s.Errors = append(s.Errors, &SyntaxError{
+		File:   s.file,
+		Line:   s.token.Line,
+		Column: s.token.Column,
+		Msg:    fmt.Sprintf(fmtStr, args...),
+	})
 }
 
 // raise directly promote any error to a printable Scanner error
@@ -398,7 +430,7 @@ func isUtf8(r rune) bool {
 }
 
 func isReserved(r rune) bool {
-	return !isSpace(r) && strings.IndexRune("\"();", r) < 0
+	return r >= 0 && !isSpace(r) && strings.IndexRune("\"();", r) < 0
 }
 
 func isSpace(r rune) bool {
@@ -411,7 +443,7 @@ func isSpace(r rune) bool {
 }
 
 func isSymbol(r rune) bool {
-	return strings.IndexRune("+-*/\\^~=<>!?@#$%&|:`.'", r) > 0
+	return strings.IndexRune("+-*/\\^~=<>!?@#$%&|:`.'", r) >= 0
 }
 
 func isSign(r rune) bool {
@@ -473,7 +505,7 @@ func isNat(s string) bool {
 	if strings.HasPrefix(s, "0x") {
 		return isHexNum(s[2:])
 	}
-	return isNat(s)
+	return isNum(s)
 }
 
 func isInt(s string) bool {
@@ -502,23 +534,46 @@ func isFloat(s string) bool {
 		return isHexNum(s[6:]) // len("nan:0x") == 6
 	}
 
+	isDigits, exp := isNum, "eE"
 	if strings.HasPrefix(s, "0x") {
-		period := strings.IndexRune(s[2:], '.')
-		if period <= 1 {
-			// there must be an hexnum between the '0x' and the '.'
+		s = s[2:]
+		isDigits, exp = isHexNum, "pP"
+	}
+
+	// The mantissa is followed by an optional fraction and exponent; the
+	// exponent is always decimal.
+	mantissa := s
+	if i := strings.IndexAny(s, "."+exp); i >= 0 {
+		mantissa, s = s[:i], s[i:]
+	} else {
+		s = ""
+	}
+	if !isDigits(mantissa) {
+		return false
+	}
+	if strings.HasPrefix(s, ".") {
+		s = s[1:]
+		frac := s
+		if i := strings.IndexAny(s, exp); i >= 0 {
+			frac, s = s[:i], s[i:]
+		} else {
+			s = ""
+		}
+		if frac != "" && !isDigits(frac) {
 			return false
 		}
-
-		units := s[2 : 2+period]
-		if 2+period == len(s) {
-			return isHexNum(units)
+		if s == "" {
+			return true
 		}
-
-		decimals := s[2+period+1:]
-		return isHexNum(units) && isHexNum(decimals)
 	}
-
-	return false
+	if s == "" || strings.IndexByte(exp, s[0]) < 0 {
+		return false
+	}
+	s = s[1:]
+	if len(s) > 0 && isSign(rune(s[0])) {
+		s = s[1:]
+	}
+	return isNum(s)
 }
 
 func isHexNum(s string) bool {
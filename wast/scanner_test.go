@@ -11,31 +11,31 @@ import (
 
 func TestScanner(t *testing.T) {
 	for _, fname := range []string{
-		//		"../exec/testdata/spec/address.wast", // FIXME
+		"../exec/testdata/spec/address.wast",
 		"../exec/testdata/spec/block.wast",
 		"../exec/testdata/spec/break-drop.wast",
 		"../exec/testdata/spec/br_if.wast",
-		//		"../exec/testdata/spec/br_table.wast", // FIXME
-		//		"../exec/testdata/spec/br.wast", // FIXME
+		"../exec/testdata/spec/br_table.wast",
+		"../exec/testdata/spec/br.wast",
 		"../exec/testdata/spec/call_indirect.wast",
-		//		"../exec/testdata/spec/endianness.wast", // FIXME
+		"../exec/testdata/spec/endianness.wast",
 		"../exec/testdata/spec/fac.wast",
 		"../exec/testdata/spec/forward.wast",
 		"../exec/testdata/spec/get_local.wast",
 		"../exec/testdata/spec/globals.wast",
 		"../exec/testdata/spec/if.wast",
-		//		"../exec/testdata/spec/loop.wast", // FIXME
+		"../exec/testdata/spec/loop.wast",
 		"../exec/testdata/spec/memory_redundancy.wast",
 		"../exec/testdata/spec/names.wast",
 		"../exec/testdata/spec/nop.wast",
 		"../exec/testdata/spec/resizing.wast",
-		//		"../exec/testdata/spec/return.wast", // FIXME
+		"../exec/testdata/spec/return.wast",
 		"../exec/testdata/spec/select.wast",
 		"../exec/testdata/spec/switch.wast",
 		"../exec/testdata/spec/tee_local.wast",
 		"../exec/testdata/spec/traps_int_div.wast",
 		"../exec/testdata/spec/traps_int_rem.wast",
-		//		"../exec/testdata/spec/traps_mem.wast", // FIXME
+		"../exec/testdata/spec/traps_mem.wast",
 		"../exec/testdata/spec/unwind.wast",
 	} {
 		t.Run(fname, func(t *testing.T) {
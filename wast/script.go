@@ -0,0 +1,211 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wast
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+// Script is a script of the format of the spec test suite: a sequence of
+// modules, and of actions and assertions on them.
+type Script struct {
+	Commands []*Command
+}
+
+// Command is a command of a script.
+type Command struct {
+	// Keyword is the keyword of the command: module, register, invoke, get,
+	// assert_return, assert_trap, assert_exhaustion, assert_invalid,
+	// assert_malformed or assert_unlinkable. The assert_return_canonical_nan
+	// and assert_return_arithmetic_nan commands of older scripts are
+	// assert_return commands.
+	Keyword string
+	Line    int
+
+	// Module is the module of the module command and of the assertions on
+	// modules.
+	Module *ScriptModule
+
+	// Name is the name a module is registered as by a register command, and
+	// ModuleID the identifier of the module, empty for the last one.
+	Name     string
+	ModuleID string
+
+	Action  *Action // The action of invoke, get and the assertions on actions
+	Results []Value // The results expected by assert_return
+	Message string  // The failure expected by the other assertions
+}
+
+// ScriptModule is a module of a script.
+type ScriptModule struct {
+	ID string // The identifier of the module, without its $, if any
+
+	// Binary is the binary encoding of the module. It is not decoded for
+	// modules written in the binary format, which may be malformed.
+	Binary []byte
+	// Err is the error compiling a module written in the text format, for
+	// instance the *SyntaxError of a malformed module.
+	Err error
+}
+
+// Action is an invocation of an exported function, or a read of an exported
+// global.
+type Action struct {
+	Keyword  string // invoke or get
+	ModuleID string // The identifier of the module, empty for the last one
+	Field    string
+	Args     []Value
+}
+
+// Value is an argument of an invocation, or an expected result.
+type Value struct {
+	Type wasm.ValueType
+	Bits uint64 // The bits of the value, e.g. those of a float
+
+	// NaN is canonical or arithmetic if any NaN of this kind is expected,
+	// in which case Bits is unset. Type is also unset for the NaN patterns
+	// of older scripts, which do not state it.
+	NaN string
+}
+
+// ParseScript parses the script read from r. Errors in the text of the
+// commands are returned as a *SyntaxError, while those in the text of
+// modules are kept in their Err field.
+func ParseScript(r io.Reader) (s *Script, err error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := readNodes(newScanner("", buf))
+	if err != nil {
+		return nil, err
+	}
+	defer catchError("", &err)
+	s = &Script{}
+	for _, n := range nodes {
+		s.Commands = append(s.Commands, command(n))
+	}
+	return s, nil
+}
+
+// command parses the command n.
+func command(n *node) *Command {
+	c := elems(n)
+	cmd := &Command{Keyword: n.keyword(), Line: n.tok.Line}
+	switch cmd.Keyword {
+	case "module":
+		cmd.Module = scriptModule(n)
+		return cmd
+	case "register":
+		cmd.Name = c.str()
+		if id := c.optID(); id != nil {
+			cmd.ModuleID = id.Text[1:]
+		}
+	case "invoke", "get":
+		cmd.Action = action(n)
+		return cmd
+	case "assert_return":
+		cmd.Action = action(c.next())
+		for !c.done() {
+			cmd.Results = append(cmd.Results, value(c.next(), true))
+		}
+	case "assert_return_canonical_nan", "assert_return_arithmetic_nan":
+		cmd.Keyword = "assert_return"
+		cmd.Action = action(c.next())
+		nan := strings.TrimSuffix(strings.TrimPrefix(n.keyword(), "assert_return_"), "_nan")
+		cmd.Results = []Value{{NaN: nan}}
+	case "assert_trap":
+		if c.peek().keyword() == "module" {
+			cmd.Module = scriptModule(c.next())
+		} else {
+			cmd.Action = action(c.next())
+		}
+		cmd.Message = c.str()
+	case "assert_exhaustion":
+		cmd.Action = action(c.next())
+		cmd.Message = c.str()
+	case "assert_invalid", "assert_malformed", "assert_unlinkable":
+		m := c.next()
+		if m.keyword() != "module" {
+			errorf(m.tok, "expected (module ...), got %s", describe(m))
+		}
+		cmd.Module = scriptModule(m)
+		cmd.Message = c.str()
+	default:
+		errorf(n.tok, "expected a command, got %s", describe(n))
+	}
+	c.close()
+	return cmd
+}
+
+// scriptModule compiles the module form n, keeping the errors of its text.
+func scriptModule(n *node) *ScriptModule {
+	m := &ScriptModule{}
+	if id := elems(n).optID(); id != nil {
+		m.ID = id.Text[1:]
+	}
+	m.Binary, m.Err = encodeModule(n)
+	return m
+}
+
+// action parses the action n.
+func action(n *node) *Action {
+	a := &Action{Keyword: n.keyword()}
+	if a.Keyword != "invoke" && a.Keyword != "get" {
+		errorf(n.tok, "expected an action, got %s", describe(n))
+	}
+	c := elems(n)
+	if id := c.optID(); id != nil {
+		a.ModuleID = id.Text[1:]
+	}
+	a.Field = c.str()
+	if a.Keyword == "invoke" {
+		for !c.done() {
+			a.Args = append(a.Args, value(c.next(), false))
+		}
+	}
+	c.close()
+	return a
+}
+
+// value parses the constant n, which may be a NaN pattern if result is set.
+func value(n *node, result bool) Value {
+	var v Value
+	switch n.keyword() {
+	case "i32.const":
+		v.Type = wasm.ValueTypeI32
+	case "i64.const":
+		v.Type = wasm.ValueTypeI64
+	case "f32.const":
+		v.Type = wasm.ValueTypeF32
+	case "f64.const":
+		v.Type = wasm.ValueTypeF64
+	default:
+		errorf(n.tok, "expected a constant, got %s", describe(n))
+	}
+	c := elems(n)
+	tok := c.next().tok
+	switch {
+	case result && (tok.Text == "nan:canonical" || tok.Text == "nan:arithmetic"):
+		if v.Type != wasm.ValueTypeF32 && v.Type != wasm.ValueTypeF64 {
+			errorf(tok, "unexpected %s", tok.Text)
+		}
+		v.NaN = tok.Text[len("nan:"):]
+	case v.Type == wasm.ValueTypeI32:
+		v.Bits = parseInt(tok, 32, true)
+	case v.Type == wasm.ValueTypeI64:
+		v.Bits = parseInt(tok, 64, true)
+	case v.Type == wasm.ValueTypeF32:
+		v.Bits = parseFloat(tok, 32)
+	default:
+		v.Bits = parseFloat(tok, 64)
+	}
+	c.close()
+	return v
+}
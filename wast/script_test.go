@@ -0,0 +1,91 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package wast
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+)
+
+func TestParseScript(t *testing.T) {
+	s, err := ParseScript(strings.NewReader(`
+(module $m (func (export "f") (param i64) (result f32) (f32.const 1)))
+(register "reg" $m)
+(assert_return (invoke $m "f" (i64.const -1)) (f32.const nan:canonical))
+(assert_return_arithmetic_nan (invoke "f" (i64.const 0)))
+(assert_trap (invoke "f" (i64.const 0)) "unreachable")
+(assert_exhaustion (invoke "f" (i64.const 0)) "call stack exhausted")
+(assert_malformed (module quote "(func") "unclosed")
+(assert_invalid (module binary "\00asm\01\00\00\00") "invalid")
+(get "g")`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Commands) != 9 {
+		t.Fatalf("got %d commands, want 9", len(s.Commands))
+	}
+
+	mod := s.Commands[0]
+	if mod.Keyword != "module" || mod.Line != 2 || mod.Module.ID != "m" || mod.Module.Err != nil {
+		t.Errorf("got module command %+v, module %+v", mod, mod.Module)
+	}
+	if reg := s.Commands[1]; reg.Name != "reg" || reg.ModuleID != "m" {
+		t.Errorf("got register command %+v", reg)
+	}
+	wantAction := &Action{
+		Keyword:  "invoke",
+		ModuleID: "m",
+		Field:    "f",
+		Args:     []Value{{Type: wasm.ValueTypeI64, Bits: 1<<64 - 1}},
+	}
+	ret := s.Commands[2]
+	if !reflect.DeepEqual(ret.Action, wantAction) {
+		t.Errorf("got action %+v, want %+v", ret.Action, wantAction)
+	}
+	if want := []Value{{Type: wasm.ValueTypeF32, NaN: "canonical"}}; !reflect.DeepEqual(ret.Results, want) {
+		t.Errorf("got results %+v, want %+v", ret.Results, want)
+	}
+	if old := s.Commands[3]; old.Keyword != "assert_return" || !reflect.DeepEqual(old.Results, []Value{{NaN: "arithmetic"}}) {
+		t.Errorf("got command %+v, results %+v", old, old.Results)
+	}
+	if trap := s.Commands[4]; trap.Message != "unreachable" || trap.Module != nil {
+		t.Errorf("got assert_trap command %+v", trap)
+	}
+	if _, ok := s.Commands[6].Module.Err.(*SyntaxError); !ok {
+		t.Errorf("got error %v for a malformed module, want a *SyntaxError", s.Commands[6].Module.Err)
+	}
+	if bin := s.Commands[7].Module.Binary; string(bin) != "\x00asm\x01\x00\x00\x00" {
+		t.Errorf("got binary module %q", bin)
+	}
+	if get := s.Commands[8]; get.Keyword != "get" || get.Action.Field != "g" {
+		t.Errorf("got command %+v", get)
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src          string
+		line, column int
+		msg          string
+	}{
+		{"(assert_return (call \"f\"))", 1, 16, "expected an action"},
+		{"(assert_return (invoke \"f\") (i32.const nan:canonical))", 1, 40, "unexpected nan:canonical"},
+		{"\n(frobnicate)", 2, 1, "expected a command"},
+		{"(invoke \"f\" (i32.const 1) (local.get 0))", 1, 27, "expected a constant"},
+	} {
+		_, err := ParseScript(strings.NewReader(tc.src))
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("%s: got error %v, want a *SyntaxError", tc.src, err)
+			continue
+		}
+		if serr.Line != tc.line || serr.Column != tc.column || !strings.Contains(serr.Msg, tc.msg) {
+			t.Errorf("%s: got error %v, want %d:%d: ...%s...", tc.src, err, tc.line, tc.column, tc.msg)
+		}
+	}
+}
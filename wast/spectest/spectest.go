@@ -0,0 +1,486 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package spectest runs the .wast scripts of the WebAssembly spec test suite.
+//
+// The scripts are parsed with the wast package, their modules are linked
+// with wasm.ReadModule, checked with validate.VerifyModule and run by the
+// exec package. The traps expected by assert_trap must be the ones of the
+// message of the assertion, as the reference interpreter reports them. The
+// other expected failures are not matched against the messages of the
+// reference interpreter: any error at the right stage satisfies them.
+//
+// See https://github.com/WebAssembly/spec/tree/master/interpreter#scripts
+package spectest
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-interpreter/wagon/exec"
+	"github.com/go-interpreter/wagon/validate"
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/go-interpreter/wagon/wast"
+)
+
+// DefaultMaxCallDepth is the number of nested calls beyond which the
+// functions of a script are considered to exhaust the call stack.
+const DefaultMaxCallDepth = 10000
+
+// Runner runs scripts.
+type Runner struct {
+	// KnownFailures lists the commands expected to fail, as the base name of
+	// their script and their line, e.g. "br_table.wast:1234". Their failures
+	// are counted apart.
+	KnownFailures map[string]bool
+
+	// Options are the options of the VMs of the modules, after
+	// exec.MaxCallDepth(DefaultMaxCallDepth).
+	Options []exec.VMOption
+
+	// Log, if not nil, receives a line for each failed command.
+	Log io.Writer
+}
+
+// Result is the result of running a script.
+type Result struct {
+	File   string
+	Passed int // Number of commands that succeeded
+	Failed int // Number of commands that failed, known failures excepted
+	Known  int // Number of known failures that failed
+
+	Failures []*Failure // The failed commands, known failures included
+	Fixed    []int      // The lines of the known failures that succeeded
+}
+
+func (r *Result) String() string {
+	s := fmt.Sprintf("%s: %d passed, %d failed", r.File, r.Passed, r.Failed)
+	if r.Known > 0 {
+		s += fmt.Sprintf(", %d known failures", r.Known)
+	}
+	if len(r.Fixed) > 0 {
+		s += fmt.Sprintf(", %d known failures fixed", len(r.Fixed))
+	}
+	return s
+}
+
+// Failure is a command that failed.
+type Failure struct {
+	File    string
+	Line    int
+	Keyword string
+	Known   bool // Whether the failure is a known one
+	Err     error
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("%s:%d: %s: %v", f.File, f.Line, f.Keyword, f.Err)
+}
+
+// ReadKnownFailures reads a list of known failures from r: one command per
+// line as file:line, blank lines and those starting with a # being ignored.
+func ReadKnownFailures(r io.Reader) (map[string]bool, error) {
+	known := make(map[string]bool)
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		known[line] = true
+	}
+	return known, s.Err()
+}
+
+// RunFile runs the script of the given file. An error is only returned if
+// the script cannot be read or parsed.
+func (r *Runner) RunFile(path string) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	s, err := wast.ParseScript(f)
+	if err != nil {
+		if serr, ok := err.(*wast.SyntaxError); ok && serr.File == "" {
+			serr.File = path
+		}
+		return nil, err
+	}
+	return r.Run(filepath.Base(path), s), nil
+}
+
+// Run runs the script s, named file in the result and the list of known
+// failures.
+func (r *Runner) Run(file string, s *wast.Script) *Result {
+	res := &Result{File: file}
+	st := &state{
+		options:    append([]exec.VMOption{exec.MaxCallDepth(DefaultMaxCallDepth)}, r.Options...),
+		named:      make(map[string]*instance),
+		registered: make(map[string]*wasm.Module),
+	}
+	for _, cmd := range s.Commands {
+		err := st.run(cmd)
+		known := r.KnownFailures[fmt.Sprintf("%s:%d", file, cmd.Line)]
+		switch {
+		case err == nil && known:
+			res.Passed++
+			res.Fixed = append(res.Fixed, cmd.Line)
+		case err == nil:
+			res.Passed++
+		default:
+			f := &Failure{File: file, Line: cmd.Line, Keyword: cmd.Keyword, Known: known, Err: err}
+			res.Failures = append(res.Failures, f)
+			if known {
+				res.Known++
+			} else {
+				res.Failed++
+			}
+			if r.Log != nil {
+				fmt.Fprintln(r.Log, f)
+			}
+		}
+	}
+	return res
+}
+
+// instance is a module of a script, and its VM.
+type instance struct {
+	m  *wasm.Module
+	vm *exec.VM
+}
+
+// bindGlobals binds the exported globals of the module of inst to their
+// cell in its VM, so that the modules importing them share the values of
+// the instance.
+func (inst *instance) bindGlobals() {
+	if inst.m.Export == nil {
+		return
+	}
+	for _, name := range inst.m.Export.Names {
+		entry := inst.m.Export.Entries[name]
+		if entry.Kind != wasm.ExternalGlobal {
+			continue
+		}
+		if cell, ok := inst.vm.ExportedGlobal(name); ok {
+			inst.m.GetGlobal(int(entry.Index)).Cell = cell
+		}
+	}
+}
+
+// state is the state of a running script.
+type state struct {
+	options    []exec.VMOption
+	last       *instance
+	named      map[string]*instance
+	registered map[string]*wasm.Module
+	spectest   *wasm.Module
+}
+
+// stage is a stage of the loading of a module, at which it may fail.
+type stage int
+
+const (
+	stageDecode      stage = iota // The module is malformed
+	stageLink                     // An import could not be resolved
+	stageValidate                 // The module is invalid
+	stageInstantiate              // A segment does not fit, or the start function trapped
+	stageLoaded
+)
+
+var stageNames = [...]string{
+	stageDecode:      "malformed",
+	stageLink:        "unlinkable or invalid",
+	stageValidate:    "invalid",
+	stageInstantiate: "uninstantiable",
+	stageLoaded:      "loaded",
+}
+
+func (s stage) String() string {
+	return stageNames[s]
+}
+
+// run runs the command cmd.
+func (st *state) run(cmd *wast.Command) error {
+	switch cmd.Keyword {
+	case "module":
+		inst, _, err := st.load(cmd.Module)
+		st.last = inst
+		if cmd.Module.ID != "" {
+			st.named[cmd.Module.ID] = inst
+		}
+		return err
+	case "register":
+		inst, err := st.instance(cmd.ModuleID)
+		if err != nil {
+			return err
+		}
+		inst.bindGlobals()
+		st.registered[cmd.Name] = inst.m
+		return nil
+	case "invoke", "get":
+		_, err := st.do(cmd.Action)
+		return err
+	case "assert_return":
+		got, err := st.do(cmd.Action)
+		if err != nil {
+			return err
+		}
+		return checkResults(got, cmd.Results)
+	case "assert_trap":
+		if cmd.Module != nil {
+			_, failed, err := st.load(cmd.Module)
+			if err != nil && failed != stageInstantiate {
+				return fmt.Errorf("expected a trap (%s), but the module is %s: %v", cmd.Message, failed, err)
+			}
+			return expectTrap(err, cmd.Message)
+		}
+		_, err := st.do(cmd.Action)
+		return expectTrap(err, cmd.Message)
+	case "assert_exhaustion":
+		if _, err := st.do(cmd.Action); err != exec.ErrCallStackExhausted {
+			return fmt.Errorf("expected the call stack to be exhausted, got error %v", err)
+		}
+		return nil
+	case "assert_malformed":
+		return st.expectFailure(cmd, stageDecode)
+	case "assert_invalid":
+		return st.expectFailure(cmd, stageLink, stageValidate)
+	case "assert_unlinkable":
+		return st.expectFailure(cmd, stageLink, stageInstantiate)
+	}
+	return fmt.Errorf("unsupported command")
+}
+
+// expectFailure checks that the module of cmd fails to load at one of the
+// given stages.
+func (st *state) expectFailure(cmd *wast.Command, stages ...stage) error {
+	_, failed, err := st.load(cmd.Module)
+	for _, s := range stages {
+		if failed == s {
+			return nil
+		}
+	}
+	if err == nil {
+		return fmt.Errorf("expected a failure: %s", cmd.Message)
+	}
+	return fmt.Errorf("expected a failure (%s), but the module is %s: %v", cmd.Message, failed, err)
+}
+
+// expectTrap checks that err is a trap reported by the reference
+// interpreter with a message starting with msg.
+func expectTrap(err error, msg string) error {
+	if err == nil {
+		return fmt.Errorf("expected a trap: %s", msg)
+	}
+	if got := trapMessage(err); got == "" || !strings.HasPrefix(got, msg) {
+		return fmt.Errorf("expected a trap (%s), got error %v", msg, err)
+	}
+	return nil
+}
+
+// trapMessage returns the message the reference interpreter reports the
+// trap err with, or "" if err is not a trap.
+func trapMessage(err error) string {
+	switch err {
+	case exec.ErrUnreachable:
+		return "unreachable executed"
+	case exec.ErrOutOfBoundsMemoryAccess:
+		return "out of bounds memory access"
+	case exec.ErrUndefinedElementIndex:
+		return "undefined element"
+	case exec.ErrSignatureMismatch:
+		return "indirect call type mismatch"
+	case exec.ErrCallStackExhausted:
+		return "call stack exhausted"
+	}
+	if _, ok := err.(wasm.UninitializedTableEntryError); ok {
+		return "uninitialized element"
+	}
+	// Integer overflows and divisions by zero are reported with the
+	// messages of the Go runtime.
+	for _, msg := range []string{"integer overflow", "integer divide by zero"} {
+		if strings.HasSuffix(err.Error(), msg) {
+			return msg
+		}
+	}
+	return ""
+}
+
+// load loads the module sm, returning the stage at which it failed, if any.
+func (st *state) load(sm *wast.ScriptModule) (inst *instance, failed stage, err error) {
+	if sm.Err != nil {
+		return nil, stageDecode, sm.Err
+	}
+	if _, err := wasm.DecodeModule(bytes.NewReader(sm.Binary)); err != nil {
+		return nil, stageDecode, err
+	}
+	m, err := wasm.ReadModule(bytes.NewReader(sm.Binary), st.resolve)
+	if err != nil {
+		return nil, stageLink, err
+	}
+	if err := validate.VerifyModule(m); err != nil {
+		return nil, stageValidate, err
+	}
+
+	// The start function is run without recovering its traps.
+	defer func() {
+		if e := recover(); e != nil {
+			inst, failed = nil, stageInstantiate
+			if err, _ = e.(error); err == nil {
+				err = fmt.Errorf("%v", e)
+			}
+		}
+	}()
+	vm, err := exec.NewVM(m, st.options...)
+	if err != nil {
+		return nil, stageInstantiate, err
+	}
+	vm.RecoverPanic = true
+	return &instance{m: m, vm: vm}, stageLoaded, nil
+}
+
+// resolve resolves the imports of a module, from the registered modules and
+// the spectest module.
+func (st *state) resolve(name string) (*wasm.Module, error) {
+	if m, ok := st.registered[name]; ok {
+		return m, nil
+	}
+	if name != "spectest" {
+		return nil, fmt.Errorf("spectest: unknown module %q", name)
+	}
+	if st.spectest == nil {
+		m, err := newSpectestModule()
+		if err != nil {
+			return nil, err
+		}
+		st.spectest = m
+	}
+	return st.spectest, nil
+}
+
+// instance returns the module of the given identifier, or the last one.
+func (st *state) instance(id string) (*instance, error) {
+	inst := st.last
+	if id != "" {
+		inst = st.named[id]
+	}
+	if inst == nil {
+		if id != "" {
+			return nil, fmt.Errorf("no module $%s", id)
+		}
+		return nil, errors.New("no module")
+	}
+	return inst, nil
+}
+
+// do performs the action a, returning its results.
+func (st *state) do(a *wast.Action) ([]wast.Value, error) {
+	inst, err := st.instance(a.ModuleID)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := inst.vm.GetExportEntry(a.Field)
+	if !ok {
+		return nil, fmt.Errorf("no export %q", a.Field)
+	}
+	if a.Keyword == "get" {
+		g := inst.m.GetGlobal(int(entry.Index))
+		if entry.Kind != wasm.ExternalGlobal || g == nil {
+			return nil, fmt.Errorf("export %q is not a global", a.Field)
+		}
+		bits, _ := inst.vm.GetGlobal(a.Field)
+		return []wast.Value{{Type: g.Type.Type, Bits: bits}}, nil
+	}
+
+	fn := inst.m.GetFunction(int(entry.Index))
+	if entry.Kind != wasm.ExternalFunction || fn == nil {
+		return nil, fmt.Errorf("export %q is not a function", a.Field)
+	}
+	if len(fn.Sig.ReturnTypes) > 1 {
+		return nil, errors.New("functions with several results are not supported")
+	}
+	args := make([]uint64, len(a.Args))
+	for i, v := range a.Args {
+		args[i] = v.Bits
+	}
+	ret, err := inst.vm.ExecCode(int64(entry.Index), args...)
+	if err != nil {
+		return nil, err
+	}
+	switch v := ret.(type) {
+	case uint32:
+		return []wast.Value{{Type: wasm.ValueTypeI32, Bits: uint64(v)}}, nil
+	case uint64:
+		return []wast.Value{{Type: wasm.ValueTypeI64, Bits: v}}, nil
+	case float32:
+		return []wast.Value{{Type: wasm.ValueTypeF32, Bits: uint64(math.Float32bits(v))}}, nil
+	case float64:
+		return []wast.Value{{Type: wasm.ValueTypeF64, Bits: math.Float64bits(v)}}, nil
+	}
+	return nil, nil
+}
+
+// checkResults checks that the results got match those expected.
+func checkResults(got, want []wast.Value) error {
+	if len(got) != len(want) {
+		return fmt.Errorf("got %d results, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if !match(got[i], w) {
+			return fmt.Errorf("got result %s, want %s", format(got[i]), format(w))
+		}
+	}
+	return nil
+}
+
+// match returns whether the value v matches the expected value w.
+func match(v, w wast.Value) bool {
+	if w.Type != 0 && v.Type != w.Type {
+		return false
+	}
+	var sign, exp, quiet uint64
+	switch v.Type {
+	case wasm.ValueTypeI32:
+		return w.NaN == "" && uint32(v.Bits) == uint32(w.Bits)
+	case wasm.ValueTypeI64:
+		return w.NaN == "" && v.Bits == w.Bits
+	case wasm.ValueTypeF32:
+		sign, exp, quiet = 1<<31, 0x7f800000, 1<<22
+		v.Bits, w.Bits = uint64(uint32(v.Bits)), uint64(uint32(w.Bits))
+	default:
+		sign, exp, quiet = 1<<63, 0x7ff0000000000000, 1<<51
+	}
+	abs := v.Bits &^ sign
+	switch w.NaN {
+	case "canonical":
+		return abs == exp|quiet
+	case "arithmetic":
+		return abs&exp == exp && abs&quiet != 0
+	}
+	return v.Bits == w.Bits
+}
+
+// format returns a description of the value v.
+func format(v wast.Value) string {
+	if v.NaN != "" {
+		return "nan:" + v.NaN
+	}
+	switch v.Type {
+	case wasm.ValueTypeI32:
+		return fmt.Sprintf("i32:%d", int32(v.Bits))
+	case wasm.ValueTypeI64:
+		return fmt.Sprintf("i64:%d", int64(v.Bits))
+	case wasm.ValueTypeF32:
+		return fmt.Sprintf("f32:%v (%#08x)", math.Float32frombits(uint32(v.Bits)), uint32(v.Bits))
+	}
+	return fmt.Sprintf("f64:%v (%#016x)", math.Float64frombits(v.Bits), v.Bits)
+}
@@ -0,0 +1,43 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package spectest
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/go-interpreter/wagon/wast"
+)
+
+// spectestText is the text of the spectest module the scripts import from.
+// Its print functions print nothing.
+const spectestText = `(module
+	(global (export "global_i32") i32 (i32.const 666))
+	(global (export "global_i64") i64 (i64.const 666))
+	(global (export "global_f32") f32 (f32.const 666.6))
+	(global (export "global_f64") f64 (f64.const 666.6))
+	(table (export "table") 10 20 funcref)
+	(memory (export "memory") 1 2)
+	(func (export "print"))
+	(func (export "print_i32") (param i32))
+	(func (export "print_i64") (param i64))
+	(func (export "print_f32") (param f32))
+	(func (export "print_f64") (param f64))
+	(func (export "print_i32_f32") (param i32 f32))
+	(func (export "print_f64_f64") (param f64 f64)))`
+
+// newSpectestModule returns the spectest module, with its index spaces.
+func newSpectestModule() (*wasm.Module, error) {
+	m, err := wast.ParseModule(strings.NewReader(spectestText))
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, m); err != nil {
+		return nil, err
+	}
+	return wasm.ReadModule(buf, nil)
+}
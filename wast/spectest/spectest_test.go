@@ -0,0 +1,92 @@
+// Copyright 2026 The go-interpreter Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package spectest
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/go-interpreter/wagon/wasm"
+	"github.com/go-interpreter/wagon/wast"
+)
+
+func TestRunFile(t *testing.T) {
+	var r Runner
+	res, err := r.RunFile("testdata/commands.wast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range res.Failures {
+		t.Error(f)
+	}
+	if res.Passed != 29 || res.Failed != 0 {
+		t.Errorf("got result %v, want 29 passed", res)
+	}
+}
+
+func TestKnownFailures(t *testing.T) {
+	f, err := os.Open("testdata/known_failures.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	known, err := ReadKnownFailures(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]bool{"failures.wast:5": true, "failures.wast:7": true}; !reflect.DeepEqual(known, want) {
+		t.Fatalf("got known failures %v, want %v", known, want)
+	}
+
+	r := Runner{KnownFailures: known}
+	res, err := r.RunFile("testdata/failures.wast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []int
+	for _, f := range res.Failures {
+		lines = append(lines, f.Line)
+	}
+	if want := []int{6, 7, 8, 9, 13, 14}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("got failures at lines %v, want %v", lines, want)
+	}
+	if want := []int{5}; !reflect.DeepEqual(res.Fixed, want) {
+		t.Errorf("got fixed failures at lines %v, want %v", res.Fixed, want)
+	}
+	want := "failures.wast: 3 passed, 5 failed, 1 known failures, 1 known failures fixed"
+	if got := res.String(); got != want {
+		t.Errorf("got result %q, want %q", got, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	f32 := func(bits uint64) wast.Value { return wast.Value{Type: wasm.ValueTypeF32, Bits: bits} }
+	f64 := func(bits uint64) wast.Value { return wast.Value{Type: wasm.ValueTypeF64, Bits: bits} }
+	canonical := wast.Value{NaN: "canonical"}
+	arithmetic := wast.Value{NaN: "arithmetic"}
+	for _, tc := range []struct {
+		got, want wast.Value
+		match     bool
+	}{
+		{f32(0x7fc00000), canonical, true},
+		{f32(0xffc00000), canonical, true},
+		{f32(0x7fc00001), canonical, false},
+		{f32(0x7fc00001), arithmetic, true},
+		{f32(0x7fa00000), arithmetic, false},
+		{f32(0x7f800000), arithmetic, false},
+		{f64(0x7ff8000000000000), canonical, true},
+		{f64(0xfff8000000000001), arithmetic, true},
+		{f64(0x7ff8000000000000), wast.Value{Type: wasm.ValueTypeF32, NaN: "canonical"}, false},
+		{f32(0x80000000), f32(0x80000000), true},
+		{f32(0x80000000), f32(0), false},
+		{wast.Value{Type: wasm.ValueTypeI32, Bits: 0xffffffff}, wast.Value{Type: wasm.ValueTypeI32, Bits: 0xffffffffffffffff}, true},
+		{wast.Value{Type: wasm.ValueTypeI32, Bits: 1}, canonical, false},
+	} {
+		if got := match(tc.got, tc.want); got != tc.match {
+			t.Errorf("match(%s, %s) = %v, want %v", format(tc.got), format(tc.want), got, tc.match)
+		}
+	}
+}
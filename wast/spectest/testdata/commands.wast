@@ -0,0 +1,75 @@
+;; A script using each command of the spec test suite.
+
+(module $math
+  (func (export "add") (param i32 i32) (result i32)
+    (i32.add (local.get 0) (local.get 1)))
+  (func (export "div") (param i32 i32) (result i32)
+    (i32.div_s (local.get 0) (local.get 1)))
+  (func (export "nan") (param f32) (result f32)
+    (f32.div (local.get 0) (f32.const 0)))
+  (func (export "neg") (param f64) (result f64)
+    (f64.neg (local.get 0)))
+  (func $loop (export "loop") (call $loop))
+  (func (export "fail") (unreachable))
+  (memory 1)
+  (func (export "load") (param i32) (result i32) (i32.load (local.get 0)))
+  (global (export "answer") i64 (i64.const -42))
+  (global $counter (export "counter") (mut i32) (i32.const 0))
+  (func (export "bump")
+    (global.set $counter (i32.add (global.get $counter) (i32.const 1))))
+)
+
+(assert_return (invoke "add" (i32.const 1) (i32.const -2)) (i32.const -1))
+(assert_return (invoke "add" (i32.const 0x7fffffff) (i32.const 1)) (i32.const 0x80000000))
+(assert_return (invoke "nan" (f32.const 0)) (f32.const nan:canonical))
+(assert_return (invoke "nan" (f32.const nan:0x200000)) (f32.const nan:arithmetic))
+(assert_return_canonical_nan (invoke "nan" (f32.const -0)))
+(assert_return (invoke "neg" (f64.const 0x1p-1)) (f64.const -0.5))
+(assert_return (invoke "neg" (f64.const -inf)) (f64.const inf))
+(assert_return (get "answer") (i64.const -42))
+(invoke "add" (i32.const 1) (i32.const 2))
+(get "answer")
+(assert_trap (invoke "div" (i32.const 1) (i32.const 0)) "integer divide by zero")
+(assert_trap (invoke "fail") "unreachable")
+(assert_trap (invoke "load" (i32.const 65536)) "out of bounds memory access")
+(assert_exhaustion (invoke "loop") "call stack exhausted")
+
+(invoke "bump")
+(register "math" $math)
+
+(module
+  (import "math" "add" (func $add (param i32 i32) (result i32)))
+  (import "spectest" "global_i32" (global $g i32))
+  (import "spectest" "print_i32" (func $print (param i32)))
+  (import "math" "counter" (global $counter (mut i32)))
+  (func (export "get_counter") (result i32) (global.get $counter))
+  (func (export "set_counter") (param i32) (global.set $counter (local.get 0)))
+  (func (export "add666") (param i32) (result i32)
+    (call $print (local.get 0))
+    (call $add (local.get 0) (global.get $g)))
+)
+
+(assert_return (invoke "add666" (i32.const 1)) (i32.const 667))
+(assert_return (invoke $math "add" (i32.const 2) (i32.const 3)) (i32.const 5))
+(assert_return (invoke "get_counter") (i32.const 1))
+(invoke "set_counter" (i32.const 5))
+(assert_return (get $math "counter") (i32.const 5))
+
+(assert_invalid
+  (module (func (result i32) (i64.const 0)))
+  "type mismatch")
+(assert_invalid
+  (module binary "\00asm" "\01\00\00\00" "\01\01\00" "\03\02\01\00" "\0a\04\01\02\00\0b")
+  "unknown type")
+(assert_malformed
+  (module quote "(func (i32.const 0x))")
+  "unknown operator")
+(assert_malformed
+  (module binary "\00asm" "\02\00\00\00")
+  "unknown binary version")
+(assert_unlinkable
+  (module (import "spectest" "unknown" (func)))
+  "unknown import")
+(assert_trap
+  (module (func $start unreachable) (start $start))
+  "unreachable")
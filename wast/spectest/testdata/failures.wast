@@ -0,0 +1,14 @@
+(module
+  (func (export "one") (result i32) (i32.const 1))
+)
+
+(assert_return (invoke "one") (i32.const 1))
+(assert_return (invoke "one") (i32.const 2))
+(assert_return (invoke "one") (i32.const 3))
+(assert_trap (invoke "one") "unreachable")
+(assert_return (invoke "missing"))
+(module
+  (func (export "div") (param i32) (result i32) (i32.div_u (i32.const 1) (local.get 0)))
+)
+(assert_trap (invoke "div" (i32.const 0)) "out of bounds memory access")
+(assert_invalid (module binary "\00asm") "type mismatch")
@@ -195,6 +195,7 @@ const (
 	INPUT
 	OUTPUT
 	EOF
+	RESERVED // Keyword without a TokenKind of its own, e.g. an operator
 )
 
 var tokenKindOf = map[string]TokenKind{
@@ -467,6 +468,7 @@ var tokenStrings = [...]string{
 	INPUT:                        "INPUT",
 	OUTPUT:                       "OUTPUT",
 	EOF:                          "EOF",
+	RESERVED:                     "RESERVED",
 }
 
 func (t TokenKind) String() string {
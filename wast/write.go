@@ -10,7 +10,9 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/go-interpreter/wagon/disasm"
 	"github.com/go-interpreter/wagon/wasm"
@@ -19,12 +21,45 @@ import (
 
 const tab = `  `
 
+// WriteOption describes a customization of the text written by WriteTo.
+type WriteOption func(w *writer)
+
+// FoldExprs sets whether the instructions of functions are written in the
+// folded form, as S-expressions nesting the instructions computing their
+// operands, instead of the flat form.
+func FoldExprs(v bool) WriteOption {
+	return func(w *writer) {
+		w.fold = v
+	}
+}
+
+// Names sets whether locals and globals are written with their identifiers
+// from the name section, and blocks, loops, ifs and trys with generated
+// labels, $B1, $L2, $I3 or $T4 after their kind and depth. Functions are
+// always written with the identifiers of the name section.
+func Names(v bool) WriteOption {
+	return func(w *writer) {
+		w.names = v
+	}
+}
+
+// Annotations sets whether the custom sections are written as @custom
+// annotations, placed after the section preceding them.
+func Annotations(v bool) WriteOption {
+	return func(w *writer) {
+		w.annotate = v
+	}
+}
+
 // WriteTo writes a WASM module in a text representation.
-func WriteTo(w io.Writer, m *wasm.Module) error {
+func WriteTo(w io.Writer, m *wasm.Module, opts ...WriteOption) error {
 	wr, err := newWriter(w, m)
 	if err != nil {
 		return err
 	}
+	for _, opt := range opts {
+		opt(wr)
+	}
 	return wr.writeModule()
 }
 
@@ -32,25 +67,84 @@ type writer struct {
 	bw *bufio.Writer
 	m  *wasm.Module
 
-	fnames  wasm.NameMap
-	funcOff int
-	err     error
+	fnames    wasm.NameMap
+	funcOff   int
+	tableOff  int
+	memOff    int
+	globalOff int
+	tagOff    int
+	err       error
+
+	fold     bool
+	names    bool
+	annotate bool
+
+	gnames wasm.NameMap            // Names of the globals
+	lnames map[uint32]wasm.NameMap // Names of the locals of each function
+
+	results int          // The number of results of the function being written
+	locals  wasm.NameMap // The names of its locals, if names are used
+	labels  []string     // The labels of the blocks enclosing the instruction
 }
 
 func newWriter(w io.Writer, m *wasm.Module) (*writer, error) {
-	wr := &writer{bw: bufio.NewWriter(w), m: m}
+	wr := &writer{bw: bufio.NewWriter(w), m: m, fnames: make(wasm.NameMap)}
 	if s := m.Custom(wasm.CustomSectionName); s != nil {
 		var names wasm.NameSection
 		_ = names.UnmarshalWASM(bytes.NewReader(s.Data))
 		sub, _ := names.Decode(wasm.NameFunction)
 		funcs, ok := sub.(*wasm.FunctionNames)
 		if ok {
-			wr.fnames = funcs.Names
+			wr.fnames = uniqueNames(funcs.Names)
+		}
+		sub, _ = names.Decode(wasm.NameLocal)
+		if locals, ok := sub.(*wasm.LocalNames); ok {
+			wr.lnames = make(map[uint32]wasm.NameMap, len(locals.Funcs))
+			for i, names := range locals.Funcs {
+				wr.lnames[i] = uniqueNames(names)
+			}
+		}
+		sub, _ = names.Decode(wasm.NameGlobal)
+		if globals, ok := sub.(*wasm.GlobalNames); ok {
+			wr.gnames = uniqueNames(globals.Names)
 		}
 	}
 	return wr, nil
 }
 
+// uniqueNames returns the names that are valid identifiers, the first one
+// only of those given to several entries.
+func uniqueNames(names wasm.NameMap) wasm.NameMap {
+	indices := make([]uint32, 0, len(names))
+	for i := range names {
+		indices = append(indices, i)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+
+	unique := make(wasm.NameMap)
+	used := make(map[string]bool)
+	for _, i := range indices {
+		if name := names[i]; isIDName(name) && !used[name] {
+			unique[i] = name
+			used[name] = true
+		}
+	}
+	return unique
+}
+
+// isIDName returns whether $name is a valid identifier.
+func isIDName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r <= ' ' || r >= 0x7f || strings.ContainsRune(`"(),;[]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
+
 func (w *writer) writeModule() error {
 	bw := w.bw
 	bw.WriteString("(module")
@@ -61,9 +155,12 @@ func (w *writer) writeModule() error {
 	w.writeGlobals()
 	w.writeTables()
 	w.writeMemory()
+	w.writeTags()
 	w.writeExports()
+	w.writeStart()
 	w.writeElements()
 	w.writeData()
+	w.writeCustoms()
 
 	bw.WriteString(")\n")
 	if err := bw.Flush(); err != nil {
@@ -118,27 +215,50 @@ func (w *writer) writeImports() {
 	if w.m.Import == nil {
 		return
 	}
+	used := make(map[string]bool)
+	for _, name := range w.fnames {
+		used[name] = true
+	}
 	w.WriteString("\n")
 	for i, e := range w.m.Import.Entries {
 		if i != 0 {
 			w.WriteString("\n")
 		}
 		w.WriteString(tab + "(import ")
-		w.Print("%q %q ", e.ModuleName, e.FieldName)
+		w.Print("%s %s ", quoteData([]byte(e.ModuleName)), quoteData([]byte(e.FieldName)))
 		switch im := e.Type.(type) {
 		case wasm.FuncImport:
-			w.Print("(func (;%d;) (type %d))", w.funcOff, im.Type)
-			if w.fnames == nil {
-				w.fnames = make(wasm.NameMap)
+			index := uint32(w.funcOff)
+			if _, ok := w.fnames[index]; !ok {
+				if name := e.ModuleName + "." + e.FieldName; isIDName(name) && !used[name] {
+					w.fnames[index] = name
+					used[name] = true
+				}
+			}
+			if name, ok := w.fnames[index]; ok {
+				w.Print("(func $%s (type %d))", name, im.Type)
+			} else {
+				w.Print("(func (;%d;) (type %d))", index, im.Type)
 			}
-			w.fnames[uint32(w.funcOff)] = e.ModuleName + "." + e.FieldName
 			w.funcOff++
 		case wasm.TableImport:
-			// TODO
+			w.Print("(table (;%d;)", w.tableOff)
+			w.writeTableType(im.Type)
+			w.WriteString(")")
+			w.tableOff++
 		case wasm.MemoryImport:
-			// TODO
+			w.Print("(memory (;%d;)", w.memOff)
+			w.writeMemoryType(im.Type)
+			w.WriteString(")")
+			w.memOff++
 		case wasm.GlobalVarImport:
-			// TODO
+			w.WriteString("(global " + w.globalID(uint32(w.globalOff)))
+			w.writeGlobalType(im.Type)
+			w.WriteString(")")
+			w.globalOff++
+		case wasm.TagImport:
+			w.Print("(tag (;%d;) (type %d))", w.tagOff, im.Type.Type)
+			w.tagOff++
 		}
 		w.WriteString(")")
 	}
@@ -161,28 +281,63 @@ func (w *writer) writeFunctions() {
 			fmt.Fprintf(w.bw, "(;%d;)", ind)
 		}
 		fmt.Fprintf(w.bw, " (type %d)", int(t))
-		if int(t) < len(w.m.Types.Entries) {
-			sig := w.m.Types.Entries[t]
-			w.writeFuncType(sig)
+		w.locals = nil
+		if w.names {
+			w.locals = w.lnames[uint32(ind)]
 		}
+		sig := w.typeSig(t)
+		w.writeLocals(" ", "param", sig.ParamTypes, 0)
+		w.writeLocals(" ", "result", sig.ReturnTypes, -1)
+		w.results = len(sig.ReturnTypes)
 		if w.m.Code != nil && i < len(w.m.Code.Bodies) {
 			b := w.m.Code.Bodies[i]
-			if len(b.Locals) > 0 {
-				w.WriteString("\n" + tab + tab + "(local")
-				for _, l := range b.Locals {
-					for i := 0; i < int(l.Count); i++ {
-						w.WriteString(" ")
-						w.WriteString(l.Type.String())
-					}
+			var locals []wasm.ValueType
+			for _, l := range b.Locals {
+				for i := 0; i < int(l.Count); i++ {
+					locals = append(locals, l.Type)
 				}
-				w.WriteString(")")
 			}
+			w.writeLocals("\n"+tab+tab, "local", locals, len(sig.ParamTypes))
 			w.writeCode(b.Code, false)
 		}
 		w.WriteString(")")
 	}
 }
 
+// writeLocals writes the types of the parameters, results or locals of a
+// function as lists starting with kw, the first one preceded by sep. The
+// locals named in w.locals from the index off are written in their own
+// list, and if off is negative the types are results, without names.
+func (w *writer) writeLocals(sep, kw string, types []wasm.ValueType, off int) {
+	open := false
+	for i, t := range types {
+		name, named := "", false
+		if off >= 0 {
+			name, named = w.locals[uint32(off+i)]
+		}
+		if open && named {
+			w.WriteString(")")
+			open = false
+		}
+		if !open {
+			w.WriteString(sep + "(" + kw)
+			sep = " "
+			open = true
+		}
+		if named {
+			w.WriteString(" $" + name)
+		}
+		w.WriteString(" " + t.String())
+		if named {
+			w.WriteString(")")
+			open = false
+		}
+	}
+	if open {
+		w.WriteString(")")
+	}
+}
+
 func (w *writer) writeGlobals() {
 	if w.m.Global == nil {
 		return
@@ -190,20 +345,39 @@ func (w *writer) writeGlobals() {
 	for i, e := range w.m.Global.Globals {
 		w.WriteString("\n")
 		w.WriteString(tab + "(global ")
-		w.Print("(;%d;)", i)
-		if e.Type.Mutable {
-			w.WriteString(" (mut")
-		}
-		w.Print(" %v", e.Type.Type)
-		if e.Type.Mutable {
-			w.WriteString(")")
-		}
+		w.WriteString(w.globalID(uint32(w.globalOff + i)))
+		w.writeGlobalType(e.Type)
 		w.WriteString(" (")
 		w.writeCode(e.Init, true)
 		w.WriteString("))")
 	}
 }
 
+// globalID returns the identifier of the global of the given index, or a
+// comment with its index if it has none.
+func (w *writer) globalID(index uint32) string {
+	if name, ok := w.gnames[index]; ok && w.names {
+		return "$" + name
+	}
+	return fmt.Sprintf("(;%d;)", index)
+}
+
+// funcRef returns a reference to the function of the given index.
+func (w *writer) funcRef(index uint32) string {
+	if name, ok := w.fnames[index]; ok {
+		return "$" + name
+	}
+	return strconv.FormatUint(uint64(index), 10)
+}
+
+func (w *writer) writeGlobalType(t wasm.GlobalVar) {
+	if t.Mutable {
+		w.Print(" (mut %v)", t.Type)
+	} else {
+		w.Print(" %v", t.Type)
+	}
+}
+
 func (w *writer) writeTables() {
 	if w.m.Table == nil {
 		return
@@ -211,16 +385,20 @@ func (w *writer) writeTables() {
 	w.WriteString("\n")
 	for i, t := range w.m.Table.Entries {
 		w.WriteString(tab + "(table ")
-		w.Print("(;%d;)", i)
-		w.Print(" %d %d ", t.Limits.Initial, t.Limits.Maximum)
-		switch t.ElementType {
-		case wasm.ElemTypeAnyFunc:
-			w.WriteString("anyfunc")
-		}
+		w.Print("(;%d;)", w.tableOff+i)
+		w.writeTableType(t)
 		w.WriteString(")")
 	}
 }
 
+func (w *writer) writeTableType(t wasm.Table) {
+	w.writeLimits(t.Limits)
+	switch t.ElementType {
+	case wasm.ElemTypeAnyFunc:
+		w.WriteString(" anyfunc")
+	}
+}
+
 func (w *writer) writeMemory() {
 	if w.m.Memory == nil {
 		return
@@ -228,15 +406,36 @@ func (w *writer) writeMemory() {
 	w.WriteString("\n")
 	for i, e := range w.m.Memory.Entries {
 		w.WriteString(tab + "(memory ")
-		w.Print("(;%d;)", i)
-		w.Print(" %d", e.Limits.Initial)
-		if e.Limits.Flags&0x1 != 0 {
-			w.Print(" %d", e.Limits.Maximum)
-		}
+		w.Print("(;%d;)", w.memOff+i)
+		w.writeMemoryType(e)
 		w.WriteString(")")
 	}
 }
 
+func (w *writer) writeMemoryType(m wasm.Memory) {
+	if m.Limits.Is64() {
+		w.WriteString(" i64")
+	}
+	w.writeLimits(m.Limits)
+}
+
+func (w *writer) writeLimits(l wasm.ResizableLimits) {
+	w.Print(" %d", l.Initial)
+	if l.Flags&0x1 != 0 {
+		w.Print(" %d", l.Maximum)
+	}
+}
+
+func (w *writer) writeTags() {
+	if w.m.Tags == nil {
+		return
+	}
+	for i, t := range w.m.Tags.Entries {
+		w.WriteString("\n")
+		w.Print(tab+"(tag (;%d;) (type %d))", w.tagOff+i, t.Type)
+	}
+}
+
 func (w *writer) writeExports() {
 	if w.m.Export == nil {
 		return
@@ -247,19 +446,33 @@ func (w *writer) writeExports() {
 		if i != 0 {
 			w.WriteString("\n")
 		}
-		w.Print(tab+"(export %q (", e.FieldStr)
+		w.Print(tab+"(export %s (", quoteData([]byte(e.FieldStr)))
+		ref := strconv.FormatUint(uint64(e.Index), 10)
 		switch e.Kind {
 		case wasm.ExternalFunction:
 			w.WriteString("func")
+			ref = w.funcRef(e.Index)
 		case wasm.ExternalMemory:
 			w.WriteString("memory")
 		case wasm.ExternalTable:
 			w.WriteString("table")
 		case wasm.ExternalGlobal:
 			w.WriteString("global")
+			if name, ok := w.gnames[e.Index]; ok && w.names {
+				ref = "$" + name
+			}
+		case wasm.ExternalTag:
+			w.WriteString("tag")
 		}
-		w.Print(" %d))", e.Index)
+		w.Print(" %s))", ref)
+	}
+}
+
+func (w *writer) writeStart() {
+	if w.m.Start == nil {
+		return
 	}
+	w.Print("\n"+tab+"(start %s)", w.funcRef(w.m.Start.Index))
 }
 
 func (w *writer) writeElements() {
@@ -276,7 +489,7 @@ func (w *writer) writeElements() {
 		w.writeCode(d.Offset, true)
 		w.WriteString(")")
 		for _, v := range d.Elems {
-			w.Print(" %d", v)
+			w.WriteString(" " + w.funcRef(v))
 		}
 		w.WriteString(")")
 	}
@@ -298,6 +511,32 @@ func (w *writer) writeData() {
 	}
 }
 
+// writeCustoms writes the custom sections as annotations, if enabled.
+func (w *writer) writeCustoms() {
+	if !w.annotate {
+		return
+	}
+	if len(w.m.Sections) == 0 {
+		for _, c := range w.m.Customs {
+			w.Print("\n"+tab+"(@custom %s %s)", quoteData([]byte(c.Name)), quoteData(c.Data))
+		}
+		return
+	}
+	place := "(before first)"
+	for _, s := range w.m.Sections {
+		c, ok := s.(*wasm.SectionCustom)
+		if !ok {
+			for _, n := range sectionNames {
+				if n.id == s.SectionID() {
+					place = "(after " + n.name + ")"
+				}
+			}
+			continue
+		}
+		w.Print("\n"+tab+"(@custom %s %s %s)", quoteData([]byte(c.Name)), place, quoteData(c.Data))
+	}
+}
+
 func (w *writer) WriteString(s string) {
 	if w.err != nil {
 		return
@@ -339,18 +578,22 @@ func (w *writer) writeCode(code []byte, isInit bool) {
 		w.err = err
 		return
 	}
+	if w.fold && !isInit {
+		w.writeFolded(instr)
+		return
+	}
 	tabs := 2
 	block := 0
-	writeBlock := func(d int) {
-		w.Print(" %d (;@%d;)", d, block-d)
-	}
 	hadEnd := false
 	for i, ins := range instr {
 		if !isInit {
 			w.WriteString("\n")
 		}
 		switch ins.Op.Code {
-		case operators.End, operators.Else:
+		case operators.End, operators.Delegate:
+			w.popLabel()
+			fallthrough
+		case operators.Else, operators.Catch, operators.CatchAll:
 			tabs--
 			block--
 		}
@@ -367,124 +610,232 @@ func (w *writer) writeCode(code []byte, isInit bool) {
 				w.WriteString(tab)
 			}
 		}
-		w.WriteString(ins.Op.Name)
 		switch ins.Op.Code {
-		case operators.Else:
+		case operators.Else, operators.Catch, operators.CatchAll:
+			w.WriteString(w.instrText(ins, block))
 			tabs++
 			block++
-		case operators.Block, operators.Loop, operators.If:
+		case operators.Block, operators.Loop, operators.If, operators.Try:
 			tabs++
 			block++
-			b := ins.Immediates[0].(wasm.BlockType)
-			if b != wasm.BlockTypeEmpty {
-				w.WriteString(" (result ")
-				w.WriteString(b.String())
-				w.WriteString(")")
-			}
-			w.Print("  ;; label = @%d", block)
-			continue
-		case operators.F32Const:
-			i1 := ins.Immediates[0].(float32)
-			w.WriteString(" " + formatFloat32(i1))
-			continue
-		case operators.F64Const:
-			i1 := ins.Immediates[0].(float64)
-			w.WriteString(" " + formatFloat64(i1))
-			continue
-		case operators.BrIf, operators.Br:
-			i1 := ins.Immediates[0].(uint32)
-			writeBlock(int(i1))
-			continue
-		case operators.BrTable:
-			n := ins.Immediates[0].(uint32)
-			for i := 0; i < int(n); i++ {
-				v := ins.Immediates[i+1].(uint32)
-				writeBlock(int(v))
-			}
-			def := ins.Immediates[n+1].(uint32)
-			writeBlock(int(def))
-			continue
-		case operators.Call:
-			i1 := ins.Immediates[0].(uint32)
-			if name, ok := w.fnames[i1]; ok {
-				w.WriteString(" $")
-				w.WriteString(name)
-			} else {
-				w.Print(" %v", i1)
-			}
-			continue
-		case operators.CallIndirect:
-			i1 := ins.Immediates[0].(uint32)
-			w.Print(" (type %d)", i1)
-			continue
-		case operators.CurrentMemory, operators.GrowMemory:
-			r := ins.Immediates[0].(uint8)
-			if r == 0 {
-				continue
-			}
-		case operators.I32Store, operators.I64Store,
-			operators.I32Store8, operators.I64Store8,
-			operators.I32Store16, operators.I64Store16,
-			operators.I64Store32,
-			operators.F32Store, operators.F64Store,
-			operators.I32Load, operators.I64Load,
-			operators.I32Load8u, operators.I32Load8s,
-			operators.I32Load16u, operators.I32Load16s,
-			operators.I64Load8u, operators.I64Load8s,
-			operators.I64Load16u, operators.I64Load16s,
-			operators.I64Load32u, operators.I64Load32s,
-			operators.F32Load, operators.F64Load:
-
-			i1 := ins.Immediates[0].(uint32)
-			i2 := ins.Immediates[1].(uint32)
-			dst := 0 // in log 2 (i8)
-			switch ins.Op.Code {
-			case operators.I64Load, operators.I64Store,
-				operators.F64Load, operators.F64Store:
-				dst = 3
-			case operators.I32Load, operators.I64Load32s, operators.I64Load32u,
-				operators.I32Store, operators.I64Store32,
-				operators.F32Load, operators.F32Store:
-				dst = 2
-			case operators.I32Load16u, operators.I32Load16s, operators.I64Load16u, operators.I64Load16s,
-				operators.I32Store16, operators.I64Store16:
-				dst = 1
-			case operators.I32Load8u, operators.I32Load8s, operators.I64Load8u, operators.I64Load8s,
-				operators.I32Store8, operators.I64Store8:
-				dst = 0
+			w.WriteString(w.instrText(ins, block))
+			w.pushLabel(ins.Op.Code, block)
+			if !w.names {
+				w.Print("  ;; label = @%d", block)
 			}
-			if i2 != 0 {
-				w.Print(" offset=%d", i2)
-			}
-			if int(i1) != dst {
-				w.Print(" align=%d", 1<<i1)
+		default:
+			w.WriteString(w.instrText(ins, block))
+		}
+	}
+}
+
+// labelName returns the label generated for the block, loop, if or try op
+// at the given depth.
+func labelName(op byte, block int) string {
+	prefix := "B"
+	switch op {
+	case operators.Loop:
+		prefix = "L"
+	case operators.If:
+		prefix = "I"
+	case operators.Try:
+		prefix = "T"
+	}
+	return prefix + strconv.Itoa(block)
+}
+
+// pushLabel enters the block, loop, if or try op, at the given depth.
+func (w *writer) pushLabel(op byte, block int) {
+	w.labels = append(w.labels, labelName(op, block))
+}
+
+// popLabel leaves the innermost block.
+func (w *writer) popLabel() {
+	if n := len(w.labels); n > 0 {
+		w.labels = w.labels[:n-1]
+	}
+}
+
+// instrText returns the text of the instruction ins, nested in the given
+// number of blocks: its name followed by its immediates.
+func (w *writer) instrText(ins disasm.Instr, block int) string {
+	buf := new(bytes.Buffer)
+	buf.WriteString(ins.Op.Name)
+	writeBlock := func(d int) {
+		if n := len(w.labels); w.names && d < n {
+			buf.WriteString(" $" + w.labels[n-1-d])
+			return
+		}
+		fmt.Fprintf(buf, " %d (;@%d;)", d, block-d)
+	}
+	switch ins.Op.Code {
+	case operators.Else, operators.Catch, operators.CatchAll:
+	case operators.Block, operators.Loop, operators.If, operators.Try:
+		if w.names {
+			buf.WriteString(" $" + labelName(ins.Op.Code, block))
+		}
+		b := ins.Immediates[0].(wasm.BlockType)
+		if b != wasm.BlockTypeEmpty {
+			buf.WriteString(" (result ")
+			buf.WriteString(b.String())
+			buf.WriteString(")")
+		}
+		return buf.String()
+	case operators.F32Const:
+		i1 := ins.Immediates[0].(float32)
+		buf.WriteString(" " + formatFloat32(i1))
+		return buf.String()
+	case operators.F64Const:
+		i1 := ins.Immediates[0].(float64)
+		buf.WriteString(" " + formatFloat64(i1))
+		return buf.String()
+	case operators.BrIf, operators.Br, operators.Rethrow, operators.Delegate:
+		i1 := ins.Immediates[0].(uint32)
+		writeBlock(int(i1))
+		return buf.String()
+	case operators.BrTable:
+		n := ins.Immediates[0].(uint32)
+		for i := 0; i < int(n); i++ {
+			v := ins.Immediates[i+1].(uint32)
+			writeBlock(int(v))
+		}
+		def := ins.Immediates[n+1].(uint32)
+		writeBlock(int(def))
+		return buf.String()
+	case operators.Call, operators.ReturnCall:
+		i1 := ins.Immediates[0].(uint32)
+		buf.WriteString(" " + w.funcRef(i1))
+		return buf.String()
+	case operators.GetLocal, operators.SetLocal, operators.TeeLocal:
+		i1 := ins.Immediates[0].(uint32)
+		if name, ok := w.locals[i1]; ok {
+			buf.WriteString(" $" + name)
+			return buf.String()
+		}
+	case operators.GetGlobal, operators.SetGlobal:
+		i1 := ins.Immediates[0].(uint32)
+		if name, ok := w.gnames[i1]; ok && w.names {
+			buf.WriteString(" $" + name)
+			return buf.String()
+		}
+	case operators.CallIndirect, operators.ReturnCallIndirect:
+		i1 := ins.Immediates[0].(uint32)
+		fmt.Fprintf(buf, " (type %d)", i1)
+		return buf.String()
+	case operators.CurrentMemory, operators.GrowMemory, operators.MemoryFill:
+		r := ins.Immediates[0].(uint32)
+		if r == 0 {
+			return buf.String()
+		}
+	case operators.MemoryCopy:
+		if ins.Immediates[0].(uint32) == 0 && ins.Immediates[1].(uint32) == 0 {
+			return buf.String()
+		}
+	case operators.I32Store, operators.I64Store,
+		operators.I32Store8, operators.I64Store8,
+		operators.I32Store16, operators.I64Store16,
+		operators.I64Store32,
+		operators.F32Store, operators.F64Store,
+		operators.I32Load, operators.I64Load,
+		operators.I32Load8u, operators.I32Load8s,
+		operators.I32Load16u, operators.I32Load16s,
+		operators.I64Load8u, operators.I64Load8s,
+		operators.I64Load16u, operators.I64Load16s,
+		operators.I64Load32u, operators.I64Load32s,
+		operators.F32Load, operators.F64Load:
+
+		i1 := ins.Immediates[0].(uint32)
+		var i2 uint64
+		switch v := ins.Immediates[1].(type) {
+		case uint32:
+			i2 = uint64(v)
+		case uint64:
+			i2 = v
+		}
+		dst := 0 // in log 2 (i8)
+		switch ins.Op.Code {
+		case operators.I64Load, operators.I64Store,
+			operators.F64Load, operators.F64Store:
+			dst = 3
+		case operators.I32Load, operators.I64Load32s, operators.I64Load32u,
+			operators.I32Store, operators.I64Store32,
+			operators.F32Load, operators.F32Store:
+			dst = 2
+		case operators.I32Load16u, operators.I32Load16s, operators.I64Load16u, operators.I64Load16s,
+			operators.I32Store16, operators.I64Store16:
+			dst = 1
+		case operators.I32Load8u, operators.I32Load8s, operators.I64Load8u, operators.I64Load8s,
+			operators.I32Store8, operators.I64Store8:
+			dst = 0
+		}
+		if len(ins.Immediates) > 2 {
+			if idx := ins.Immediates[2].(uint32); idx != 0 {
+				fmt.Fprintf(buf, " %d", idx)
 			}
-			continue
 		}
-		for _, a := range ins.Immediates {
-			w.WriteString(" ")
-			w.Print("%v", a)
+		if i2 != 0 {
+			fmt.Fprintf(buf, " offset=%d", i2)
+		}
+		if int(i1) != dst {
+			fmt.Fprintf(buf, " align=%d", 1<<i1)
 		}
+		return buf.String()
 	}
+	for _, a := range ins.Immediates {
+		buf.WriteString(" ")
+		fmt.Fprintf(buf, "%v", a)
+	}
+	return buf.String()
 }
 
 func formatFloat32(v float32) string {
-	s := ""
-	if v == float32(int32(v)) {
-		s = strconv.FormatInt(int64(v), 10)
-	} else {
-		s = strconv.FormatFloat(float64(v), 'g', -1, 32)
-	}
-	return fmt.Sprintf("%#0x (;=%s;)", math.Float32bits(v), s)
+	return formatFloat(uint64(math.Float32bits(v)), 32)
 }
 
 func formatFloat64(v float64) string {
-	// TODO: https://github.com/WebAssembly/wabt/blob/master/src/literal.cc (FloatWriter<T>::WriteHex)
-	s := ""
-	if v == float64(int64(v)) {
-		s = strconv.FormatInt(int64(v), 10)
-	} else {
-		s = strconv.FormatFloat(float64(v), 'g', -1, 64)
+	return formatFloat(math.Float64bits(v), 64)
+}
+
+// formatFloat returns the hexadecimal literal of the float of the given size
+// whose bits are bits, followed by its decimal value in a comment. NaNs keep
+// their payload, which a conversion to float64 may alter.
+func formatFloat(bits uint64, size int) string {
+	mantBits := uint(52)
+	if size == 32 {
+		mantBits = 23
+	}
+	expMask := uint64(1)<<uint(size-1) - 1<<mantBits
+	mantMask := uint64(1)<<mantBits - 1
+	sign := ""
+	if bits>>uint(size-1) != 0 {
+		sign = "-"
+	}
+	switch mant := bits & mantMask; {
+	case bits&expMask != expMask:
+	case mant == 0:
+		return sign + "inf"
+	case mant == 1<<(mantBits-1):
+		return sign + "nan"
+	default:
+		return fmt.Sprintf("%snan:%#x", sign, mant)
+	}
+
+	v := math.Float64frombits(bits)
+	if size == 32 {
+		v = float64(math.Float32frombits(uint32(bits)))
+	}
+	s := strconv.FormatFloat(v, 'x', -1, size)
+	// Go writes at least two digits of exponent, e.g. 0x1p+00.
+	if p := strings.IndexByte(s, 'p'); p >= 0 {
+		exp := strings.TrimLeft(s[p+2:], "0")
+		if exp == "" {
+			exp = "0"
+		}
+		s = s[:p+2] + exp
+	}
+	dec := strconv.FormatFloat(v, 'g', -1, size)
+	if v == math.Trunc(v) && math.Abs(v) < 1e21 {
+		dec = strconv.FormatFloat(v, 'f', -1, size)
 	}
-	return fmt.Sprintf("%#0x (;=%v;)", math.Float64bits(v), s)
+	return fmt.Sprintf("%s (;=%s;)", s, dec)
 }
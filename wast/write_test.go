@@ -64,3 +64,266 @@ func TestAssemble(t *testing.T) {
 		}
 	}
 }
+
+// encodeSections returns the encoding of the sections of m, except empty
+// ones, which the text format cannot express, and custom sections unless
+// customs is set. The name section is left out, as the identifiers written
+// for imports are added to it.
+func encodeSections(t *testing.T, m *wasm.Module, customs bool) []byte {
+	var sections []wasm.Section
+	for _, s := range m.Sections {
+		if c, ok := s.(*wasm.SectionCustom); ok && (!customs || c.Name == wasm.CustomSectionName) {
+			continue
+		}
+		buf := new(bytes.Buffer)
+		if err := s.WritePayload(buf); err != nil {
+			t.Fatal(err)
+		}
+		if s.SectionID() != wasm.SectionIDStart && bytes.Equal(buf.Bytes(), []byte{0}) {
+			continue
+		}
+		sections = append(sections, s)
+	}
+	buf := new(bytes.Buffer)
+	if err := wasm.EncodeModule(buf, &wasm.Module{Sections: sections}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWriteParse(t *testing.T) {
+	for _, dir := range testPaths {
+		fnames, err := filepath.Glob(filepath.Join(dir, "*.wasm"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, fname := range fnames {
+			name := fname
+			t.Run(filepath.Base(name), func(t *testing.T) {
+				raw, err := ioutil.ReadFile(name)
+				if err != nil {
+					t.Fatal(err)
+				}
+				m, err := wasm.DecodeModule(bytes.NewReader(raw))
+				if err != nil {
+					t.Skipf("error reading module %v", err)
+				}
+				for i := 0; i < 8; i++ {
+					fold, names, annotations := i&1 != 0, i&2 != 0, i&4 != 0
+					buf := new(bytes.Buffer)
+					err := wast.WriteTo(buf, m, wast.FoldExprs(fold), wast.Names(names), wast.Annotations(annotations))
+					if err != nil {
+						t.Fatal(err)
+					}
+					got, err := wast.ParseModule(buf)
+					if err != nil {
+						t.Fatalf("fold=%v names=%v annotations=%v: %v", fold, names, annotations, err)
+					}
+					if !bytes.Equal(encodeSections(t, got, annotations), encodeSections(t, m, annotations)) {
+						t.Errorf("fold=%v names=%v annotations=%v: the parsed module differs", fold, names, annotations)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestWriteFolded(t *testing.T) {
+	const src = `(module
+  (func $f (param i32 i32) (result i32)
+    local.get 0
+    local.get 1
+    i32.add
+    block (result i32)
+      local.get 0
+      br_if 0
+      drop
+      i32.const 1
+    end
+    i32.mul
+    if
+      nop
+    else
+      local.get 1
+      local.get 0
+      call $f
+      return
+    end
+    loop
+    end
+    i32.const 0))
+`
+	const want = `(module
+  (type (;0;) (func (param i32 i32) (result i32)))
+  (func $f (type 0) (param i32 i32) (result i32)
+    (if  ;; label = @1
+      (i32.mul
+        (i32.add
+          (get_local 0)
+          (get_local 1))
+        (block (result i32)  ;; label = @1
+          (drop
+            (br_if 0 (;@1;)
+              (get_local 0)))
+          (i32.const 1)))
+      (then
+        (nop))
+      (else
+        (return
+          (call $f
+            (get_local 1)
+            (get_local 0)))))
+    (loop  ;; label = @1
+    )
+    (i32.const 0)))
+`
+	m, err := wast.ParseModule(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := wast.WriteTo(buf, m, wast.FoldExprs(true)); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestWriteNames(t *testing.T) {
+	const src = `(module
+  (global $sp (mut i32) (i32.const 1024))
+  (func $fac (export "fac") (param i32) (param $n i32) (result i32) (local $acc i32) (local f64)
+    i32.const 1
+    local.set $acc
+    block $done
+      loop $again
+        local.get $n
+        i32.eqz
+        br_if $done
+        local.get $acc
+        local.get $n
+        i32.mul
+        local.set $acc
+        local.get $n
+        i32.const 1
+        i32.sub
+        local.set $n
+        br $again
+      end
+    end
+    global.get $sp
+    drop
+    local.get $acc)
+  (@custom "producers" (after code) "wagon\00"))
+`
+	for _, tc := range []struct {
+		opts []wast.WriteOption
+		want string
+	}{
+		{
+			opts: []wast.WriteOption{wast.Names(true), wast.Annotations(true)},
+			want: `(module
+  (type (;0;) (func (param i32 i32) (result i32)))
+  (func $fac (type 0) (param i32) (param $n i32) (result i32)
+    (local $acc i32) (local f64)
+    i32.const 1
+    set_local $acc
+    block $B1
+      loop $L2
+        get_local $n
+        i32.eqz
+        br_if $B1
+        get_local $acc
+        get_local $n
+        i32.mul
+        set_local $acc
+        get_local $n
+        i32.const 1
+        i32.sub
+        set_local $n
+        br $L2
+      end
+    end
+    get_global $sp
+    drop
+    get_local $acc)
+  (global $sp (mut i32) (i32.const 1024))
+  (export "fac" (func $fac))
+  (@custom "producers" (after code) "wagon\00")
+  (@custom "name" (after code) "\01\06\01\00\03fac\02\0b\01\00\02\01\01n\02\03acc\07\05\01\00\02sp"))
+`,
+		},
+		{
+			opts: []wast.WriteOption{wast.Names(true), wast.FoldExprs(true)},
+			want: `(module
+  (type (;0;) (func (param i32 i32) (result i32)))
+  (func $fac (type 0) (param i32) (param $n i32) (result i32)
+    (local $acc i32) (local f64)
+    (set_local $acc
+      (i32.const 1))
+    (block $B1
+      (loop $L2
+        (br_if $B1
+          (i32.eqz
+            (get_local $n)))
+        (set_local $acc
+          (i32.mul
+            (get_local $acc)
+            (get_local $n)))
+        (set_local $n
+          (i32.sub
+            (get_local $n)
+            (i32.const 1)))
+        (br $L2)))
+    (drop
+      (get_global $sp))
+    (get_local $acc))
+  (global $sp (mut i32) (i32.const 1024))
+  (export "fac" (func $fac)))
+`,
+		},
+		{
+			want: `(module
+  (type (;0;) (func (param i32 i32) (result i32)))
+  (func $fac (type 0) (param i32 i32) (result i32)
+    (local i32 f64)
+    i32.const 1
+    set_local 2
+    block  ;; label = @1
+      loop  ;; label = @2
+        get_local 1
+        i32.eqz
+        br_if 1 (;@1;)
+        get_local 2
+        get_local 1
+        i32.mul
+        set_local 2
+        get_local 1
+        i32.const 1
+        i32.sub
+        set_local 1
+        br 0 (;@2;)
+      end
+    end
+    get_global 0
+    drop
+    get_local 2)
+  (global (;0;) (mut i32) (i32.const 1024))
+  (export "fac" (func $fac)))
+`,
+		},
+	} {
+		m, err := wast.ParseModule(strings.NewReader(src))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := new(bytes.Buffer)
+		if err := wast.WriteTo(buf, m, tc.opts...); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tc.want {
+			t.Errorf("got:\n%s\nwant:\n%s", got, tc.want)
+		}
+	}
+}